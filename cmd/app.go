@@ -10,45 +10,41 @@ import (
 	"time"
 
 	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/geoip"
 	"github.com/ydcloud-dy/leaf-api/pkg/logger"
 	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+	"github.com/ydcloud-dy/leaf-api/pkg/privacy"
 	"github.com/ydcloud-dy/leaf-api/pkg/redis"
+	"github.com/ydcloud-dy/leaf-api/pkg/useragent"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Run 运行应用
 func Run(configPath string) error {
-	// 加载配置
-	if err := config.LoadConfig(configPath); err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	if err := setup(configPath); err != nil {
+		return err
 	}
-
-	// 初始化日志
-	logger.Init()
 	logger.Info("Starting Blog Admin API...")
 
-	// 初始化数据库
-	if err := config.InitDatabase(); err != nil {
-		return fmt.Errorf("failed to initialize database: %w", err)
+	// 初始化缓存（Redis 或进程内）
+	if err := initCache(); err != nil {
+		return err
 	}
 
-	// 自动迁移数据库
-	if err := po.AutoMigrate(config.DB); err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+	// 加载 UA 解析规则
+	if err := initUserAgent(); err != nil {
+		return err
 	}
 
-	// 初始化 Redis
-	if err := redis.InitRedis(); err != nil {
-		logger.Warn("Failed to initialize Redis: ", err)
-		logger.Warn("Online user tracking and visit duration recording will be disabled")
-	} else {
-		logger.Info("Redis connected successfully")
-	}
+	// 加载 IP 地址库
+	initGeoIP()
 
-	// 初始化 OSS
-	if err := oss.Init(); err != nil {
-		logger.Warn("Failed to initialize OSS: ", err)
+	// 设置访客隐私策略
+	if err := initPrivacy(); err != nil {
+		return err
 	}
 
 	// 创建默认管理员
@@ -72,6 +68,7 @@ func Run(configPath string) error {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	srv.RegisterOnShutdown(app.CloseStreams)
 
 	// 在 goroutine 中启动服务器
 	go func() {
@@ -91,8 +88,12 @@ func Run(configPath string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// 超时后仍继续执行清理，保证缓冲的浏览记录写入数据库
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown: ", err)
+		logger.Error("Server forced to shutdown: ", err)
+	}
+	if err := app.Stop(); err != nil {
+		logger.Error("Failed to stop app: ", err)
 	}
 
 	// 关闭数据库连接
@@ -100,7 +101,11 @@ func Run(configPath string) error {
 		sqlDB.Close()
 	}
 
-	// 关闭 Redis 连接
+	// 关闭 IP 地址库
+	geoip.Default().Close()
+
+	// 关闭缓存和 Redis 连接
+	cache.Default().Close()
 	if err := redis.Close(); err != nil {
 		logger.Error("Failed to close Redis: ", err)
 	}
@@ -109,6 +114,108 @@ func Run(configPath string) error {
 	return nil
 }
 
+// setup 加载配置并初始化日志、数据库和存储，服务和命令行子命令共用
+func setup(configPath string) error {
+	// 加载配置
+	if err := config.LoadConfig(configPath); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// 初始化日志
+	logger.Init()
+
+	// 初始化数据库
+	if err := config.InitDatabase(); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	// 自动迁移数据库，章节内排序字段首次创建时按原规则回填
+	backfillChapterOrder := !config.DB.Migrator().HasColumn(&po.Article{}, "SortInChapter")
+	if err := po.AutoMigrate(config.DB); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	if backfillChapterOrder {
+		if err := data.BackfillChapterOrder(config.DB); err != nil {
+			return fmt.Errorf("failed to backfill chapter order: %w", err)
+		}
+	}
+
+	// 初始化 OSS
+	if err := oss.Init(); err != nil {
+		logger.Warn("Failed to initialize OSS: ", err)
+	}
+
+	return nil
+}
+
+// initCache 按配置选择缓存实现，auto 模式下 Redis 不可用时回退到进程内缓存
+func initCache() error {
+	driver := config.AppConfig.Cache.Driver
+	switch driver {
+	case cache.DriverMemory:
+		logger.Info("Using in-process cache")
+		return nil
+	case cache.DriverRedis, cache.DriverAuto:
+	default:
+		return fmt.Errorf("unknown cache driver: %s", driver)
+	}
+
+	if err := redis.InitRedis(); err != nil {
+		if driver == cache.DriverRedis {
+			return fmt.Errorf("failed to initialize redis: %w", err)
+		}
+		logger.Warn("Failed to initialize Redis: ", err)
+		logger.Warn("Falling back to in-process cache, online tracking is limited to this instance")
+		return nil
+	}
+	cache.Use(cache.NewRedis(redis.GetClient()))
+	logger.Info("Redis connected successfully")
+	return nil
+}
+
+// initUserAgent 加载 UA 解析规则，未配置规则文件时使用内置规则
+func initUserAgent() error {
+	path := config.AppConfig.Analytics.UARules
+	if path == "" {
+		return nil
+	}
+	parser, err := useragent.Load(path)
+	if err != nil {
+		return err
+	}
+	useragent.Use(parser)
+	logger.Info("Loaded user-agent rules from ", path)
+	return nil
+}
+
+// initGeoIP 加载 IP 地址库，未配置或加载失败时不解析归属地，不影响服务启动
+func initGeoIP() {
+	path := config.AppConfig.Analytics.GeoIPDB
+	if path == "" {
+		logger.Info("No IP database configured, IP geolocation is disabled")
+		return
+	}
+	locator, err := geoip.Open(path, config.AppConfig.Analytics.GeoIPLang)
+	if err != nil {
+		logger.Warn("Failed to load IP database, IP geolocation is disabled: ", err)
+		return
+	}
+	geoip.Use(locator)
+	logger.Info("Loaded IP database from ", path)
+}
+
+// initPrivacy 设置访客 IP 的保存方式和是否遵守 DNT/GPC
+func initPrivacy() error {
+	cfg := config.AppConfig.Analytics
+	policy, err := privacy.New(cfg.IPMode, time.Duration(cfg.SaltRotation)*time.Hour, cfg.HonorDNT)
+	if err != nil {
+		return err
+	}
+	privacy.Use(policy)
+	logger.Info("Visitor IP mode: ", cfg.IPMode, ", honor DNT/GPC: ", cfg.HonorDNT)
+	return nil
+}
+
 // initDefaultAdmin 创建默认管理员
 func initDefaultAdmin() {
 	var count int64
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+)
+
+// BuildStatic 将博客渲染为静态 HTML 站点
+func BuildStatic(configPath string, args []string) error {
+	fs := flag.NewFlagSet("build-static", flag.ContinueOnError)
+	out := fs.String("out", "", "output directory")
+	theme := fs.String("theme", "", "theme directory (default: static.theme, or the built-in theme)")
+	baseURL := fs.String("base-url", "", "public URL of the static site (default: static.base_url)")
+	full := fs.Bool("full", false, "ignore the previous build and re-render everything")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "Usage:\n  leaf-api build-static --out <dir> [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *out == "" {
+		fs.Usage()
+		return errors.New("missing output directory")
+	}
+
+	if err := setup(configPath); err != nil {
+		return err
+	}
+	d, err := data.NewData(config.DB)
+	if err != nil {
+		return err
+	}
+	staticSiteUseCase := biz.NewStaticSiteUseCase(d, biz.NewChapterUseCase(d))
+
+	stats, err := staticSiteUseCase.Build(biz.StaticSiteOptions{
+		Out:     *out,
+		Theme:   *theme,
+		BaseURL: *baseURL,
+		Full:    *full,
+	})
+	if err != nil {
+		return err
+	}
+
+	result, _ := json.MarshalIndent(stats, "", "  ")
+	fmt.Println(string(result))
+	return nil
+}
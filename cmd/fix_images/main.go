@@ -5,7 +5,9 @@ import (
 	"log"
 
 	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
 	mdutils "github.com/ydcloud-dy/leaf-api/pkg/markdown"
 )
 
@@ -15,13 +17,16 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 初始化日志
+	logger.Init()
+
 	// 初始化数据库
 	if err := config.InitDatabase(); err != nil {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 
 	// 创建图片处理器
-	processor := mdutils.NewImageProcessor("uploads", "")
+	processor := mdutils.NewImageProcessor("uploads", "").WithCache(data.NewImageCacheRepo(config.DB))
 
 	// 查询所有文章
 	var articles []po.Article
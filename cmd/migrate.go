@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/migrate"
+)
+
+// migrateUsage 迁移子命令用法
+const migrateUsage = `Usage:
+  leaf-api migrate wordpress [flags] <export.xml>
+  leaf-api migrate hexo [flags] <hexo-site-dir>
+
+Flags:
+`
+
+// Migrate 从 WordPress 或 Hexo 迁移站点数据
+func Migrate(configPath string, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only print the report, do not write anything")
+	author := fs.String("author", "", "username of the default author (default: first admin)")
+	siteKey := fs.String("site", "", "hexo only: site URL or key that namespaces the ID mappings (default: url in _config.yml)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), migrateUsage)
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("missing migration source")
+	}
+	source := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("missing input path")
+	}
+	input := fs.Arg(0)
+
+	var site *migrate.Site
+	var err error
+	switch source {
+	case "wordpress", "wp":
+		f, openErr := os.Open(input)
+		if openErr != nil {
+			return openErr
+		}
+		site, err = migrate.ParseWXR(f)
+		f.Close()
+	case "hexo":
+		site, err = migrate.LoadHexo(os.DirFS(input), 0)
+		if err == nil && *siteKey != "" {
+			site.Source = migrate.HexoSource(*siteKey)
+		}
+		if err == nil && site.Source == "" {
+			return errors.New("no site url in _config.yml, use -site to name the hexo site")
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migration source: %s", source)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := setup(configPath); err != nil {
+		return err
+	}
+	initDefaultCategories()
+
+	authorID, err := defaultAuthorID(*author)
+	if err != nil {
+		return err
+	}
+
+	d, err := data.NewData(config.DB)
+	if err != nil {
+		return err
+	}
+	migrationUseCase := biz.NewMigrationUseCase(d, biz.NewArticleUseCase(d))
+
+	report, err := migrationUseCase.Run(site, biz.MigrationOptions{DryRun: *dryRun, AuthorID: authorID})
+	if err != nil {
+		return err
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	return nil
+}
+
+// defaultAuthorID 查找默认作者，未指定时使用第一个管理员
+func defaultAuthorID(username string) (uint, error) {
+	var user po.User
+	query := config.DB.Model(&po.User{})
+	if username != "" {
+		query = query.Where("username = ?", username)
+	} else {
+		query = query.Where("role IN ?", []string{"admin", "super_admin"}).Order("id ASC")
+	}
+	if err := query.First(&user).Error; err != nil {
+		if username != "" {
+			return 0, fmt.Errorf("user not found: %s", username)
+		}
+		return 0, errors.New("no admin user found, use -author to specify the default author")
+	}
+	return user.ID, nil
+}
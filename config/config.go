@@ -13,12 +13,18 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	OSS      OSSConfig      `mapstructure:"oss"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	OSS       OSSConfig       `mapstructure:"oss"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	Log       LogConfig       `mapstructure:"log"`
+	Upload    UploadConfig    `mapstructure:"upload"`
+	Image     ImageConfig     `mapstructure:"image"`
+	Import    ImportConfig    `mapstructure:"import"`
+	Static    StaticConfig    `mapstructure:"static"`
+	Analytics AnalyticsConfig `mapstructure:"analytics"`
 }
 
 type ServerConfig struct {
@@ -58,6 +64,59 @@ type OSSConfig struct {
 	BaseURL         string `mapstructure:"base_url"`
 }
 
+type UploadConfig struct {
+	MaxSize   int64                         `mapstructure:"max_size"`   // default max file size in MB
+	UserQuota int64                         `mapstructure:"user_quota"` // storage quota per non-admin user in MB, 0 means unlimited
+	Folders   map[string]UploadFolderConfig `mapstructure:"folders"`    // per-folder rules, keyed by top-level folder name
+}
+
+type UploadFolderConfig struct {
+	MaxSize      int64    `mapstructure:"max_size"`      // max file size in MB, 0 falls back to upload.max_size
+	AllowedTypes []string `mapstructure:"allowed_types"` // sniffed MIME types, supports wildcards like image/*
+}
+
+type ImageConfig struct {
+	Workers int             `mapstructure:"workers"`  // concurrent image downloads per import
+	PerHost int             `mapstructure:"per_host"` // concurrent downloads per remote host
+	Timeout int             `mapstructure:"timeout"`  // download timeout in seconds
+	MaxSize int64           `mapstructure:"max_size"` // max image size in MB
+	Rules   []ImageHostRule `mapstructure:"rules"`
+}
+
+type ImageHostRule struct {
+	Host    string `mapstructure:"host"`    // host suffix, e.g. yuque.com matches cdn.yuque.com
+	Action  string `mapstructure:"action"`  // download, skip, proxy
+	Proxy   string `mapstructure:"proxy"`   // proxy URL prefix; fallback for download, always used for proxy
+	Referer string `mapstructure:"referer"` // Referer header sent when downloading
+}
+
+type ImportConfig struct {
+	Workers       int   `mapstructure:"workers"`         // background workers processing import jobs
+	MaxBundleSize int64 `mapstructure:"max_bundle_size"` // max uncompressed size of a zip bundle, in MB
+}
+
+type StaticConfig struct {
+	Title       string `mapstructure:"title"`       // site title
+	Description string `mapstructure:"description"` // site description
+	BaseURL     string `mapstructure:"base_url"`    // public URL of the static site, used for links and feeds
+	Theme       string `mapstructure:"theme"`       // theme directory, empty uses the built-in theme
+	PageSize    int    `mapstructure:"page_size"`   // articles per list page
+}
+
+type AnalyticsConfig struct {
+	RollupInterval int    `mapstructure:"rollup_interval"` // minutes between rollups of page_visits into hourly/daily tables
+	RetentionDays  int    `mapstructure:"retention_days"`  // days of raw page_visits, and of IPs/user agents in views and login_logs, to keep; negative keeps them forever
+	ViewWindow     int    `mapstructure:"view_window"`     // minutes during which repeated views of an article by the same visitor count once
+	ViewFlush      int    `mapstructure:"view_flush"`      // seconds between flushes of buffered article views to MySQL
+	UARules        string `mapstructure:"ua_rules"`        // user-agent/bot rules file, empty uses the rules built into pkg/useragent
+	GeoIPDB        string `mapstructure:"geoip_db"`        // ip2region .xdb or MaxMind .mmdb file for IP geolocation, empty disables it
+	GeoIPLang      string `mapstructure:"geoip_lang"`      // language of place names read from .mmdb files, e.g. zh-CN, en
+	ArticlePath    string `mapstructure:"article_path"`    // front-end path of an article page, {id} is the article ID; attributes page visits to articles
+	IPMode         string `mapstructure:"ip_mode"`         // how visitor IPs are stored: full, truncate (IPv4 /24, IPv6 /48) or hash (salted, salt rotated); truncate and hash also drop raw user agents
+	SaltRotation   int    `mapstructure:"salt_rotation"`   // hours between rotations of the salt used by ip_mode hash and guest visitor hashes
+	HonorDNT       bool   `mapstructure:"honor_dnt"`       // skip visit, view and presence tracking for requests sending DNT: 1 or Sec-GPC: 1
+}
+
 type RedisConfig struct {
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
@@ -66,6 +125,10 @@ type RedisConfig struct {
 	PoolSize int    `mapstructure:"pool_size"`
 }
 
+type CacheConfig struct {
+	Driver string `mapstructure:"driver"` // auto (redis, falling back to memory), redis, memory
+}
+
 var AppConfig *Config
 var DB *gorm.DB
 
@@ -118,6 +181,72 @@ func LoadConfig(configPath string) error {
 		AppConfig.Log.Output = "stdout"
 	}
 
+	// Set defaults for cache config
+	if AppConfig.Cache.Driver == "" {
+		AppConfig.Cache.Driver = "auto"
+	}
+
+	// Set defaults for upload config
+	if AppConfig.Upload.MaxSize <= 0 {
+		AppConfig.Upload.MaxSize = 20
+	}
+
+	// Set defaults for image import config
+	if AppConfig.Image.Workers <= 0 {
+		AppConfig.Image.Workers = 8
+	}
+	if AppConfig.Image.PerHost <= 0 {
+		AppConfig.Image.PerHost = 2
+	}
+	if AppConfig.Image.Timeout <= 0 {
+		AppConfig.Image.Timeout = 30
+	}
+	if AppConfig.Image.MaxSize <= 0 {
+		AppConfig.Image.MaxSize = 20
+	}
+
+	// Set defaults for import config
+	if AppConfig.Import.Workers <= 0 {
+		AppConfig.Import.Workers = 2
+	}
+	if AppConfig.Import.MaxBundleSize <= 0 {
+		AppConfig.Import.MaxBundleSize = 200
+	}
+
+	// Set defaults for static site config
+	if AppConfig.Static.Title == "" {
+		AppConfig.Static.Title = "Leaf Blog"
+	}
+	if AppConfig.Static.PageSize <= 0 {
+		AppConfig.Static.PageSize = 10
+	}
+
+	// Set defaults for analytics config
+	if AppConfig.Analytics.RollupInterval <= 0 {
+		AppConfig.Analytics.RollupInterval = 5
+	}
+	if AppConfig.Analytics.RetentionDays == 0 {
+		AppConfig.Analytics.RetentionDays = 90
+	}
+	if AppConfig.Analytics.ViewWindow <= 0 {
+		AppConfig.Analytics.ViewWindow = 30
+	}
+	if AppConfig.Analytics.ViewFlush <= 0 {
+		AppConfig.Analytics.ViewFlush = 10
+	}
+	if AppConfig.Analytics.ArticlePath == "" {
+		AppConfig.Analytics.ArticlePath = "/articles/{id}"
+	}
+	if AppConfig.Analytics.IPMode == "" {
+		AppConfig.Analytics.IPMode = "full"
+	}
+	if AppConfig.Analytics.SaltRotation <= 0 {
+		AppConfig.Analytics.SaltRotation = 24
+	}
+	if AppConfig.Analytics.GeoIPLang == "" {
+		AppConfig.Analytics.GeoIPLang = "zh-CN"
+	}
+
 	return nil
 }
 
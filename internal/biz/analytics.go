@@ -0,0 +1,972 @@
+package biz
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/geoip"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/privacy"
+	"github.com/ydcloud-dy/leaf-api/pkg/useragent"
+)
+
+const (
+	// rollupLockKey 多实例部署时只允许一个实例执行汇总
+	rollupLockKey = "analytics:rollup:lock"
+	// pruneBatchSize 每批删除的原始访问记录条数
+	pruneBatchSize = 5000
+	// uvKeyTTL 按天的独立访客 HyperLogLog 保留时间，覆盖最长 30 天的查询
+	uvKeyTTL = 32 * 24 * time.Hour
+	// MaxVisitorDays 按天查询独立访客的最大天数
+	MaxVisitorDays = 30
+	// maxRangeDays 按日期范围查询的最大天数
+	maxRangeDays = 366
+)
+
+// AnalyticsUseCase 访问统计业务用例接口
+type AnalyticsUseCase interface {
+	// Rollup 把截至 now 的访问记录汇总到小时/天统计表
+	Rollup(now time.Time) error
+	// Purge 清理超出保留期的访问数据：删除原始访问记录，清空浏览记录和登录记录中的 IP 和 UA
+	Purge(now time.Time) error
+	// VisitTrend 最近 days 天每天的 PV/UV
+	VisitTrend(days int) (*dto.VisitTrendResponse, error)
+	// RealtimeVisits 最近一小时按分钟的访问量
+	RealtimeVisits() (*dto.RealtimeVisitsResponse, error)
+	// TopPages 最近 days 天访问量最高的页面
+	TopPages(days, limit int) ([]dto.PageStat, error)
+	// TopReferrers 最近 days 天访问量最高的来源页面
+	TopReferrers(days, limit int) ([]dto.ReferrerStat, error)
+	// RecordVisitor 记录独立访客，登录用户按用户ID识别，游客按隐私策略的访客哈希识别；articleID 为 0 时只计入全站
+	RecordVisitor(userID uint, ip, userAgent string, articleID uint) error
+	// RecordCrawl 在服务端记录爬虫抓取文章，path 为规则中没有 {id} 时使用的请求路径；不是爬虫时忽略
+	RecordCrawl(articleID uint, path, ip, userAgent string) error
+	// TodayUV 今日独立访客数估算
+	TodayUV() (int64, error)
+	// ArticleVisitors 文章最近 days 天每天的独立访客数估算
+	ArticleVisitors(articleID uint, days int) (*dto.ArticleVisitorsResponse, error)
+	// Sources 日期范围内的访问来源类别和主要来源域名
+	Sources(req *dto.DateRangeRequest) (*dto.SourceReport, error)
+	// Campaigns 日期范围内的 UTM 推广活动
+	Campaigns(req *dto.DateRangeRequest) (*dto.CampaignReport, error)
+	// SearchEngines 日期范围内的搜索引擎和搜索关键词
+	SearchEngines(req *dto.DateRangeRequest) (*dto.SearchReport, error)
+	// Crawlers 日期范围内的爬虫访问
+	Crawlers(req *dto.DateRangeRequest) (*dto.CrawlerReport, error)
+	// RecordLogin 记录一次登录及其 IP 归属地和客户端
+	RecordLogin(userID uint, portal, ip, userAgent string) error
+	// LoginLogs 分页查询登录记录
+	LoginLogs(req *dto.LoginLogListRequest) (*dto.PageResponse, error)
+	// Locations 日期范围内访问或登录的地区分布
+	Locations(req *dto.GeoRequest) (*dto.GeoReport, error)
+	// ArticleIDFromPath 按文章页路径规则解析文章ID，不是文章页时返回 0
+	ArticleIDFromPath(path string) uint
+	// ArticleReport 文章在日期范围内按天的浏览、访客、阅读时长、滚动深度和互动数据，以及主要外部来源
+	ArticleReport(articleID uint, req *dto.DateRangeRequest) (*dto.ArticleReport, error)
+	// ExportUserData 导出用户的访问、浏览和登录记录
+	ExportUserData(userID uint) (*dto.TrackingExport, error)
+	// DeleteUserData 删除用户的访问、浏览和登录记录
+	DeleteUserData(userID uint) (*dto.TrackingDeleteResult, error)
+}
+
+// analyticsUseCase 访问统计业务用例实现
+type analyticsUseCase struct {
+	data          *data.Data
+	articlePath   string         // 文章页路径规则，{id} 为文章ID
+	articlePathRe *regexp.Regexp // 从路径中解析文章ID，规则中没有 {id} 时为 nil
+}
+
+// NewAnalyticsUseCase 创建访问统计业务用例，并启动定时汇总
+func NewAnalyticsUseCase(d *data.Data) AnalyticsUseCase {
+	uc := &analyticsUseCase{data: d, articlePath: "/articles/{id}"}
+
+	interval := 5 * time.Minute
+	if config.AppConfig != nil {
+		if config.AppConfig.Analytics.RollupInterval > 0 {
+			interval = time.Duration(config.AppConfig.Analytics.RollupInterval) * time.Minute
+		}
+		if config.AppConfig.Analytics.ArticlePath != "" {
+			uc.articlePath = config.AppConfig.Analytics.ArticlePath
+		}
+	}
+	if strings.Contains(uc.articlePath, "{id}") {
+		pattern := strings.Replace(regexp.QuoteMeta(uc.articlePath), `\{id\}`, `(\d+)`, 1)
+		uc.articlePathRe = regexp.MustCompile("^" + pattern + `(?:[?#].*)?$`)
+	}
+	go uc.loop(interval)
+
+	return uc
+}
+
+// loop 启动时先汇总一次，之后按间隔定时汇总
+func (uc *analyticsUseCase) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		uc.runRollup(interval)
+		<-ticker.C
+	}
+}
+
+// runRollup 抢到锁后执行一次汇总，汇总成功后清理超出保留期的数据
+func (uc *analyticsUseCase) runRollup(interval time.Duration) {
+	release, ok := acquireLock(rollupLockKey, interval/2)
+	if !ok {
+		return
+	}
+	defer release()
+
+	now := time.Now()
+	if err := uc.Rollup(now); err != nil {
+		logger.Error("访问统计汇总失败: ", err)
+		return
+	}
+	// 汇总完成后才清理原始记录，按整天清理，不会影响当天的汇总
+	if err := uc.Purge(now); err != nil {
+		logger.Error("清理过期访问数据失败: ", err)
+	}
+}
+
+// acquireLock 获取多实例互斥锁，持有期间定时续期，调用返回的 release 释放
+func acquireLock(key string, ttl time.Duration) (release func(), ok bool) {
+	store := cache.Default()
+	token := uuid.NewString()
+	ok, err := store.SetNX(key, token, ttl)
+	if err != nil {
+		logger.Warn("获取锁失败: ", key, " ", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := store.Expire(key, ttl); err != nil {
+					logger.Warn("锁续期失败: ", key, " ", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		// 只删除自己持有的锁，锁已过期被其他实例拿到时保留
+		if value, err := store.Get(key); err == nil && value == token {
+			if err := store.Del(key); err != nil {
+				logger.Warn("释放锁失败: ", key, " ", err)
+			}
+		}
+	}, true
+}
+
+// hourStart 所在小时的开始时间
+func hourStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// dayStart 所在天的零点
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Rollup 把截至 now 的访问记录汇总到小时/天统计表
+func (uc *analyticsUseCase) Rollup(now time.Time) error {
+	repo := uc.data.AnalyticsRepo
+
+	// 从最近一次汇总的时间段开始重新汇总（该时间段汇总时可能还没结束），没有汇总过时从最早的访问记录开始
+	from, err := repo.LatestHourly()
+	if err != nil {
+		return err
+	}
+	if from == nil {
+		if from, err = repo.EarliestVisit(); err != nil || from == nil {
+			return err
+		}
+	}
+	for h := hourStart(from.In(now.Location())); !h.After(now); h = hourStart(h.Add(time.Hour)) {
+		if err := repo.RollupHourly(h); err != nil {
+			return err
+		}
+	}
+
+	if from, err = repo.LatestDaily(); err != nil {
+		return err
+	}
+	if from == nil {
+		if from, err = repo.EarliestVisit(); err != nil || from == nil {
+			return err
+		}
+	}
+	for d := dayStart(from.In(now.Location())); !d.After(now); d = d.AddDate(0, 0, 1) {
+		if err := repo.RollupDaily(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge 清理超出保留期的访问数据：删除原始访问记录，清空浏览记录和登录记录中的 IP 和 UA
+// 浏览记录和登录记录本身保留，浏览量和登录统计不受影响
+func (uc *analyticsUseCase) Purge(now time.Time) error {
+	before, ok := retentionStart(now)
+	if !ok {
+		return nil
+	}
+	date := before.Format("2006-01-02")
+
+	deleted, err := uc.data.AnalyticsRepo.PruneVisits(before, pruneBatchSize)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logger.Info("已清理 ", deleted, " 条 ", date, " 之前的访问记录")
+	}
+
+	views, err := uc.data.ViewRepo.ClearIPBefore(before, pruneBatchSize)
+	if err != nil {
+		return err
+	}
+	logins, err := uc.data.AnalyticsRepo.ClearLoginClientBefore(before, pruneBatchSize)
+	if err != nil {
+		return err
+	}
+	if views > 0 || logins > 0 {
+		logger.Info("已清空 ", date, " 之前 ", views, " 条浏览记录和 ", logins, " 条登录记录中的 IP")
+	}
+	return nil
+}
+
+// VisitTrend 最近 days 天每天的 PV/UV
+// PV 来自按天汇总表；UV 优先使用 HyperLogLog 估算，没有 HyperLogLog 数据的日期使用汇总表中按访客标识去重的结果
+func (uc *analyticsUseCase) VisitTrend(days int) (*dto.VisitTrendResponse, error) {
+	today := dayStart(time.Now())
+	start := today.AddDate(0, 0, -(days - 1))
+
+	totals, err := uc.data.AnalyticsRepo.ListDailyTotals(start, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]po.VisitDaily, len(totals))
+	for _, t := range totals {
+		byDate[t.Period.Format("2006-01-02")] = t
+	}
+
+	resp := &dto.VisitTrendResponse{
+		Dates: make([]string, days),
+		PV:    make([]int64, days),
+		UV:    make([]int64, days),
+	}
+	store := cache.Default()
+	hllKeys := make([]string, 0, days)
+	var fallbackUV int64
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		date := day.Format("2006-01-02")
+		resp.Dates[i] = date
+		t := byDate[date]
+		resp.PV[i] = t.PV
+		resp.TotalPV += t.PV
+
+		key := uvDayKey(day)
+		uv, err := store.PFCount(key)
+		if err != nil {
+			return nil, err
+		}
+		if uv > 0 {
+			resp.UV[i] = uv
+			hllKeys = append(hllKeys, key)
+		} else {
+			resp.UV[i] = t.UV
+			fallbackUV += t.UV
+		}
+	}
+
+	// 有 HyperLogLog 的日期合并后去重，其余日期只能累加
+	resp.TotalUV = fallbackUV
+	if len(hllKeys) > 0 {
+		uv, err := store.PFCount(hllKeys...)
+		if err != nil {
+			return nil, err
+		}
+		resp.TotalUV += uv
+	}
+	return resp, nil
+}
+
+// RealtimeVisits 最近一小时按分钟的访问量
+func (uc *analyticsUseCase) RealtimeVisits() (*dto.RealtimeVisitsResponse, error) {
+	now := time.Now()
+	start := now.Add(-59 * time.Minute).Truncate(time.Minute)
+
+	// 分钟粒度的数据直接按分钟分组查询原始记录
+	counts, err := uc.data.AnalyticsRepo.CountByMinute(start)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.RealtimeVisitsResponse{
+		Timestamps: make([]string, 60),
+		Visits:     make([]int64, 60),
+	}
+	for i := 0; i < 60; i++ {
+		minute := start.Add(time.Duration(i) * time.Minute)
+		resp.Timestamps[i] = minute.Format("15:04")
+		resp.Visits[i] = counts[minute.Format("2006-01-02 15:04")]
+	}
+	return resp, nil
+}
+
+// TopPages 最近 days 天访问量最高的页面
+func (uc *analyticsUseCase) TopPages(days, limit int) ([]dto.PageStat, error) {
+	start := dayStart(time.Now()).AddDate(0, 0, -(days - 1))
+	stats, err := uc.data.AnalyticsRepo.TopDaily(po.VisitDimensionPath, start, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.PageStat, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, dto.PageStat{
+			Path:        s.Name,
+			Visits:      s.PV,
+			DailyUVSum:  s.UV,
+			AvgDuration: s.AvgDuration(),
+		})
+	}
+	return list, nil
+}
+
+// TopReferrers 最近 days 天访问量最高的来源页面
+func (uc *analyticsUseCase) TopReferrers(days, limit int) ([]dto.ReferrerStat, error) {
+	start := dayStart(time.Now()).AddDate(0, 0, -(days - 1))
+	stats, err := uc.data.AnalyticsRepo.TopDaily(po.VisitDimensionReferrer, start, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.ReferrerStat, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, dto.ReferrerStat{
+			Referrer:   s.Name,
+			Visits:     s.PV,
+			DailyUVSum: s.UV,
+		})
+	}
+	return list, nil
+}
+
+// RecordCrawl 在服务端记录爬虫抓取文章，爬虫大多不执行前端的访问信标，path 为规则中没有 {id} 时使用的请求路径；不是爬虫时忽略
+func (uc *analyticsUseCase) RecordCrawl(articleID uint, path, ip, userAgent string) error {
+	client := useragent.Parse(userAgent)
+	if !client.IsBot {
+		return nil
+	}
+	// 按文章页路径记录，和前端信标上报的路径一致
+	if uc.articlePathRe != nil {
+		path = strings.Replace(uc.articlePath, "{id}", strconv.FormatUint(uint64(articleID), 10), 1)
+	}
+	loc := geoip.Lookup(ip)
+	return uc.data.AnalyticsRepo.CreateVisit(&po.PageVisit{
+		IP:          privacy.IP(ip),
+		Path:        path,
+		UserAgent:   privacy.UserAgent(userAgent),
+		CreatedAt:   time.Now(),
+		Browser:     client.Browser,
+		OS:          client.OS,
+		Device:      client.Device,
+		IsBot:       true,
+		BotName:     client.BotName,
+		BotCategory: client.BotCategory,
+		Country:     loc.Country,
+		Province:    loc.Province,
+		City:        loc.City,
+		ArticleID:   articleID,
+	})
+}
+
+// uvDayKey 全站某天的独立访客
+func uvDayKey(day time.Time) string {
+	return "uv:day:" + day.Format("20060102")
+}
+
+// uvArticleKey 文章累计的独立访客
+func uvArticleKey(articleID uint) string {
+	return fmt.Sprintf("uv:article:%d", articleID)
+}
+
+// uvArticleDayKey 文章某天的独立访客
+func uvArticleDayKey(articleID uint, day time.Time) string {
+	return fmt.Sprintf("uv:article:%d:%s", articleID, day.Format("20060102"))
+}
+
+// visitorID 访客标识，游客使用隐私策略按周期更换盐的 IP+UA 哈希，不直接保存
+func visitorID(userID uint, ip, userAgent string) string {
+	if userID > 0 {
+		return fmt.Sprintf("u:%d", userID)
+	}
+	return privacy.Visitor(ip, userAgent)
+}
+
+// RecordVisitor 记录独立访客，登录用户按用户ID识别，游客按隐私策略的访客哈希识别；articleID 为 0 时只计入全站
+func (uc *analyticsUseCase) RecordVisitor(userID uint, ip, userAgent string, articleID uint) error {
+	// 爬虫不计入独立访客
+	if useragent.Parse(userAgent).IsBot {
+		return nil
+	}
+	visitor := visitorID(userID, ip, userAgent)
+	today := time.Now()
+	store := cache.Default()
+
+	if err := store.PFAdd(uvDayKey(today), uvKeyTTL, visitor); err != nil {
+		return err
+	}
+	if articleID == 0 {
+		return nil
+	}
+	if err := store.PFAdd(uvArticleDayKey(articleID, today), uvKeyTTL, visitor); err != nil {
+		return err
+	}
+	return store.PFAdd(uvArticleKey(articleID), 0, visitor)
+}
+
+// TodayUV 今日独立访客数估算
+func (uc *analyticsUseCase) TodayUV() (int64, error) {
+	return cache.Default().PFCount(uvDayKey(time.Now()))
+}
+
+// ArticleVisitors 文章最近 days 天每天的独立访客数估算
+func (uc *analyticsUseCase) ArticleVisitors(articleID uint, days int) (*dto.ArticleVisitorsResponse, error) {
+	if _, err := uc.data.ArticleRepo.FindByID(articleID); err != nil {
+		return nil, errors.New("文章不存在")
+	}
+	if days <= 0 || days > MaxVisitorDays {
+		days = 7
+	}
+
+	store := cache.Default()
+	start := dayStart(time.Now()).AddDate(0, 0, -(days - 1))
+	resp := &dto.ArticleVisitorsResponse{
+		ArticleID: articleID,
+		Dates:     make([]string, days),
+		UV:        make([]int64, days),
+	}
+	keys := make([]string, days)
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		resp.Dates[i] = day.Format("2006-01-02")
+		keys[i] = uvArticleDayKey(articleID, day)
+		uv, err := store.PFCount(keys[i])
+		if err != nil {
+			return nil, err
+		}
+		resp.UV[i] = uv
+	}
+
+	var err error
+	if resp.RangeUV, err = store.PFCount(keys...); err != nil {
+		return nil, err
+	}
+	if resp.TotalUV, err = store.PFCount(uvArticleKey(articleID)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseDateRange 解析日期范围，返回 [start, end) 和 limit
+func parseDateRange(req *dto.DateRangeRequest) (time.Time, time.Time, int, error) {
+	end := dayStart(time.Now())
+	if req.EndDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, errors.New("结束日期格式错误，应为 YYYY-MM-DD")
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -6)
+	if req.StartDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, errors.New("开始日期格式错误，应为 YYYY-MM-DD")
+		}
+		start = t
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, 0, errors.New("开始日期不能晚于结束日期")
+	}
+	if end.Sub(start) >= maxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("日期范围不能超过 %d 天", maxRangeDays)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	return start, end.AddDate(0, 0, 1), limit, nil
+}
+
+// retentionDays 原始访问记录的保留天数，负数表示永久保留
+func retentionDays() int {
+	if config.AppConfig != nil && config.AppConfig.Analytics.RetentionDays != 0 {
+		return config.AppConfig.Analytics.RetentionDays
+	}
+	return 90
+}
+
+// retentionStart 原始访问记录保留期的开始时间，永久保留时 ok 为 false
+func retentionStart(now time.Time) (time.Time, bool) {
+	days := retentionDays()
+	if days < 0 {
+		return time.Time{}, false
+	}
+	return dayStart(now.AddDate(0, 0, -days)), true
+}
+
+// clampToRetention 把读取原始访问记录的日期范围限制在保留期内，更早的记录已被清理，统计结果会偏低
+func clampToRetention(start, end time.Time) (time.Time, error) {
+	from, ok := retentionStart(time.Now())
+	if !ok || !start.Before(from) {
+		return start, nil
+	}
+	if !from.Before(end) {
+		return time.Time{}, fmt.Errorf("访问记录只保留 %d 天，日期范围内没有数据", retentionDays())
+	}
+	return from, nil
+}
+
+// Sources 日期范围内的访问来源类别和主要来源域名
+func (uc *analyticsUseCase) Sources(req *dto.DateRangeRequest) (*dto.SourceReport, error) {
+	start, end, limit, err := parseDateRange(req)
+	if err != nil {
+		return nil, err
+	}
+	if start, err = clampToRetention(start, end); err != nil {
+		return nil, err
+	}
+
+	categories, err := uc.data.AnalyticsRepo.CountByCategory(start, end)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := uc.data.AnalyticsRepo.TopSources(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.SourceReport{
+		StartDate:  start.Format("2006-01-02"),
+		EndDate:    end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Categories: make([]dto.SourceCategoryStat, 0, len(categories)),
+		Sources:    make([]dto.SourceStat, 0, len(sources)),
+	}
+	for _, c := range categories {
+		report.Categories = append(report.Categories, dto.SourceCategoryStat{Category: c.Category, Visits: c.PV, UV: c.UV})
+	}
+	for _, s := range sources {
+		report.Sources = append(report.Sources, dto.SourceStat{Domain: s.Domain, Category: s.Category, Visits: s.PV, UV: s.UV})
+	}
+	return report, nil
+}
+
+// Campaigns 日期范围内的 UTM 推广活动
+func (uc *analyticsUseCase) Campaigns(req *dto.DateRangeRequest) (*dto.CampaignReport, error) {
+	start, end, limit, err := parseDateRange(req)
+	if err != nil {
+		return nil, err
+	}
+	if start, err = clampToRetention(start, end); err != nil {
+		return nil, err
+	}
+
+	campaigns, err := uc.data.AnalyticsRepo.TopCampaigns(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.CampaignReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Campaigns: make([]dto.CampaignStat, 0, len(campaigns)),
+	}
+	for _, c := range campaigns {
+		report.Campaigns = append(report.Campaigns, dto.CampaignStat{
+			Source:   c.Source,
+			Medium:   c.Medium,
+			Campaign: c.Campaign,
+			Visits:   c.PV,
+			UV:       c.UV,
+		})
+	}
+	return report, nil
+}
+
+// SearchEngines 日期范围内的搜索引擎和搜索关键词
+func (uc *analyticsUseCase) SearchEngines(req *dto.DateRangeRequest) (*dto.SearchReport, error) {
+	start, end, limit, err := parseDateRange(req)
+	if err != nil {
+		return nil, err
+	}
+	if start, err = clampToRetention(start, end); err != nil {
+		return nil, err
+	}
+
+	engines, err := uc.data.AnalyticsRepo.TopSearchEngines(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	terms, err := uc.data.AnalyticsRepo.TopSearchTerms(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.SearchReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Engines:   make([]dto.SearchEngineStat, 0, len(engines)),
+		Terms:     make([]dto.SearchTermStat, 0, len(terms)),
+	}
+	for _, e := range engines {
+		report.Engines = append(report.Engines, dto.SearchEngineStat{Engine: e.Engine, Visits: e.PV, UV: e.UV})
+	}
+	for _, t := range terms {
+		report.Terms = append(report.Terms, dto.SearchTermStat{Engine: t.Engine, Term: t.Term, Visits: t.PV, UV: t.UV})
+	}
+	return report, nil
+}
+
+// Crawlers 日期范围内的爬虫访问
+func (uc *analyticsUseCase) Crawlers(req *dto.DateRangeRequest) (*dto.CrawlerReport, error) {
+	start, end, limit, err := parseDateRange(req)
+	if err != nil {
+		return nil, err
+	}
+	if start, err = clampToRetention(start, end); err != nil {
+		return nil, err
+	}
+
+	crawlers, err := uc.data.AnalyticsRepo.TopCrawlers(start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.CrawlerReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Crawlers:  make([]dto.CrawlerStat, 0, len(crawlers)),
+	}
+	for _, c := range crawlers {
+		report.TotalVisits += c.PV
+		report.Crawlers = append(report.Crawlers, dto.CrawlerStat{
+			Name:     c.BotName,
+			Category: c.BotCategory,
+			Visits:   c.PV,
+			Pages:    c.Pages,
+			LastSeen: c.LastSeen,
+		})
+	}
+	return report, nil
+}
+
+// RecordLogin 记录一次登录及其 IP 归属地和客户端，IP 和 UA 按隐私策略保存
+func (uc *analyticsUseCase) RecordLogin(userID uint, portal, ip, userAgent string) error {
+	client := useragent.Parse(userAgent)
+	loc := geoip.Lookup(ip)
+	return uc.data.AnalyticsRepo.CreateLoginLog(&po.LoginLog{
+		UserID:    userID,
+		Portal:    portal,
+		IP:        privacy.IP(ip),
+		UserAgent: privacy.UserAgent(userAgent),
+		Browser:   client.Browser,
+		OS:        client.OS,
+		Device:    client.Device,
+		Country:   loc.Country,
+		Province:  loc.Province,
+		City:      loc.City,
+		CreatedAt: time.Now(),
+	})
+}
+
+// LoginLogs 分页查询登录记录
+func (uc *analyticsUseCase) LoginLogs(req *dto.LoginLogListRequest) (*dto.PageResponse, error) {
+	logs, total, err := uc.data.AnalyticsRepo.ListLoginLogs(req.UserID, req.Page, req.Limit)
+	if err != nil {
+		return nil, errors.New("查询登录记录失败")
+	}
+
+	items := make([]dto.LoginLogResponse, 0, len(logs))
+	for _, log := range logs {
+		items = append(items, dto.LoginLogResponse{
+			ID:        log.ID,
+			UserID:    log.UserID,
+			Username:  log.Username,
+			Portal:    log.Portal,
+			IP:        log.IP,
+			Location:  geoip.Location{Country: log.Country, Province: log.Province, City: log.City}.String(),
+			Browser:   log.Browser,
+			OS:        log.OS,
+			Device:    log.Device,
+			CreatedAt: log.CreatedAt,
+		})
+	}
+
+	return &dto.PageResponse{
+		Total: total,
+		Page:  req.Page,
+		Limit: req.Limit,
+		Data:  items,
+	}, nil
+}
+
+// Locations 日期范围内访问或登录的地区分布
+func (uc *analyticsUseCase) Locations(req *dto.GeoRequest) (*dto.GeoReport, error) {
+	start, end, limit, err := parseDateRange(&req.DateRangeRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := data.LocationFilter{
+		Start:    start,
+		End:      end,
+		Level:    req.Level,
+		Country:  req.Country,
+		Province: req.Province,
+		Limit:    limit,
+	}
+	if filter.Level == "" {
+		filter.Level = data.LocationLevelCountry
+	}
+	source := req.Source
+	if source == "" {
+		source = "visits"
+	}
+	// 访问记录超过保留期会被清理，登录记录只清空 IP 和 UA，地区仍保留
+	if source != "logins" {
+		if start, err = clampToRetention(start, end); err != nil {
+			return nil, err
+		}
+		filter.Start = start
+	}
+
+	var stats []data.LocationStat
+	if source == "logins" {
+		stats, err = uc.data.AnalyticsRepo.CountLoginsByLocation(filter)
+	} else {
+		stats, err = uc.data.AnalyticsRepo.CountVisitsByLocation(filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.GeoReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Source:    source,
+		Level:     filter.Level,
+		Enabled:   geoip.Enabled(),
+		Locations: make([]dto.LocationStat, 0, len(stats)),
+	}
+	for _, s := range stats {
+		report.Locations = append(report.Locations, dto.LocationStat{
+			Country:  s.Country,
+			Province: s.Province,
+			City:     s.City,
+			Name:     geoip.Location{Country: s.Country, Province: s.Province, City: s.City}.String(),
+			Visits:   s.PV,
+			Visitors: s.UV,
+		})
+	}
+	return report, nil
+}
+
+// ArticleIDFromPath 按文章页路径规则解析文章ID，不是文章页时返回 0
+func (uc *analyticsUseCase) ArticleIDFromPath(path string) uint {
+	if uc.articlePathRe == nil {
+		return 0
+	}
+	m := uc.articlePathRe.FindStringSubmatch(path)
+	if m == nil {
+		return 0
+	}
+	id, _ := strconv.ParseUint(m[1], 10, 64)
+	return uint(id)
+}
+
+// ArticleReport 文章在日期范围内按天的浏览、访客、阅读时长、滚动深度和互动数据，以及主要外部来源
+func (uc *analyticsUseCase) ArticleReport(articleID uint, req *dto.DateRangeRequest) (*dto.ArticleReport, error) {
+	article, err := uc.data.ArticleRepo.FindByID(articleID)
+	if err != nil {
+		return nil, errors.New("文章不存在")
+	}
+	start, end, limit, err := parseDateRange(req)
+	if err != nil {
+		return nil, err
+	}
+	if start, err = clampToRetention(start, end); err != nil {
+		return nil, err
+	}
+
+	// 没有上报 article_id 的旧访问记录按文章页路径匹配
+	path := ""
+	if uc.articlePathRe != nil {
+		path = strings.Replace(uc.articlePath, "{id}", strconv.FormatUint(uint64(articleID), 10), 1)
+	}
+	visits, err := uc.data.AnalyticsRepo.ArticleVisitsByDay(articleID, path, start, end)
+	if err != nil {
+		return nil, err
+	}
+	visitMap := make(map[string]data.ArticleDayStat, len(visits))
+	for _, v := range visits {
+		visitMap[v.Day] = v
+	}
+	views, err := uc.data.ViewRepo.CountByDay(articleID, start)
+	if err != nil {
+		return nil, err
+	}
+	likes, favorites, comments, err := uc.data.AnalyticsRepo.CountArticleActivityByDay(articleID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	referrers, err := uc.data.AnalyticsRepo.ArticleReferrers(articleID, path, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.ArticleReport{
+		ArticleID: article.ID,
+		Title:     article.Title,
+		ViewCount: article.ViewCount,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Days:      make([]dto.ArticleDayStat, 0),
+		Referrers: make([]dto.SourceStat, 0, len(referrers)),
+	}
+
+	// 独立访客在 HyperLogLog 保留期内按访客ID估算，更早的按访客标识去重
+	store := cache.Default()
+	hllFrom := dayStart(time.Now()).AddDate(0, 0, -(MaxVisitorDays - 1))
+	var durationSum, durationCount, scrollSum, scrollCount int64
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		v := visitMap[date]
+		stat := dto.ArticleDayStat{
+			Date:      date,
+			Views:     views[date],
+			Visitors:  v.UV,
+			Visits:    v.PV,
+			Likes:     likes[date],
+			Favorites: favorites[date],
+			Comments:  comments[date],
+		}
+		if !day.Before(hllFrom) {
+			if stat.Visitors, err = store.PFCount(uvArticleDayKey(articleID, day)); err != nil {
+				return nil, err
+			}
+		}
+		if v.DurationCount > 0 {
+			stat.AvgDuration = float64(v.DurationSum) / float64(v.DurationCount)
+		}
+		if v.ScrollCount > 0 {
+			stat.AvgScroll = float64(v.ScrollSum) / float64(v.ScrollCount)
+		}
+		report.Days = append(report.Days, stat)
+
+		report.Totals.Views += stat.Views
+		report.Totals.Visits += stat.Visits
+		report.Totals.Likes += stat.Likes
+		report.Totals.Favorites += stat.Favorites
+		report.Totals.Comments += stat.Comments
+		durationSum += v.DurationSum
+		durationCount += v.DurationCount
+		scrollSum += v.ScrollSum
+		scrollCount += v.ScrollCount
+	}
+	if durationCount > 0 {
+		report.Totals.AvgDuration = float64(durationSum) / float64(durationCount)
+	}
+	if scrollCount > 0 {
+		report.Totals.AvgScroll = float64(scrollSum) / float64(scrollCount)
+	}
+
+	for _, r := range referrers {
+		report.Referrers = append(report.Referrers, dto.SourceStat{Domain: r.Domain, Category: r.Category, Visits: r.PV, UV: r.UV})
+	}
+	return report, nil
+}
+
+// ExportUserData 导出用户的访问、浏览和登录记录
+func (uc *analyticsUseCase) ExportUserData(userID uint) (*dto.TrackingExport, error) {
+	t, err := uc.data.AnalyticsRepo.ListUserTracking(userID)
+	if err != nil {
+		return nil, errors.New("查询追踪数据失败")
+	}
+
+	export := &dto.TrackingExport{
+		UserID:     userID,
+		ExportedAt: time.Now(),
+		Visits:     make([]dto.TrackingVisit, 0, len(t.Visits)),
+		Views:      make([]dto.TrackingView, 0, len(t.Views)),
+		Logins:     make([]dto.TrackingLogin, 0, len(t.Logins)),
+	}
+	for _, v := range t.Visits {
+		export.Visits = append(export.Visits, dto.TrackingVisit{
+			Path:          v.Path,
+			ArticleID:     v.ArticleID,
+			Duration:      v.Duration,
+			ScrollPercent: v.ScrollPercent,
+			Referrer:      v.Referrer,
+			IP:            v.IP,
+			UserAgent:     v.UserAgent,
+			Browser:       v.Browser,
+			OS:            v.OS,
+			Device:        v.Device,
+			Location:      geoip.Location{Country: v.Country, Province: v.Province, City: v.City}.String(),
+			CreatedAt:     v.CreatedAt,
+		})
+	}
+	for _, v := range t.Views {
+		export.Views = append(export.Views, dto.TrackingView{
+			ArticleID: v.ArticleID,
+			IP:        v.IP,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+	for _, l := range t.Logins {
+		export.Logins = append(export.Logins, dto.TrackingLogin{
+			IP:        l.IP,
+			UserAgent: l.UserAgent,
+			Browser:   l.Browser,
+			OS:        l.OS,
+			Device:    l.Device,
+			Location:  geoip.Location{Country: l.Country, Province: l.Province, City: l.City}.String(),
+			CreatedAt: l.CreatedAt,
+		})
+	}
+	return export, nil
+}
+
+// DeleteUserData 删除用户的访问、浏览和登录记录
+// 已汇总的统计数据和独立访客估算不含个人信息，不受影响；文章累计浏览量不会减少
+func (uc *analyticsUseCase) DeleteUserData(userID uint) (*dto.TrackingDeleteResult, error) {
+	visits, views, logins, err := uc.data.AnalyticsRepo.DeleteUserTracking(userID)
+	if err != nil {
+		return nil, errors.New("删除追踪数据失败")
+	}
+	return &dto.TrackingDeleteResult{Visits: visits, Views: views, Logins: logins}, nil
+}
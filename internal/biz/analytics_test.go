@@ -0,0 +1,101 @@
+package biz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/privacy"
+)
+
+func useMemoryCache(tb testing.TB) cache.Cache {
+	tb.Helper()
+	prev := cache.Default()
+	store := cache.NewMemory()
+	cache.Use(store)
+	tb.Cleanup(func() { cache.Use(prev) })
+	return store
+}
+
+func TestAcquireLock(t *testing.T) {
+	store := useMemoryCache(t)
+
+	release, ok := acquireLock("test:lock", 30*time.Millisecond)
+	if !ok {
+		t.Fatal("first acquire failed")
+	}
+	if _, ok := acquireLock("test:lock", time.Minute); ok {
+		t.Fatal("second acquire succeeded while lock is held")
+	}
+
+	// 持有期间续期，超过 TTL 仍未过期
+	time.Sleep(80 * time.Millisecond)
+	if exists, _ := store.Exists("test:lock"); !exists {
+		t.Fatal("lock expired while held")
+	}
+
+	release()
+	if exists, _ := store.Exists("test:lock"); exists {
+		t.Fatal("lock still held after release")
+	}
+	again, ok := acquireLock("test:lock", time.Minute)
+	if !ok {
+		t.Fatal("acquire after release failed")
+	}
+	again()
+}
+
+func TestAcquireLockKeepsOtherOwner(t *testing.T) {
+	store := useMemoryCache(t)
+
+	release, ok := acquireLock("test:lock", time.Minute)
+	if !ok {
+		t.Fatal("acquire failed")
+	}
+	// 锁已过期并被其他实例拿到，释放时不能删除
+	if err := store.Set("test:lock", "other", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if value, _ := store.Get("test:lock"); value != "other" {
+		t.Errorf("lock = %q, want other owner kept", value)
+	}
+}
+
+func TestClampToRetention(t *testing.T) {
+	today := dayStart(time.Now())
+	from, _ := retentionStart(time.Now())
+
+	start, err := clampToRetention(today.AddDate(0, 0, -6), today.AddDate(0, 0, 1))
+	if err != nil || !start.Equal(today.AddDate(0, 0, -6)) {
+		t.Errorf("recent range: start = %v, err = %v", start, err)
+	}
+
+	start, err = clampToRetention(today.AddDate(0, 0, -200), today.AddDate(0, 0, 1))
+	if err != nil || !start.Equal(from) {
+		t.Errorf("partly expired range: start = %v, want %v, err = %v", start, from, err)
+	}
+
+	if _, err := clampToRetention(today.AddDate(0, 0, -300), today.AddDate(0, 0, -200)); err == nil {
+		t.Error("expired range: want error")
+	}
+}
+
+func TestVisitorID(t *testing.T) {
+	useMemoryCache(t)
+	prev := privacy.Default()
+	t.Cleanup(func() { privacy.Use(prev) })
+	p, err := privacy.New(privacy.ModeTruncate, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privacy.Use(p)
+
+	if got := visitorID(7, "203.0.113.1", "ua"); got != "u:7" {
+		t.Errorf("user visitorID = %q, want u:7", got)
+	}
+	// 游客使用隐私策略按周期更换盐的哈希，与在线访客的标识一致
+	if got, want := visitorID(0, "203.0.113.1", "ua"), privacy.Visitor("203.0.113.1", "ua"); got != want {
+		t.Errorf("guest visitorID = %q, want %q", got, want)
+	}
+}
@@ -44,12 +44,16 @@ type ArticleUseCase interface {
 
 // articleUseCase 文章业务用例实现
 type articleUseCase struct {
-	data *data.Data
+	data           *data.Data
+	imageProcessor *mdutils.ImageProcessor
 }
 
 // NewArticleUseCase 创建文章业务用例
 func NewArticleUseCase(d *data.Data) ArticleUseCase {
-	return &articleUseCase{data: d}
+	return &articleUseCase{
+		data:           d,
+		imageProcessor: mdutils.NewImageProcessor("uploads", "").WithCache(d.ImageCacheRepo),
+	}
 }
 
 // Create 创建文章
@@ -60,12 +64,8 @@ func (uc *articleUseCase) Create(req *dto.CreateArticleRequest, authorID uint) (
 	}
 
 	// 处理 Markdown 中的图片（下载外部图片并替换为本地链接）
-	processor := mdutils.NewImageProcessor("uploads", "")
-	processedMarkdown, err := processor.ProcessMarkdownImages(req.ContentMarkdown)
-	if err != nil {
-		// 图片处理失败不阻断文章创建，使用原始内容
-		processedMarkdown = req.ContentMarkdown
-	}
+	// 图片处理失败不阻断文章创建，失败的图片保留原链接
+	processedMarkdown, imageResult := uc.imageProcessor.Process(req.ContentMarkdown)
 
 	// 清理 Markdown 内容中的多余符号
 	processedMarkdown = mdutils.CleanMarkdownContent(processedMarkdown)
@@ -79,6 +79,7 @@ func (uc *articleUseCase) Create(req *dto.CreateArticleRequest, authorID uint) (
 	// 创建文章
 	article := &po.Article{
 		Title:           req.Title,
+		Slug:            req.Slug,
 		ContentMarkdown: processedMarkdown, // 使用处理后的 Markdown
 		ContentHTML:     contentHTML,
 		Summary:         req.Summary,
@@ -94,6 +95,15 @@ func (uc *articleUseCase) Create(req *dto.CreateArticleRequest, authorID uint) (
 		article.CreatedAt = *req.CreatedAt
 	}
 
+	// 设置章节内排序
+	if article.ChapterID != nil {
+		if req.SortInChapter != nil {
+			article.SortInChapter = *req.SortInChapter
+		} else {
+			article.SortInChapter = uc.defaultSortInChapter(*article.ChapterID, article.Title)
+		}
+	}
+
 	if err := uc.data.ArticleRepo.Create(article); err != nil {
 		return nil, errors.New("创建文章失败: " + err.Error())
 	}
@@ -106,7 +116,12 @@ func (uc *articleUseCase) Create(req *dto.CreateArticleRequest, authorID uint) (
 	}
 
 	// 重新查询文章（包含关联数据）
-	return uc.GetByID(article.ID)
+	resp, err := uc.GetByID(article.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp.ImageFailures = imageFailures(imageResult)
+	return resp, nil
 }
 
 // Update 更新文章
@@ -118,17 +133,18 @@ func (uc *articleUseCase) Update(id uint, req *dto.UpdateArticleRequest) (*dto.A
 	}
 
 	// 更新字段
+	var imageResult *mdutils.ProcessResult
 	if req.Title != "" {
 		article.Title = req.Title
 	}
+	if req.Slug != "" {
+		article.Slug = req.Slug
+	}
 	if req.ContentMarkdown != "" {
 		// 处理 Markdown 中的图片（下载外部图片并替换为本地链接）
-		processor := mdutils.NewImageProcessor("uploads", "")
-		processedMarkdown, err := processor.ProcessMarkdownImages(req.ContentMarkdown)
-		if err != nil {
-			// 图片处理失败不阻断文章更新，使用原始内容
-			processedMarkdown = req.ContentMarkdown
-		}
+		// 图片处理失败不阻断文章更新，失败的图片保留原链接
+		var processedMarkdown string
+		processedMarkdown, imageResult = uc.imageProcessor.Process(req.ContentMarkdown)
 
 		// 清理 Markdown 内容中的多余符号
 		processedMarkdown = mdutils.CleanMarkdownContent(processedMarkdown)
@@ -154,8 +170,14 @@ func (uc *articleUseCase) Update(id uint, req *dto.UpdateArticleRequest) (*dto.A
 		}
 		article.CategoryID = req.CategoryID
 	}
-	// 设置章节ID（可为空）
+	// 设置章节ID（可为空），移动到新章节时重新计算章节内排序
+	chapterChanged := req.ChapterID != nil && (article.ChapterID == nil || *article.ChapterID != *req.ChapterID)
 	article.ChapterID = req.ChapterID
+	if req.SortInChapter != nil {
+		article.SortInChapter = *req.SortInChapter
+	} else if chapterChanged {
+		article.SortInChapter = uc.defaultSortInChapter(*req.ChapterID, article.Title)
+	}
 	if req.Status >= 0 {
 		article.Status = req.Status
 	}
@@ -177,7 +199,36 @@ func (uc *articleUseCase) Update(id uint, req *dto.UpdateArticleRequest) (*dto.A
 	}
 
 	// 重新查询文章
-	return uc.GetByID(id)
+	resp, err := uc.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	resp.ImageFailures = imageFailures(imageResult)
+	return resp, nil
+}
+
+// defaultSortInChapter 新加入章节的文章的默认排序：标题带序号（如 "1."、"一、"）时使用序号，否则排在章节末尾
+func (uc *articleUseCase) defaultSortInChapter(chapterID uint, title string) int {
+	if num, ok := data.TitleNumber(title); ok {
+		return num
+	}
+	next, err := uc.data.ChapterRepo.NextSortInChapter(chapterID)
+	if err != nil {
+		return 0
+	}
+	return next
+}
+
+// imageFailures 提取处理失败的图片
+func imageFailures(result *mdutils.ProcessResult) []dto.ImageFailure {
+	if result == nil {
+		return nil
+	}
+	var failures []dto.ImageFailure
+	for _, img := range result.Failed() {
+		failures = append(failures, dto.ImageFailure{URL: img.URL, Error: img.Error})
+	}
+	return failures
 }
 
 // Delete 删除文章
@@ -208,12 +259,23 @@ func (uc *articleUseCase) GetByID(id uint) (*dto.ArticleResponse, error) {
 func (uc *articleUseCase) List(req *dto.ArticleListRequest) (*dto.PageResponse, error) {
 	// 解析查询参数
 	var categoryID, tagID uint
-	if req.Category != "" {
+	if req.CategoryID > 0 {
+		categoryID = req.CategoryID
+	} else if req.Category != "" {
 		category, err := uc.data.CategoryRepo.FindByName(req.Category)
 		if err == nil {
 			categoryID = category.ID
 		}
 	}
+	// 分类筛选包含所有子孙分类
+	var categoryIDs []uint
+	if categoryID > 0 {
+		ids, err := uc.data.CategoryRepo.Descendants(categoryID)
+		if err != nil {
+			return nil, errors.New("查询文章列表失败")
+		}
+		categoryIDs = ids
+	}
 	if req.Tag != "" {
 		tag, err := uc.data.TagRepo.FindByName(req.Tag)
 		if err == nil {
@@ -224,7 +286,7 @@ func (uc *articleUseCase) List(req *dto.ArticleListRequest) (*dto.PageResponse,
 	// 查询文章列表
 	articles, total, err := uc.data.ArticleRepo.List(
 		req.Page, req.Limit,
-		categoryID, tagID,
+		categoryIDs, tagID,
 		req.Status, req.Keyword, req.Sort,
 	)
 	if err != nil {
@@ -264,6 +326,7 @@ func (uc *articleUseCase) convertToArticleResponse(article *po.Article) *dto.Art
 	resp := &dto.ArticleResponse{
 		ID:              article.ID,
 		Title:           article.Title,
+		Slug:            article.Slug,
 		ContentMarkdown: article.ContentMarkdown,
 		ContentHTML:     article.ContentHTML,
 		Summary:         article.Summary,
@@ -271,6 +334,7 @@ func (uc *articleUseCase) convertToArticleResponse(article *po.Article) *dto.Art
 		AuthorID:        article.AuthorID,
 		CategoryID:      article.CategoryID,
 		ChapterID:       article.ChapterID,
+		SortInChapter:   article.SortInChapter,
 		Status:          article.Status,
 		ViewCount:       article.ViewCount,
 		LikeCount:       article.LikeCount,
@@ -462,12 +526,30 @@ func (uc *articleUseCase) BatchUpdateFields(req *dto.BatchUpdateFieldsRequest) e
 		updates["created_at"] = *req.CreatedAt
 	}
 
+	// 移入新章节的文章需要重新计算章节内排序
+	var moved []po.Article
+	if req.ChapterID != nil {
+		if err := uc.data.GetDB().Select("id, title").
+			Where("id IN ? AND (chapter_id IS NULL OR chapter_id <> ?)", req.ArticleIDs, *req.ChapterID).
+			Order("id ASC").
+			Find(&moved).Error; err != nil {
+			return errors.New("批量更新字段失败: " + err.Error())
+		}
+	}
+
 	// 更新基础字段
 	if len(updates) > 0 {
 		if err := uc.data.ArticleRepo.BatchUpdateFields(req.ArticleIDs, updates); err != nil {
 			return errors.New("批量更新字段失败: " + err.Error())
 		}
 	}
+	for _, article := range moved {
+		sortInChapter := uc.defaultSortInChapter(*req.ChapterID, article.Title)
+		if err := uc.data.GetDB().Model(&po.Article{}).Where("id = ?", article.ID).
+			UpdateColumn("sort_in_chapter", sortInChapter).Error; err != nil {
+			return errors.New("批量更新字段失败: " + err.Error())
+		}
+	}
 
 	// 更新标签关联
 	if len(req.TagIDs) > 0 {
@@ -501,14 +583,17 @@ func (uc *articleUseCase) GetAdjacentArticles(id uint) (map[string]*dto.ArticleL
 
 	result := make(map[string]*dto.ArticleListItem)
 
-	if prevArticle != nil {
-		prev := uc.convertToArticleListItem(prevArticle)
-		result["prev"] = &prev
-	}
-
-	if nextArticle != nil {
-		next := uc.convertToArticleListItem(nextArticle)
-		result["next"] = &next
+	// 相邻文章只包含排序字段，列表项需要的作者、分类和标签单独加载
+	for key, adjacent := range map[string]*po.Article{"prev": prevArticle, "next": nextArticle} {
+		if adjacent == nil {
+			continue
+		}
+		article, err := uc.data.ArticleRepo.FindByIDWithRelations(adjacent.ID)
+		if err != nil {
+			return nil, errors.New("获取相邻文章失败: " + err.Error())
+		}
+		item := uc.convertToArticleListItem(article)
+		result[key] = &item
 	}
 
 	return result, nil
@@ -0,0 +1,616 @@
+package biz
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/backup"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BackupUseCase 全站备份与恢复业务用例接口
+type BackupUseCase interface {
+	// Export 导出全站内容和引用的媒体文件到归档
+	Export(w io.Writer, includePasswords bool) error
+	// Restore 从归档恢复到空数据库，所有 ID 重新分配，maxSize 为归档解压后总大小上限（字节），0 表示不限制
+	Restore(r io.ReaderAt, size, maxSize int64) (*dto.RestoreReport, error)
+}
+
+// backupUseCase 全站备份与恢复业务用例实现
+type backupUseCase struct {
+	data *data.Data
+}
+
+// NewBackupUseCase 创建全站备份与恢复业务用例
+func NewBackupUseCase(d *data.Data) BackupUseCase {
+	return &backupUseCase{data: d}
+}
+
+// articleTag 文章与标签的关联
+type articleTag struct {
+	ArticleID uint
+	TagID     uint
+}
+
+// Export 导出全站内容和引用的媒体文件到归档
+func (uc *backupUseCase) Export(w io.Writer, includePasswords bool) error {
+	db := uc.data.GetDB()
+	aw := backup.NewWriter(w, includePasswords)
+	media := newMediaCollector()
+
+	var users []po.User
+	if err := db.Order("id ASC").Find(&users).Error; err != nil {
+		return err
+	}
+	userRecords := make([]backup.UserRecord, 0, len(users))
+	for _, u := range users {
+		rec := backup.UserRecord{
+			ID: u.ID, Username: u.Username, Email: u.Email, Nickname: u.Nickname,
+			Avatar: u.Avatar, Bio: u.Bio, Skills: u.Skills, Contacts: u.Contacts,
+			Role: u.Role, IsBlogger: u.IsBlogger, Status: u.Status,
+			CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt,
+		}
+		if includePasswords {
+			rec.Password = u.Password
+		}
+		media.collect(u.Avatar)
+		userRecords = append(userRecords, rec)
+	}
+	if err := aw.WriteEntities(backup.UsersFile, len(userRecords), userRecords); err != nil {
+		return err
+	}
+
+	var categories []po.Category
+	if err := db.Order("id ASC").Find(&categories).Error; err != nil {
+		return err
+	}
+	var categoryAliases []po.CategoryAlias
+	if err := db.Order("id ASC").Find(&categoryAliases).Error; err != nil {
+		return err
+	}
+	aliasesByCategory := make(map[uint][]string)
+	for _, a := range categoryAliases {
+		aliasesByCategory[a.CategoryID] = append(aliasesByCategory[a.CategoryID], a.Name)
+	}
+	categoryRecords := make([]backup.CategoryRecord, 0, len(categories))
+	for _, c := range categories {
+		categoryRecords = append(categoryRecords, backup.CategoryRecord{
+			ID: c.ID, ParentID: c.ParentID, Name: c.Name, Description: c.Description, Color: c.Color, Sort: c.Sort,
+			Aliases: aliasesByCategory[c.ID], CreatedAt: c.CreatedAt, UpdatedAt: c.UpdatedAt,
+		})
+	}
+	if err := aw.WriteEntities(backup.CategoriesFile, len(categoryRecords), categoryRecords); err != nil {
+		return err
+	}
+
+	var tags []po.Tag
+	if err := db.Order("id ASC").Find(&tags).Error; err != nil {
+		return err
+	}
+	var tagAliases []po.TagAlias
+	if err := db.Order("id ASC").Find(&tagAliases).Error; err != nil {
+		return err
+	}
+	aliasesByTag := make(map[uint][]string)
+	for _, a := range tagAliases {
+		aliasesByTag[a.TagID] = append(aliasesByTag[a.TagID], a.Name)
+	}
+	tagRecords := make([]backup.TagRecord, 0, len(tags))
+	for _, t := range tags {
+		tagRecords = append(tagRecords, backup.TagRecord{
+			ID: t.ID, Name: t.Name, Color: t.Color, Description: t.Description,
+			Aliases: aliasesByTag[t.ID], CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt,
+		})
+	}
+	if err := aw.WriteEntities(backup.TagsFile, len(tagRecords), tagRecords); err != nil {
+		return err
+	}
+
+	var chapters []po.Chapter
+	if err := db.Order("id ASC").Find(&chapters).Error; err != nil {
+		return err
+	}
+	chapterRecords := make([]backup.ChapterRecord, 0, len(chapters))
+	for _, c := range chapters {
+		chapterRecords = append(chapterRecords, backup.ChapterRecord{
+			ID: c.ID, TagID: c.TagID, ParentID: c.ParentID, Name: c.Name, Sort: c.Sort,
+			CreatedAt: c.CreatedAt, UpdatedAt: c.UpdatedAt,
+		})
+	}
+	if err := aw.WriteEntities(backup.ChaptersFile, len(chapterRecords), chapterRecords); err != nil {
+		return err
+	}
+
+	var articles []po.Article
+	if err := db.Order("id ASC").Find(&articles).Error; err != nil {
+		return err
+	}
+	var links []articleTag
+	if err := db.Table("article_tags").Select("article_id, tag_id").Scan(&links).Error; err != nil {
+		return err
+	}
+	articleTags := make(map[uint][]uint)
+	for _, l := range links {
+		articleTags[l.ArticleID] = append(articleTags[l.ArticleID], l.TagID)
+	}
+	articleRecords := make([]backup.ArticleRecord, 0, len(articles))
+	for _, a := range articles {
+		media.collect(a.Cover)
+		media.collect(a.ContentMarkdown)
+		media.collect(a.ContentHTML)
+		tagIDs := articleTags[a.ID]
+		if tagIDs == nil {
+			tagIDs = []uint{}
+		}
+		articleRecords = append(articleRecords, backup.ArticleRecord{
+			ID: a.ID, Title: a.Title, Slug: a.Slug,
+			ContentMarkdown: a.ContentMarkdown, ContentHTML: a.ContentHTML,
+			Summary: a.Summary, Cover: a.Cover,
+			AuthorID: a.AuthorID, CategoryID: a.CategoryID, ChapterID: a.ChapterID,
+			SortInChapter: a.SortInChapter, TagIDs: tagIDs,
+			Status: a.Status, ViewCount: a.ViewCount, LikeCount: a.LikeCount,
+			FavoriteCount: a.FavoriteCount, CommentCount: a.CommentCount,
+			CreatedAt: a.CreatedAt, UpdatedAt: a.UpdatedAt,
+		})
+	}
+	if err := aw.WriteEntities(backup.ArticlesFile, len(articleRecords), articleRecords); err != nil {
+		return err
+	}
+
+	var comments []po.Comment
+	if err := db.Order("id ASC").Find(&comments).Error; err != nil {
+		return err
+	}
+	commentRecords := make([]backup.CommentRecord, 0, len(comments))
+	for _, c := range comments {
+		commentRecords = append(commentRecords, backup.CommentRecord{
+			ID: c.ID, ArticleID: c.ArticleID, UserID: c.UserID, ParentID: c.ParentID,
+			ReplyToUserID: c.ReplyToUserID, Content: c.Content, LikeCount: c.LikeCount,
+			Status: c.Status, CreatedAt: c.CreatedAt, UpdatedAt: c.UpdatedAt,
+		})
+	}
+	if err := aw.WriteEntities(backup.CommentsFile, len(commentRecords), commentRecords); err != nil {
+		return err
+	}
+
+	var settings []po.Setting
+	if err := db.Order("id ASC").Find(&settings).Error; err != nil {
+		return err
+	}
+	settingRecords := make([]backup.SettingRecord, 0, len(settings))
+	for _, s := range settings {
+		media.collect(s.Value)
+		settingRecords = append(settingRecords, backup.SettingRecord{Key: s.Key, Value: s.Value})
+	}
+	if err := aw.WriteEntities(backup.SettingsFile, len(settingRecords), settingRecords); err != nil {
+		return err
+	}
+
+	var files []po.File
+	if err := db.Order("id ASC").Find(&files).Error; err != nil {
+		return err
+	}
+	fileRecords := make([]backup.FileRecord, 0, len(files))
+	for _, f := range files {
+		media.collect(f.URL)
+		fileRecords = append(fileRecords, backup.FileRecord{
+			ID: f.ID, Name: f.Name, URL: f.URL, Size: f.Size, Type: f.Type,
+			MimeType: f.MimeType, UserID: f.UserID, CreatedAt: f.CreatedAt,
+		})
+	}
+	if err := aw.WriteEntities(backup.FilesFile, len(fileRecords), fileRecords); err != nil {
+		return err
+	}
+
+	// 媒体文件读取失败不影响导出，只记录日志
+	for _, url := range media.list() {
+		objectKey, _ := oss.ObjectKey(url)
+		content, err := oss.ReadFile(url)
+		if err != nil {
+			logger.Warn("Failed to read media for backup: ", url, ": ", err)
+			continue
+		}
+		if err := aw.WriteMedia(url, objectKey, content); err != nil {
+			return err
+		}
+	}
+
+	return aw.Close()
+}
+
+// Restore 从归档恢复到空数据库，所有 ID 重新分配
+func (uc *backupUseCase) Restore(r io.ReaderAt, size, maxSize int64) (*dto.RestoreReport, error) {
+	reader, err := backup.OpenReader(r, size, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.ensureEmpty(); err != nil {
+		return nil, err
+	}
+
+	var (
+		users      []backup.UserRecord
+		categories []backup.CategoryRecord
+		tags       []backup.TagRecord
+		chapters   []backup.ChapterRecord
+		articles   []backup.ArticleRecord
+		comments   []backup.CommentRecord
+		settings   []backup.SettingRecord
+		files      []backup.FileRecord
+	)
+	entities := map[string]interface{}{
+		backup.UsersFile:      &users,
+		backup.CategoriesFile: &categories,
+		backup.TagsFile:       &tags,
+		backup.ChaptersFile:   &chapters,
+		backup.ArticlesFile:   &articles,
+		backup.CommentsFile:   &comments,
+		backup.SettingsFile:   &settings,
+		backup.FilesFile:      &files,
+	}
+	for name, v := range entities {
+		if err := reader.ReadJSON(name, v); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &dto.RestoreReport{Version: reader.Manifest.Version, Warnings: []string{}}
+
+	// 先上传媒体文件，得到新旧地址的映射；数据库恢复失败时删除已上传的文件
+	urls := make(map[string]string)
+	var uploaded []string
+	for _, entry := range reader.Manifest.Media {
+		content, err := reader.ReadMedia(entry)
+		if err != nil {
+			report.Warnings = append(report.Warnings, err.Error())
+			continue
+		}
+		objectKey, ok := oss.ObjectKey(entry.URL)
+		if !ok {
+			objectKey = strings.TrimPrefix(entry.Path, backup.MediaDir+"/")
+		}
+		stored, err := oss.UploadBytes(content, objectKey)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("上传 %s 失败: %v", entry.URL, err))
+			continue
+		}
+		uploaded = append(uploaded, stored)
+		report.Media++
+		if stored != entry.URL {
+			urls[entry.URL] = stored
+		}
+	}
+	rewrite := newURLRewriter(urls)
+
+	err = uc.data.GetDB().Transaction(func(tx *gorm.DB) error {
+		userIDs, err := restoreUsers(tx, users, reader.Manifest.IncludePasswords, rewrite, report)
+		if err != nil {
+			return err
+		}
+
+		categoryIDs := make(map[uint]uint)
+		var createdCategories []backup.CategoryRecord
+		for _, rec := range categories {
+			var existing po.Category
+			if err := tx.Where("name = ?", rec.Name).First(&existing).Error; err == nil {
+				categoryIDs[rec.ID] = existing.ID
+				report.Categories.Existing++
+				continue
+			}
+			category := &po.Category{
+				Name: rec.Name, Description: rec.Description, Color: rec.Color, Sort: rec.Sort,
+				CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt,
+			}
+			if err := tx.Create(category).Error; err != nil {
+				return fmt.Errorf("恢复分类 %s 失败: %w", rec.Name, err)
+			}
+			for _, alias := range rec.Aliases {
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+					Create(&po.CategoryAlias{Name: alias, CategoryID: category.ID}).Error; err != nil {
+					return fmt.Errorf("恢复分类别名 %s 失败: %w", alias, err)
+				}
+			}
+			categoryIDs[rec.ID] = category.ID
+			createdCategories = append(createdCategories, rec)
+			report.Categories.Created++
+		}
+		// 父分类可能排在子分类之后，全部创建后再设置父子关系
+		for _, rec := range createdCategories {
+			parentID := remapID(categoryIDs, rec.ParentID)
+			if parentID == nil {
+				continue
+			}
+			if err := tx.Model(&po.Category{}).Where("id = ?", categoryIDs[rec.ID]).
+				Update("parent_id", *parentID).Error; err != nil {
+				return fmt.Errorf("恢复分类 %s 失败: %w", rec.Name, err)
+			}
+		}
+
+		tagIDs := make(map[uint]uint)
+		for _, rec := range tags {
+			tag := &po.Tag{
+				Name: rec.Name, Color: rec.Color, Description: rec.Description,
+				CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt,
+			}
+			if err := tx.Create(tag).Error; err != nil {
+				return fmt.Errorf("恢复标签 %s 失败: %w", rec.Name, err)
+			}
+			for _, alias := range rec.Aliases {
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+					Create(&po.TagAlias{Name: alias, TagID: tag.ID}).Error; err != nil {
+					return fmt.Errorf("恢复标签别名 %s 失败: %w", alias, err)
+				}
+			}
+			tagIDs[rec.ID] = tag.ID
+			report.Tags.Created++
+		}
+
+		chapterIDs, err := restoreChapters(tx, chapters, tagIDs, report)
+		if err != nil {
+			return err
+		}
+
+		articleIDs := make(map[uint]uint)
+		for _, rec := range articles {
+			article := &po.Article{
+				Title: rec.Title, Slug: rec.Slug,
+				ContentMarkdown: rewrite(rec.ContentMarkdown), ContentHTML: rewrite(rec.ContentHTML),
+				Summary: rec.Summary, Cover: rewrite(rec.Cover),
+				AuthorID: userIDs[rec.AuthorID], CategoryID: categoryIDs[rec.CategoryID],
+				ChapterID: remapID(chapterIDs, rec.ChapterID), SortInChapter: rec.SortInChapter,
+				Status: rec.Status, ViewCount: rec.ViewCount, LikeCount: rec.LikeCount,
+				FavoriteCount: rec.FavoriteCount, CommentCount: rec.CommentCount,
+				CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt,
+			}
+			if err := tx.Omit(clause.Associations).Create(article).Error; err != nil {
+				return fmt.Errorf("恢复文章 %s 失败: %w", rec.Title, err)
+			}
+			articleIDs[rec.ID] = article.ID
+			for _, tagID := range rec.TagIDs {
+				if newID, ok := tagIDs[tagID]; ok {
+					if err := tx.Table("article_tags").Create(map[string]interface{}{"article_id": article.ID, "tag_id": newID}).Error; err != nil {
+						return fmt.Errorf("恢复文章标签失败: %w", err)
+					}
+				}
+			}
+			report.Articles.Created++
+		}
+
+		if err := restoreComments(tx, comments, articleIDs, userIDs, report); err != nil {
+			return err
+		}
+
+		for _, rec := range files {
+			file := &po.File{
+				Name: rec.Name, URL: rewrite(rec.URL), Size: rec.Size, Type: rec.Type,
+				MimeType: rec.MimeType, UserID: userIDs[rec.UserID], CreatedAt: rec.CreatedAt,
+			}
+			if err := tx.Create(file).Error; err != nil {
+				return fmt.Errorf("恢复文件记录 %s 失败: %w", rec.Name, err)
+			}
+			report.Files.Created++
+		}
+
+		for _, rec := range settings {
+			setting := &po.Setting{Key: rec.Key, Value: rewrite(rec.Value)}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(setting).Error; err != nil {
+				return fmt.Errorf("恢复设置 %s 失败: %w", rec.Key, err)
+			}
+			report.Settings.Created++
+		}
+
+		return nil
+	})
+	if err != nil {
+		removeMedia(uploaded)
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// removeMedia 删除恢复失败时已上传的媒体文件
+func removeMedia(urls []string) {
+	for _, url := range urls {
+		if err := oss.RemoveFile(url); err != nil {
+			logger.Warn("Failed to remove restored media: ", url, ": ", err)
+		}
+	}
+}
+
+// ensureEmpty 恢复只允许在没有内容的数据库上进行（默认管理员和默认分类除外）
+func (uc *backupUseCase) ensureEmpty() error {
+	db := uc.data.GetDB()
+	for _, model := range []interface{}{&po.Article{}, &po.Tag{}, &po.Chapter{}, &po.Comment{}} {
+		var count int64
+		if err := db.Model(model).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("数据库中已有内容，只能恢复到空数据库")
+		}
+	}
+	return nil
+}
+
+// restoreUsers 恢复用户，同名用户（如默认管理员）直接复用
+func restoreUsers(tx *gorm.DB, records []backup.UserRecord, withPasswords bool, rewrite func(string) string, report *dto.RestoreReport) (map[uint]uint, error) {
+	ids := make(map[uint]uint)
+	resetCount := 0
+	for _, rec := range records {
+		var existing po.User
+		if err := tx.Where("username = ?", rec.Username).First(&existing).Error; err == nil {
+			ids[rec.ID] = existing.ID
+			report.Users.Existing++
+			continue
+		}
+
+		password := rec.Password
+		if !withPasswords || password == "" {
+			hashed, err := bcrypt.GenerateFromPassword([]byte(uuid.New().String()), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, err
+			}
+			password = string(hashed)
+			resetCount++
+		}
+
+		user := &po.User{
+			Username: rec.Username, Email: rec.Email, Password: password,
+			Nickname: rec.Nickname, Avatar: rewrite(rec.Avatar), Bio: rec.Bio,
+			Skills: rec.Skills, Contacts: rec.Contacts, Role: rec.Role,
+			IsBlogger: rec.IsBlogger, Status: rec.Status,
+			CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt,
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return nil, fmt.Errorf("恢复用户 %s 失败: %w", rec.Username, err)
+		}
+		ids[rec.ID] = user.ID
+		report.Users.Created++
+	}
+	if resetCount > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d 个用户的备份不含密码，已设置随机密码，需要管理员重置后登录", resetCount))
+	}
+	return ids, nil
+}
+
+// restoreChapters 恢复章节，父章节先于子章节创建
+func restoreChapters(tx *gorm.DB, records []backup.ChapterRecord, tagIDs map[uint]uint, report *dto.RestoreReport) (map[uint]uint, error) {
+	ids := make(map[uint]uint)
+	pending := records
+	for len(pending) > 0 {
+		var next []backup.ChapterRecord
+		for _, rec := range pending {
+			if rec.ParentID != nil {
+				if _, ok := ids[*rec.ParentID]; !ok {
+					next = append(next, rec)
+					continue
+				}
+			}
+			chapter := &po.Chapter{
+				TagID: tagIDs[rec.TagID], ParentID: remapID(ids, rec.ParentID),
+				Name: rec.Name, Sort: rec.Sort, CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt,
+			}
+			if err := tx.Omit(clause.Associations).Create(chapter).Error; err != nil {
+				return nil, fmt.Errorf("恢复章节 %s 失败: %w", rec.Name, err)
+			}
+			ids[rec.ID] = chapter.ID
+			report.Chapters.Created++
+		}
+		if len(next) == len(pending) {
+			return nil, errors.New("章节的父级关系无效")
+		}
+		pending = next
+	}
+	return ids, nil
+}
+
+// restoreComments 恢复评论，先创建全部评论再回填父评论
+func restoreComments(tx *gorm.DB, records []backup.CommentRecord, articleIDs, userIDs map[uint]uint, report *dto.RestoreReport) error {
+	ids := make(map[uint]uint)
+	for _, rec := range records {
+		comment := &po.Comment{
+			ArticleID: remapID(articleIDs, rec.ArticleID), UserID: userIDs[rec.UserID],
+			ReplyToUserID: remapID(userIDs, rec.ReplyToUserID), Content: rec.Content,
+			LikeCount: rec.LikeCount, Status: rec.Status,
+			CreatedAt: rec.CreatedAt, UpdatedAt: rec.UpdatedAt,
+		}
+		if err := tx.Omit(clause.Associations).Create(comment).Error; err != nil {
+			return fmt.Errorf("恢复评论 #%d 失败: %w", rec.ID, err)
+		}
+		ids[rec.ID] = comment.ID
+		report.Comments.Created++
+	}
+
+	for _, rec := range records {
+		if parentID := remapID(ids, rec.ParentID); parentID != nil {
+			if err := tx.Model(&po.Comment{}).Where("id = ?", ids[rec.ID]).UpdateColumn("parent_id", *parentID).Error; err != nil {
+				return fmt.Errorf("恢复评论回复关系失败: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// remapID 映射可为空的 ID，找不到时返回 nil
+func remapID(ids map[uint]uint, id *uint) *uint {
+	if id == nil {
+		return nil
+	}
+	newID, ok := ids[*id]
+	if !ok {
+		return nil
+	}
+	return &newID
+}
+
+// newURLRewriter 创建媒体地址替换函数，较长的地址优先替换
+func newURLRewriter(urls map[string]string) func(string) string {
+	if len(urls) == 0 {
+		return func(s string) string { return s }
+	}
+	olds := make([]string, 0, len(urls))
+	for old := range urls {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool { return len(olds[i]) > len(olds[j]) })
+
+	pairs := make([]string, 0, len(urls)*2)
+	for _, old := range olds {
+		pairs = append(pairs, old, urls[old])
+	}
+	replacer := strings.NewReplacer(pairs...)
+	return replacer.Replace
+}
+
+// mediaCollector 收集内容中引用的本站媒体地址
+type mediaCollector struct {
+	pattern *regexp.Regexp
+	seen    map[string]bool
+	urls    []string
+}
+
+// newMediaCollector 创建媒体地址收集器，匹配本地 /uploads/ 和 OSS 地址
+func newMediaCollector() *mediaCollector {
+	prefixes := []string{regexp.QuoteMeta("/uploads/")}
+	if baseURL := strings.TrimRight(config.AppConfig.OSS.BaseURL, "/"); baseURL != "" {
+		prefixes = append(prefixes, regexp.QuoteMeta(baseURL+"/"))
+	}
+	return &mediaCollector{
+		pattern: regexp.MustCompile(`(?:` + strings.Join(prefixes, "|") + `)[^\s"'()<>\[\]]+`),
+		seen:    make(map[string]bool),
+	}
+}
+
+// collect 从文本中收集媒体地址
+func (m *mediaCollector) collect(text string) {
+	for _, url := range m.pattern.FindAllString(text, -1) {
+		if m.seen[url] || path.Ext(url) == "" {
+			continue
+		}
+		m.seen[url] = true
+		m.urls = append(m.urls, url)
+	}
+}
+
+// list 返回收集到的媒体地址
+func (m *mediaCollector) list() []string {
+	return m.urls
+}
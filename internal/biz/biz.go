@@ -6,24 +6,41 @@ import (
 
 // Biz 业务逻辑层结构
 type Biz struct {
-	AuthUseCase     AuthUseCase
-	ArticleUseCase  ArticleUseCase
-	UserUseCase     UserUseCase
-	CategoryUseCase CategoryUseCase
-	TagUseCase      TagUseCase
-	CommentUseCase  CommentUseCase
-	BlogUseCase     BlogUseCase
+	AuthUseCase      AuthUseCase
+	ArticleUseCase   ArticleUseCase
+	UserUseCase      UserUseCase
+	CategoryUseCase  CategoryUseCase
+	TagUseCase       TagUseCase
+	CommentUseCase   CommentUseCase
+	BlogUseCase      BlogUseCase
+	ImportJobUseCase ImportJobUseCase
+	MigrationUseCase MigrationUseCase
+	BackupUseCase    BackupUseCase
+	ChapterUseCase   ChapterUseCase
+	AnalyticsUseCase AnalyticsUseCase
+	ViewUseCase      ViewUseCase
 }
 
 // NewBiz 创建业务逻辑层实例
 func NewBiz(d *data.Data) *Biz {
+	articleUseCase := NewArticleUseCase(d)
+
+	// 迁移任务在后台 goroutine 中执行，服务启动时清理上次未结束的任务
+	failInterruptedRuns(d)
+
 	return &Biz{
-		AuthUseCase:     NewAuthUseCase(d),
-		ArticleUseCase:  NewArticleUseCase(d),
-		UserUseCase:     NewUserUseCase(d),
-		CategoryUseCase: NewCategoryUseCase(d),
-		TagUseCase:      NewTagUseCase(d),
-		CommentUseCase:  NewCommentUseCase(d),
-		BlogUseCase:     NewBlogUseCase(d),
+		AuthUseCase:      NewAuthUseCase(d),
+		ArticleUseCase:   articleUseCase,
+		UserUseCase:      NewUserUseCase(d),
+		CategoryUseCase:  NewCategoryUseCase(d),
+		TagUseCase:       NewTagUseCase(d),
+		CommentUseCase:   NewCommentUseCase(d),
+		BlogUseCase:      NewBlogUseCase(d),
+		ImportJobUseCase: NewImportJobUseCase(d, articleUseCase),
+		MigrationUseCase: NewMigrationUseCase(d, articleUseCase),
+		BackupUseCase:    NewBackupUseCase(d),
+		ChapterUseCase:   NewChapterUseCase(d),
+		AnalyticsUseCase: NewAnalyticsUseCase(d),
+		ViewUseCase:      NewViewUseCase(d),
 	}
 }
@@ -207,10 +207,7 @@ func (uc *blogUseCase) GetArticleDetail(articleID, userID uint) (*dto.ArticleDet
 		return nil, errors.New("文章不存在或未发布")
 	}
 
-	// 增加浏览量（异步更新，不影响返回）
-	go func() {
-		_ = uc.data.ArticleRepo.IncrementViewCount(articleID)
-	}()
+	// 浏览量由 ViewUseCase 去重后批量写入
 
 	// 转换为响应结构
 	articleResp := &dto.ArticleResponse{
@@ -270,10 +267,25 @@ func (uc *blogUseCase) GetArticleDetail(articleID, userID uint) (*dto.ArticleDet
 		isFavorited, _ = uc.data.FavoriteRepo.Exists(articleID, userID)
 	}
 
+	// 分类面包屑
+	breadcrumbs := []dto.CategoryInfo{}
+	if article.CategoryID > 0 {
+		if path, err := uc.data.CategoryRepo.Ancestors(article.CategoryID); err == nil {
+			for _, category := range path {
+				breadcrumbs = append(breadcrumbs, dto.CategoryInfo{
+					ID:          category.ID,
+					Name:        category.Name,
+					Description: category.Description,
+				})
+			}
+		}
+	}
+
 	return &dto.ArticleDetailResponse{
 		ArticleResponse: *articleResp,
 		IsLiked:         isLiked,
 		IsFavorited:     isFavorited,
+		Breadcrumbs:     breadcrumbs,
 	}, nil
 }
 
@@ -887,32 +899,20 @@ func (uc *blogUseCase) GetAdjacentArticles(articleID uint) (*dto.AdjacentArticle
 
 	response := &dto.AdjacentArticlesResponse{}
 
-	// 查询上一篇文章（ID小于当前文章ID，按ID降序，取第一条）
-	var prevArticle po.Article
-	err = uc.data.GetDB().Model(&po.Article{}).
-		Where("id < ? AND status = ?", articleID, 1).
-		Order("id DESC").
-		Limit(1).
-		Select("id, title").
-		First(&prevArticle).Error
+	// 与章节目录使用同一顺序：属于章节的文章按目录顺序，其他文章按 ID 顺序
+	prevArticle, nextArticle, err := uc.data.ArticleRepo.GetAdjacentArticles(articleID)
+	if err != nil {
+		return nil, err
+	}
 
-	if err == nil {
+	if prevArticle != nil {
 		response.Prev = &dto.AdjacentArticleSummary{
 			ID:    prevArticle.ID,
 			Title: prevArticle.Title,
 		}
 	}
 
-	// 查询下一篇文章（ID大于当前文章ID，按ID升序，取第一条）
-	var nextArticle po.Article
-	err = uc.data.GetDB().Model(&po.Article{}).
-		Where("id > ? AND status = ?", articleID, 1).
-		Order("id ASC").
-		Limit(1).
-		Select("id, title").
-		First(&nextArticle).Error
-
-	if err == nil {
+	if nextArticle != nil {
 		response.Next = &dto.AdjacentArticleSummary{
 			ID:    nextArticle.ID,
 			Title: nextArticle.Title,
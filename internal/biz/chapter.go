@@ -0,0 +1,631 @@
+package biz
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/book"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+)
+
+// ChapterUseCase 章节业务用例接口
+type ChapterUseCase interface {
+	// GetTreeByTag 获取标签下的章节树及已发布的文章
+	GetTreeByTag(tagID uint) ([]dto.ChapterTreeNode, error)
+	// ExportBook 将标签的章节树导出为电子书，format 为 epub 或 html，返回生成的本地文件路径
+	ExportBook(tagID uint, format string) (string, error)
+	// ReorderArticles 按给定顺序设置章节内文章的排序
+	ReorderArticles(chapterID uint, articleIDs []uint) error
+	// UpdateTree 批量调整标签下章节的父章节和排序，校验无循环后在一个事务中生效
+	UpdateTree(tagID uint, items []dto.ChapterTreeItem) error
+	// CheckParent 校验章节能否挂到 parentID 下：父章节须属于 tagID 且不能是自身或子章节
+	CheckParent(chapterID, tagID, parentID uint) error
+	// MoveToTag 将章节连同子章节和文章移动到其他标签
+	MoveToTag(chapterID uint, req *dto.MoveChapterRequest) error
+	// GetProgress 获取用户在标签章节系列中的阅读进度
+	GetProgress(userID, tagID uint) (*dto.ChapterProgressResponse, error)
+	// ContinueReading 按章节阅读顺序返回用户下一篇要读的文章
+	ContinueReading(userID, tagID uint) (*dto.ContinueReadingResponse, error)
+}
+
+// 电子书导出格式
+const (
+	BookFormatEPUB = "epub"
+	BookFormatHTML = "html"
+)
+
+const (
+	// bookCachePrefix 导出电子书的缓存，key 包含标签、格式和内容版本，值为生成的文件路径
+	bookCachePrefix = "chapter:book:"
+	// bookCacheTTL 导出电子书的缓存时间，内容变化后版本不同，旧缓存自然过期
+	bookCacheTTL = time.Hour
+)
+
+// chapterUseCase 章节业务用例实现
+type chapterUseCase struct {
+	data *data.Data
+	// exportLocks 按标签串行生成电子书，避免缓存失效时并发请求重复读取全部图片
+	exportLocks sync.Map
+}
+
+// NewChapterUseCase 创建章节业务用例
+func NewChapterUseCase(d *data.Data) ChapterUseCase {
+	return &chapterUseCase{data: d}
+}
+
+// GetTreeByTag 获取标签下的章节树及已发布的文章
+func (uc *chapterUseCase) GetTreeByTag(tagID uint) ([]dto.ChapterTreeNode, error) {
+	// 查询该标签下的所有章节
+	chapters, err := uc.data.ChapterRepo.ListByTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return []dto.ChapterTreeNode{}, nil
+	}
+
+	// 收集所有章节ID
+	chapterIDs := make([]uint, len(chapters))
+	for i, chapter := range chapters {
+		chapterIDs[i] = chapter.ID
+	}
+
+	// 一次性查询所有章节的文章 (优化N+1查询问题)
+	var allArticles []po.Article
+	if err := uc.data.GetDB().Where("chapter_id IN ? AND status = 1", chapterIDs).
+		Select("id, title, chapter_id, sort_in_chapter, view_count, created_at").
+		Find(&allArticles).Error; err != nil {
+		return nil, err
+	}
+
+	// 按章节目录的阅读顺序排列后分组，与上一篇/下一篇使用同一顺序
+	articlesByChapter := make(map[uint][]po.Article)
+	for _, article := range data.ChapterReadingOrder(chapters, allArticles) {
+		articlesByChapter[*article.ChapterID] = append(articlesByChapter[*article.ChapterID], article)
+	}
+
+	// 按父章节分组，章节已按 sort、id 排序
+	children := make(map[uint][]po.Chapter)
+	var roots []po.Chapter
+	for _, chapter := range chapters {
+		if chapter.ParentID == nil {
+			roots = append(roots, chapter)
+		} else {
+			children[*chapter.ParentID] = append(children[*chapter.ParentID], chapter)
+		}
+	}
+
+	// 递归构建树形结构，支持任意层级
+	var build func(chapters []po.Chapter) []dto.ChapterTreeNode
+	build = func(chapters []po.Chapter) []dto.ChapterTreeNode {
+		nodes := make([]dto.ChapterTreeNode, 0, len(chapters))
+		for _, chapter := range chapters {
+			articles := make([]dto.ChapterArticle, 0, len(articlesByChapter[chapter.ID]))
+			for _, a := range articlesByChapter[chapter.ID] {
+				articles = append(articles, dto.ChapterArticle{
+					ID: a.ID, Title: a.Title, ChapterID: a.ChapterID, SortInChapter: a.SortInChapter,
+					ViewCount: a.ViewCount, CreatedAt: a.CreatedAt,
+				})
+			}
+			nodes = append(nodes, dto.ChapterTreeNode{
+				ID:          chapter.ID,
+				TagID:       chapter.TagID,
+				ParentID:    chapter.ParentID,
+				Name:        chapter.Name,
+				Sort:        chapter.Sort,
+				CreatedAt:   chapter.CreatedAt,
+				UpdatedAt:   chapter.UpdatedAt,
+				Articles:    articles,
+				SubChapters: build(children[chapter.ID]),
+			})
+		}
+		return nodes
+	}
+
+	return build(roots), nil
+}
+
+// ReorderArticles 按给定顺序设置章节内文章的排序
+func (uc *chapterUseCase) ReorderArticles(chapterID uint, articleIDs []uint) error {
+	if _, err := uc.data.ChapterRepo.FindByID(chapterID); err != nil {
+		return errors.New("章节不存在")
+	}
+
+	seen := make(map[uint]bool, len(articleIDs))
+	for _, id := range articleIDs {
+		if seen[id] {
+			return fmt.Errorf("文章 #%d 重复", id)
+		}
+		seen[id] = true
+	}
+
+	var count int64
+	if err := uc.data.GetDB().Model(&po.Article{}).
+		Where("id IN ? AND chapter_id = ?", articleIDs, chapterID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(articleIDs) {
+		return errors.New("部分文章不存在或不属于该章节")
+	}
+
+	return uc.data.ChapterRepo.ReorderArticles(chapterID, articleIDs)
+}
+
+// UpdateTree 批量调整标签下章节的父章节和排序，校验无循环后在一个事务中生效
+func (uc *chapterUseCase) UpdateTree(tagID uint, items []dto.ChapterTreeItem) error {
+	chapters, err := uc.data.ChapterRepo.ListByTag(tagID)
+	if err != nil {
+		return err
+	}
+	parents := make(map[uint]*uint, len(chapters))
+	for _, chapter := range chapters {
+		parents[chapter.ID] = chapter.ParentID
+	}
+
+	seen := make(map[uint]bool, len(items))
+	updates := make([]po.Chapter, 0, len(items))
+	for _, item := range items {
+		if _, ok := parents[item.ID]; !ok {
+			return fmt.Errorf("章节 #%d 不存在或不属于该标签", item.ID)
+		}
+		if seen[item.ID] {
+			return fmt.Errorf("章节 #%d 重复", item.ID)
+		}
+		seen[item.ID] = true
+		if item.ParentID != nil {
+			if _, ok := parents[*item.ParentID]; !ok {
+				return fmt.Errorf("父章节 #%d 不存在或不属于该标签", *item.ParentID)
+			}
+		}
+		updates = append(updates, po.Chapter{ID: item.ID, ParentID: item.ParentID, Sort: item.Sort})
+	}
+
+	// 在调整后的父子关系上检查循环，未列出的章节沿用原父章节
+	for _, item := range items {
+		parents[item.ID] = item.ParentID
+	}
+	for _, chapter := range chapters {
+		if chapterInCycle(parents, chapter.ID) {
+			return fmt.Errorf("章节 #%d 的父子关系形成循环", chapter.ID)
+		}
+	}
+
+	return uc.data.ChapterRepo.UpdateTree(updates)
+}
+
+// CheckParent 校验章节能否挂到 parentID 下：父章节须属于 tagID 且不能是自身或子章节
+func (uc *chapterUseCase) CheckParent(chapterID, tagID, parentID uint) error {
+	parent, err := uc.data.ChapterRepo.FindByID(parentID)
+	if err != nil {
+		return errors.New("父章节不存在")
+	}
+	if parent.TagID != tagID {
+		return errors.New("父章节不属于该标签")
+	}
+
+	chapters, err := uc.data.ChapterRepo.ListByTag(parent.TagID)
+	if err != nil {
+		return err
+	}
+	parents := make(map[uint]*uint, len(chapters)+1)
+	for _, chapter := range chapters {
+		parents[chapter.ID] = chapter.ParentID
+	}
+	parents[chapterID] = &parentID
+	if chapterInCycle(parents, chapterID) {
+		return errors.New("不能移动到自身或子章节下")
+	}
+	return nil
+}
+
+// MoveToTag 将章节连同子章节和文章移动到其他标签
+func (uc *chapterUseCase) MoveToTag(chapterID uint, req *dto.MoveChapterRequest) error {
+	chapter, err := uc.data.ChapterRepo.FindByID(chapterID)
+	if err != nil {
+		return errors.New("章节不存在")
+	}
+	if _, err := uc.data.TagRepo.FindByID(req.TagID); err != nil {
+		return errors.New("标签不存在")
+	}
+
+	// 收集子树内的所有章节
+	chapters, err := uc.data.ChapterRepo.ListByTag(chapter.TagID)
+	if err != nil {
+		return err
+	}
+	children := make(map[uint][]uint)
+	for _, c := range chapters {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	subtree := []uint{chapter.ID}
+	for i := 0; i < len(subtree); i++ {
+		subtree = append(subtree, children[subtree[i]]...)
+	}
+
+	if req.ParentID != nil {
+		if err := uc.CheckParent(chapter.ID, req.TagID, *req.ParentID); err != nil {
+			return err
+		}
+	}
+
+	sortOrder := 0
+	if req.Sort != nil {
+		sortOrder = *req.Sort
+	} else {
+		// 排在目标位置同级章节的最后
+		siblings, err := uc.data.ChapterRepo.ListByTag(req.TagID)
+		if err != nil {
+			return err
+		}
+		for _, c := range siblings {
+			if c.ID != chapter.ID && sameParent(c.ParentID, req.ParentID) && c.Sort >= sortOrder {
+				sortOrder = c.Sort + 1
+			}
+		}
+	}
+
+	return uc.data.ChapterRepo.MoveSubtree(chapter.ID, req.ParentID, sortOrder, subtree, chapter.TagID, req.TagID)
+}
+
+// chapterInCycle 沿父章节链向上查找，回到出发章节或链中出现重复即为循环
+func chapterInCycle(parents map[uint]*uint, id uint) bool {
+	visited := map[uint]bool{id: true}
+	for parent := parents[id]; parent != nil; parent = parents[*parent] {
+		if visited[*parent] {
+			return true
+		}
+		visited[*parent] = true
+	}
+	return false
+}
+
+// sameParent 判断两个父章节ID是否相同，都为空表示同为一级章节
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// GetProgress 获取用户在标签章节系列中的阅读进度
+func (uc *chapterUseCase) GetProgress(userID, tagID uint) (*dto.ChapterProgressResponse, error) {
+	articles, err := uc.readingProgress(userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.ChapterProgressResponse{TagID: tagID, Total: len(articles), Articles: articles}
+	for i := range articles {
+		if articles[i].Completed {
+			result.Completed++
+		}
+		if articles[i].LastReadAt != nil && (result.LastRead == nil || articles[i].LastReadAt.After(*result.LastRead.LastReadAt)) {
+			result.LastRead = &articles[i]
+		}
+	}
+	if result.Total > 0 {
+		result.Percent = result.Completed * 100 / result.Total
+	}
+	return result, nil
+}
+
+// ContinueReading 按章节阅读顺序返回用户下一篇要读的文章
+// 最近阅读的文章未读完时继续该篇，否则取其后第一篇未读完的文章，后面都已读完时从头查找
+func (uc *chapterUseCase) ContinueReading(userID, tagID uint) (*dto.ContinueReadingResponse, error) {
+	articles, err := uc.readingProgress(userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	last := -1
+	for i, article := range articles {
+		if article.LastReadAt != nil && (last < 0 || article.LastReadAt.After(*articles[last].LastReadAt)) {
+			last = i
+		}
+	}
+	if last >= 0 {
+		start = last
+	}
+
+	for i := 0; i < len(articles); i++ {
+		article := articles[(start+i)%len(articles)]
+		if !article.Completed {
+			return &dto.ContinueReadingResponse{Article: &article}, nil
+		}
+	}
+	return &dto.ContinueReadingResponse{Finished: len(articles) > 0}, nil
+}
+
+// readingProgress 按章节阅读顺序列出标签下已发布的文章及用户的阅读进度
+func (uc *chapterUseCase) readingProgress(userID, tagID uint) ([]dto.ArticleProgress, error) {
+	chapters, err := uc.data.ChapterRepo.ListByTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return []dto.ArticleProgress{}, nil
+	}
+	chapterIDs := make([]uint, len(chapters))
+	for i, chapter := range chapters {
+		chapterIDs[i] = chapter.ID
+	}
+
+	var articles []po.Article
+	if err := uc.data.GetDB().Where("chapter_id IN ? AND status = 1", chapterIDs).
+		Select("id, title, chapter_id, sort_in_chapter, created_at").
+		Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	articles = data.ChapterReadingOrder(chapters, articles)
+
+	articleIDs := make([]uint, len(articles))
+	for i, article := range articles {
+		articleIDs[i] = article.ID
+	}
+	records, err := uc.data.ReadingProgressRepo.ListByArticles(userID, articleIDs)
+	if err != nil {
+		return nil, err
+	}
+	progress := make(map[uint]po.ReadingProgress, len(records))
+	for _, record := range records {
+		progress[record.ArticleID] = record
+	}
+
+	result := make([]dto.ArticleProgress, 0, len(articles))
+	for _, article := range articles {
+		item := dto.ArticleProgress{ArticleID: article.ID, Title: article.Title, ChapterID: article.ChapterID}
+		if record, ok := progress[article.ID]; ok {
+			lastReadAt := record.LastReadAt
+			item.ScrollPercent = record.ScrollPercent
+			item.Completed = record.Completed
+			item.LastReadAt = &lastReadAt
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// ExportBook 将标签的章节树导出为电子书，format 为 epub 或 html，返回生成的本地文件路径
+func (uc *chapterUseCase) ExportBook(tagID uint, format string) (string, error) {
+	if format != BookFormatEPUB && format != BookFormatHTML {
+		return "", fmt.Errorf("不支持的导出格式: %s", format)
+	}
+
+	tag, err := uc.data.TagRepo.FindByID(tagID)
+	if err != nil {
+		return "", errors.New("标签不存在")
+	}
+	tree, err := uc.GetTreeByTag(tagID)
+	if err != nil {
+		return "", err
+	}
+	if len(tree) == 0 {
+		return "", errors.New("该标签下没有章节")
+	}
+
+	// 按阅读顺序收集文章
+	var articleIDs []uint
+	var collect func(nodes []dto.ChapterTreeNode)
+	collect = func(nodes []dto.ChapterTreeNode) {
+		for _, node := range nodes {
+			for _, article := range node.Articles {
+				articleIDs = append(articleIDs, article.ID)
+			}
+			collect(node.SubChapters)
+		}
+	}
+	collect(tree)
+
+	// 先按目录结构和更新时间算出内容版本，命中缓存时不再读取正文和图片
+	version, err := uc.bookVersion(tag, tree, articleIDs)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s%d:%s:%s", bookCachePrefix, tag.ID, format, version)
+	if path, ok := cachedBook(key); ok {
+		return path, nil
+	}
+
+	lock, _ := uc.exportLocks.LoadOrStore(tag.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+	// 等锁期间其他请求可能已经生成好了
+	if path, ok := cachedBook(key); ok {
+		return path, nil
+	}
+
+	var articles []po.Article
+	if len(articleIDs) > 0 {
+		if err := uc.data.GetDB().
+			Select("id, title, content_html, cover, author_id, updated_at").
+			Preload("Author").
+			Where("id IN ?", articleIDs).
+			Find(&articles).Error; err != nil {
+			return "", err
+		}
+	}
+	articleMap := make(map[uint]*po.Article, len(articles))
+	for i := range articles {
+		articleMap[articles[i].ID] = &articles[i]
+	}
+
+	b := &book.Book{
+		ID:       "urn:uuid:" + uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("leaf-api/tags/%d", tag.ID))).String(),
+		Title:    tag.Name,
+		Language: "zh-CN",
+		Modified: tag.UpdatedAt,
+	}
+
+	// 章节作为小节，章节下的文章和子章节作为下级小节
+	var build func(nodes []dto.ChapterTreeNode) []book.Section
+	build = func(nodes []dto.ChapterTreeNode) []book.Section {
+		sections := make([]book.Section, 0, len(nodes))
+		for _, node := range nodes {
+			if node.UpdatedAt.After(b.Modified) {
+				b.Modified = node.UpdatedAt
+			}
+			section := book.Section{Title: node.Name}
+			for _, item := range node.Articles {
+				article, ok := articleMap[item.ID]
+				if !ok {
+					continue
+				}
+				if article.UpdatedAt.After(b.Modified) {
+					b.Modified = article.UpdatedAt
+				}
+				// 作者和封面取第一篇文章
+				if b.Author == "" {
+					b.Author = article.Author.Nickname
+					if b.Author == "" {
+						b.Author = article.Author.Username
+					}
+				}
+				if b.Cover == nil && article.Cover != "" {
+					if cover, err := storageImage(article.Cover); err == nil {
+						b.Cover = cover
+					}
+				}
+				section.Children = append(section.Children, book.Section{Title: article.Title, Content: article.ContentHTML})
+			}
+			section.Children = append(section.Children, build(node.SubChapters)...)
+			sections = append(sections, section)
+		}
+		return sections
+	}
+	b.Sections = build(tree)
+
+	var buf bytes.Buffer
+	if format == BookFormatEPUB {
+		err = book.WriteEPUB(&buf, b, storageImage)
+	} else {
+		err = book.WriteHTML(&buf, b)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	path, err := saveBook(tag.ID, format, version, buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	if err := cache.Default().Set(key, path, bookCacheTTL); err != nil {
+		logger.Warn("缓存电子书失败: ", err)
+	}
+	return path, nil
+}
+
+// bookDir 导出电子书的本地目录
+func bookDir() string {
+	return filepath.Join(os.TempDir(), "leaf-api", "books")
+}
+
+// cachedBook 查找缓存中的电子书文件，多实例部署时文件可能在其他实例上，不存在时重新生成
+func cachedBook(key string) (string, bool) {
+	path, err := cache.Default().Get(key)
+	if err != nil {
+		return "", false
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// saveBook 按标签、格式和内容版本保存电子书文件，并删除该标签同格式的旧版本
+func saveBook(tagID uint, format, version string, content []byte) (string, error) {
+	dir := bookDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	prefix := fmt.Sprintf("%d-%s-", tagID, format)
+	path := filepath.Join(dir, prefix+version+"."+format)
+
+	// 先写临时文件再重命名，其他请求不会读到写了一半的文件
+	f, err := os.CreateTemp(dir, "book-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	olds, _ := filepath.Glob(filepath.Join(dir, prefix+"*."+format))
+	for _, old := range olds {
+		if old != path {
+			os.Remove(old)
+		}
+	}
+	return path, nil
+}
+
+// bookVersion 根据章节结构、文章顺序和各自的更新时间计算电子书内容版本
+func (uc *chapterUseCase) bookVersion(tag *po.Tag, tree []dto.ChapterTreeNode, articleIDs []uint) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "tag %s %d\n", tag.Name, tag.UpdatedAt.UnixNano())
+	var walk func(nodes []dto.ChapterTreeNode)
+	walk = func(nodes []dto.ChapterTreeNode) {
+		for _, node := range nodes {
+			fmt.Fprintf(h, "chapter %d %s %d\n", node.ID, node.Name, node.UpdatedAt.UnixNano())
+			for _, article := range node.Articles {
+				fmt.Fprintf(h, "article %d\n", article.ID)
+			}
+			walk(node.SubChapters)
+			fmt.Fprint(h, "end\n")
+		}
+	}
+	walk(tree)
+
+	if len(articleIDs) > 0 {
+		var updates []po.Article
+		if err := uc.data.GetDB().
+			Select("id, updated_at").
+			Where("id IN ?", articleIDs).
+			Order("id ASC").
+			Find(&updates).Error; err != nil {
+			return "", err
+		}
+		for _, article := range updates {
+			fmt.Fprintf(h, "updated %d %d\n", article.ID, article.UpdatedAt.UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// storageImage 从存储后端读取图片，外部图片不打包
+func storageImage(src string) (*book.Resource, error) {
+	if _, ok := oss.ObjectKey(src); !ok {
+		return nil, fmt.Errorf("不是本站存储的图片: %s", src)
+	}
+	content, err := oss.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	return &book.Resource{Data: content}, nil
+}
@@ -0,0 +1,52 @@
+package biz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+)
+
+func TestSaveBook(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	useMemoryCache(t)
+
+	old, err := saveBook(1, BookFormatEPUB, "v1", []byte("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := saveBook(2, BookFormatEPUB, "v1", []byte("other"))
+	html, _ := saveBook(1, BookFormatHTML, "v1", []byte("html"))
+
+	path, err := saveBook(1, BookFormatEPUB, "v2", []byte("new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "new" {
+		t.Errorf("content = %q, want new", content)
+	}
+	// 同一标签同格式的旧版本被删除，其他标签和格式保留
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old version kept: %v", err)
+	}
+	for _, keep := range []string{other, html} {
+		if _, err := os.Stat(keep); err != nil {
+			t.Errorf("%s removed: %v", filepath.Base(keep), err)
+		}
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(bookDir(), "*.tmp")); len(tmps) != 0 {
+		t.Errorf("temp files left: %v", tmps)
+	}
+
+	// 缓存只保存路径，文件不存在时视为未命中
+	cache.Default().Set("book", path, time.Minute)
+	if got, ok := cachedBook("book"); !ok || got != path {
+		t.Errorf("cachedBook = %q, %v", got, ok)
+	}
+	cache.Default().Set("book", old, time.Minute)
+	if _, ok := cachedBook("book"); ok {
+		t.Error("cachedBook hit for a removed file")
+	}
+}
@@ -0,0 +1,711 @@
+package biz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	mdutils "github.com/ydcloud-dy/leaf-api/pkg/markdown"
+	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+)
+
+// ImportJobUseCase 文章导入任务业务用例接口
+type ImportJobUseCase interface {
+	// Submit 提交导入任务，文件由后台 worker 异步处理
+	Submit(authorID uint, files []dto.ImportFile) (*dto.ImportJobResponse, error)
+	// SubmitBundle 提交 Markdown 压缩包导入任务，相对路径图片由后台 worker 上传，目录映射为 tagID 下的章节
+	SubmitBundle(authorID uint, data []byte, tagID *uint) (*dto.ImportJobResponse, error)
+	// GetByID 查询导入任务进度
+	GetByID(id uint) (*dto.ImportJobResponse, error)
+}
+
+const (
+	// importItemLease 处理中文件的租期，超过租期没有续租的文件视为所在实例已中断，由其它实例恢复
+	importItemLease = 10 * time.Minute
+	// importItemRenew 处理文件期间的续租间隔
+	importItemRenew = importItemLease / 5
+)
+
+// importJobUseCase 文章导入任务业务用例实现
+type importJobUseCase struct {
+	data           *data.Data
+	articleUseCase ArticleUseCase
+	queue          chan uint // 待处理的文件ID
+
+	// 等待放入队列的文件ID，由一个 feeder 协程按顺序放入队列，提交请求不会因队列满而阻塞
+	backlogMu sync.Mutex
+	backlog   []uint
+	wake      chan struct{}
+
+	// 章节没有唯一索引，查找和创建章节时加锁，避免多个 worker 重复创建同名章节
+	chapterMu sync.Mutex
+}
+
+// NewImportJobUseCase 创建文章导入任务业务用例，并启动后台 worker
+func NewImportJobUseCase(d *data.Data, articleUseCase ArticleUseCase) ImportJobUseCase {
+	uc := &importJobUseCase{
+		data:           d,
+		articleUseCase: articleUseCase,
+		queue:          make(chan uint, 1024),
+		wake:           make(chan struct{}, 1),
+	}
+	go uc.feed()
+
+	workers := 2
+	if config.AppConfig != nil && config.AppConfig.Import.Workers > 0 {
+		workers = config.AppConfig.Import.Workers
+	}
+	for i := 0; i < workers; i++ {
+		go uc.worker()
+	}
+
+	// 启动时恢复所有待处理文件和租期已过的处理中文件，之后定时恢复其它实例中断的文件
+	uc.recover(time.Now())
+	go uc.recoverLoop()
+
+	return uc
+}
+
+// recover 恢复 pendingBefore 之前创建的待处理文件和租期已过的处理中文件
+func (uc *importJobUseCase) recover(pendingBefore time.Time) {
+	staleBefore := time.Now().Add(-importItemLease)
+	ids, err := uc.data.ImportJobRepo.ListRecoverableItemIDs(pendingBefore, staleBefore)
+	if err != nil {
+		logger.Error("Failed to load unfinished import items: ", err)
+		return
+	}
+	if len(ids) > 0 {
+		logger.Info("Resuming ", len(ids), " unfinished import items")
+		uc.enqueue(uc.recoverItems(ids, staleBefore))
+	}
+}
+
+// recoverLoop 定时恢复中断的文件，只处理超过租期的文件，不会抢走其它存活实例正在处理的文件
+func (uc *importJobUseCase) recoverLoop() {
+	ticker := time.NewTicker(importItemLease / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		uc.recover(time.Now().Add(-importItemLease))
+	}
+}
+
+// Submit 提交导入任务，文件由后台 worker 异步处理
+func (uc *importJobUseCase) Submit(authorID uint, files []dto.ImportFile) (*dto.ImportJobResponse, error) {
+	return uc.submit(authorID, files, "")
+}
+
+// submit 创建导入任务并放入处理队列，bundlePath 为压缩包导入时保存的临时文件
+func (uc *importJobUseCase) submit(authorID uint, files []dto.ImportFile, bundlePath string) (*dto.ImportJobResponse, error) {
+	if len(files) == 0 {
+		return nil, errors.New("没有可导入的文件")
+	}
+
+	job := &po.ImportJob{
+		AuthorID:   authorID,
+		Status:     po.ImportJobPending,
+		Total:      len(files),
+		BundlePath: bundlePath,
+	}
+	for _, f := range files {
+		item := po.ImportJobItem{
+			Filename:    f.Filename,
+			Content:     f.Content,
+			TagID:       f.TagID,
+			ChapterPath: strings.Join(f.Folders, "/"),
+			Status:      po.ImportItemPending,
+		}
+		if len(f.ImageFailures) > 0 {
+			failures, _ := json.Marshal(f.ImageFailures)
+			item.ImageFailures = string(failures)
+		}
+		job.Items = append(job.Items, item)
+	}
+
+	if err := uc.data.ImportJobRepo.Create(job); err != nil {
+		return nil, errors.New("创建导入任务失败: " + err.Error())
+	}
+
+	ids := make([]uint, 0, len(job.Items))
+	for _, item := range job.Items {
+		ids = append(ids, item.ID)
+	}
+	uc.enqueue(ids)
+
+	return uc.GetByID(job.ID)
+}
+
+// SubmitBundle 提交 Markdown 压缩包导入任务，相对路径图片由后台 worker 上传，目录映射为 tagID 下的章节
+// 压缩包保存为临时文件，处理每个文件时再上传它引用的图片，提交请求不需要等待上传
+func (uc *importJobUseCase) SubmitBundle(authorID uint, data []byte, tagID *uint) (*dto.ImportJobResponse, error) {
+	if tagID != nil {
+		if _, err := uc.data.TagRepo.FindByID(*tagID); err != nil {
+			return nil, errors.New("标签不存在")
+		}
+	}
+
+	bundle, err := mdutils.OpenBundle(data, config.AppConfig.Import.MaxBundleSize*1024*1024)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := bundle.Documents()
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("压缩包中没有 Markdown 文件")
+	}
+
+	files := make([]dto.ImportFile, 0, len(docs))
+	for _, doc := range docs {
+		file := dto.ImportFile{
+			Filename: doc.Path,
+			Content:  doc.Content,
+			TagID:    tagID,
+		}
+		// 只有指定了标签时目录才有意义
+		if tagID != nil {
+			file.Folders = doc.Folders
+		}
+		files = append(files, file)
+	}
+
+	bundlePath, err := saveBundle(data)
+	if err != nil {
+		return nil, errors.New("保存压缩包失败: " + err.Error())
+	}
+	job, err := uc.submit(authorID, files, bundlePath)
+	if err != nil {
+		os.Remove(bundlePath)
+		return nil, err
+	}
+	return job, nil
+}
+
+// saveBundle 把压缩包保存到临时目录，返回文件路径
+func saveBundle(data []byte) (string, error) {
+	dir := filepath.Join(os.TempDir(), "leaf-api", "imports")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "bundle-*.zip")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// resolveBundleImages 上传文件引用的压缩包内图片，失败的图片记录到文件的图片失败列表，返回已上传的存储地址
+func (uc *importJobUseCase) resolveBundleImages(job *po.ImportJob, item *po.ImportJobItem) []string {
+	var uploaded []string
+	var failures []dto.ImageFailure
+	bundle, err := mdutils.OpenBundleFile(job.BundlePath, config.AppConfig.Import.MaxBundleSize*1024*1024)
+	if err != nil {
+		// 临时文件丢失（如重启后被清理）时文章照常导入，相对路径图片保留原链接
+		failures = append(failures, dto.ImageFailure{URL: item.Filename, Error: "读取压缩包失败: " + err.Error()})
+	} else {
+		var results []mdutils.ImageResult
+		item.Content, results = bundle.ResolveImages(item.Filename, item.Content)
+		bundle.Close()
+		for _, r := range results {
+			if r.Status == mdutils.ImageStatusFailed {
+				failures = append(failures, dto.ImageFailure{URL: r.URL, Error: r.Error})
+			} else {
+				uploaded = append(uploaded, r.StoredURL)
+			}
+		}
+	}
+	if len(failures) == 0 {
+		return uploaded
+	}
+
+	var existing []dto.ImageFailure
+	if item.ImageFailures != "" {
+		_ = json.Unmarshal([]byte(item.ImageFailures), &existing)
+	}
+	data, _ := json.Marshal(append(existing, failures...))
+	item.ImageFailures = string(data)
+	return uploaded
+}
+
+// removeUploads 删除导入未成功的文件已上传的压缩包图片
+func removeUploads(urls []string) {
+	removed := make(map[string]bool)
+	for _, url := range urls {
+		// 同一张图片可能被文档以不同路径多次引用
+		if removed[url] {
+			continue
+		}
+		removed[url] = true
+		if err := oss.RemoveFile(url); err != nil {
+			logger.Warn("Failed to remove imported image: ", err)
+		}
+	}
+}
+
+// removeBundle 任务完成后删除压缩包临时文件
+func (uc *importJobUseCase) removeBundle(job *po.ImportJob) {
+	if job.BundlePath == "" {
+		return
+	}
+	completed, err := uc.data.ImportJobRepo.IsCompleted(job.ID)
+	if err != nil || !completed {
+		return
+	}
+	if err := os.Remove(job.BundlePath); err != nil && !os.IsNotExist(err) {
+		logger.Warn("删除导入压缩包失败: ", err)
+	}
+}
+
+// GetByID 查询导入任务进度
+func (uc *importJobUseCase) GetByID(id uint) (*dto.ImportJobResponse, error) {
+	job, err := uc.data.ImportJobRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("导入任务不存在")
+	}
+
+	resp := &dto.ImportJobResponse{
+		ID:         job.ID,
+		Status:     job.Status,
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Succeeded:  job.Succeeded,
+		Failed:     job.Failed,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+		FinishedAt: job.FinishedAt,
+		Items:      make([]dto.ImportJobItemResponse, 0, len(job.Items)),
+	}
+	for _, item := range job.Items {
+		itemResp := dto.ImportJobItemResponse{
+			ID:        item.ID,
+			Filename:  item.Filename,
+			Status:    item.Status,
+			ArticleID: item.ArticleID,
+			Error:     item.Error,
+		}
+		if item.ImageFailures != "" {
+			_ = json.Unmarshal([]byte(item.ImageFailures), &itemResp.ImageFailures)
+		}
+		resp.Items = append(resp.Items, itemResp)
+	}
+
+	return resp, nil
+}
+
+// enqueue 将文件加入待入队列表，由 feeder 协程放入处理队列，队列满时不阻塞调用方
+func (uc *importJobUseCase) enqueue(ids []uint) {
+	if len(ids) == 0 {
+		return
+	}
+	uc.backlogMu.Lock()
+	uc.backlog = append(uc.backlog, ids...)
+	uc.backlogMu.Unlock()
+	select {
+	case uc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// feed 把待入队列表中的文件按顺序放入处理队列
+func (uc *importJobUseCase) feed() {
+	for range uc.wake {
+		for {
+			uc.backlogMu.Lock()
+			ids := uc.backlog
+			uc.backlog = nil
+			uc.backlogMu.Unlock()
+			if len(ids) == 0 {
+				break
+			}
+			for _, id := range ids {
+				uc.queue <- id
+			}
+		}
+	}
+}
+
+// recoverItems 处理中断的文件，返回需要重新处理的文件ID，staleBefore 之后续租过的处理中文件不恢复
+// 处理中的文件可能已经创建了文章但没来得及保存结果，已创建的直接标记成功，否则恢复为待处理
+func (uc *importJobUseCase) recoverItems(ids []uint, staleBefore time.Time) []uint {
+	pending := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		item, err := uc.data.ImportJobRepo.FindItemByID(id)
+		if err != nil {
+			logger.Error("Failed to load import item: ", err)
+			continue
+		}
+		if item.Status == po.ImportItemPending {
+			pending = append(pending, id)
+			continue
+		}
+		// 列出之后其它实例可能已完成或续租了该文件
+		if item.Status != po.ImportItemRunning || (item.StartedAt != nil && !item.StartedAt.Before(staleBefore)) {
+			continue
+		}
+
+		job, err := uc.data.ImportJobRepo.FindByID(item.JobID)
+		if err != nil {
+			logger.Error("Failed to load import job: ", err)
+			continue
+		}
+		if article := uc.findImportedArticle(item, job.AuthorID); article != nil {
+			item.Status = po.ImportItemSuccess
+			item.ArticleID = &article.ID
+			if finished, err := uc.data.ImportJobRepo.FinishItem(item); err != nil {
+				logger.Error("Failed to finish import item: ", err)
+			} else if finished {
+				uc.removeBundle(job)
+			}
+			continue
+		}
+		if err := uc.data.ImportJobRepo.ResetItem(id, staleBefore); err != nil {
+			logger.Error("Failed to reset import item: ", err)
+			continue
+		}
+		pending = append(pending, id)
+	}
+	return pending
+}
+
+// findImportedArticle 查找中断的文件已经创建的文章：同标题或 slug、同作者，且在任务提交后创建或修改
+func (uc *importJobUseCase) findImportedArticle(item *po.ImportJobItem, authorID uint) *po.Article {
+	fm, _, title, err := parseImportItem(item)
+	if err != nil {
+		return nil
+	}
+	article, err := uc.data.ArticleRepo.FindByTitleOrSlug(title, fm.Slug)
+	if err != nil || article.AuthorID != authorID || article.UpdatedAt.Before(item.CreatedAt) {
+		return nil
+	}
+	return article
+}
+
+// worker 后台处理导入文件
+func (uc *importJobUseCase) worker() {
+	for id := range uc.queue {
+		uc.processItem(id)
+	}
+}
+
+// processItem 处理单个导入文件
+func (uc *importJobUseCase) processItem(id uint) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Import item panic: ", r)
+		}
+	}()
+
+	item, err := uc.data.ImportJobRepo.FindItemByID(id)
+	if err != nil {
+		logger.Error("Failed to load import item: ", err)
+		return
+	}
+	if item.Status != po.ImportItemPending {
+		return
+	}
+
+	job, err := uc.data.ImportJobRepo.FindByID(item.JobID)
+	if err != nil {
+		logger.Error("Failed to load import job: ", err)
+		return
+	}
+
+	claimed, err := uc.data.ImportJobRepo.StartItem(item)
+	if err != nil {
+		logger.Error("Failed to start import item: ", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	stop := uc.renewLease(item.ID)
+	defer stop()
+
+	// 先检查冲突再上传压缩包内图片，冲突的文件不产生无人引用的图片
+	var uploaded []string
+	var article *dto.ArticleResponse
+	err = uc.checkConflict(item)
+	if err == nil {
+		if job.BundlePath != "" {
+			uploaded = uc.resolveBundleImages(job, item)
+		}
+		article, err = uc.importFile(item, job.AuthorID)
+	}
+	if errors.Is(err, errImportConflict) {
+		item.Status = po.ImportItemConflict
+		item.Error = err.Error()
+	} else if err != nil {
+		item.Status = po.ImportItemFailed
+		item.Error = err.Error()
+	} else {
+		item.Status = po.ImportItemSuccess
+		item.ArticleID = &article.ID
+
+		// 合并解析压缩包时和下载远程图片时的失败记录
+		var failures []dto.ImageFailure
+		if item.ImageFailures != "" {
+			_ = json.Unmarshal([]byte(item.ImageFailures), &failures)
+		}
+		failures = append(failures, article.ImageFailures...)
+		if len(failures) > 0 {
+			data, _ := json.Marshal(failures)
+			item.ImageFailures = string(data)
+		}
+	}
+
+	finished, err := uc.data.ImportJobRepo.FinishItem(item)
+	if err != nil {
+		logger.Error("Failed to finish import item: ", err)
+		return
+	}
+	if !finished {
+		// 接手的实例按相同的地址重新上传图片，这里不能删除
+		logger.Warn("Import item ", item.ID, " was recovered by another instance, result discarded")
+		return
+	}
+	if item.Status != po.ImportItemSuccess {
+		removeUploads(uploaded)
+	}
+	uc.removeBundle(job)
+}
+
+// renewLease 处理文件期间定时续租，返回停止续租的函数
+func (uc *importJobUseCase) renewLease(id uint) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(importItemRenew)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := uc.data.ImportJobRepo.TouchItem(id); err != nil {
+					logger.Warn("Failed to renew import item lease: ", err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// errImportConflict 导入的文章与已有文章冲突
+var errImportConflict = errors.New("文章已存在")
+
+// checkConflict 检查文件是否与已有文章的标题或 slug 冲突，避免重复导入
+func (uc *importJobUseCase) checkConflict(item *po.ImportJobItem) error {
+	fm, _, title, err := parseImportItem(item)
+	if err != nil {
+		return err
+	}
+	if existing, err := uc.data.ArticleRepo.FindByTitleOrSlug(title, fm.Slug); err == nil {
+		return fmt.Errorf("%w: ID=%d, 标题=%s", errImportConflict, existing.ID, existing.Title)
+	}
+	return nil
+}
+
+// importFile 将 Markdown 文件导入为文章
+// 支持 Hugo/Hexo 风格的 Front Matter，缺失的标签、分类和章节会自动创建
+func (uc *importJobUseCase) importFile(item *po.ImportJobItem, authorID uint) (*dto.ArticleResponse, error) {
+	fm, body, title, err := parseImportItem(item)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID, err := uc.resolveCategory(fm.Categories)
+	if err != nil {
+		return nil, err
+	}
+
+	tagIDs, err := uc.resolveTags(fm.Tags)
+	if err != nil {
+		return nil, err
+	}
+	// 压缩包导入时指定的标签排在最前，章节挂在该标签下
+	if item.TagID != nil {
+		tagIDs = prependTagID(tagIDs, *item.TagID)
+	}
+
+	// Front Matter 中的 chapter 优先，否则使用压缩包内的目录层级
+	var chapterPath []string
+	if fm.Chapter != "" {
+		chapterPath = []string{fm.Chapter}
+	} else if item.TagID != nil && item.ChapterPath != "" {
+		chapterPath = strings.Split(item.ChapterPath, "/")
+	}
+
+	var chapterID *uint
+	if len(chapterPath) > 0 && len(tagIDs) > 0 {
+		chapter, err := uc.resolveChapter(tagIDs[0], chapterPath)
+		if err != nil {
+			return nil, err
+		}
+		chapterID = &chapter.ID
+	}
+
+	summary := fm.Summary
+	if summary == "" {
+		summary = generateSummary(body, 200)
+	}
+
+	// 未指定 draft 时默认为草稿
+	status := 0
+	if fm.Draft != nil && !*fm.Draft {
+		status = 1
+	}
+
+	req := &dto.CreateArticleRequest{
+		Title:           title,
+		Slug:            fm.Slug,
+		ContentMarkdown: body,
+		Summary:         summary,
+		Cover:           fm.Cover,
+		Status:          status,
+		CategoryID:      categoryID,
+		ChapterID:       chapterID,
+		TagIDs:          tagIDs,
+		CreatedAt:       fm.Date,
+	}
+
+	article, err := uc.articleUseCase.Create(req, authorID)
+	if err != nil {
+		return nil, errors.New("创建文章失败 - " + err.Error())
+	}
+	return article, nil
+}
+
+// parseImportItem 解析导入文件的 Front Matter 和正文，没有标题时使用文件名（去掉扩展名）
+func parseImportItem(item *po.ImportJobItem) (*mdutils.FrontMatter, string, string, error) {
+	fm, body, err := mdutils.ParseFrontMatter(item.Content)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if fm == nil {
+		fm = &mdutils.FrontMatter{}
+	}
+
+	title := fm.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(item.Filename), filepath.Ext(item.Filename))
+	}
+	return fm, body, title, nil
+}
+
+// resolveCategory 根据名称查找分类，不存在则创建；未指定时使用默认分类
+func (uc *importJobUseCase) resolveCategory(names []string) (uint, error) {
+	if len(names) == 0 {
+		categoryID, err := uc.articleUseCase.GetDefaultCategoryID()
+		if err != nil {
+			return 0, errors.New("获取默认分类失败: " + err.Error())
+		}
+		return categoryID, nil
+	}
+
+	name := names[0]
+	if category, err := uc.data.CategoryRepo.FindByName(name); err == nil {
+		return category.ID, nil
+	}
+	category := &po.Category{Name: name}
+	if err := uc.data.CategoryRepo.Create(category); err != nil {
+		// 名称有唯一索引，其它 worker 可能刚创建了同名分类
+		if existing, findErr := uc.data.CategoryRepo.FindByName(name); findErr == nil {
+			return existing.ID, nil
+		}
+		return 0, errors.New("创建分类失败: " + err.Error())
+	}
+	return category.ID, nil
+}
+
+// resolveTags 根据名称查找标签，不存在则创建
+func (uc *importJobUseCase) resolveTags(names []string) ([]uint, error) {
+	tagIDs := make([]uint, 0, len(names))
+	seen := make(map[uint]bool)
+	for _, name := range names {
+		tag, err := uc.data.TagRepo.FindByName(name)
+		if err != nil {
+			tag = &po.Tag{Name: name}
+			if err := uc.data.TagRepo.Create(tag); err != nil {
+				// 名称有唯一索引，其它 worker 可能刚创建了同名标签
+				existing, findErr := uc.data.TagRepo.FindByName(name)
+				if findErr != nil {
+					return nil, errors.New("创建标签失败: " + err.Error())
+				}
+				tag = existing
+			}
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+	return tagIDs, nil
+}
+
+// resolveChapter 在标签下按层级路径查找章节，不存在的层级会逐级创建，返回最后一级章节
+func (uc *importJobUseCase) resolveChapter(tagID uint, path []string) (*po.Chapter, error) {
+	uc.chapterMu.Lock()
+	defer uc.chapterMu.Unlock()
+
+	var chapter *po.Chapter
+	var parentID *uint
+	for _, name := range path {
+		found, err := uc.data.ChapterRepo.FindByTagAndName(tagID, parentID, name)
+		if err != nil {
+			found = &po.Chapter{TagID: tagID, ParentID: parentID, Name: name}
+			if err := uc.data.ChapterRepo.Create(found); err != nil {
+				return nil, errors.New("创建章节失败: " + err.Error())
+			}
+		}
+		chapter = found
+		parentID = &found.ID
+	}
+	return chapter, nil
+}
+
+// prependTagID 将标签 ID 放到列表最前面并去重
+func prependTagID(tagIDs []uint, tagID uint) []uint {
+	result := []uint{tagID}
+	for _, id := range tagIDs {
+		if id != tagID {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// generateSummary 从内容中生成摘要
+func generateSummary(content string, maxLen int) string {
+	// 移除 Markdown 标记
+	content = strings.ReplaceAll(content, "#", "")
+	content = strings.ReplaceAll(content, "*", "")
+	content = strings.ReplaceAll(content, "_", "")
+	content = strings.ReplaceAll(content, "`", "")
+	content = strings.ReplaceAll(content, "\n", " ")
+	content = strings.TrimSpace(content)
+
+	// 截取指定长度
+	runes := []rune(content)
+	if len(runes) > maxLen {
+		return string(runes[:maxLen]) + "..."
+	}
+	return content
+}
@@ -0,0 +1,36 @@
+package biz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImportJobEnqueue(t *testing.T) {
+	uc := &importJobUseCase{queue: make(chan uint, 1), wake: make(chan struct{}, 1)}
+	go uc.feed()
+
+	// 队列已满时提交不阻塞，文件按提交顺序进入队列
+	done := make(chan struct{})
+	go func() {
+		uc.enqueue([]uint{1, 2, 3})
+		uc.enqueue(nil)
+		uc.enqueue([]uint{4, 5})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("enqueue blocked")
+	}
+
+	for want := uint(1); want <= 5; want++ {
+		select {
+		case got := <-uc.queue:
+			if got != want {
+				t.Fatalf("queue = %d, want %d", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("item %d not queued", want)
+		}
+	}
+}
@@ -0,0 +1,702 @@
+package biz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	mdutils "github.com/ydcloud-dy/leaf-api/pkg/markdown"
+	"github.com/ydcloud-dy/leaf-api/pkg/migrate"
+	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+	"github.com/ydcloud-dy/leaf-api/pkg/upload"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usernameRegex 用户名中允许的字符
+var usernameRegex = regexp.MustCompile(`[^a-zA-Z0-9_\p{Han}-]+`)
+
+// MigrationOptions 迁移选项
+type MigrationOptions struct {
+	DryRun   bool // 试运行，只生成报告不写入数据
+	AuthorID uint // 找不到作者时使用的默认作者
+}
+
+// MigrationUseCase 博客迁移业务用例接口
+type MigrationUseCase interface {
+	// Run 同步执行迁移并返回报告
+	Run(site *migrate.Site, opts MigrationOptions) (*dto.MigrationReport, error)
+	// Submit 提交迁移任务，在后台执行
+	Submit(site *migrate.Site, opts MigrationOptions) (*dto.MigrationRunResponse, error)
+	// GetRun 查询迁移任务
+	GetRun(id uint) (*dto.MigrationRunResponse, error)
+}
+
+// migrationUseCase 博客迁移业务用例实现
+type migrationUseCase struct {
+	data           *data.Data
+	articleUseCase ArticleUseCase
+	client         *http.Client
+}
+
+// NewMigrationUseCase 创建博客迁移业务用例
+func NewMigrationUseCase(d *data.Data, articleUseCase ArticleUseCase) MigrationUseCase {
+	timeout := 30
+	if config.AppConfig != nil && config.AppConfig.Image.Timeout > 0 {
+		timeout = config.AppConfig.Image.Timeout
+	}
+	return &migrationUseCase{
+		data:           d,
+		articleUseCase: articleUseCase,
+		client:         &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+// failInterruptedRuns 把重启前未结束的迁移任务标记为失败
+// 迁移任务只保存在内存中，进程退出后无法继续，重复执行迁移不会产生重复数据，重新提交即可
+func failInterruptedRuns(d *data.Data) {
+	n, err := d.MigrationRepo.FailUnfinishedRuns("服务重启，任务已中断，请重新提交")
+	if err != nil {
+		logger.Error("Failed to mark interrupted migration runs: ", err)
+	} else if n > 0 {
+		logger.Warn("Marked ", n, " interrupted migration runs as failed")
+	}
+}
+
+// Run 同步执行迁移并返回报告
+func (uc *migrationUseCase) Run(site *migrate.Site, opts MigrationOptions) (*dto.MigrationReport, error) {
+	if opts.AuthorID == 0 {
+		return nil, errors.New("未指定默认作者")
+	}
+
+	m := &migrator{
+		uc:          uc,
+		site:        site,
+		opts:        opts,
+		report:      &dto.MigrationReport{Source: site.Source, DryRun: opts.DryRun, Skipped: site.Skipped},
+		users:       make(map[string]uint),
+		userByID:    make(map[string]uint),
+		categories:  make(map[string]uint),
+		tags:        make(map[string]uint),
+		attachments: make(map[string]string),
+		commenters:  make(map[string]uint),
+	}
+	m.run()
+	return m.report, nil
+}
+
+// Submit 提交迁移任务，在后台执行
+func (uc *migrationUseCase) Submit(site *migrate.Site, opts MigrationOptions) (*dto.MigrationRunResponse, error) {
+	run := &po.MigrationRun{
+		Source:  site.Source,
+		DryRun:  opts.DryRun,
+		Status:  po.MigrationRunPending,
+		AdminID: opts.AuthorID,
+	}
+	if err := uc.data.MigrationRepo.CreateRun(run); err != nil {
+		return nil, errors.New("创建迁移任务失败: " + err.Error())
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Migration panic: ", r)
+				run.Status = po.MigrationRunFailed
+				run.Error = fmt.Sprint(r)
+				_ = uc.data.MigrationRepo.UpdateRun(run)
+			}
+		}()
+
+		run.Status = po.MigrationRunRunning
+		_ = uc.data.MigrationRepo.UpdateRun(run)
+
+		report, err := uc.Run(site, opts)
+		if err != nil {
+			run.Status = po.MigrationRunFailed
+			run.Error = err.Error()
+		} else {
+			run.Status = po.MigrationRunCompleted
+			raw, _ := json.Marshal(report)
+			run.Report = string(raw)
+		}
+		if err := uc.data.MigrationRepo.UpdateRun(run); err != nil {
+			logger.Error("Failed to update migration run: ", err)
+		}
+	}()
+
+	return uc.toRunResponse(run), nil
+}
+
+// GetRun 查询迁移任务
+func (uc *migrationUseCase) GetRun(id uint) (*dto.MigrationRunResponse, error) {
+	run, err := uc.data.MigrationRepo.FindRunByID(id)
+	if err != nil {
+		return nil, errors.New("迁移任务不存在")
+	}
+	return uc.toRunResponse(run), nil
+}
+
+// toRunResponse 转换迁移任务响应
+func (uc *migrationUseCase) toRunResponse(run *po.MigrationRun) *dto.MigrationRunResponse {
+	resp := &dto.MigrationRunResponse{
+		ID:         run.ID,
+		Source:     run.Source,
+		DryRun:     run.DryRun,
+		Status:     run.Status,
+		Error:      run.Error,
+		CreatedAt:  run.CreatedAt,
+		FinishedAt: run.FinishedAt,
+	}
+	if run.Report != "" {
+		var report dto.MigrationReport
+		if err := json.Unmarshal([]byte(run.Report), &report); err == nil {
+			resp.Report = &report
+		}
+	}
+	return resp
+}
+
+// migrator 单次迁移的状态
+type migrator struct {
+	uc     *migrationUseCase
+	site   *migrate.Site
+	opts   MigrationOptions
+	report *dto.MigrationReport
+
+	users       map[string]uint   // 作者 Login -> 用户ID
+	userByID    map[string]uint   // 作者 ID -> 用户ID
+	categories  map[string]uint   // 分类名称 -> 分类ID
+	tags        map[string]uint   // 标签名称 -> 标签ID
+	attachments map[string]string // 附件原地址 -> 本站地址
+	commenters  map[string]uint   // 评论者邮箱或昵称 -> 用户ID
+}
+
+// run 按依赖顺序迁移：作者、分类、标签、附件、文章（含评论）
+func (m *migrator) run() {
+	for _, author := range m.site.Authors {
+		m.migrateAuthor(author)
+	}
+	for _, term := range m.site.Categories {
+		m.ensureCategory(term.Name, term.Description)
+	}
+	for _, term := range m.site.Tags {
+		m.ensureTag(term.Name)
+	}
+	for _, att := range m.site.Attachments {
+		m.migrateAttachment(att)
+	}
+	for _, post := range m.site.Posts {
+		m.migratePost(post)
+	}
+}
+
+// data 数据层
+func (m *migrator) data() *data.Data {
+	return m.uc.data
+}
+
+// mapping 查询已迁移的本站 ID
+func (m *migrator) mapping(kind, sourceID string) (uint, bool) {
+	return m.data().MigrationRepo.FindMapping(m.site.Source, kind, sourceID)
+}
+
+// saveMapping 记录映射，失败只记录错误
+func (m *migrator) saveMapping(kind, sourceID string, targetID uint) {
+	if m.opts.DryRun {
+		return
+	}
+	if err := m.data().MigrationRepo.SaveMapping(m.site.Source, kind, sourceID, targetID); err != nil {
+		m.errorf("记录 %s %s 的映射失败: %v", kind, sourceID, err)
+	}
+}
+
+// errorf 记录错误
+func (m *migrator) errorf(format string, args ...interface{}) {
+	m.report.Errors = append(m.report.Errors, fmt.Sprintf(format, args...))
+}
+
+// warnf 记录警告
+func (m *migrator) warnf(format string, args ...interface{}) {
+	m.report.Warnings = append(m.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// migrateAuthor 迁移作者：已有同名或同邮箱用户时直接关联
+func (m *migrator) migrateAuthor(author migrate.Author) {
+	userID, err := m.ensureUser(po.MigrationKindUser, author.Login, author.Login, author.Email, author.DisplayName, &m.report.Users)
+	if err != nil {
+		m.errorf("迁移作者 %s 失败: %v", author.Login, err)
+		return
+	}
+	m.users[author.Login] = userID
+	if author.ID != "" {
+		m.userByID[author.ID] = userID
+	}
+}
+
+// ensureUser 查找或创建用户，新用户使用随机密码，需要通过找回密码或管理员重置后登录
+func (m *migrator) ensureUser(kind, sourceID, username, email, nickname string, counter *dto.MigrationCounter) (uint, error) {
+	if id, ok := m.mapping(kind, sourceID); ok {
+		counter.Existing++
+		return id, nil
+	}
+
+	if email != "" {
+		if user, err := m.data().UserRepo.FindByEmail(email); err == nil {
+			counter.Existing++
+			m.saveMapping(kind, sourceID, user.ID)
+			return user.ID, nil
+		}
+	}
+	if kind == po.MigrationKindUser {
+		if user, err := m.data().UserRepo.FindByUsername(username); err == nil {
+			counter.Existing++
+			m.saveMapping(kind, sourceID, user.ID)
+			return user.ID, nil
+		}
+	}
+
+	counter.Created++
+	if m.opts.DryRun {
+		return 0, nil
+	}
+
+	username = m.uniqueUsername(username)
+	if email == "" {
+		email = username + "@migrated.invalid"
+	}
+	if nickname == "" {
+		nickname = username
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte(uuid.New().String()), bcrypt.DefaultCost)
+	if err != nil {
+		counter.Created--
+		counter.Failed++
+		return 0, err
+	}
+
+	user := &po.User{
+		Username: username,
+		Email:    email,
+		Password: string(password),
+		Nickname: nickname,
+		Role:     "user",
+		Status:   1,
+	}
+	if err := m.data().UserRepo.Create(user); err != nil {
+		counter.Created--
+		counter.Failed++
+		return 0, err
+	}
+	m.saveMapping(kind, sourceID, user.ID)
+	return user.ID, nil
+}
+
+// uniqueUsername 生成未被占用的用户名
+func (m *migrator) uniqueUsername(name string) string {
+	base := usernameRegex.ReplaceAllString(name, "_")
+	base = strings.Trim(base, "_")
+	if base == "" {
+		base = "user"
+	}
+	if runes := []rune(base); len(runes) > 40 {
+		base = string(runes[:40])
+	}
+
+	username := base
+	for i := 1; ; i++ {
+		if _, err := m.data().UserRepo.FindByUsername(username); err != nil {
+			return username
+		}
+		username = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// ensureCategory 查找或创建分类
+func (m *migrator) ensureCategory(name, description string) uint {
+	if name == "" {
+		return 0
+	}
+	if id, ok := m.categories[name]; ok {
+		return id
+	}
+
+	counter := &m.report.Categories
+	if id, ok := m.mapping(po.MigrationKindCategory, name); ok {
+		counter.Existing++
+		m.categories[name] = id
+		return id
+	}
+	if category, err := m.data().CategoryRepo.FindByName(name); err == nil {
+		counter.Existing++
+		m.categories[name] = category.ID
+		m.saveMapping(po.MigrationKindCategory, name, category.ID)
+		return category.ID
+	}
+
+	counter.Created++
+	if m.opts.DryRun {
+		m.categories[name] = 0
+		return 0
+	}
+	category := &po.Category{Name: name, Description: description}
+	if err := m.data().CategoryRepo.Create(category); err != nil {
+		counter.Created--
+		counter.Failed++
+		m.errorf("创建分类 %s 失败: %v", name, err)
+		return 0
+	}
+	m.categories[name] = category.ID
+	m.saveMapping(po.MigrationKindCategory, name, category.ID)
+	return category.ID
+}
+
+// ensureTag 查找或创建标签
+func (m *migrator) ensureTag(name string) uint {
+	if name == "" {
+		return 0
+	}
+	if id, ok := m.tags[name]; ok {
+		return id
+	}
+
+	counter := &m.report.Tags
+	if id, ok := m.mapping(po.MigrationKindTag, name); ok {
+		counter.Existing++
+		m.tags[name] = id
+		return id
+	}
+	if tag, err := m.data().TagRepo.FindByName(name); err == nil {
+		counter.Existing++
+		m.tags[name] = tag.ID
+		m.saveMapping(po.MigrationKindTag, name, tag.ID)
+		return tag.ID
+	}
+
+	counter.Created++
+	if m.opts.DryRun {
+		m.tags[name] = 0
+		return 0
+	}
+	tag := &po.Tag{Name: name}
+	if err := m.data().TagRepo.Create(tag); err != nil {
+		counter.Created--
+		counter.Failed++
+		m.errorf("创建标签 %s 失败: %v", name, err)
+		return 0
+	}
+	m.tags[name] = tag.ID
+	m.saveMapping(po.MigrationKindTag, name, tag.ID)
+	return tag.ID
+}
+
+// migrateAttachment 下载附件并上传到本站存储，记录为文件
+func (m *migrator) migrateAttachment(att migrate.Attachment) {
+	counter := &m.report.Attachments
+	if id, ok := m.mapping(po.MigrationKindAttachment, att.ID); ok {
+		counter.Existing++
+		if file, err := m.data().FileRepo.FindByID(id); err == nil {
+			m.attachments[att.URL] = file.URL
+		}
+		return
+	}
+
+	counter.Created++
+	if m.opts.DryRun {
+		return
+	}
+
+	file, err := m.uc.fetchAttachment(att, m.opts.AuthorID)
+	if err != nil {
+		counter.Created--
+		counter.Failed++
+		m.errorf("下载附件 %s 失败: %v", att.URL, err)
+		return
+	}
+	if err := m.data().FileRepo.Create(file); err != nil {
+		counter.Created--
+		counter.Failed++
+		m.errorf("保存附件 %s 失败: %v", att.URL, err)
+		return
+	}
+
+	m.attachments[att.URL] = file.URL
+	// 写入图片缓存，文章中引用的同一图片不会再次下载
+	_ = m.data().ImageCacheRepo.Set(att.URL, file.URL)
+	m.saveMapping(po.MigrationKindAttachment, att.ID, file.ID)
+}
+
+// migratePost 迁移文章及其评论
+func (m *migrator) migratePost(post migrate.Post) {
+	counter := &m.report.Articles
+	articleID, ok := m.mapping(po.MigrationKindArticle, post.ID)
+	if ok {
+		counter.Existing++
+	} else {
+		counter.Created++
+		if !m.opts.DryRun {
+			id, err := m.createArticle(post)
+			if err != nil {
+				counter.Created--
+				counter.Failed++
+				m.errorf("迁移文章 %s 失败: %v", post.Title, err)
+				return
+			}
+			articleID = id
+			m.saveMapping(po.MigrationKindArticle, post.ID, articleID)
+		}
+	}
+
+	m.migrateComments(post, articleID)
+}
+
+// createArticle 创建文章，HTML 内容转换为 Markdown
+func (m *migrator) createArticle(post migrate.Post) (uint, error) {
+	content := m.replaceAttachments(post.Content)
+	if post.Format == migrate.FormatHTML {
+		converted, err := mdutils.HTMLToMarkdown(content)
+		if err != nil {
+			return 0, err
+		}
+		content = converted
+	}
+	if strings.TrimSpace(content) == "" {
+		return 0, errors.New("文章内容为空")
+	}
+
+	categoryID := uint(0)
+	for _, name := range post.Categories {
+		if categoryID = m.ensureCategory(name, ""); categoryID != 0 {
+			break
+		}
+	}
+	if categoryID == 0 {
+		id, err := m.uc.articleUseCase.GetDefaultCategoryID()
+		if err != nil {
+			return 0, errors.New("获取默认分类失败: " + err.Error())
+		}
+		categoryID = id
+	}
+
+	tagIDs := make([]uint, 0, len(post.Tags))
+	for _, name := range post.Tags {
+		if id := m.ensureTag(name); id != 0 {
+			tagIDs = append(tagIDs, id)
+		}
+	}
+
+	authorID, ok := m.users[post.Author]
+	if !ok || authorID == 0 {
+		authorID = m.opts.AuthorID
+	}
+
+	title := post.Title
+	if title == "" {
+		title = "无标题"
+	}
+
+	summary := post.Excerpt
+	if summary == "" {
+		summary = content
+	}
+
+	status := 0
+	if post.Status == migrate.PostPublished {
+		status = 1
+	}
+
+	req := &dto.CreateArticleRequest{
+		Title:           title,
+		Slug:            post.Slug,
+		ContentMarkdown: content,
+		Summary:         generateSummary(summary, 200),
+		Cover:           m.replaceAttachments(post.Cover),
+		Status:          status,
+		CategoryID:      categoryID,
+		TagIDs:          tagIDs,
+	}
+	if !post.Date.IsZero() {
+		req.CreatedAt = &post.Date
+	}
+
+	article, err := m.uc.articleUseCase.Create(req, authorID)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range article.ImageFailures {
+		m.warnf("文章 %s 的图片 %s 下载失败: %s", title, f.URL, f.Error)
+	}
+	return article.ID, nil
+}
+
+// replaceAttachments 将内容中的附件原地址替换为本站地址
+func (m *migrator) replaceAttachments(content string) string {
+	for src, stored := range m.attachments {
+		content = strings.ReplaceAll(content, src, stored)
+	}
+	return content
+}
+
+// migrateComments 迁移文章评论，父评论先于子评论创建
+func (m *migrator) migrateComments(post migrate.Post, articleID uint) {
+	commentIDs := make(map[string]uint)   // 评论原 ID -> 本站评论 ID
+	commentUsers := make(map[string]uint) // 评论原 ID -> 评论者用户 ID
+
+	pending := post.Comments
+	for len(pending) > 0 {
+		var next []migrate.Comment
+		for _, c := range pending {
+			if c.ParentID != "" {
+				if _, ok := commentIDs[c.ParentID]; !ok && m.hasComment(pending, c.ParentID) {
+					next = append(next, c)
+					continue
+				}
+			}
+			m.migrateComment(c, articleID, commentIDs, commentUsers)
+		}
+		// 父评论缺失时剩下的评论按顶级评论处理
+		if len(next) == len(pending) {
+			for _, c := range next {
+				c.ParentID = ""
+				m.migrateComment(c, articleID, commentIDs, commentUsers)
+			}
+			break
+		}
+		pending = next
+	}
+}
+
+// hasComment 判断列表中是否存在指定评论
+func (m *migrator) hasComment(comments []migrate.Comment, id string) bool {
+	for _, c := range comments {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateComment 迁移单条评论
+func (m *migrator) migrateComment(c migrate.Comment, articleID uint, commentIDs, commentUsers map[string]uint) {
+	counter := &m.report.Comments
+	if id, ok := m.mapping(po.MigrationKindComment, c.ID); ok {
+		counter.Existing++
+		commentIDs[c.ID] = id
+		if comment, err := m.data().CommentRepo.FindByID(id); err == nil {
+			commentUsers[c.ID] = comment.UserID
+		}
+		return
+	}
+
+	// 评论者是站点作者时关联作者账号，否则按邮箱或昵称创建评论者账号
+	userID, ok := m.userByID[c.AuthorID]
+	if !ok {
+		key := strings.ToLower(c.AuthorEmail)
+		if key == "" {
+			key = "name:" + c.AuthorName
+		}
+		if userID, ok = m.commenters[key]; !ok {
+			id, err := m.ensureUser(po.MigrationKindCommenter, key, c.AuthorName, c.AuthorEmail, c.AuthorName, &m.report.Users)
+			if err != nil {
+				counter.Failed++
+				m.errorf("创建评论者 %s 失败: %v", c.AuthorName, err)
+				return
+			}
+			m.commenters[key] = id
+			userID = id
+		}
+	}
+
+	counter.Created++
+	if m.opts.DryRun {
+		commentIDs[c.ID] = 0
+		return
+	}
+
+	comment := &po.Comment{
+		ArticleID: &articleID,
+		UserID:    userID,
+		Content:   c.Content,
+		Status:    0,
+	}
+	if c.Approved {
+		comment.Status = 1
+	}
+	if !c.Date.IsZero() {
+		comment.CreatedAt = c.Date
+	}
+	if parentID, ok := commentIDs[c.ParentID]; ok && c.ParentID != "" {
+		comment.ParentID = &parentID
+		if replyTo, ok := commentUsers[c.ParentID]; ok {
+			comment.ReplyToUserID = &replyTo
+		}
+	}
+
+	if err := m.data().CommentRepo.Create(comment); err != nil {
+		counter.Created--
+		counter.Failed++
+		m.errorf("迁移评论 #%s 失败: %v", c.ID, err)
+		return
+	}
+	if err := m.data().ArticleRepo.IncrementCommentCount(articleID); err != nil {
+		m.warnf("更新文章 #%d 的评论数失败: %v", articleID, err)
+	}
+
+	commentIDs[c.ID] = comment.ID
+	commentUsers[c.ID] = userID
+	m.saveMapping(po.MigrationKindComment, c.ID, comment.ID)
+}
+
+// fetchAttachment 下载附件并上传到存储
+func (uc *migrationUseCase) fetchAttachment(att migrate.Attachment, userID uint) (*po.File, error) {
+	resp, err := uc.client.Get(att.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP 状态码错误: %d", resp.StatusCode)
+	}
+
+	maxSize := config.AppConfig.Upload.MaxSize * 1024 * 1024
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	// 与后台上传文件使用相同的校验，扩展名按内容类型确定，不信任远程文件名
+	mimeType, err := upload.ValidateBytes(raw, upload.DefaultFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	name := path.Base(resp.Request.URL.Path)
+	objectKey := fmt.Sprintf("%s/%s/%s%s", upload.DefaultFolder, time.Now().Format("2006/01/02"), uuid.New().String(), upload.Extension(mimeType))
+	url, err := oss.UploadBytes(raw, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	title := att.Title
+	if title == "" {
+		title = name
+	}
+	return &po.File{
+		Name:     title,
+		URL:      url,
+		Size:     int64(len(raw)),
+		Type:     upload.DefaultFolder,
+		MimeType: mimeType,
+		UserID:   userID,
+	}, nil
+}
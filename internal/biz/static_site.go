@@ -0,0 +1,467 @@
+package biz
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+	"github.com/ydcloud-dy/leaf-api/pkg/staticsite"
+)
+
+// feedSize 订阅中的文章数量
+const feedSize = 20
+
+// localMediaRegex 匹配文章中引用的本地上传文件
+var localMediaRegex = regexp.MustCompile(`/uploads/[^\s"'()<>]+`)
+
+// StaticSiteOptions 静态站点构建选项，为空的字段使用配置文件中的值
+type StaticSiteOptions struct {
+	Out     string // 输出目录
+	Theme   string // 主题目录
+	BaseURL string // 站点公开地址
+	Full    bool   // 忽略上次构建结果，全部重新渲染
+}
+
+// StaticSiteUseCase 静态站点构建业务用例接口
+type StaticSiteUseCase interface {
+	// Build 将已发布的文章渲染为静态 HTML 站点
+	Build(opts StaticSiteOptions) (*staticsite.Stats, error)
+}
+
+// staticSiteUseCase 静态站点构建业务用例实现
+type staticSiteUseCase struct {
+	data           *data.Data
+	chapterUseCase ChapterUseCase
+}
+
+// NewStaticSiteUseCase 创建静态站点构建业务用例
+func NewStaticSiteUseCase(d *data.Data, chapterUseCase ChapterUseCase) StaticSiteUseCase {
+	return &staticSiteUseCase{data: d, chapterUseCase: chapterUseCase}
+}
+
+// siteBuild 一次构建的上下文
+type siteBuild struct {
+	site    staticsite.Site
+	builder *staticsite.Builder
+}
+
+// Build 将已发布的文章渲染为静态 HTML 站点
+func (uc *staticSiteUseCase) Build(opts StaticSiteOptions) (*staticsite.Stats, error) {
+	cfg := config.AppConfig.Static
+	if opts.Theme == "" {
+		opts.Theme = cfg.Theme
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = cfg.BaseURL
+	}
+
+	site := staticsite.Site{
+		Title:       cfg.Title,
+		Description: cfg.Description,
+		BaseURL:     strings.TrimRight(opts.BaseURL, "/"),
+		BasePath:    "/",
+	}
+	if site.BaseURL != "" {
+		u, err := url.Parse(site.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("站点地址无效: %w", err)
+		}
+		site.BasePath = strings.TrimRight(u.Path, "/") + "/"
+	} else {
+		logger.Warn("Static site base_url is not set, feeds will use relative links")
+	}
+
+	theme, err := staticsite.LoadTheme(opts.Theme, site)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := staticsite.NewBuilder(opts.Out, theme, opts.Full)
+	if err != nil {
+		return nil, err
+	}
+	b := &siteBuild{site: site, builder: builder}
+
+	// 已发布文章，列表页不需要正文
+	var articles []po.Article
+	if err := uc.data.GetDB().
+		Select("id, title, slug, summary, cover, author_id, category_id, chapter_id, sort_in_chapter, status, created_at, updated_at").
+		Preload("Author").Preload("Category").Preload("Tags").
+		Where("status = ?", 1).
+		Order("created_at DESC, id DESC").
+		Find(&articles).Error; err != nil {
+		return nil, err
+	}
+
+	var categories []po.Category
+	if err := uc.data.GetDB().Order("sort ASC, id ASC").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	var tags []po.Tag
+	if err := uc.data.GetDB().Order("id ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	var chapters []po.Chapter
+	if err := uc.data.GetDB().Select("id, tag_id, parent_id, sort").Find(&chapters).Error; err != nil {
+		return nil, err
+	}
+	chapterTags := make(map[uint]uint, len(chapters))
+	for _, chapter := range chapters {
+		chapterTags[chapter.ID] = chapter.TagID
+	}
+
+	items := make([]staticsite.ArticleItem, len(articles))
+	for i, article := range articles {
+		items[i] = b.articleItem(article)
+	}
+
+	if err := uc.buildArticles(b, articles, items, chapters); err != nil {
+		return nil, err
+	}
+
+	// 首页
+	if err := b.list("", "", "", "", items); err != nil {
+		return nil, err
+	}
+
+	// 分类
+	categoryTerms := make([]staticsite.Term, 0, len(categories))
+	for _, category := range categories {
+		var list []staticsite.ArticleItem
+		for _, item := range items {
+			if item.Category != nil && item.Category.URL == b.url(categoryPath(category.ID)) {
+				list = append(list, item)
+			}
+		}
+		if err := b.list(categoryPath(category.ID), category.Name, category.Description, "", list); err != nil {
+			return nil, err
+		}
+		categoryTerms = append(categoryTerms, staticsite.Term{Name: category.Name, URL: b.url(categoryPath(category.ID)), Count: len(list)})
+	}
+	if err := b.terms("categories/", "分类", categoryTerms); err != nil {
+		return nil, err
+	}
+
+	// 标签和章节目录
+	tagsWithChapters := make(map[uint]bool)
+	for _, tagID := range chapterTags {
+		tagsWithChapters[tagID] = true
+	}
+	tagTerms := make([]staticsite.Term, 0, len(tags))
+	for _, tag := range tags {
+		var list []staticsite.ArticleItem
+		for _, item := range items {
+			for _, link := range item.Tags {
+				if link.URL == b.url(tagPath(tag.ID)) {
+					list = append(list, item)
+					break
+				}
+			}
+		}
+
+		chaptersURL := ""
+		if tagsWithChapters[tag.ID] {
+			chaptersURL = b.url(tagPath(tag.ID) + "chapters/")
+			if err := uc.buildChapters(b, tag); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := b.list(tagPath(tag.ID), tag.Name, "", chaptersURL, list); err != nil {
+			return nil, err
+		}
+		tagTerms = append(tagTerms, staticsite.Term{Name: tag.Name, URL: b.url(tagPath(tag.ID)), Color: tag.Color, Count: len(list)})
+	}
+	if err := b.terms("tags/", "标签", tagTerms); err != nil {
+		return nil, err
+	}
+
+	// 归档
+	if err := b.archive(items); err != nil {
+		return nil, err
+	}
+
+	// 订阅
+	if err := b.feeds(items); err != nil {
+		return nil, err
+	}
+
+	// 主题静态资源
+	for _, p := range theme.StaticFiles() {
+		content := theme.StaticFile(p)
+		if err := builder.File(p, p, func() ([]byte, error) { return content, nil }); err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Finish()
+}
+
+// buildArticles 渲染文章页，上一篇/下一篇与博客前台一致：
+// 属于章节的文章按章节目录的阅读顺序，其他文章按 ID 顺序
+func (uc *staticSiteUseCase) buildArticles(b *siteBuild, articles []po.Article, items []staticsite.ArticleItem, chapters []po.Chapter) error {
+	index := make(map[uint]int, len(articles))
+	for i, article := range articles {
+		index[article.ID] = i
+	}
+
+	// 按 ID 顺序
+	byID := make([]int, len(articles))
+	for i := range articles {
+		byID[i] = i
+	}
+	sort.Slice(byID, func(i, j int) bool { return articles[byID[i]].ID < articles[byID[j]].ID })
+	prev := make(map[uint]int, len(articles))
+	next := make(map[uint]int, len(articles))
+	link := func(order []int) {
+		for pos, idx := range order {
+			prev[articles[idx].ID], next[articles[idx].ID] = -1, -1
+			if pos > 0 {
+				prev[articles[idx].ID] = order[pos-1]
+			}
+			if pos < len(order)-1 {
+				next[articles[idx].ID] = order[pos+1]
+			}
+		}
+	}
+	link(byID)
+
+	// 按标签的章节目录顺序，覆盖属于章节的文章
+	chaptersByTag := make(map[uint][]po.Chapter)
+	chapterTags := make(map[uint]uint, len(chapters))
+	for _, chapter := range chapters {
+		chaptersByTag[chapter.TagID] = append(chaptersByTag[chapter.TagID], chapter)
+		chapterTags[chapter.ID] = chapter.TagID
+	}
+	for _, tagChapters := range chaptersByTag {
+		var order []int
+		for _, article := range data.ChapterReadingOrder(tagChapters, articles) {
+			order = append(order, index[article.ID])
+		}
+		link(order)
+	}
+
+	for idx, article := range articles {
+		page := staticsite.ArticlePage{Site: b.site, Article: items[idx]}
+		if i := prev[article.ID]; i >= 0 {
+			page.Prev = &staticsite.Link{Title: items[i].Title, URL: items[i].URL}
+		}
+		if i := next[article.ID]; i >= 0 {
+			page.Next = &staticsite.Link{Title: items[i].Title, URL: items[i].URL}
+		}
+		if article.ChapterID != nil {
+			if tagID, ok := chapterTags[*article.ChapterID]; ok {
+				page.Chapters = b.url(tagPath(tagID) + "chapters/")
+			}
+		}
+
+		id := article.ID
+		err := b.builder.Page(articlePath(id)+"index.html", staticsite.PageArticle, page, func() (interface{}, error) {
+			var content po.Article
+			if err := uc.data.GetDB().Select("id, content_html").First(&content, id).Error; err != nil {
+				return nil, err
+			}
+			page.Content = template.HTML(b.rewriteMedia(content.ContentHTML))
+			return page, nil
+		})
+		if err != nil {
+			return fmt.Errorf("渲染文章 #%d 失败: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// buildChapters 渲染标签的章节目录页
+func (uc *staticSiteUseCase) buildChapters(b *siteBuild, tag po.Tag) error {
+	tree, err := uc.chapterUseCase.GetTreeByTag(tag.ID)
+	if err != nil {
+		return err
+	}
+
+	var convert func(nodes []dto.ChapterTreeNode) []staticsite.ChapterNode
+	convert = func(nodes []dto.ChapterTreeNode) []staticsite.ChapterNode {
+		result := make([]staticsite.ChapterNode, 0, len(nodes))
+		for _, node := range nodes {
+			links := make([]staticsite.Link, 0, len(node.Articles))
+			for _, article := range node.Articles {
+				links = append(links, staticsite.Link{Title: article.Title, URL: b.url(articlePath(article.ID))})
+			}
+			result = append(result, staticsite.ChapterNode{Name: node.Name, Articles: links, Children: convert(node.SubChapters)})
+		}
+		return result
+	}
+
+	page := staticsite.ChaptersPage{
+		Site:     b.site,
+		Tag:      staticsite.Link{Title: tag.Name, URL: b.url(tagPath(tag.ID))},
+		Chapters: convert(tree),
+	}
+	return b.builder.Page(tagPath(tag.ID)+"chapters/index.html", staticsite.PageChapters, page, func() (interface{}, error) {
+		return page, nil
+	})
+}
+
+// list 渲染分页的文章列表，dir 为列表所在目录
+func (b *siteBuild) list(dir, title, description, chapters string, items []staticsite.ArticleItem) error {
+	pageSize := config.AppConfig.Static.PageSize
+	totalPages := (len(items) + pageSize - 1) / pageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	pageDir := func(n int) string {
+		if n == 1 {
+			return dir
+		}
+		return fmt.Sprintf("%spage/%d/", dir, n)
+	}
+
+	for n := 1; n <= totalPages; n++ {
+		start := (n - 1) * pageSize
+		end := start + pageSize
+		if end > len(items) {
+			end = len(items)
+		}
+		page := staticsite.ListPage{
+			Site:        b.site,
+			Title:       title,
+			Description: description,
+			Articles:    items[start:end],
+			Pagination:  staticsite.Pagination{Page: n, TotalPages: totalPages},
+			Chapters:    chapters,
+		}
+		if n > 1 {
+			page.Pagination.Prev = b.url(pageDir(n - 1))
+		}
+		if n < totalPages {
+			page.Pagination.Next = b.url(pageDir(n + 1))
+		}
+		if err := b.builder.Page(pageDir(n)+"index.html", staticsite.PageList, page, func() (interface{}, error) {
+			return page, nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// terms 渲染分类或标签索引页
+func (b *siteBuild) terms(dir, title string, terms []staticsite.Term) error {
+	page := staticsite.TermsPage{Site: b.site, Title: title, Terms: terms}
+	return b.builder.Page(dir+"index.html", staticsite.PageTerms, page, func() (interface{}, error) {
+		return page, nil
+	})
+}
+
+// archive 渲染按月分组的归档页
+func (b *siteBuild) archive(items []staticsite.ArticleItem) error {
+	page := staticsite.ArchivePage{Site: b.site, Title: "归档", Groups: []staticsite.ArchiveGroup{}}
+	for _, item := range items {
+		month := item.CreatedAt.Format("2006-01")
+		if n := len(page.Groups); n == 0 || page.Groups[n-1].Title != month {
+			page.Groups = append(page.Groups, staticsite.ArchiveGroup{Title: month})
+		}
+		group := &page.Groups[len(page.Groups)-1]
+		group.Articles = append(group.Articles, item)
+	}
+	return b.builder.Page("archive/index.html", staticsite.PageArchive, page, func() (interface{}, error) {
+		return page, nil
+	})
+}
+
+// feeds 生成 RSS 和 Atom 订阅
+func (b *siteBuild) feeds(items []staticsite.ArticleItem) error {
+	if len(items) > feedSize {
+		items = items[:feedSize]
+	}
+	feedItems := make([]staticsite.FeedItem, 0, len(items))
+	for _, item := range items {
+		feedItem := staticsite.FeedItem{
+			Title:     item.Title,
+			URL:       staticsite.AbsURL(b.site, item.URL),
+			Summary:   item.Summary,
+			Author:    item.Author,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+		}
+		if item.Category != nil {
+			feedItem.Category = item.Category.Title
+		}
+		feedItems = append(feedItems, feedItem)
+	}
+
+	if err := b.builder.File("feed.xml", feedItems, func() ([]byte, error) {
+		return staticsite.RSS(b.site, feedItems)
+	}); err != nil {
+		return err
+	}
+	return b.builder.File("atom.xml", feedItems, func() ([]byte, error) {
+		return staticsite.Atom(b.site, staticsite.AbsURL(b.site, b.url("atom.xml")), feedItems)
+	})
+}
+
+// articleItem 转换为模板使用的文章摘要
+func (b *siteBuild) articleItem(article po.Article) staticsite.ArticleItem {
+	item := staticsite.ArticleItem{
+		ID:        article.ID,
+		Title:     article.Title,
+		Summary:   article.Summary,
+		Cover:     b.rewriteMedia(article.Cover),
+		URL:       b.url(articlePath(article.ID)),
+		Author:    article.Author.Nickname,
+		Tags:      make([]staticsite.Link, 0, len(article.Tags)),
+		CreatedAt: article.CreatedAt,
+		UpdatedAt: article.UpdatedAt,
+	}
+	if item.Author == "" {
+		item.Author = article.Author.Username
+	}
+	if article.Category.ID != 0 {
+		item.Category = &staticsite.Link{Title: article.Category.Name, URL: b.url(categoryPath(article.Category.ID))}
+	}
+	for _, tag := range article.Tags {
+		item.Tags = append(item.Tags, staticsite.Link{Title: tag.Name, URL: b.url(tagPath(tag.ID))})
+	}
+	return item
+}
+
+// rewriteMedia 将本地上传文件复制到静态站点，并按站点路径前缀改写地址
+// 上传文件名唯一且不会修改，已存在的文件不再复制
+func (b *siteBuild) rewriteMedia(content string) string {
+	return localMediaRegex.ReplaceAllStringFunc(content, func(src string) string {
+		rel := strings.TrimPrefix(src, "/")
+		if err := b.builder.Asset(rel, func() ([]byte, error) { return oss.ReadFile(src) }); err != nil {
+			logger.Warn("Failed to copy media to static site: ", src, ": ", err)
+			return src
+		}
+		return b.url(rel)
+	})
+}
+
+// url 站内路径转为带路径前缀的链接
+func (b *siteBuild) url(p string) string {
+	return b.site.BasePath + strings.TrimPrefix(p, "/")
+}
+
+// articlePath 文章页目录
+func articlePath(id uint) string {
+	return fmt.Sprintf("articles/%d/", id)
+}
+
+// categoryPath 分类页目录
+func categoryPath(id uint) string {
+	return fmt.Sprintf("categories/%d/", id)
+}
+
+// tagPath 标签页目录
+func tagPath(id uint) string {
+	return fmt.Sprintf("tags/%d/", id)
+}
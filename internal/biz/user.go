@@ -197,12 +197,18 @@ func (uc *userUseCase) convertToUserResponse(user *po.User) *dto.UserResponse {
 
 // CategoryUseCase 分类业务用例接口
 type CategoryUseCase interface {
-	// Create 创建分类
-	Create(name, description string, sort int) error
+	// Create 创建分类，parentID 为空表示一级分类
+	Create(name, description string, sort int, parentID *uint) error
+	// Update 更新分类名称、描述、颜色和排序
+	Update(id uint, req *dto.UpdateCategoryRequest) (*po.Category, error)
+	// Merge 将分类合并到目标分类，原名称保留为别名
+	Merge(id, targetID uint) error
 	// Delete 删除分类
 	Delete(id uint) error
 	// List 查询分类列表
 	List() ([]po.Category, error)
+	// Tree 查询分类树
+	Tree() ([]dto.CategoryTreeNode, error)
 }
 
 // categoryUseCase 分类业务用例实现
@@ -215,14 +221,20 @@ func NewCategoryUseCase(d *data.Data) CategoryUseCase {
 	return &categoryUseCase{data: d}
 }
 
-// Create 创建分类
-func (uc *categoryUseCase) Create(name, description string, sort int) error {
+// Create 创建分类，parentID 为空表示一级分类
+func (uc *categoryUseCase) Create(name, description string, sort int, parentID *uint) error {
 	// 检查分类名称是否已存在
 	if _, err := uc.data.CategoryRepo.FindByName(name); err == nil {
 		return errors.New("分类名称已存在")
 	}
+	if parentID != nil {
+		if _, err := uc.data.CategoryRepo.FindByID(*parentID); err != nil {
+			return errors.New("父分类不存在")
+		}
+	}
 
 	category := &po.Category{
+		ParentID:    parentID,
 		Name:        name,
 		Description: description,
 		Sort:        sort,
@@ -235,6 +247,89 @@ func (uc *categoryUseCase) Create(name, description string, sort int) error {
 	return nil
 }
 
+// Update 更新分类名称、描述、颜色和排序
+func (uc *categoryUseCase) Update(id uint, req *dto.UpdateCategoryRequest) (*po.Category, error) {
+	category, err := uc.data.CategoryRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("分类不存在")
+	}
+
+	if req.Name != nil && *req.Name != category.Name {
+		// 新名称不能与其他分类的名称或别名重复
+		if existing, err := uc.data.CategoryRepo.FindByName(*req.Name); err == nil && existing.ID != id {
+			return nil, errors.New("分类名称已存在")
+		}
+		category.Name = *req.Name
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.Color != nil {
+		category.Color = *req.Color
+	}
+	if req.Sort != nil {
+		category.Sort = *req.Sort
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			// 父分类不能是自身或子孙分类
+			descendants, err := uc.data.CategoryRepo.Descendants(id)
+			if err != nil {
+				return nil, errors.New("查询失败")
+			}
+			for _, descendant := range descendants {
+				if descendant == *req.ParentID {
+					return nil, errors.New("不能移动到自身或子分类下")
+				}
+			}
+			if _, err := uc.data.CategoryRepo.FindByID(*req.ParentID); err != nil {
+				return nil, errors.New("父分类不存在")
+			}
+			parentID := *req.ParentID
+			category.ParentID = &parentID
+		}
+	}
+
+	if err := uc.data.CategoryRepo.Update(category); err != nil {
+		return nil, errors.New("更新分类失败")
+	}
+
+	return category, nil
+}
+
+// Merge 将分类合并到目标分类，原名称保留为别名
+func (uc *categoryUseCase) Merge(id, targetID uint) error {
+	if id == targetID {
+		return errors.New("不能合并到自身")
+	}
+	source, err := uc.data.CategoryRepo.FindByID(id)
+	if err != nil {
+		return errors.New("分类不存在")
+	}
+	target, err := uc.data.CategoryRepo.FindByID(targetID)
+	if err != nil {
+		return errors.New("目标分类不存在")
+	}
+	// 子分类会移到目标分类下，目标不能是源分类的子孙分类
+	descendants, err := uc.data.CategoryRepo.Descendants(id)
+	if err != nil {
+		return errors.New("查询失败")
+	}
+	for _, descendant := range descendants {
+		if descendant == targetID {
+			return errors.New("不能合并到子分类")
+		}
+	}
+
+	if err := uc.data.CategoryRepo.Merge(source, target); err != nil {
+		return errors.New("合并分类失败")
+	}
+
+	return nil
+}
+
 // Delete 删除分类
 func (uc *categoryUseCase) Delete(id uint) error {
 	// 检查分类是否存在
@@ -242,6 +337,15 @@ func (uc *categoryUseCase) Delete(id uint) error {
 		return errors.New("分类不存在")
 	}
 
+	// 检查分类下是否有子分类
+	hasChildren, err := uc.data.CategoryRepo.HasChildren(id)
+	if err != nil {
+		return errors.New("查询失败")
+	}
+	if hasChildren {
+		return errors.New("该分类下存在子分类，无法删除")
+	}
+
 	// 检查分类下是否有文章
 	hasArticles, err := uc.data.CategoryRepo.HasArticles(id)
 	if err != nil {
@@ -273,10 +377,57 @@ func (uc *categoryUseCase) List() ([]po.Category, error) {
 	return result, nil
 }
 
+// Tree 查询分类树
+func (uc *categoryUseCase) Tree() ([]dto.CategoryTreeNode, error) {
+	categories, err := uc.data.CategoryRepo.List()
+	if err != nil {
+		return nil, errors.New("查询分类列表失败")
+	}
+
+	// 按父分类分组，列表已按 sort 排序；父分类不存在的作为一级分类
+	exists := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		exists[category.ID] = true
+	}
+	children := make(map[uint][]*po.Category)
+	var roots []*po.Category
+	for _, category := range categories {
+		if category.ParentID == nil || !exists[*category.ParentID] {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(categories []*po.Category) []dto.CategoryTreeNode
+	build = func(categories []*po.Category) []dto.CategoryTreeNode {
+		nodes := make([]dto.CategoryTreeNode, 0, len(categories))
+		for _, category := range categories {
+			nodes = append(nodes, dto.CategoryTreeNode{
+				ID:          category.ID,
+				ParentID:    category.ParentID,
+				Name:        category.Name,
+				Description: category.Description,
+				Color:       category.Color,
+				Sort:        category.Sort,
+				CreatedAt:   category.CreatedAt,
+				Children:    build(children[category.ID]),
+			})
+		}
+		return nodes
+	}
+
+	return build(roots), nil
+}
+
 // TagUseCase 标签业务用例接口
 type TagUseCase interface {
 	// Create 创建标签
 	Create(name, color string) error
+	// Update 更新标签名称、颜色和描述
+	Update(id uint, req *dto.UpdateTagRequest) (*po.Tag, error)
+	// Merge 将标签合并到目标标签，原名称保留为别名
+	Merge(id, targetID uint) error
 	// Delete 删除标签
 	Delete(id uint) error
 	// List 查询标签列表
@@ -312,6 +463,55 @@ func (uc *tagUseCase) Create(name, color string) error {
 	return nil
 }
 
+// Update 更新标签名称、颜色和描述
+func (uc *tagUseCase) Update(id uint, req *dto.UpdateTagRequest) (*po.Tag, error) {
+	tag, err := uc.data.TagRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("标签不存在")
+	}
+
+	if req.Name != nil && *req.Name != tag.Name {
+		// 新名称不能与其他标签的名称或别名重复
+		if existing, err := uc.data.TagRepo.FindByName(*req.Name); err == nil && existing.ID != id {
+			return nil, errors.New("标签名称已存在")
+		}
+		tag.Name = *req.Name
+	}
+	if req.Color != nil {
+		tag.Color = *req.Color
+	}
+	if req.Description != nil {
+		tag.Description = *req.Description
+	}
+
+	if err := uc.data.TagRepo.Update(tag); err != nil {
+		return nil, errors.New("更新标签失败")
+	}
+
+	return tag, nil
+}
+
+// Merge 将标签合并到目标标签，原名称保留为别名
+func (uc *tagUseCase) Merge(id, targetID uint) error {
+	if id == targetID {
+		return errors.New("不能合并到自身")
+	}
+	source, err := uc.data.TagRepo.FindByID(id)
+	if err != nil {
+		return errors.New("标签不存在")
+	}
+	target, err := uc.data.TagRepo.FindByID(targetID)
+	if err != nil {
+		return errors.New("目标标签不存在")
+	}
+
+	if err := uc.data.TagRepo.Merge(source, target); err != nil {
+		return errors.New("合并标签失败")
+	}
+
+	return nil
+}
+
 // Delete 删除标签
 func (uc *tagUseCase) Delete(id uint) error {
 	// 检查标签是否存在
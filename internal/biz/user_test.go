@@ -0,0 +1,158 @@
+package biz
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"gorm.io/gorm"
+)
+
+// memoryCategoryRepo 内存中的分类仓储，只实现分类更新和合并用到的方法
+type memoryCategoryRepo struct {
+	data.CategoryRepo
+	categories map[uint]*po.Category
+	merged     [2]uint // 最后一次合并的源和目标分类
+}
+
+func newMemoryCategoryRepo(categories ...po.Category) *memoryCategoryRepo {
+	r := &memoryCategoryRepo{categories: make(map[uint]*po.Category)}
+	for i := range categories {
+		r.categories[categories[i].ID] = &categories[i]
+	}
+	return r
+}
+
+func (r *memoryCategoryRepo) FindByID(id uint) (*po.Category, error) {
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *category
+	return &copied, nil
+}
+
+func (r *memoryCategoryRepo) FindByName(name string) (*po.Category, error) {
+	for _, category := range r.categories {
+		if category.Name == name {
+			return r.FindByID(category.ID)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryCategoryRepo) Descendants(id uint) ([]uint, error) {
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		for _, category := range r.categories {
+			if category.ParentID != nil && *category.ParentID == ids[i] {
+				ids = append(ids, category.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryCategoryRepo) Update(category *po.Category) error {
+	copied := *category
+	r.categories[category.ID] = &copied
+	return nil
+}
+
+func (r *memoryCategoryRepo) Merge(source, target *po.Category) error {
+	r.merged = [2]uint{source.ID, target.ID}
+	return nil
+}
+
+// categoryTree 1 -> 2 -> 3，4 为另一个一级分类
+func categoryTree() *memoryCategoryRepo {
+	id := func(v uint) *uint { return &v }
+	return newMemoryCategoryRepo(
+		po.Category{ID: 1, Name: "后端"},
+		po.Category{ID: 2, Name: "Go", ParentID: id(1)},
+		po.Category{ID: 3, Name: "并发", ParentID: id(2)},
+		po.Category{ID: 4, Name: "前端"},
+	)
+}
+
+func TestCategoryUpdateParent(t *testing.T) {
+	ptr := func(v uint) *uint { return &v }
+	parent := func(v uint) *dto.UpdateCategoryRequest { return &dto.UpdateCategoryRequest{ParentID: ptr(v)} }
+	tests := []struct {
+		name    string
+		id      uint
+		req     *dto.UpdateCategoryRequest
+		wantErr string
+		parent  *uint // 成功时期望的父分类
+	}{
+		{"self", 1, parent(1), "不能移动到自身或子分类下", nil},
+		{"child", 1, parent(2), "不能移动到自身或子分类下", nil},
+		{"grandchild", 1, parent(3), "不能移动到自身或子分类下", nil},
+		{"missing parent", 2, parent(99), "父分类不存在", nil},
+		{"other branch", 2, parent(4), "", ptr(4)},
+		{"move up", 3, parent(1), "", ptr(1)},
+		{"to top level", 2, parent(0), "", nil},
+	}
+	for _, tt := range tests {
+		repo := categoryTree()
+		uc := NewCategoryUseCase(&data.Data{CategoryRepo: repo})
+
+		_, err := uc.Update(tt.id, tt.req)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := repo.categories[tt.id].ParentID; !reflect.DeepEqual(got, tt.parent) {
+			t.Errorf("%s: parent = %v, want %v", tt.name, got, tt.parent)
+		}
+	}
+}
+
+func TestCategoryUpdateName(t *testing.T) {
+	repo := categoryTree()
+	uc := NewCategoryUseCase(&data.Data{CategoryRepo: repo})
+
+	name := "前端"
+	if _, err := uc.Update(1, &dto.UpdateCategoryRequest{Name: &name}); err == nil {
+		t.Error("duplicate name: want error")
+	}
+	name = "服务端"
+	if category, err := uc.Update(1, &dto.UpdateCategoryRequest{Name: &name}); err != nil || category.Name != name {
+		t.Errorf("rename = %+v, %v", category, err)
+	}
+}
+
+func TestCategoryMergeTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		id, target uint
+		wantErr    string
+	}{
+		{"self", 1, 1, "不能合并到自身"},
+		{"descendant", 1, 3, "不能合并到子分类"},
+		{"missing target", 1, 99, "目标分类不存在"},
+		{"sibling branch", 2, 4, ""},
+		{"into parent", 3, 1, ""},
+	}
+	for _, tt := range tests {
+		repo := categoryTree()
+		err := NewCategoryUseCase(&data.Data{CategoryRepo: repo}).Merge(tt.id, tt.target)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || repo.merged != [2]uint{tt.id, tt.target} {
+			t.Errorf("%s: err = %v, merged = %v", tt.name, err, repo.merged)
+		}
+	}
+}
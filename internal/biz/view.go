@@ -0,0 +1,135 @@
+package biz
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/privacy"
+	"github.com/ydcloud-dy/leaf-api/pkg/useragent"
+)
+
+const (
+	// viewPendingKey 等待写入数据库的浏览记录
+	viewPendingKey = "view:pending"
+	// viewFlushBatch 每批写入数据库的浏览记录数
+	viewFlushBatch = 1000
+)
+
+// ViewUseCase 文章浏览量业务用例接口
+type ViewUseCase interface {
+	// Record 记录一次文章浏览，同一访客在窗口期内重复浏览和爬虫不计数，返回是否计数
+	Record(articleID, userID uint, ip, userAgent string) bool
+	// Flush 把缓冲的浏览记录写入数据库
+	Flush() error
+}
+
+// viewUseCase 文章浏览量业务用例实现
+type viewUseCase struct {
+	data   *data.Data
+	window time.Duration
+}
+
+// pendingView 缓冲中的浏览记录
+type pendingView struct {
+	ArticleID uint   `json:"a"`
+	UserID    uint   `json:"u,omitempty"`
+	IP        string `json:"ip"`
+	At        int64  `json:"t"`
+}
+
+// NewViewUseCase 创建文章浏览量业务用例，并启动定时写入
+func NewViewUseCase(d *data.Data) ViewUseCase {
+	uc := &viewUseCase{data: d, window: 30 * time.Minute}
+
+	interval := 10 * time.Second
+	if config.AppConfig != nil {
+		if config.AppConfig.Analytics.ViewWindow > 0 {
+			uc.window = time.Duration(config.AppConfig.Analytics.ViewWindow) * time.Minute
+		}
+		if config.AppConfig.Analytics.ViewFlush > 0 {
+			interval = time.Duration(config.AppConfig.Analytics.ViewFlush) * time.Second
+		}
+	}
+	go uc.loop(interval)
+
+	return uc
+}
+
+// loop 定时写入缓冲的浏览记录
+func (uc *viewUseCase) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := uc.Flush(); err != nil {
+			logger.Error("写入浏览记录失败: ", err)
+		}
+	}
+}
+
+// Record 记录一次文章浏览，同一访客在窗口期内重复浏览和爬虫不计数，返回是否计数
+func (uc *viewUseCase) Record(articleID, userID uint, ip, userAgent string) bool {
+	if useragent.Parse(userAgent).IsBot {
+		return false
+	}
+
+	store := cache.Default()
+	seenKey := fmt.Sprintf("view:seen:%d:%s", articleID, visitorID(userID, ip, userAgent))
+	ok, err := store.SetNX(seenKey, "1", uc.window)
+	if err != nil {
+		logger.Warn("浏览去重失败: ", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	payload, _ := json.Marshal(pendingView{ArticleID: articleID, UserID: userID, IP: privacy.IP(ip), At: time.Now().Unix()})
+	if err := store.RPush(viewPendingKey, string(payload)); err != nil {
+		logger.Warn("缓冲浏览记录失败: ", err)
+		return false
+	}
+	return true
+}
+
+// Flush 把缓冲的浏览记录写入数据库
+func (uc *viewUseCase) Flush() error {
+	store := cache.Default()
+	for {
+		items, err := store.LPopN(viewPendingKey, viewFlushBatch)
+		if err != nil || len(items) == 0 {
+			return err
+		}
+
+		views := make([]po.View, 0, len(items))
+		for _, item := range items {
+			var v pendingView
+			if err := json.Unmarshal([]byte(item), &v); err != nil {
+				continue
+			}
+			views = append(views, po.View{
+				ArticleID: v.ArticleID,
+				UserID:    v.UserID,
+				IP:        v.IP,
+				CreatedAt: time.Unix(v.At, 0),
+			})
+		}
+
+		if err := uc.data.ViewRepo.CreateBatch(views); err != nil {
+			// 写入失败时放回队列，下次重试
+			if pushErr := store.RPush(viewPendingKey, items...); pushErr != nil {
+				logger.Error("浏览记录放回队列失败，丢弃 ", len(items), " 条: ", pushErr)
+			}
+			return err
+		}
+
+		if len(items) < viewFlushBatch {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,665 @@
+package data
+
+import (
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/referrer"
+	"gorm.io/gorm"
+)
+
+// VisitStat 访问统计汇总结果
+type VisitStat struct {
+	Dimension     string
+	Name          string
+	PV            int64
+	UV            int64
+	DurationSum   int64
+	DurationCount int64
+}
+
+// AvgDuration 平均停留时长（秒），只计算有停留时长的记录
+func (s VisitStat) AvgDuration() float64 {
+	if s.DurationCount == 0 {
+		return 0
+	}
+	return float64(s.DurationSum) / float64(s.DurationCount)
+}
+
+// SourceStat 来源统计
+type SourceStat struct {
+	Category string
+	Domain   string
+	PV       int64
+	UV       int64
+}
+
+// CampaignStat 推广活动统计
+type CampaignStat struct {
+	Source   string
+	Medium   string
+	Campaign string
+	PV       int64
+	UV       int64
+}
+
+// SearchStat 搜索引擎和关键词统计
+type SearchStat struct {
+	Engine string
+	Term   string
+	PV     int64
+	UV     int64
+}
+
+// CrawlerStat 爬虫访问统计
+type CrawlerStat struct {
+	BotName     string
+	BotCategory string
+	PV          int64
+	Pages       int64 // 访问的不同页面数
+	LastSeen    time.Time
+}
+
+// 地区统计粒度
+const (
+	LocationLevelCountry  = "country"
+	LocationLevelProvince = "province"
+	LocationLevelCity     = "city"
+)
+
+// LocationFilter 地区统计条件
+type LocationFilter struct {
+	Start    time.Time
+	End      time.Time
+	Level    string // country, province, city
+	Country  string // 只统计该国家，为空时不限
+	Province string // 只统计该省份，为空时不限
+	Limit    int
+}
+
+// LocationStat 地区统计，粒度以下的字段为空
+type LocationStat struct {
+	Country  string
+	Province string
+	City     string
+	PV       int64
+	UV       int64
+}
+
+// LoginLogRecord 登录记录及用户名
+type LoginLogRecord struct {
+	po.LoginLog
+	Username string
+}
+
+// ArticleDayStat 文章按天的访问统计
+type ArticleDayStat struct {
+	Day           string // 2006-01-02
+	PV            int64
+	UV            int64 // 按访客标识去重
+	DurationSum   int64
+	DurationCount int64
+	ScrollSum     int64
+	ScrollCount   int64
+}
+
+// UserTracking 博客用户的追踪数据
+type UserTracking struct {
+	Visits []po.PageVisit
+	Views  []po.View
+	Logins []po.LoginLog
+}
+
+// AnalyticsRepo 访问统计仓储接口
+type AnalyticsRepo interface {
+	// RollupHourly 重新汇总从 start 开始一小时内的访问记录
+	RollupHourly(start time.Time) error
+	// RollupDaily 重新汇总从 start 开始一天内的访问记录
+	RollupDaily(start time.Time) error
+	// LatestHourly 最近一次小时汇总的时间段，没有汇总记录时返回 nil
+	LatestHourly() (*time.Time, error)
+	// LatestDaily 最近一次按天汇总的时间段，没有汇总记录时返回 nil
+	LatestDaily() (*time.Time, error)
+	// EarliestVisit 最早一条访问记录的时间，没有访问记录时返回 nil
+	EarliestVisit() (*time.Time, error)
+	// PruneVisits 分批删除 before 之前的原始访问记录，返回删除的条数
+	PruneVisits(before time.Time, batch int) (int64, error)
+	// ClearLoginClientBefore 分批清空 before 之前登录记录中的 IP 和 UA，返回清空的条数
+	ClearLoginClientBefore(before time.Time, batch int) (int64, error)
+	// ListDailyTotals 查询 [start, end) 内每天的全站汇总
+	ListDailyTotals(start, end time.Time) ([]po.VisitDaily, error)
+	// SumHourlyTotal 合计 start 之后各小时的全站汇总
+	SumHourlyTotal(start time.Time) (*VisitStat, error)
+	// TopDaily 按 PV 排序查询 start 之后某个维度的按天汇总
+	TopDaily(dimension string, start time.Time, limit int) ([]VisitStat, error)
+	// CountByMinute 按分钟统计 start 之后的原始访问记录（不含爬虫），key 为 "2006-01-02 15:04"
+	CountByMinute(start time.Time) (map[string]int64, error)
+	// CountByCategory 按来源类别统计 [start, end) 内的访问记录
+	CountByCategory(start, end time.Time) ([]SourceStat, error)
+	// TopSources 按来源域名统计 [start, end) 内的外部来源，不含直接访问和站内跳转
+	TopSources(start, end time.Time, limit int) ([]SourceStat, error)
+	// TopCampaigns 按 UTM 参数统计 [start, end) 内的推广活动
+	TopCampaigns(start, end time.Time, limit int) ([]CampaignStat, error)
+	// TopSearchEngines 按搜索引擎统计 [start, end) 内的搜索来源
+	TopSearchEngines(start, end time.Time, limit int) ([]SearchStat, error)
+	// TopSearchTerms 按搜索关键词统计 [start, end) 内的搜索来源
+	TopSearchTerms(start, end time.Time, limit int) ([]SearchStat, error)
+	// TopCrawlers 按爬虫统计 [start, end) 内的爬虫访问
+	TopCrawlers(start, end time.Time, limit int) ([]CrawlerStat, error)
+	// CountVisitsByLocation 按地区统计访问记录（不含爬虫），UV 按访客标识去重
+	CountVisitsByLocation(filter LocationFilter) ([]LocationStat, error)
+	// CountLoginsByLocation 按地区统计登录记录，UV 按用户去重
+	CountLoginsByLocation(filter LocationFilter) ([]LocationStat, error)
+	// CreateVisit 创建访问记录
+	CreateVisit(visit *po.PageVisit) error
+	// CreateLoginLog 创建登录记录
+	CreateLoginLog(log *po.LoginLog) error
+	// ListLoginLogs 分页查询登录记录，userID 为 0 时查询所有用户
+	ListLoginLogs(userID uint, page, limit int) ([]LoginLogRecord, int64, error)
+	// ArticleVisitsByDay 按天统计 [start, end) 内文章的访问记录（不含爬虫），path 用于匹配没有 article_id 的记录
+	ArticleVisitsByDay(articleID uint, path string, start, end time.Time) ([]ArticleDayStat, error)
+	// ArticleReferrers 按来源域名统计 [start, end) 内文章的外部来源
+	ArticleReferrers(articleID uint, path string, start, end time.Time, limit int) ([]SourceStat, error)
+	// CountArticleActivityByDay 按天统计 [start, end) 内文章的点赞、收藏和评论数，key 为 "2006-01-02"
+	CountArticleActivityByDay(articleID uint, start, end time.Time) (likes, favorites, comments map[string]int64, err error)
+	// ListUserTracking 查询博客用户的访问记录、浏览记录和前台登录记录
+	ListUserTracking(userID uint) (*UserTracking, error)
+	// DeleteUserTracking 删除博客用户的访问记录、浏览记录和前台登录记录，返回各自删除的条数
+	DeleteUserTracking(userID uint) (visits, views, logins int64, err error)
+}
+
+// analyticsRepo 访问统计仓储实现
+type analyticsRepo struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepo 创建访问统计仓储
+func NewAnalyticsRepo(db *gorm.DB) AnalyticsRepo {
+	return &analyticsRepo{db: db}
+}
+
+// visitorColumn 独立访客的去重列，没有访客标识的旧记录按 IP 去重
+const visitorColumn = "COALESCE(NULLIF(visitor, ''), ip)"
+
+// visitStatColumns 汇总查询的统计列
+const visitStatColumns = "COUNT(*) AS pv, COUNT(DISTINCT " + visitorColumn + ") AS uv, " +
+	"COALESCE(SUM(duration), 0) AS duration_sum, " +
+	"COALESCE(SUM(CASE WHEN duration > 0 THEN 1 ELSE 0 END), 0) AS duration_count"
+
+// aggregateVisits 汇总 [start, end) 内的访问记录（不含爬虫），全站合计总会返回一行，用于记录汇总进度
+func aggregateVisits(tx *gorm.DB, start, end time.Time) ([]VisitStat, error) {
+	var total VisitStat
+	if err := tx.Model(&po.PageVisit{}).
+		Select("'' AS name, "+visitStatColumns).
+		Where("created_at >= ? AND created_at < ? AND is_bot = ?", start, end, false).
+		Scan(&total).Error; err != nil {
+		return nil, err
+	}
+	total.Dimension = po.VisitDimensionTotal
+	stats := []VisitStat{total}
+	if total.PV == 0 {
+		return stats, nil
+	}
+
+	groups := []struct {
+		dimension string
+		column    string
+	}{
+		{po.VisitDimensionPath, "path"},
+		{po.VisitDimensionReferrer, "referrer"},
+	}
+	for _, g := range groups {
+		var list []VisitStat
+		if err := tx.Model(&po.PageVisit{}).
+			Select(g.column+" AS name, "+visitStatColumns).
+			Where("created_at >= ? AND created_at < ? AND is_bot = ?", start, end, false).
+			Group(g.column).
+			Scan(&list).Error; err != nil {
+			return nil, err
+		}
+		for i := range list {
+			list[i].Dimension = g.dimension
+		}
+		stats = append(stats, list...)
+	}
+	return stats, nil
+}
+
+// RollupHourly 重新汇总从 start 开始一小时内的访问记录
+func (r *analyticsRepo) RollupHourly(start time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		stats, err := aggregateVisits(tx, start, start.Add(time.Hour))
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("period = ?", start).Delete(&po.VisitHourly{}).Error; err != nil {
+			return err
+		}
+		rows := make([]po.VisitHourly, 0, len(stats))
+		for _, s := range stats {
+			rows = append(rows, po.VisitHourly{
+				Period:        start,
+				Dimension:     s.Dimension,
+				Name:          s.Name,
+				PV:            s.PV,
+				UV:            s.UV,
+				DurationSum:   s.DurationSum,
+				DurationCount: s.DurationCount,
+			})
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+// RollupDaily 重新汇总从 start 开始一天内的访问记录
+func (r *analyticsRepo) RollupDaily(start time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		stats, err := aggregateVisits(tx, start, start.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("period = ?", start).Delete(&po.VisitDaily{}).Error; err != nil {
+			return err
+		}
+		rows := make([]po.VisitDaily, 0, len(stats))
+		for _, s := range stats {
+			rows = append(rows, po.VisitDaily{
+				Period:        start,
+				Dimension:     s.Dimension,
+				Name:          s.Name,
+				PV:            s.PV,
+				UV:            s.UV,
+				DurationSum:   s.DurationSum,
+				DurationCount: s.DurationCount,
+			})
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+}
+
+// latestPeriod 查询汇总表中最新的时间段
+func (r *analyticsRepo) latestPeriod(model interface{}) (*time.Time, error) {
+	var periods []time.Time
+	err := r.db.Model(model).
+		Where("dimension = ?", po.VisitDimensionTotal).
+		Order("period DESC").
+		Limit(1).
+		Pluck("period", &periods).Error
+	if err != nil || len(periods) == 0 {
+		return nil, err
+	}
+	return &periods[0], nil
+}
+
+// LatestHourly 最近一次小时汇总的时间段，没有汇总记录时返回 nil
+func (r *analyticsRepo) LatestHourly() (*time.Time, error) {
+	return r.latestPeriod(&po.VisitHourly{})
+}
+
+// LatestDaily 最近一次按天汇总的时间段，没有汇总记录时返回 nil
+func (r *analyticsRepo) LatestDaily() (*time.Time, error) {
+	return r.latestPeriod(&po.VisitDaily{})
+}
+
+// EarliestVisit 最早一条访问记录的时间，没有访问记录时返回 nil
+func (r *analyticsRepo) EarliestVisit() (*time.Time, error) {
+	var visits []po.PageVisit
+	if err := r.db.Select("created_at").Order("created_at ASC").Limit(1).Find(&visits).Error; err != nil {
+		return nil, err
+	}
+	if len(visits) == 0 {
+		return nil, nil
+	}
+	return &visits[0].CreatedAt, nil
+}
+
+// execInBatches 重复执行带 LIMIT 的删除或更新语句，直到影响的行数不足一批，返回影响的总行数
+// 分批执行，避免一次处理大量数据长时间锁表；sql 的最后一个参数必须是 LIMIT
+func execInBatches(db *gorm.DB, batch int, sql string, args ...interface{}) (int64, error) {
+	args = append(args, batch)
+	var total int64
+	for {
+		result := db.Exec(sql, args...)
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < int64(batch) {
+			return total, nil
+		}
+	}
+}
+
+// PruneVisits 分批删除 before 之前的原始访问记录，返回删除的条数
+func (r *analyticsRepo) PruneVisits(before time.Time, batch int) (int64, error) {
+	return execInBatches(r.db, batch, "DELETE FROM page_visits WHERE created_at < ? LIMIT ?", before)
+}
+
+// ClearLoginClientBefore 分批清空 before 之前登录记录中的 IP 和 UA，返回清空的条数
+func (r *analyticsRepo) ClearLoginClientBefore(before time.Time, batch int) (int64, error) {
+	return execInBatches(r.db, batch,
+		"UPDATE login_logs SET ip = '', user_agent = '' WHERE created_at < ? AND (ip <> '' OR user_agent <> '') LIMIT ?", before)
+}
+
+// ListDailyTotals 查询 [start, end) 内每天的全站汇总
+func (r *analyticsRepo) ListDailyTotals(start, end time.Time) ([]po.VisitDaily, error) {
+	var list []po.VisitDaily
+	err := r.db.Where("dimension = ? AND period >= ? AND period < ?", po.VisitDimensionTotal, start, end).
+		Order("period ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// SumHourlyTotal 合计 start 之后各小时的全站汇总，各小时的独立访客不能相加，UV 不合计
+func (r *analyticsRepo) SumHourlyTotal(start time.Time) (*VisitStat, error) {
+	var stat VisitStat
+	err := r.db.Model(&po.VisitHourly{}).
+		Select("COALESCE(SUM(pv), 0) AS pv, "+
+			"COALESCE(SUM(duration_sum), 0) AS duration_sum, COALESCE(SUM(duration_count), 0) AS duration_count").
+		Where("dimension = ? AND period >= ?", po.VisitDimensionTotal, start).
+		Scan(&stat).Error
+	if err != nil {
+		return nil, err
+	}
+	stat.Dimension = po.VisitDimensionTotal
+	return &stat, nil
+}
+
+// TopDaily 按 PV 排序查询 start 之后某个维度的按天汇总，UV 为各天独立访客数之和
+func (r *analyticsRepo) TopDaily(dimension string, start time.Time, limit int) ([]VisitStat, error) {
+	var list []VisitStat
+	err := r.db.Model(&po.VisitDaily{}).
+		Select("name, SUM(pv) AS pv, SUM(uv) AS uv, SUM(duration_sum) AS duration_sum, SUM(duration_count) AS duration_count").
+		Where("dimension = ? AND period >= ?", dimension, start).
+		Group("name").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&list).Error
+	for i := range list {
+		list[i].Dimension = dimension
+	}
+	return list, err
+}
+
+// CountByMinute 按分钟统计 start 之后的原始访问记录（不含爬虫），key 为 "2006-01-02 15:04"
+func (r *analyticsRepo) CountByMinute(start time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Minute string
+		Count  int64
+	}
+	err := r.db.Model(&po.PageVisit{}).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d %H:%i') AS minute, COUNT(*) AS count").
+		Where("created_at >= ? AND is_bot = ?", start, false).
+		Group("minute").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Minute] = row.Count
+	}
+	return counts, nil
+}
+
+// CountByCategory 按来源类别统计 [start, end) 内的访问记录
+func (r *analyticsRepo) CountByCategory(start, end time.Time) ([]SourceStat, error) {
+	var list []SourceStat
+	err := r.db.Model(&po.PageVisit{}).
+		Select("ref_category AS category, COUNT(*) AS pv, COUNT(DISTINCT "+visitorColumn+") AS uv").
+		Where("created_at >= ? AND created_at < ? AND is_bot = ? AND ref_category <> ''", start, end, false).
+		Group("ref_category").
+		Order("pv DESC").
+		Scan(&list).Error
+	return list, err
+}
+
+// TopSources 按来源域名统计 [start, end) 内的外部来源，不含直接访问和站内跳转
+func (r *analyticsRepo) TopSources(start, end time.Time, limit int) ([]SourceStat, error) {
+	var list []SourceStat
+	err := r.db.Model(&po.PageVisit{}).
+		Select("ref_category AS category, ref_domain AS domain, COUNT(*) AS pv, COUNT(DISTINCT "+visitorColumn+") AS uv").
+		Where("created_at >= ? AND created_at < ? AND is_bot = ? AND ref_domain <> '' AND ref_category <> ?", start, end, false, referrer.CategoryInternal).
+		Group("ref_category, ref_domain").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// TopCampaigns 按 UTM 参数统计 [start, end) 内的推广活动
+func (r *analyticsRepo) TopCampaigns(start, end time.Time, limit int) ([]CampaignStat, error) {
+	var list []CampaignStat
+	err := r.db.Model(&po.PageVisit{}).
+		Select("utm_source AS source, utm_medium AS medium, utm_campaign AS campaign, COUNT(*) AS pv, COUNT(DISTINCT "+visitorColumn+") AS uv").
+		Where("created_at >= ? AND created_at < ? AND is_bot = ? AND (utm_source <> '' OR utm_campaign <> '')", start, end, false).
+		Group("utm_source, utm_medium, utm_campaign").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// TopSearchEngines 按搜索引擎统计 [start, end) 内的搜索来源
+func (r *analyticsRepo) TopSearchEngines(start, end time.Time, limit int) ([]SearchStat, error) {
+	var list []SearchStat
+	err := r.db.Model(&po.PageVisit{}).
+		Select("search_engine AS engine, COUNT(*) AS pv, COUNT(DISTINCT "+visitorColumn+") AS uv").
+		Where("created_at >= ? AND created_at < ? AND is_bot = ? AND search_engine <> ''", start, end, false).
+		Group("search_engine").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// TopSearchTerms 按搜索关键词统计 [start, end) 内的搜索来源
+func (r *analyticsRepo) TopSearchTerms(start, end time.Time, limit int) ([]SearchStat, error) {
+	var list []SearchStat
+	err := r.db.Model(&po.PageVisit{}).
+		Select("search_engine AS engine, search_term AS term, COUNT(*) AS pv, COUNT(DISTINCT "+visitorColumn+") AS uv").
+		Where("created_at >= ? AND created_at < ? AND is_bot = ? AND search_term <> ''", start, end, false).
+		Group("search_engine, search_term").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// TopCrawlers 按爬虫统计 [start, end) 内的爬虫访问
+func (r *analyticsRepo) TopCrawlers(start, end time.Time, limit int) ([]CrawlerStat, error) {
+	var list []CrawlerStat
+	err := r.db.Model(&po.PageVisit{}).
+		Select("bot_name, bot_category, COUNT(*) AS pv, COUNT(DISTINCT path) AS pages, MAX(created_at) AS last_seen").
+		Where("created_at >= ? AND created_at < ? AND is_bot = ?", start, end, true).
+		Group("bot_name, bot_category").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// locationColumns 地区统计粒度对应的分组列
+func locationColumns(level string) string {
+	switch level {
+	case LocationLevelCity:
+		return "country, province, city"
+	case LocationLevelProvince:
+		return "country, province"
+	default:
+		return "country"
+	}
+}
+
+// countByLocation 按地区分组统计，只统计已解析出国家的记录
+func countByLocation(query *gorm.DB, filter LocationFilter, uvColumn string) ([]LocationStat, error) {
+	columns := locationColumns(filter.Level)
+	query = query.Where("created_at >= ? AND created_at < ? AND country <> ''", filter.Start, filter.End)
+	if filter.Country != "" {
+		query = query.Where("country = ?", filter.Country)
+	}
+	if filter.Province != "" {
+		query = query.Where("province = ?", filter.Province)
+	}
+
+	var list []LocationStat
+	err := query.
+		Select(columns + ", COUNT(*) AS pv, COUNT(DISTINCT " + uvColumn + ") AS uv").
+		Group(columns).
+		Order("pv DESC").
+		Limit(filter.Limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// CountVisitsByLocation 按地区统计访问记录（不含爬虫），UV 按访客标识去重
+func (r *analyticsRepo) CountVisitsByLocation(filter LocationFilter) ([]LocationStat, error) {
+	return countByLocation(r.db.Model(&po.PageVisit{}).Where("is_bot = ?", false), filter, visitorColumn)
+}
+
+// CountLoginsByLocation 按地区统计登录记录，UV 按用户去重
+func (r *analyticsRepo) CountLoginsByLocation(filter LocationFilter) ([]LocationStat, error) {
+	return countByLocation(r.db.Model(&po.LoginLog{}), filter, "user_id")
+}
+
+// CreateVisit 创建访问记录
+func (r *analyticsRepo) CreateVisit(visit *po.PageVisit) error {
+	return r.db.Create(visit).Error
+}
+
+// CreateLoginLog 创建登录记录
+func (r *analyticsRepo) CreateLoginLog(log *po.LoginLog) error {
+	return r.db.Create(log).Error
+}
+
+// ListLoginLogs 分页查询登录记录，userID 为 0 时查询所有用户
+func (r *analyticsRepo) ListLoginLogs(userID uint, page, limit int) ([]LoginLogRecord, int64, error) {
+	var logs []LoginLogRecord
+	var total int64
+
+	query := r.db.Model(&po.LoginLog{})
+	if userID > 0 {
+		query = query.Where("login_logs.user_id = ?", userID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.
+		Select("login_logs.*, users.username").
+		Joins("LEFT JOIN users ON users.id = login_logs.user_id").
+		Order("login_logs.created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// articleVisits 文章的访问记录（不含爬虫），没有 article_id 的记录按路径匹配，路径可以带查询参数
+func (r *analyticsRepo) articleVisits(articleID uint, path string, start, end time.Time) *gorm.DB {
+	query := r.db.Model(&po.PageVisit{}).
+		Where("created_at >= ? AND created_at < ? AND is_bot = ?", start, end, false)
+	if path == "" {
+		return query.Where("article_id = ?", articleID)
+	}
+	return query.Where("article_id = ? OR (article_id = 0 AND (path = ? OR path LIKE ?))", articleID, path, path+"?%")
+}
+
+// ArticleVisitsByDay 按天统计 [start, end) 内文章的访问记录（不含爬虫），path 用于匹配没有 article_id 的记录
+func (r *analyticsRepo) ArticleVisitsByDay(articleID uint, path string, start, end time.Time) ([]ArticleDayStat, error) {
+	var list []ArticleDayStat
+	err := r.articleVisits(articleID, path, start, end).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS day, " + visitStatColumns + ", " +
+			"COALESCE(SUM(scroll_percent), 0) AS scroll_sum, COUNT(scroll_percent) AS scroll_count").
+		Group("day").
+		Order("day").
+		Scan(&list).Error
+	return list, err
+}
+
+// ArticleReferrers 按来源域名统计 [start, end) 内文章的外部来源
+func (r *analyticsRepo) ArticleReferrers(articleID uint, path string, start, end time.Time, limit int) ([]SourceStat, error) {
+	var list []SourceStat
+	err := r.articleVisits(articleID, path, start, end).
+		Select("ref_category AS category, ref_domain AS domain, COUNT(*) AS pv, COUNT(DISTINCT "+visitorColumn+") AS uv").
+		Where("ref_category IN ?", []string{referrer.CategorySearch, referrer.CategorySocial, referrer.CategoryReferral}).
+		Group("ref_category, ref_domain").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// CountArticleActivityByDay 按天统计 [start, end) 内文章的点赞、收藏和评论数，key 为 "2006-01-02"
+func (r *analyticsRepo) CountArticleActivityByDay(articleID uint, start, end time.Time) (likes, favorites, comments map[string]int64, err error) {
+	if likes, err = r.countArticleByDay(&po.Like{}, articleID, start, end); err != nil {
+		return
+	}
+	if favorites, err = r.countArticleByDay(&po.Favorite{}, articleID, start, end); err != nil {
+		return
+	}
+	comments, err = r.countArticleByDay(&po.Comment{}, articleID, start, end)
+	return
+}
+
+// countArticleByDay 按天统计某张表中文章 [start, end) 内的记录数
+func (r *analyticsRepo) countArticleByDay(model interface{}, articleID uint, start, end time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	err := r.db.Model(model).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS day, COUNT(*) AS count").
+		Where("article_id = ? AND created_at >= ? AND created_at < ?", articleID, start, end).
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day] = row.Count
+	}
+	return counts, nil
+}
+
+// ListUserTracking 查询博客用户的访问记录、浏览记录和前台登录记录
+func (r *analyticsRepo) ListUserTracking(userID uint) (*UserTracking, error) {
+	t := &UserTracking{}
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&t.Visits).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&t.Views).Error; err != nil {
+		return nil, err
+	}
+	// 后台登录记录的 user_id 是管理员ID，只查前台登录
+	if err := r.db.Where("user_id = ? AND portal = ?", userID, po.LoginPortalBlog).Order("created_at").Find(&t.Logins).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeleteUserTracking 删除博客用户的访问记录、浏览记录和前台登录记录，返回各自删除的条数
+func (r *analyticsRepo) DeleteUserTracking(userID uint) (visits, views, logins int64, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ?", userID).Delete(&po.PageVisit{})
+		if result.Error != nil {
+			return result.Error
+		}
+		visits = result.RowsAffected
+		if result = tx.Where("user_id = ?", userID).Delete(&po.View{}); result.Error != nil {
+			return result.Error
+		}
+		views = result.RowsAffected
+		if result = tx.Where("user_id = ? AND portal = ?", userID, po.LoginPortalBlog).Delete(&po.LoginLog{}); result.Error != nil {
+			return result.Error
+		}
+		logins = result.RowsAffected
+		return nil
+	})
+	return visits, views, logins, err
+}
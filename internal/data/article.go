@@ -19,8 +19,10 @@ type ArticleRepo interface {
 	FindByID(id uint) (*po.Article, error)
 	// FindByIDWithRelations 根据 ID 查询文章（包含关联数据）
 	FindByIDWithRelations(id uint) (*po.Article, error)
+	// FindByTitleOrSlug 根据标题或 slug 查询文章（slug 为空时只按标题）
+	FindByTitleOrSlug(title, slug string) (*po.Article, error)
 	// List 查询文章列表
-	List(page, limit int, categoryID, tagID uint, status, keyword, sort string) ([]*po.Article, int64, error)
+	List(page, limit int, categoryIDs []uint, tagID uint, status, keyword, sort string) ([]*po.Article, int64, error)
 	// UpdateStatus 更新文章状态
 	UpdateStatus(id uint, status int) error
 	// IncrementViewCount 增加浏览量
@@ -47,7 +49,7 @@ type ArticleRepo interface {
 	BatchAssociateTags(articleIDs []uint, tagIDs []uint) error
 	// BatchDelete 批量删除
 	BatchDelete(articleIDs []uint) error
-	// GetAdjacentArticles 获取上一篇和下一篇文章（基于章节排序）
+	// GetAdjacentArticles 获取上一篇和下一篇文章（基于章节排序），只包含 ID、标题和章节排序字段
 	GetAdjacentArticles(id uint) (*po.Article, *po.Article, error)
 }
 
@@ -71,12 +73,14 @@ func (r *articleRepo) Update(article *po.Article) error {
 	// 使用 Updates 并设置 UpdatedAt，允许更新 CreatedAt
 	return r.db.Model(article).Updates(map[string]interface{}{
 		"title":            article.Title,
+		"slug":             article.Slug,
 		"content_markdown": article.ContentMarkdown,
 		"content_html":     article.ContentHTML,
 		"summary":          article.Summary,
 		"cover":            article.Cover,
 		"category_id":      article.CategoryID,
 		"chapter_id":       article.ChapterID,
+		"sort_in_chapter":  article.SortInChapter,
 		"status":           article.Status,
 		"created_at":       article.CreatedAt, // 明确允许更新创建时间
 		"updated_at":       time.Now(),
@@ -108,8 +112,21 @@ func (r *articleRepo) FindByIDWithRelations(id uint) (*po.Article, error) {
 	return &article, nil
 }
 
+// FindByTitleOrSlug 根据标题或 slug 查询文章（slug 为空时只按标题）
+func (r *articleRepo) FindByTitleOrSlug(title, slug string) (*po.Article, error) {
+	var article po.Article
+	query := r.db.Where("title = ?", title)
+	if slug != "" {
+		query = r.db.Where("title = ? OR slug = ?", title, slug)
+	}
+	if err := query.First(&article).Error; err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
 // List 查询文章列表
-func (r *articleRepo) List(page, limit int, categoryID, tagID uint, status, keyword, sort string) ([]*po.Article, int64, error) {
+func (r *articleRepo) List(page, limit int, categoryIDs []uint, tagID uint, status, keyword, sort string) ([]*po.Article, int64, error) {
 	var articles []*po.Article
 	var total int64
 
@@ -117,8 +134,8 @@ func (r *articleRepo) List(page, limit int, categoryID, tagID uint, status, keyw
 	query := r.db.Model(&po.Article{}).Preload("Author").Preload("Category").Preload("Tags")
 
 	// 分类过滤
-	if categoryID > 0 {
-		query = query.Where("category_id = ?", categoryID)
+	if len(categoryIDs) > 0 {
+		query = query.Where("category_id IN ?", categoryIDs)
 	}
 
 	// 标签过滤
@@ -259,11 +276,14 @@ func (r *articleRepo) BatchDelete(articleIDs []uint) error {
 	return r.db.Select("Tags").Delete(&po.Article{}, articleIDs).Error
 }
 
-// GetAdjacentArticles 获取上一篇和下一篇文章（基于章节排序）
+// adjacentColumns 查询相邻文章时只取排序和展示需要的字段，created_at 用于 LessInChapter 中排序相同时的比较
+const adjacentColumns = "id, title, chapter_id, sort_in_chapter, created_at"
+
+// GetAdjacentArticles 获取上一篇和下一篇文章（基于章节排序），只返回 adjacentColumns 中的字段
 func (r *articleRepo) GetAdjacentArticles(id uint) (*po.Article, *po.Article, error) {
 	// 获取当前文章
 	var currentArticle po.Article
-	if err := r.db.Preload("Chapter").First(&currentArticle, id).Error; err != nil {
+	if err := r.db.Select("id, chapter_id").First(&currentArticle, id).Error; err != nil {
 		return nil, nil, err
 	}
 
@@ -280,9 +300,7 @@ func (r *articleRepo) GetAdjacentArticles(id uint) (*po.Article, *po.Article, er
 
 	// 获取同一标签下的所有章节（包括父章节和子章节）
 	var allChapters []po.Chapter
-	if err := r.db.Where("tag_id = ?", currentChapter.TagID).
-		Order("parent_id ASC, sort ASC, id ASC").
-		Find(&allChapters).Error; err != nil {
+	if err := r.db.Where("tag_id = ?", currentChapter.TagID).Find(&allChapters).Error; err != nil {
 		return nil, nil, err
 	}
 
@@ -293,14 +311,14 @@ func (r *articleRepo) GetAdjacentArticles(id uint) (*po.Article, *po.Article, er
 	}
 
 	var allArticles []po.Article
-	if err := r.db.Where("chapter_id IN ? AND status = ?", chapterIDs, 1).
-		Preload("Author").Preload("Category").Preload("Tags").Preload("Chapter").
+	if err := r.db.Select(adjacentColumns).
+		Where("chapter_id IN ? AND status = ?", chapterIDs, 1).
 		Find(&allArticles).Error; err != nil {
 		return nil, nil, err
 	}
 
-	// 按照章节顺序和创建时间排序文章
-	sortedArticles := r.sortArticlesByChapter(allArticles, allChapters)
+	// 按章节目录的阅读顺序排列文章
+	sortedArticles := ChapterReadingOrder(allChapters, allArticles)
 
 	// 找到当前文章的位置
 	currentIndex := -1
@@ -335,10 +353,9 @@ func (r *articleRepo) getAdjacentArticlesByID(id uint) (*po.Article, *po.Article
 	var prevArticle, nextArticle po.Article
 
 	// 获取上一篇（ID小于当前文章ID，按ID降序，取第一条）
-	err := r.db.Where("id < ? AND status = ?", id, 1).
+	err := r.db.Select(adjacentColumns).
+		Where("id < ? AND status = ?", id, 1).
 		Order("id DESC").
-		Limit(1).
-		Preload("Author").Preload("Category").Preload("Tags").Preload("Chapter").
 		First(&prevArticle).Error
 	var prev *po.Article
 	if err == nil {
@@ -346,10 +363,9 @@ func (r *articleRepo) getAdjacentArticlesByID(id uint) (*po.Article, *po.Article
 	}
 
 	// 获取下一篇（ID大于当前文章ID，按ID升序，取第一条）
-	err = r.db.Where("id > ? AND status = ?", id, 1).
+	err = r.db.Select(adjacentColumns).
+		Where("id > ? AND status = ?", id, 1).
 		Order("id ASC").
-		Limit(1).
-		Preload("Author").Preload("Category").Preload("Tags").Preload("Chapter").
 		First(&nextArticle).Error
 	var next *po.Article
 	if err == nil {
@@ -358,35 +374,3 @@ func (r *articleRepo) getAdjacentArticlesByID(id uint) (*po.Article, *po.Article
 
 	return prev, next, nil
 }
-
-// sortArticlesByChapter 按章节顺序排序文章
-func (r *articleRepo) sortArticlesByChapter(articles []po.Article, chapters []po.Chapter) []po.Article {
-	// 创建章节ID到排序值的映射
-	chapterSortMap := make(map[uint]int)
-	for i, chapter := range chapters {
-		chapterSortMap[chapter.ID] = i
-	}
-
-	// 按照章节顺序和创建时间排序
-	sorted := make([]po.Article, len(articles))
-	copy(sorted, articles)
-
-	for i := 0; i < len(sorted); i++ {
-		for j := i + 1; j < len(sorted); j++ {
-			iChapterSort := chapterSortMap[*sorted[i].ChapterID]
-			jChapterSort := chapterSortMap[*sorted[j].ChapterID]
-
-			// 先按章节排序
-			if iChapterSort > jChapterSort {
-				sorted[i], sorted[j] = sorted[j], sorted[i]
-			} else if iChapterSort == jChapterSort {
-				// 同一章节内按创建时间排序
-				if sorted[i].CreatedAt.After(sorted[j].CreatedAt) {
-					sorted[i], sorted[j] = sorted[j], sorted[i]
-				}
-			}
-		}
-	}
-
-	return sorted
-}
@@ -1,6 +1,8 @@
 package data
 
 import (
+	"errors"
+
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
 	"gorm.io/gorm"
 )
@@ -15,12 +17,20 @@ type CategoryRepo interface {
 	Delete(id uint) error
 	// FindByID 根据 ID 查询分类
 	FindByID(id uint) (*po.Category, error)
-	// FindByName 根据名称查询分类
+	// FindByName 根据名称查询分类，名称不存在时按合并留下的别名查询
 	FindByName(name string) (*po.Category, error)
 	// List 查询分类列表
 	List() ([]*po.Category, error)
 	// HasArticles 检查分类下是否有文章
 	HasArticles(id uint) (bool, error)
+	// HasChildren 检查分类下是否有子分类
+	HasChildren(id uint) (bool, error)
+	// Descendants 返回分类自身及所有子孙分类的 ID
+	Descendants(id uint) ([]uint, error)
+	// Ancestors 返回从一级分类到该分类的路径
+	Ancestors(id uint) ([]po.Category, error)
+	// Merge 将源分类合并到目标分类：文章和子分类移到目标分类，源分类名称记为别名后删除源分类
+	Merge(source, target *po.Category) error
 }
 
 // categoryRepo 分类仓储实现
@@ -58,10 +68,16 @@ func (r *categoryRepo) FindByID(id uint) (*po.Category, error) {
 	return &category, nil
 }
 
-// FindByName 根据名称查询分类
+// FindByName 根据名称查询分类，名称不存在时按合并留下的别名查询
 func (r *categoryRepo) FindByName(name string) (*po.Category, error) {
 	var category po.Category
 	err := r.db.Where("name = ?", name).First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var alias po.CategoryAlias
+		if r.db.Where("name = ?", name).First(&alias).Error == nil {
+			err = r.db.First(&category, alias.CategoryID).Error
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -84,3 +100,86 @@ func (r *categoryRepo) HasArticles(id uint) (bool, error) {
 	err := r.db.Model(&po.Article{}).Where("category_id = ?", id).Count(&count).Error
 	return count > 0, err
 }
+
+// HasChildren 检查分类下是否有子分类
+func (r *categoryRepo) HasChildren(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&po.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// Descendants 返回分类自身及所有子孙分类的 ID
+func (r *categoryRepo) Descendants(id uint) ([]uint, error) {
+	var categories []po.Category
+	if err := r.db.Select("id, parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	children := make(map[uint][]uint)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	ids := []uint{id}
+	seen := map[uint]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Ancestors 返回从一级分类到该分类的路径
+func (r *categoryRepo) Ancestors(id uint) ([]po.Category, error) {
+	var categories []po.Category
+	if err := r.db.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]po.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	var path []po.Category
+	seen := make(map[uint]bool)
+	current, ok := byID[id]
+	for ok && !seen[current.ID] {
+		seen[current.ID] = true
+		path = append([]po.Category{current}, path...)
+		if current.ParentID == nil {
+			break
+		}
+		current, ok = byID[*current.ParentID]
+	}
+	return path, nil
+}
+
+// Merge 将源分类合并到目标分类：文章和子分类移到目标分类，源分类名称记为别名后删除源分类
+func (r *categoryRepo) Merge(source, target *po.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&po.Article{}).Where("category_id = ?", source.ID).
+			UpdateColumn("category_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&po.Category{}).Where("parent_id = ?", source.ID).
+			Update("parent_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		// 源分类原有的别名和名称都指向目标分类
+		if err := tx.Model(&po.CategoryAlias{}).Where("category_id = ?", source.ID).
+			Update("category_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&po.CategoryAlias{Name: source.Name, CategoryID: target.ID}).Error; err != nil {
+			return err
+		}
+		// 关联已全部迁移，直接删除以释放名称
+		return tx.Unscoped().Delete(&po.Category{}, source.ID).Error
+	})
+}
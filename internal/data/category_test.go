@@ -0,0 +1,91 @@
+package data
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+)
+
+func TestCategoryMerge(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	// 文章（包括已删除的）和子分类都移到目标分类
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `articles` SET `category_id`=? WHERE category_id = ?")).
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `categories` SET `parent_id`=?,`updated_at`=? WHERE parent_id = ? AND `categories`.`deleted_at` IS NULL")).
+		WithArgs(2, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `category_aliases` SET `category_id`=? WHERE category_id = ?")).
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `category_aliases` (`name`,`category_id`,`created_at`) VALUES (?,?,?)")).
+		WithArgs("后端开发", 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `categories` WHERE `categories`.`id` = ?")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := NewCategoryRepo(db).Merge(&po.Category{ID: 1, Name: "后端开发"}, &po.Category{ID: 2, Name: "后端"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCategoryMergeRollback(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// 别名已存在等失败时整体回滚，文章和子分类不会只迁移一半
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `articles`")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `categories`")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `category_aliases`")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `category_aliases`")).WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
+
+	if err := NewCategoryRepo(db).Merge(&po.Category{ID: 1}, &po.Category{ID: 2}); err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestCategoryFindByName(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE name = ?")).
+		WithArgs("后端开发").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `category_aliases` WHERE name = ?")).
+		WithArgs("后端开发").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "category_id"}).AddRow(1, "后端开发", 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `categories` WHERE `categories`.`id` = ?")).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "后端"))
+
+	category, err := NewCategoryRepo(db).FindByName("后端开发")
+	if err != nil || category.ID != 2 || category.Name != "后端" {
+		t.Errorf("FindByName = %+v, %v, want alias target", category, err)
+	}
+}
+
+func TestCategoryDescendants(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// 1 -> 2 -> 4, 1 -> 3, 5 为其他分支；6 和 7 互为父分类，不会死循环
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, parent_id FROM `categories`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).
+			AddRow(1, nil).AddRow(2, 1).AddRow(3, 1).AddRow(4, 2).AddRow(5, nil).AddRow(6, 7).AddRow(7, 6))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, parent_id FROM `categories`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(6, 7).AddRow(7, 6))
+
+	repo := NewCategoryRepo(db)
+	if ids, err := repo.Descendants(1); err != nil || !reflect.DeepEqual(ids, []uint{1, 2, 3, 4}) {
+		t.Errorf("Descendants(1) = %v, %v", ids, err)
+	}
+	if ids, err := repo.Descendants(6); err != nil || !reflect.DeepEqual(ids, []uint{6, 7}) {
+		t.Errorf("Descendants(6) = %v, %v", ids, err)
+	}
+}
@@ -0,0 +1,163 @@
+package data
+
+import (
+	"sort"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"gorm.io/gorm"
+)
+
+// ChapterRepo 章节仓储接口
+type ChapterRepo interface {
+	// Create 创建章节
+	Create(chapter *po.Chapter) error
+	// FindByID 根据 ID 查询章节
+	FindByID(id uint) (*po.Chapter, error)
+	// FindByTagAndName 根据标签、父章节和名称查询章节，parentID 为空表示一级章节
+	FindByTagAndName(tagID uint, parentID *uint, name string) (*po.Chapter, error)
+	// ListByTag 查询标签下的所有章节
+	ListByTag(tagID uint) ([]po.Chapter, error)
+	// NextSortInChapter 章节内下一个排序值（当前最大值 + 1）
+	NextSortInChapter(chapterID uint) (int, error)
+	// ReorderArticles 按给定顺序设置章节内文章的排序，未列出的文章保持原顺序排在后面
+	ReorderArticles(chapterID uint, articleIDs []uint) error
+	// UpdateTree 在一个事务中更新多个章节的父章节和排序
+	UpdateTree(chapters []po.Chapter) error
+	// MoveSubtree 将章节子树移动到其他标签，根章节挂到 parentID 下，子树内文章的标签随之更换
+	MoveSubtree(rootID uint, parentID *uint, sortOrder int, chapterIDs []uint, fromTagID, toTagID uint) error
+}
+
+// chapterRepo 章节仓储实现
+type chapterRepo struct {
+	db *gorm.DB
+}
+
+// NewChapterRepo 创建章节仓储
+func NewChapterRepo(db *gorm.DB) ChapterRepo {
+	return &chapterRepo{db: db}
+}
+
+// Create 创建章节
+func (r *chapterRepo) Create(chapter *po.Chapter) error {
+	return r.db.Create(chapter).Error
+}
+
+// FindByID 根据 ID 查询章节
+func (r *chapterRepo) FindByID(id uint) (*po.Chapter, error) {
+	var chapter po.Chapter
+	err := r.db.First(&chapter, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chapter, nil
+}
+
+// FindByTagAndName 根据标签、父章节和名称查询章节，parentID 为空表示一级章节
+func (r *chapterRepo) FindByTagAndName(tagID uint, parentID *uint, name string) (*po.Chapter, error) {
+	var chapter po.Chapter
+	query := r.db.Where("tag_id = ? AND name = ?", tagID, name)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	err := query.First(&chapter).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chapter, nil
+}
+
+// ListByTag 查询标签下的所有章节
+func (r *chapterRepo) ListByTag(tagID uint) ([]po.Chapter, error) {
+	var chapters []po.Chapter
+	err := r.db.Where("tag_id = ?", tagID).Order("sort ASC, id ASC").Find(&chapters).Error
+	return chapters, err
+}
+
+// NextSortInChapter 章节内下一个排序值（当前最大值 + 1）
+func (r *chapterRepo) NextSortInChapter(chapterID uint) (int, error) {
+	var max int
+	err := r.db.Model(&po.Article{}).
+		Where("chapter_id = ?", chapterID).
+		Select("COALESCE(MAX(sort_in_chapter), 0)").
+		Scan(&max).Error
+	return max + 1, err
+}
+
+// ReorderArticles 按给定顺序设置章节内文章的排序，未列出的文章保持原顺序排在后面
+func (r *chapterRepo) ReorderArticles(chapterID uint, articleIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var articles []po.Article
+		if err := tx.Select("id, chapter_id, sort_in_chapter, created_at").
+			Where("chapter_id = ?", chapterID).
+			Find(&articles).Error; err != nil {
+			return err
+		}
+		sort.SliceStable(articles, func(i, j int) bool { return LessInChapter(&articles[i], &articles[j]) })
+
+		order := make([]uint, 0, len(articles))
+		listed := make(map[uint]bool, len(articleIDs))
+		for _, id := range articleIDs {
+			listed[id] = true
+			order = append(order, id)
+		}
+		for _, article := range articles {
+			if !listed[article.ID] {
+				order = append(order, article.ID)
+			}
+		}
+
+		for i, id := range order {
+			if err := tx.Model(&po.Article{}).Where("id = ? AND chapter_id = ?", id, chapterID).
+				UpdateColumn("sort_in_chapter", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateTree 在一个事务中更新多个章节的父章节和排序
+func (r *chapterRepo) UpdateTree(chapters []po.Chapter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, chapter := range chapters {
+			if err := tx.Model(&po.Chapter{}).Where("id = ?", chapter.ID).Updates(map[string]interface{}{
+				"parent_id": chapter.ParentID,
+				"sort":      chapter.Sort,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MoveSubtree 将章节子树移动到其他标签，根章节挂到 parentID 下，子树内文章的标签随之更换
+func (r *chapterRepo) MoveSubtree(rootID uint, parentID *uint, sortOrder int, chapterIDs []uint, fromTagID, toTagID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&po.Chapter{}).Where("id = ?", rootID).Updates(map[string]interface{}{
+			"parent_id": parentID,
+			"sort":      sortOrder,
+		}).Error; err != nil {
+			return err
+		}
+		if fromTagID == toTagID {
+			return nil
+		}
+
+		if err := tx.Model(&po.Chapter{}).Where("id IN ?", chapterIDs).
+			Update("tag_id", toTagID).Error; err != nil {
+			return err
+		}
+
+		var articleIDs []uint
+		if err := tx.Model(&po.Article{}).Where("chapter_id IN ?", chapterIDs).
+			Pluck("id", &articleIDs).Error; err != nil {
+			return err
+		}
+
+		// 文章随章节离开原标签
+		return retagArticles(tx, articleIDs, fromTagID, toTagID)
+	})
+}
@@ -0,0 +1,154 @@
+package data
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"gorm.io/gorm"
+)
+
+// 章节内文章的排序规则在这里统一定义，章节目录、上一篇/下一篇和导出都使用同一顺序
+
+// LessInChapter 章节内文章排序：SortInChapter 升序，相同时按创建时间、ID
+func LessInChapter(a, b *po.Article) bool {
+	if a.SortInChapter != b.SortInChapter {
+		return a.SortInChapter < b.SortInChapter
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// ChapterReadingOrder 返回章节下文章的阅读顺序
+// 章节按树形深度优先遍历（同级按 sort、id），每个章节先列出自身的文章再进入子章节；
+// 不属于 chapters 中任何章节的文章会被忽略
+func ChapterReadingOrder(chapters []po.Chapter, articles []po.Article) []po.Article {
+	byChapter := make(map[uint][]po.Article)
+	for _, article := range articles {
+		if article.ChapterID != nil {
+			byChapter[*article.ChapterID] = append(byChapter[*article.ChapterID], article)
+		}
+	}
+	for chapterID := range byChapter {
+		list := byChapter[chapterID]
+		sort.SliceStable(list, func(i, j int) bool { return LessInChapter(&list[i], &list[j]) })
+	}
+
+	sorted := make([]po.Chapter, len(chapters))
+	copy(sorted, chapters)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Sort != sorted[j].Sort {
+			return sorted[i].Sort < sorted[j].Sort
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	known := make(map[uint]bool, len(sorted))
+	for _, chapter := range sorted {
+		known[chapter.ID] = true
+	}
+	children := make(map[uint][]uint)
+	var roots []uint
+	for _, chapter := range sorted {
+		// 父章节不在列表中时按一级章节处理
+		if chapter.ParentID != nil && known[*chapter.ParentID] {
+			children[*chapter.ParentID] = append(children[*chapter.ParentID], chapter.ID)
+		} else {
+			roots = append(roots, chapter.ID)
+		}
+	}
+
+	result := make([]po.Article, 0, len(articles))
+	visited := make(map[uint]bool, len(sorted))
+	var walk func(ids []uint)
+	walk = func(ids []uint) {
+		for _, id := range ids {
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			result = append(result, byChapter[id]...)
+			walk(children[id])
+		}
+	}
+	walk(roots)
+
+	return result
+}
+
+// chineseNumbers 标题序号中的中文数字
+var chineseNumbers = map[rune]int{
+	'一': 1, '二': 2, '三': 3, '四': 4, '五': 5,
+	'六': 6, '七': 7, '八': 8, '九': 9, '十': 10,
+	'壹': 1, '贰': 2, '叁': 3, '肆': 4, '伍': 5,
+	'陆': 6, '柒': 7, '捌': 8, '玖': 9, '拾': 10,
+}
+
+// titleNumberRegex 匹配阿拉伯数字序号: "1."、"1、"、"1 "、"1-" 等
+var titleNumberRegex = regexp.MustCompile(`^(\d+)[.\s、,，-]`)
+
+// TitleNumber 提取标题中的序号，如 "1. xxx"、"一、xxx"，仅用于新文章的默认排序
+func TitleNumber(title string) (int, bool) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return 0, false
+	}
+
+	if matches := titleNumberRegex.FindStringSubmatch(title); len(matches) > 1 {
+		if num, err := strconv.Atoi(matches[1]); err == nil {
+			return num, true
+		}
+	}
+
+	if num, ok := chineseNumbers[[]rune(title)[0]]; ok {
+		return num, true
+	}
+
+	return 0, false
+}
+
+// BackfillChapterOrder 为已有文章生成章节内排序
+// 按原来的规则（标题序号，没有序号的排在后面，相同时按创建时间）排序后依次编号，保持升级前的目录顺序
+func BackfillChapterOrder(db *gorm.DB) error {
+	var articles []po.Article
+	if err := db.Select("id, title, chapter_id, created_at").
+		Where("chapter_id IS NOT NULL").
+		Find(&articles).Error; err != nil {
+		return err
+	}
+
+	byChapter := make(map[uint][]po.Article)
+	for _, article := range articles {
+		byChapter[*article.ChapterID] = append(byChapter[*article.ChapterID], article)
+	}
+
+	legacyNumber := func(title string) int {
+		if num, ok := TitleNumber(title); ok {
+			return num
+		}
+		return 999999
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, list := range byChapter {
+			sort.SliceStable(list, func(i, j int) bool {
+				numI, numJ := legacyNumber(list[i].Title), legacyNumber(list[j].Title)
+				if numI == numJ {
+					return list[i].CreatedAt.Before(list[j].CreatedAt)
+				}
+				return numI < numJ
+			})
+			for i, article := range list {
+				if err := tx.Model(&po.Article{}).Where("id = ?", article.ID).
+					UpdateColumn("sort_in_chapter", i+1).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+)
+
+func TestTitleNumber(t *testing.T) {
+	tests := []struct {
+		title string
+		num   int
+		ok    bool
+	}{
+		{"1. 开始", 1, true},
+		{"  3 前后空格", 3, true},
+		{"10、第十篇", 10, true},
+		{"2-安装", 2, true},
+		{"7，逗号", 7, true},
+		// 多级序号只取第一级
+		{"1.10 小节", 1, true},
+		{"1.9 小节", 1, true},
+		{"一、概述", 1, true},
+		{"十 结语", 10, true},
+		{"叁、大写数字", 3, true},
+		{"概述", 0, false},
+		{"1", 0, false},
+		{"v1.0 发布", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		num, ok := TitleNumber(tt.title)
+		if num != tt.num || ok != tt.ok {
+			t.Errorf("TitleNumber(%q) = %d, %v, want %d, %v", tt.title, num, ok, tt.num, tt.ok)
+		}
+	}
+}
+
+func TestLessInChapter(t *testing.T) {
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	article := func(id uint, title string, sort int, createdAt time.Time) *po.Article {
+		a := &po.Article{Title: title, SortInChapter: sort}
+		a.ID, a.CreatedAt = id, createdAt
+		return a
+	}
+
+	tests := []struct {
+		name string
+		a, b *po.Article
+		want bool
+	}{
+		{"sort ascending", article(2, "", 1, late), article(1, "", 2, early), true},
+		{"sort descending", article(1, "", 2, early), article(2, "", 1, late), false},
+		// 排序只看 SortInChapter，不看标题序号
+		{"1.10 before 1.9 by sort", article(1, "1.10 小节", 1, late), article(2, "1.9 小节", 2, early), true},
+		{"1.9 after 1.10 by sort", article(2, "1.9 小节", 2, early), article(1, "1.10 小节", 1, late), false},
+		{"same sort, earlier first", article(2, "", 1, early), article(1, "", 1, late), true},
+		{"same sort, later second", article(1, "", 1, late), article(2, "", 1, early), false},
+		{"same sort and time, smaller ID first", article(1, "", 1, early), article(2, "", 1, early), true},
+		{"same sort and time, larger ID second", article(2, "", 1, early), article(1, "", 1, early), false},
+		{"identical", article(1, "", 1, early), article(1, "", 1, early), false},
+	}
+	for _, tt := range tests {
+		if got := LessInChapter(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: LessInChapter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChapterReadingOrder(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	chapter := func(chapterID uint, parentID *uint, sort int) po.Chapter {
+		c := po.Chapter{ParentID: parentID, Sort: sort}
+		c.ID = chapterID
+		return c
+	}
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	article := func(articleID uint, chapterID *uint, sort int, minutes int) po.Article {
+		a := po.Article{ChapterID: chapterID, SortInChapter: sort}
+		a.ID, a.CreatedAt = articleID, created.Add(time.Duration(minutes)*time.Minute)
+		return a
+	}
+
+	chapters := []po.Chapter{
+		chapter(1, nil, 2),
+		chapter(2, nil, 1),
+		chapter(4, id(1), 1), // 与章节 3 排序相同，按 ID
+		chapter(3, id(1), 1),
+		chapter(5, id(99), 3), // 父章节不在列表中，按一级章节处理
+	}
+	articles := []po.Article{
+		article(10, id(1), 2, 0),
+		article(11, id(1), 1, 0),
+		article(20, id(2), 1, 5), // 排序相同，按创建时间
+		article(21, id(2), 1, 1),
+		article(30, id(3), 1, 0),
+		article(40, id(4), 1, 0), // 排序和创建时间都相同，按 ID
+		article(39, id(4), 1, 0),
+		article(50, id(5), 1, 0),
+		article(60, nil, 1, 0),    // 不属于章节
+		article(70, id(77), 1, 0), // 章节不在列表中
+	}
+
+	var got []uint
+	for _, a := range ChapterReadingOrder(chapters, articles) {
+		got = append(got, a.ID)
+	}
+	want := []uint{21, 20, 11, 10, 30, 39, 40, 50}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+
+	if got := ChapterReadingOrder(nil, articles); len(got) != 0 {
+		t.Errorf("no chapters: order = %v, want empty", got)
+	}
+}
@@ -6,37 +6,49 @@ import (
 
 // Data 数据层结构，包含所有 Repository
 type Data struct {
-	db              *gorm.DB
-	AdminRepo       AdminRepo
-	UserRepo        UserRepo
-	ArticleRepo     ArticleRepo
-	CategoryRepo    CategoryRepo
-	TagRepo         TagRepo
-	CommentRepo     CommentRepo
-	LikeRepo        LikeRepo
-	FavoriteRepo    FavoriteRepo
-	CommentLikeRepo CommentLikeRepo
-	ViewRepo        ViewRepo
-	FileRepo        FileRepo
-	SettingRepo     SettingRepo
+	db                  *gorm.DB
+	AdminRepo           AdminRepo
+	UserRepo            UserRepo
+	ArticleRepo         ArticleRepo
+	CategoryRepo        CategoryRepo
+	TagRepo             TagRepo
+	CommentRepo         CommentRepo
+	LikeRepo            LikeRepo
+	FavoriteRepo        FavoriteRepo
+	CommentLikeRepo     CommentLikeRepo
+	ViewRepo            ViewRepo
+	FileRepo            FileRepo
+	SettingRepo         SettingRepo
+	ImageCacheRepo      ImageCacheRepo
+	ImportJobRepo       ImportJobRepo
+	ChapterRepo         ChapterRepo
+	MigrationRepo       MigrationRepo
+	ReadingProgressRepo ReadingProgressRepo
+	AnalyticsRepo       AnalyticsRepo
 }
 
 // NewData 创建数据层实例
 func NewData(db *gorm.DB) (*Data, error) {
 	return &Data{
-		db:              db,
-		AdminRepo:       NewAdminRepo(db),
-		UserRepo:        NewUserRepo(db),
-		ArticleRepo:     NewArticleRepo(db),
-		CategoryRepo:    NewCategoryRepo(db),
-		TagRepo:         NewTagRepo(db),
-		CommentRepo:     NewCommentRepo(db),
-		LikeRepo:        NewLikeRepo(db),
-		FavoriteRepo:    NewFavoriteRepo(db),
-		CommentLikeRepo: NewCommentLikeRepo(db),
-		ViewRepo:        NewViewRepo(db),
-		FileRepo:        NewFileRepo(db),
-		SettingRepo:     NewSettingRepo(db),
+		db:                  db,
+		AdminRepo:           NewAdminRepo(db),
+		UserRepo:            NewUserRepo(db),
+		ArticleRepo:         NewArticleRepo(db),
+		CategoryRepo:        NewCategoryRepo(db),
+		TagRepo:             NewTagRepo(db),
+		CommentRepo:         NewCommentRepo(db),
+		LikeRepo:            NewLikeRepo(db),
+		FavoriteRepo:        NewFavoriteRepo(db),
+		CommentLikeRepo:     NewCommentLikeRepo(db),
+		ViewRepo:            NewViewRepo(db),
+		FileRepo:            NewFileRepo(db),
+		SettingRepo:         NewSettingRepo(db),
+		ImageCacheRepo:      NewImageCacheRepo(db),
+		ImportJobRepo:       NewImportJobRepo(db),
+		ChapterRepo:         NewChapterRepo(db),
+		MigrationRepo:       NewMigrationRepo(db),
+		ReadingProgressRepo: NewReadingProgressRepo(db),
+		AnalyticsRepo:       NewAnalyticsRepo(db),
 	}, nil
 }
 
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImageCacheRepo 远程图片缓存仓储接口
+type ImageCacheRepo interface {
+	// Get 根据源地址查询已上传地址
+	Get(sourceURL string) (string, bool)
+	// Set 记录源地址对应的已上传地址
+	Set(sourceURL, storedURL string) error
+}
+
+// imageCacheRepo 远程图片缓存仓储实现
+type imageCacheRepo struct {
+	db *gorm.DB
+}
+
+// NewImageCacheRepo 创建远程图片缓存仓储
+func NewImageCacheRepo(db *gorm.DB) ImageCacheRepo {
+	return &imageCacheRepo{db: db}
+}
+
+// Get 根据源地址查询已上传地址
+func (r *imageCacheRepo) Get(sourceURL string) (string, bool) {
+	var cache po.ImageCache
+	if err := r.db.Where("source_key = ?", sourceKey(sourceURL)).First(&cache).Error; err != nil {
+		return "", false
+	}
+	return cache.StoredURL, true
+}
+
+// Set 记录源地址对应的已上传地址
+func (r *imageCacheRepo) Set(sourceURL, storedURL string) error {
+	cache := &po.ImageCache{
+		SourceKey: sourceKey(sourceURL),
+		SourceURL: sourceURL,
+		StoredURL: storedURL,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"stored_url"}),
+	}).Create(cache).Error
+}
+
+// sourceKey 计算源地址的索引键
+func sourceKey(sourceURL string) string {
+	sum := sha256.Sum256([]byte(sourceURL))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,170 @@
+package data
+
+import (
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"gorm.io/gorm"
+)
+
+// ImportJobRepo 导入任务仓储接口
+type ImportJobRepo interface {
+	// Create 创建导入任务（连同文件）
+	Create(job *po.ImportJob) error
+	// FindByID 根据 ID 查询导入任务（包含文件）
+	FindByID(id uint) (*po.ImportJob, error)
+	// FindItemByID 根据 ID 查询导入文件
+	FindItemByID(id uint) (*po.ImportJobItem, error)
+	// StartItem 领取待处理的文件并标记为处理中，文件已被其它 worker 领取或不是待处理状态时返回 false
+	StartItem(item *po.ImportJobItem) (bool, error)
+	// TouchItem 续租处理中的文件，表明处理它的实例仍然存活
+	TouchItem(id uint) error
+	// ResetItem 把租期在 staleBefore 之前的处理中文件恢复为待处理（用于恢复中断的文件）
+	ResetItem(id uint, staleBefore time.Time) error
+	// FinishItem 保存处理中文件的结果并更新任务进度，文件不是处理中状态（已被其它实例完成或恢复）时返回 false
+	FinishItem(item *po.ImportJobItem) (bool, error)
+	// ListRecoverableItemIDs 查询需要恢复的文件ID：pendingBefore 之前创建的待处理文件，以及租期在 runningBefore 之前的处理中文件
+	ListRecoverableItemIDs(pendingBefore, runningBefore time.Time) ([]uint, error)
+	// IsCompleted 查询任务是否已完成
+	IsCompleted(id uint) (bool, error)
+}
+
+// importJobRepo 导入任务仓储实现
+type importJobRepo struct {
+	db *gorm.DB
+}
+
+// NewImportJobRepo 创建导入任务仓储
+func NewImportJobRepo(db *gorm.DB) ImportJobRepo {
+	return &importJobRepo{db: db}
+}
+
+// Create 创建导入任务（连同文件）
+func (r *importJobRepo) Create(job *po.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+// FindByID 根据 ID 查询导入任务（包含文件）
+func (r *importJobRepo) FindByID(id uint) (*po.ImportJob, error) {
+	var job po.ImportJob
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Omit("content").Order("id ASC")
+	}).First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindItemByID 根据 ID 查询导入文件
+func (r *importJobRepo) FindItemByID(id uint) (*po.ImportJobItem, error) {
+	var item po.ImportJobItem
+	err := r.db.First(&item, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// StartItem 领取待处理的文件并标记为处理中，文件已被其它 worker 领取或不是待处理状态时返回 false
+func (r *importJobRepo) StartItem(item *po.ImportJobItem) (bool, error) {
+	claimed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 只有待处理的文件可以领取，同一文件重复入队时只会处理一次
+		now := time.Now()
+		result := tx.Model(&po.ImportJobItem{}).
+			Where("id = ? AND status = ?", item.ID, po.ImportItemPending).
+			Updates(map[string]interface{}{"status": po.ImportItemRunning, "started_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		claimed = true
+		item.Status = po.ImportItemRunning
+		item.StartedAt = &now
+		return tx.Model(&po.ImportJob{}).
+			Where("id = ? AND status = ?", item.JobID, po.ImportJobPending).
+			Update("status", po.ImportJobRunning).Error
+	})
+	return claimed && err == nil, err
+}
+
+// TouchItem 续租处理中的文件，表明处理它的实例仍然存活
+func (r *importJobRepo) TouchItem(id uint) error {
+	return r.db.Model(&po.ImportJobItem{}).
+		Where("id = ? AND status = ?", id, po.ImportItemRunning).
+		Update("started_at", time.Now()).Error
+}
+
+// ResetItem 把租期在 staleBefore 之前的处理中文件恢复为待处理（用于恢复中断的文件）
+func (r *importJobRepo) ResetItem(id uint, staleBefore time.Time) error {
+	return r.db.Model(&po.ImportJobItem{}).
+		Where("id = ? AND status = ? AND (started_at IS NULL OR started_at < ?)", id, po.ImportItemRunning, staleBefore).
+		Updates(map[string]interface{}{"status": po.ImportItemPending, "started_at": nil}).Error
+}
+
+// FinishItem 保存处理中文件的结果并更新任务进度，文件不是处理中状态（已被其它实例完成或恢复）时返回 false
+func (r *importJobRepo) FinishItem(item *po.ImportJobItem) (bool, error) {
+	finished := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 只有处理中的文件才能保存结果，同一文件重复完成时不会重复计数
+		result := tx.Model(&po.ImportJobItem{}).
+			Where("id = ? AND status = ?", item.ID, po.ImportItemRunning).
+			Updates(map[string]interface{}{
+				"status":         item.Status,
+				"article_id":     item.ArticleID,
+				"error":          item.Error,
+				"image_failures": item.ImageFailures,
+				"content":        "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+		finished = true
+
+		counter := "failed"
+		if item.Status == po.ImportItemSuccess {
+			counter = "succeeded"
+		}
+		if err := tx.Model(&po.ImportJob{}).Where("id = ?", item.JobID).Updates(map[string]interface{}{
+			"processed": gorm.Expr("processed + ?", 1),
+			counter:     gorm.Expr(counter+" + ?", 1),
+		}).Error; err != nil {
+			return err
+		}
+
+		// 所有文件处理完成后标记任务完成
+		return tx.Model(&po.ImportJob{}).
+			Where("id = ? AND processed >= total AND status <> ?", item.JobID, po.ImportJobCompleted).
+			Updates(map[string]interface{}{
+				"status":      po.ImportJobCompleted,
+				"finished_at": time.Now(),
+			}).Error
+	})
+	return finished && err == nil, err
+}
+
+// ListRecoverableItemIDs 查询需要恢复的文件ID：pendingBefore 之前创建的待处理文件，以及租期在 runningBefore 之前的处理中文件
+func (r *importJobRepo) ListRecoverableItemIDs(pendingBefore, runningBefore time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&po.ImportJobItem{}).
+		Where("(status = ? AND created_at < ?) OR (status = ? AND (started_at IS NULL OR started_at < ?))",
+			po.ImportItemPending, pendingBefore, po.ImportItemRunning, runningBefore).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// IsCompleted 查询任务是否已完成
+func (r *importJobRepo) IsCompleted(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&po.ImportJob{}).
+		Where("id = ? AND status = ?", id, po.ImportJobCompleted).
+		Count(&count).Error
+	return count > 0, err
+}
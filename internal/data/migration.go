@@ -0,0 +1,99 @@
+package data
+
+import (
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MigrationRepo 博客迁移仓储接口
+type MigrationRepo interface {
+	// FindMapping 查询外部实体已迁移到的本站 ID
+	FindMapping(source, kind, sourceID string) (uint, bool)
+	// SaveMapping 记录外部实体到本站 ID 的映射
+	SaveMapping(source, kind, sourceID string, targetID uint) error
+	// CreateRun 创建迁移任务
+	CreateRun(run *po.MigrationRun) error
+	// UpdateRun 更新迁移任务状态和报告
+	UpdateRun(run *po.MigrationRun) error
+	// FindRunByID 根据 ID 查询迁移任务
+	FindRunByID(id uint) (*po.MigrationRun, error)
+	// FailUnfinishedRuns 把未结束的迁移任务标记为失败，返回更新的数量
+	FailUnfinishedRuns(reason string) (int64, error)
+}
+
+// migrationRepo 博客迁移仓储实现
+type migrationRepo struct {
+	db *gorm.DB
+}
+
+// NewMigrationRepo 创建博客迁移仓储
+func NewMigrationRepo(db *gorm.DB) MigrationRepo {
+	return &migrationRepo{db: db}
+}
+
+// FindMapping 查询外部实体已迁移到的本站 ID
+func (r *migrationRepo) FindMapping(source, kind, sourceID string) (uint, bool) {
+	var mapping po.MigrationMapping
+	err := r.db.Where("source = ? AND kind = ? AND source_id = ?", source, kind, sourceID).First(&mapping).Error
+	if err != nil {
+		return 0, false
+	}
+	return mapping.TargetID, true
+}
+
+// SaveMapping 记录外部实体到本站 ID 的映射
+func (r *migrationRepo) SaveMapping(source, kind, sourceID string, targetID uint) error {
+	mapping := &po.MigrationMapping{
+		Source:   source,
+		Kind:     kind,
+		SourceID: sourceID,
+		TargetID: targetID,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source"}, {Name: "kind"}, {Name: "source_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target_id"}),
+	}).Create(mapping).Error
+}
+
+// CreateRun 创建迁移任务
+func (r *migrationRepo) CreateRun(run *po.MigrationRun) error {
+	return r.db.Create(run).Error
+}
+
+// UpdateRun 更新迁移任务状态和报告
+func (r *migrationRepo) UpdateRun(run *po.MigrationRun) error {
+	if run.Status == po.MigrationRunCompleted || run.Status == po.MigrationRunFailed {
+		now := time.Now()
+		run.FinishedAt = &now
+	}
+	return r.db.Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"report":      run.Report,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+	}).Error
+}
+
+// FindRunByID 根据 ID 查询迁移任务
+func (r *migrationRepo) FindRunByID(id uint) (*po.MigrationRun, error) {
+	var run po.MigrationRun
+	if err := r.db.First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// FailUnfinishedRuns 把未结束的迁移任务标记为失败，返回更新的数量
+func (r *migrationRepo) FailUnfinishedRuns(reason string) (int64, error) {
+	result := r.db.Model(&po.MigrationRun{}).
+		Where("status IN ?", []string{po.MigrationRunPending, po.MigrationRunRunning}).
+		Updates(map[string]interface{}{
+			"status":      po.MigrationRunFailed,
+			"error":       reason,
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -1,8 +1,12 @@
 package data
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // LikeRepo 点赞仓储接口
@@ -253,6 +257,12 @@ type ViewRepo interface {
 	CountByArticle(articleID uint) (int64, error)
 	// CountToday 统计今日浏览量
 	CountToday() (int64, error)
+	// CreateBatch 批量写入浏览记录，并在同一事务中累加对应文章的浏览量
+	CreateBatch(views []po.View) error
+	// CountByDay 按天统计文章 start 之后的浏览记录，key 为 "2006-01-02"
+	CountByDay(articleID uint, start time.Time) (map[string]int64, error)
+	// ClearIPBefore 分批清空 before 之前浏览记录中的 IP，返回清空的条数
+	ClearIPBefore(before time.Time, batch int) (int64, error)
 }
 
 // viewRepo 浏览记录仓储实现
@@ -284,6 +294,56 @@ func (r *viewRepo) CountToday() (int64, error) {
 	return count, err
 }
 
+// CreateBatch 批量写入浏览记录，并在同一事务中累加对应文章的浏览量
+func (r *viewRepo) CreateBatch(views []po.View) error {
+	if len(views) == 0 {
+		return nil
+	}
+	counts := make(map[uint]int)
+	for _, v := range views {
+		counts[v.ArticleID]++
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(views, 500).Error; err != nil {
+			return err
+		}
+		for articleID, n := range counts {
+			if err := tx.Model(&po.Article{}).Where("id = ?", articleID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CountByDay 按天统计文章 start 之后的浏览记录，key 为 "2006-01-02"
+func (r *viewRepo) CountByDay(articleID uint, start time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	err := r.db.Model(&po.View{}).
+		Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS day, COUNT(*) AS count").
+		Where("article_id = ? AND created_at >= ?", articleID, start).
+		Group("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day] = row.Count
+	}
+	return counts, nil
+}
+
+// ClearIPBefore 分批清空 before 之前浏览记录中的 IP，返回清空的条数
+func (r *viewRepo) ClearIPBefore(before time.Time, batch int) (int64, error) {
+	return execInBatches(r.db, batch, "UPDATE views SET ip = '' WHERE created_at < ? AND ip <> '' LIMIT ?", before)
+}
+
 // FileRepo 文件仓储接口
 type FileRepo interface {
 	// Create 创建文件
@@ -294,8 +354,15 @@ type FileRepo interface {
 	FindByID(id uint) (*po.File, error)
 	// List 查询文件列表
 	List(page, limit int) ([]*po.File, int64, error)
+	// SumSizeByUser 统计用户已上传文件总大小
+	SumSizeByUser(userID uint) (int64, error)
+	// CreateWithinQuota 用户已上传文件总大小加上新文件不超过 quota 字节时创建文件，否则返回 ErrQuotaExceeded
+	CreateWithinQuota(file *po.File, quota int64) error
 }
 
+// ErrQuotaExceeded 超出用户存储配额
+var ErrQuotaExceeded = errors.New("超出存储配额")
+
 // fileRepo 文件仓储实现
 type fileRepo struct {
 	db *gorm.DB
@@ -345,6 +412,43 @@ func (r *fileRepo) List(page, limit int) ([]*po.File, int64, error) {
 	return files, total, nil
 }
 
+// SumSizeByUser 统计用户已上传文件总大小
+func (r *fileRepo) SumSizeByUser(userID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&po.File{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("user_id = ?", userID).
+		Row().
+		Scan(&total)
+	return total, err
+}
+
+// CreateWithinQuota 在事务中锁定用户后统计用量并创建文件，同一用户的并发上传依次检查配额
+func (r *fileRepo) CreateWithinQuota(file *po.File, quota int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user po.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", file.UserID).
+			Find(&user).Error; err != nil {
+			return err
+		}
+
+		var used int64
+		if err := tx.Model(&po.File{}).
+			Select("COALESCE(SUM(size), 0)").
+			Where("user_id = ?", file.UserID).
+			Row().
+			Scan(&used); err != nil {
+			return err
+		}
+		if used+file.Size > quota {
+			return ErrQuotaExceeded
+		}
+		return tx.Create(file).Error
+	})
+}
+
 // SettingRepo 设置仓储接口
 type SettingRepo interface {
 	// Create 创建设置
@@ -0,0 +1,52 @@
+package data
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+)
+
+func TestFileCreateWithinQuota(t *testing.T) {
+	tests := []struct {
+		name string
+		used int64
+		err  error
+	}{
+		{"within quota", 60, nil},
+		{"exactly full", 70, nil},
+		{"exceeded", 71, ErrQuotaExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+
+			// 先锁定用户再统计用量，同一用户的并发上传在这里排队
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT `id` FROM `users` WHERE id = ?") + ".*" + regexp.QuoteMeta("FOR UPDATE")).
+				WithArgs(7).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(size), 0) FROM `files` WHERE user_id = ?")).
+				WithArgs(7).
+				WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(tt.used))
+			if tt.err == nil {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `files`")).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			file := &po.File{Name: "a.png", URL: "/uploads/a.png", Size: 30, UserID: 7}
+			err := NewFileRepo(db).CreateWithinQuota(file, 100)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && file.ID != 1 {
+				t.Errorf("file.ID = %d, want 1", file.ID)
+			}
+		})
+	}
+}
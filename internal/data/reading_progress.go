@@ -0,0 +1,68 @@
+package data
+
+import (
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReadCompletePercent 滚动到该百分比即视为已读完
+const ReadCompletePercent = 90
+
+// ReadingProgressRepo 阅读进度仓储接口
+type ReadingProgressRepo interface {
+	// Record 记录用户阅读文章的进度，只保留读到的最大滚动百分比
+	Record(userID, articleID uint, scrollPercent int) error
+	// ListByArticles 查询用户在指定文章上的阅读进度
+	ListByArticles(userID uint, articleIDs []uint) ([]po.ReadingProgress, error)
+}
+
+// readingProgressRepo 阅读进度仓储实现
+type readingProgressRepo struct {
+	db *gorm.DB
+}
+
+// NewReadingProgressRepo 创建阅读进度仓储
+func NewReadingProgressRepo(db *gorm.DB) ReadingProgressRepo {
+	return &readingProgressRepo{db: db}
+}
+
+// Record 记录用户阅读文章的进度，只保留读到的最大滚动百分比
+func (r *readingProgressRepo) Record(userID, articleID uint, scrollPercent int) error {
+	if scrollPercent < 0 {
+		scrollPercent = 0
+	}
+	if scrollPercent > 100 {
+		scrollPercent = 100
+	}
+	completed := scrollPercent >= ReadCompletePercent
+	now := time.Now()
+
+	progress := &po.ReadingProgress{
+		UserID:        userID,
+		ArticleID:     articleID,
+		ScrollPercent: scrollPercent,
+		Completed:     completed,
+		LastReadAt:    now,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "article_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"scroll_percent": gorm.Expr("GREATEST(scroll_percent, ?)", scrollPercent),
+			"completed":      gorm.Expr("completed OR ?", completed),
+			"last_read_at":   now,
+		}),
+	}).Create(progress).Error
+}
+
+// ListByArticles 查询用户在指定文章上的阅读进度
+func (r *readingProgressRepo) ListByArticles(userID uint, articleIDs []uint) ([]po.ReadingProgress, error) {
+	var list []po.ReadingProgress
+	if len(articleIDs) == 0 {
+		return list, nil
+	}
+	err := r.db.Where("user_id = ? AND article_id IN ?", userID, articleIDs).Find(&list).Error
+	return list, err
+}
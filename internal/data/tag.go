@@ -1,6 +1,8 @@
 package data
 
 import (
+	"errors"
+
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
 	"gorm.io/gorm"
 )
@@ -15,12 +17,14 @@ type TagRepo interface {
 	Delete(id uint) error
 	// FindByID 根据 ID 查询标签
 	FindByID(id uint) (*po.Tag, error)
-	// FindByName 根据名称查询标签
+	// FindByName 根据名称查询标签，名称不存在时按合并留下的别名查询
 	FindByName(name string) (*po.Tag, error)
 	// List 查询标签列表
 	List() ([]*po.Tag, error)
 	// FindByIDs 根据 ID 列表查询标签
 	FindByIDs(ids []uint) ([]*po.Tag, error)
+	// Merge 将源标签合并到目标标签：文章关联和章节移到目标标签，源标签名称记为别名后删除源标签
+	Merge(source, target *po.Tag) error
 }
 
 // tagRepo 标签仓储实现
@@ -58,10 +62,16 @@ func (r *tagRepo) FindByID(id uint) (*po.Tag, error) {
 	return &tag, nil
 }
 
-// FindByName 根据名称查询标签
+// FindByName 根据名称查询标签，名称不存在时按合并留下的别名查询
 func (r *tagRepo) FindByName(name string) (*po.Tag, error) {
 	var tag po.Tag
 	err := r.db.Where("name = ?", name).First(&tag).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var alias po.TagAlias
+		if r.db.Where("name = ?", name).First(&alias).Error == nil {
+			err = r.db.First(&tag, alias.TagID).Error
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -87,3 +97,74 @@ func (r *tagRepo) FindByIDs(ids []uint) ([]*po.Tag, error) {
 	}
 	return tags, nil
 }
+
+// Merge 将源标签合并到目标标签：文章关联和章节移到目标标签，源标签名称记为别名后删除源标签
+func (r *tagRepo) Merge(source, target *po.Tag) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var articleIDs []uint
+		if err := tx.Table("article_tags").Where("tag_id = ?", source.ID).
+			Pluck("article_id", &articleIDs).Error; err != nil {
+			return err
+		}
+		if err := retagArticles(tx, articleIDs, source.ID, target.ID); err != nil {
+			return err
+		}
+
+		// 源标签的一级章节排在目标标签已有一级章节之后
+		var maxSort int
+		if err := tx.Model(&po.Chapter{}).Where("tag_id = ? AND parent_id IS NULL", target.ID).
+			Select("COALESCE(MAX(sort), 0)").Scan(&maxSort).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&po.Chapter{}).Where("tag_id = ? AND parent_id IS NULL", source.ID).
+			UpdateColumn("sort", gorm.Expr("sort + ?", maxSort+1)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&po.Chapter{}).Where("tag_id = ?", source.ID).
+			UpdateColumn("tag_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		// 源标签原有的别名和名称都指向目标标签
+		if err := tx.Model(&po.TagAlias{}).Where("tag_id = ?", source.ID).
+			Update("tag_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&po.TagAlias{Name: source.Name, TagID: target.ID}).Error; err != nil {
+			return err
+		}
+		// 关联已全部迁移，直接删除以释放名称
+		return tx.Unscoped().Delete(&po.Tag{}, source.ID).Error
+	})
+}
+
+// retagArticles 将文章的标签关联从 fromTagID 换为 toTagID，已带有目标标签的文章不重复关联
+func retagArticles(tx *gorm.DB, articleIDs []uint, fromTagID, toTagID uint) error {
+	if len(articleIDs) == 0 {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM article_tags WHERE tag_id = ? AND article_id IN ?", fromTagID, articleIDs).Error; err != nil {
+		return err
+	}
+
+	var tagged []uint
+	if err := tx.Table("article_tags").
+		Where("tag_id = ? AND article_id IN ?", toTagID, articleIDs).
+		Pluck("article_id", &tagged).Error; err != nil {
+		return err
+	}
+	exists := make(map[uint]bool, len(tagged))
+	for _, id := range tagged {
+		exists[id] = true
+	}
+	var links []map[string]interface{}
+	for _, id := range articleIDs {
+		if !exists[id] {
+			links = append(links, map[string]interface{}{"article_id": id, "tag_id": toTagID})
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return tx.Table("article_tags").Create(links).Error
+}
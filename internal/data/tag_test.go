@@ -0,0 +1,127 @@
+package data
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+)
+
+func TestTagMerge(t *testing.T) {
+	db, mock := newMockDB(t)
+	source := &po.Tag{ID: 1, Name: "golang"}
+	target := &po.Tag{ID: 2, Name: "Go"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `article_id` FROM `article_tags` WHERE tag_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"article_id"}).AddRow(10).AddRow(11))
+	// 文章换到目标标签，已带有目标标签的文章 11 不重复关联
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM article_tags WHERE tag_id = ? AND article_id IN (?,?)")).
+		WithArgs(1, 10, 11).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `article_id` FROM `article_tags` WHERE tag_id = ? AND article_id IN (?,?)")).
+		WithArgs(2, 10, 11).
+		WillReturnRows(sqlmock.NewRows([]string{"article_id"}).AddRow(11))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `article_tags` (`article_id`,`tag_id`) VALUES (?,?)")).
+		WithArgs(10, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 源标签的一级章节排在目标标签已有的一级章节之后，再整体移到目标标签
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(sort), 0) FROM `chapters` WHERE tag_id = ? AND parent_id IS NULL")).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `chapters` SET `sort`=sort + ? WHERE tag_id = ? AND parent_id IS NULL")).
+		WithArgs(4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `chapters` SET `tag_id`=? WHERE tag_id = ?")).
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	// 源标签已有的别名改为指向目标标签，源标签名称新增为别名
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `tag_aliases` SET `tag_id`=? WHERE tag_id = ?")).
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `tag_aliases` (`name`,`tag_id`,`created_at`) VALUES (?,?,?)")).
+		WithArgs("golang", 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `tags` WHERE `tags`.`id` = ?")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := NewTagRepo(db).Merge(source, target); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagMergeRollback(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `article_id` FROM `article_tags`")).
+		WillReturnRows(sqlmock.NewRows([]string{"article_id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(sort), 0)")).
+		WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
+
+	if err := NewTagRepo(db).Merge(&po.Tag{ID: 1}, &po.Tag{ID: 2}); err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestRetagArticlesAllTagged(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// 所有文章都已带有目标标签时只删除源标签的关联
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM article_tags")).
+		WithArgs(1, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `article_id` FROM `article_tags`")).
+		WithArgs(2, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"article_id"}).AddRow(10))
+
+	if err := retagArticles(db, []uint{10}, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := retagArticles(db, nil, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTagFindByName(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewTagRepo(db)
+	tagRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Go")
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tags` WHERE name = ?")).
+		WithArgs("Go").
+		WillReturnRows(tagRows())
+	if tag, err := repo.FindByName("Go"); err != nil || tag.ID != 2 {
+		t.Errorf("FindByName(Go) = %+v, %v", tag, err)
+	}
+
+	// 名称不存在时按别名查询
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tags` WHERE name = ?")).
+		WithArgs("golang").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tag_aliases` WHERE name = ?")).
+		WithArgs("golang").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tag_id"}).AddRow(3, "golang", 2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tags` WHERE `tags`.`id` = ?")).
+		WithArgs(2).
+		WillReturnRows(tagRows())
+	if tag, err := repo.FindByName("golang"); err != nil || tag.ID != 2 || tag.Name != "Go" {
+		t.Errorf("FindByName(golang) = %+v, %v", tag, err)
+	}
+
+	// 别名也不存在时返回未找到
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tags` WHERE name = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `tag_aliases` WHERE name = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "tag_id"}))
+	if _, err := repo.FindByName("rust"); err == nil {
+		t.Error("FindByName(rust): want error")
+	}
+}
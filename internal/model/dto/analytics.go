@@ -0,0 +1,271 @@
+package dto
+
+import "time"
+
+// VisitTrendResponse 按天的访问量趋势
+type VisitTrendResponse struct {
+	Dates   []string `json:"dates"`
+	PV      []int64  `json:"pv"`
+	UV      []int64  `json:"uv"`
+	TotalPV int64    `json:"total_pv"`
+	TotalUV int64    `json:"total_uv"` // 区间内去重后的独立访客数
+}
+
+// ArticleVisitorsResponse 文章按天的独立访客数
+type ArticleVisitorsResponse struct {
+	ArticleID uint     `json:"article_id"`
+	Dates     []string `json:"dates"`
+	UV        []int64  `json:"uv"`
+	RangeUV   int64    `json:"range_uv"` // 区间内去重后的独立访客数
+	TotalUV   int64    `json:"total_uv"` // 累计独立访客数
+}
+
+// RealtimeVisitsResponse 最近一小时按分钟的访问量
+type RealtimeVisitsResponse struct {
+	Timestamps []string `json:"timestamps"`
+	Visits     []int64  `json:"visits"`
+}
+
+// PageStat 页面访问统计
+type PageStat struct {
+	Path        string  `json:"path"`
+	Visits      int64   `json:"visits"`
+	DailyUVSum  int64   `json:"daily_uv_sum"` // 各天独立访客数之和，同一访客在不同天访问会重复计数
+	AvgDuration float64 `json:"avg_duration"` // 平均停留时长（秒）
+}
+
+// ReferrerStat 来源页面访问统计
+type ReferrerStat struct {
+	Referrer   string `json:"referrer"` // 为空表示直接访问
+	Visits     int64  `json:"visits"`
+	DailyUVSum int64  `json:"daily_uv_sum"` // 各天独立访客数之和，同一访客在不同天访问会重复计数
+}
+
+// DateRangeRequest 按日期范围查询统计
+type DateRangeRequest struct {
+	StartDate string `form:"start_date"` // 开始日期 2006-01-02，默认为结束日期前 6 天
+	EndDate   string `form:"end_date"`   // 结束日期 2006-01-02（含），默认今天
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// SourceCategoryStat 来源类别统计
+type SourceCategoryStat struct {
+	Category string `json:"category"` // direct, internal, search, social, referral
+	Visits   int64  `json:"visits"`
+	UV       int64  `json:"uv"`
+}
+
+// SourceStat 来源域名统计
+type SourceStat struct {
+	Domain   string `json:"domain"`
+	Category string `json:"category"`
+	Visits   int64  `json:"visits"`
+	UV       int64  `json:"uv"`
+}
+
+// SourceReport 访问来源报表
+type SourceReport struct {
+	StartDate  string               `json:"start_date"`
+	EndDate    string               `json:"end_date"`
+	Categories []SourceCategoryStat `json:"categories"`
+	Sources    []SourceStat         `json:"sources"`
+}
+
+// CampaignStat 推广活动统计
+type CampaignStat struct {
+	Source   string `json:"utm_source"`
+	Medium   string `json:"utm_medium"`
+	Campaign string `json:"utm_campaign"`
+	Visits   int64  `json:"visits"`
+	UV       int64  `json:"uv"`
+}
+
+// CampaignReport 推广活动报表
+type CampaignReport struct {
+	StartDate string         `json:"start_date"`
+	EndDate   string         `json:"end_date"`
+	Campaigns []CampaignStat `json:"campaigns"`
+}
+
+// SearchEngineStat 搜索引擎统计
+type SearchEngineStat struct {
+	Engine string `json:"engine"`
+	Visits int64  `json:"visits"`
+	UV     int64  `json:"uv"`
+}
+
+// SearchTermStat 搜索关键词统计
+type SearchTermStat struct {
+	Engine string `json:"engine"`
+	Term   string `json:"term"`
+	Visits int64  `json:"visits"`
+	UV     int64  `json:"uv"`
+}
+
+// SearchReport 搜索来源报表，大部分搜索引擎不再传递关键词，关键词只是其中一部分
+type SearchReport struct {
+	StartDate string             `json:"start_date"`
+	EndDate   string             `json:"end_date"`
+	Engines   []SearchEngineStat `json:"engines"`
+	Terms     []SearchTermStat   `json:"terms"`
+}
+
+// CrawlerStat 爬虫访问统计
+type CrawlerStat struct {
+	Name     string    `json:"name"`
+	Category string    `json:"category"` // search, social, seo, ai, monitor, tool, other
+	Visits   int64     `json:"visits"`
+	Pages    int64     `json:"pages"` // 访问的不同页面数
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// CrawlerReport 爬虫访问报表
+type CrawlerReport struct {
+	StartDate   string        `json:"start_date"`
+	EndDate     string        `json:"end_date"`
+	TotalVisits int64         `json:"total_visits"`
+	Crawlers    []CrawlerStat `json:"crawlers"`
+}
+
+// GeoRequest 地区分布请求
+type GeoRequest struct {
+	DateRangeRequest
+	Source   string `form:"source" binding:"omitempty,oneof=visits logins"`        // visits: 页面访问（默认），logins: 登录
+	Level    string `form:"level" binding:"omitempty,oneof=country province city"` // 统计粒度，默认 country
+	Country  string `form:"country"`                                               // 只统计该国家
+	Province string `form:"province"`                                              // 只统计该省份
+}
+
+// LocationStat 地区统计
+type LocationStat struct {
+	Country  string `json:"country"`
+	Province string `json:"province,omitempty"`
+	City     string `json:"city,omitempty"`
+	Name     string `json:"name"`     // 拼接后的地区名称
+	Visits   int64  `json:"visits"`   // 访问或登录次数
+	Visitors int64  `json:"visitors"` // 访问按访客标识去重，登录按用户去重
+}
+
+// GeoReport 地区分布报表
+type GeoReport struct {
+	StartDate string         `json:"start_date"`
+	EndDate   string         `json:"end_date"`
+	Source    string         `json:"source"`
+	Level     string         `json:"level"`
+	Enabled   bool           `json:"enabled"` // 是否配置了 IP 地址库
+	Locations []LocationStat `json:"locations"`
+}
+
+// LoginLogListRequest 登录记录列表请求
+type LoginLogListRequest struct {
+	PageRequest
+	UserID uint `form:"user_id"`
+}
+
+// LoginLogResponse 登录记录
+type LoginLogResponse struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Portal    string    `json:"portal"` // admin, blog
+	IP        string    `json:"ip"`
+	Location  string    `json:"location"`
+	Browser   string    `json:"browser"`
+	OS        string    `json:"os"`
+	Device    string    `json:"device"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArticleReportRequest 文章统计报表请求
+type ArticleReportRequest struct {
+	DateRangeRequest
+	Format string `form:"format" binding:"omitempty,oneof=json csv"` // json（默认）或 csv
+}
+
+// ArticleDayStat 文章单日统计
+type ArticleDayStat struct {
+	Date        string  `json:"date"`
+	Views       int64   `json:"views"`        // 浏览量（去重后的文章详情打开次数）
+	Visitors    int64   `json:"visitors"`     // 独立访客数
+	Visits      int64   `json:"visits"`       // 页面访问记录数
+	AvgDuration float64 `json:"avg_duration"` // 平均阅读时长（秒）
+	AvgScroll   float64 `json:"avg_scroll"`   // 平均滚动深度（0-100）
+	Likes       int64   `json:"likes"`
+	Favorites   int64   `json:"favorites"`
+	Comments    int64   `json:"comments"`
+}
+
+// ArticleReportTotals 文章统计区间合计
+type ArticleReportTotals struct {
+	Views       int64   `json:"views"`
+	Visits      int64   `json:"visits"`
+	AvgDuration float64 `json:"avg_duration"`
+	AvgScroll   float64 `json:"avg_scroll"`
+	Likes       int64   `json:"likes"`
+	Favorites   int64   `json:"favorites"`
+	Comments    int64   `json:"comments"`
+}
+
+// ArticleReport 文章统计报表
+type ArticleReport struct {
+	ArticleID uint                `json:"article_id"`
+	Title     string              `json:"title"`
+	ViewCount int                 `json:"view_count"` // 累计浏览量
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	Totals    ArticleReportTotals `json:"totals"`
+	Days      []ArticleDayStat    `json:"days"`
+	Referrers []SourceStat        `json:"referrers"` // 主要外部来源
+}
+
+// TrackingVisit 导出的页面访问记录
+type TrackingVisit struct {
+	Path          string    `json:"path"`
+	ArticleID     uint      `json:"article_id,omitempty"`
+	Duration      int       `json:"duration"`
+	ScrollPercent *int      `json:"scroll_percent,omitempty"`
+	Referrer      string    `json:"referrer"`
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	Browser       string    `json:"browser"`
+	OS            string    `json:"os"`
+	Device        string    `json:"device"`
+	Location      string    `json:"location"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TrackingView 导出的文章浏览记录
+type TrackingView struct {
+	ArticleID uint      `json:"article_id"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TrackingLogin 导出的登录记录
+type TrackingLogin struct {
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Browser   string    `json:"browser"`
+	OS        string    `json:"os"`
+	Device    string    `json:"device"`
+	Location  string    `json:"location"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TrackingExport 用户的追踪数据导出
+type TrackingExport struct {
+	UserID     uint              `json:"user_id"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Visits     []TrackingVisit   `json:"visits"`
+	Views      []TrackingView    `json:"views"`
+	Logins     []TrackingLogin   `json:"logins"`
+	Presence   map[string]string `json:"presence,omitempty"` // 当前在线状态，不在线时为空
+}
+
+// TrackingDeleteResult 删除用户追踪数据的结果
+type TrackingDeleteResult struct {
+	Visits   int64 `json:"visits"`   // 删除的页面访问记录数
+	Views    int64 `json:"views"`    // 删除的文章浏览记录数
+	Logins   int64 `json:"logins"`   // 删除的登录记录数
+	Presence bool  `json:"presence"` // 是否清除了在线状态
+}
@@ -5,12 +5,14 @@ import "time"
 // CreateArticleRequest 创建文章请求
 type CreateArticleRequest struct {
 	Title           string     `json:"title" binding:"required,max=200"`
+	Slug            string     `json:"slug" binding:"max=200"`
 	ContentMarkdown string     `json:"content_markdown" binding:"required"`
 	ContentHTML     string     `json:"content_html"` // 可选，如果不传则自动从 Markdown 转换
 	Summary         string     `json:"summary" binding:"max=500"`
 	Cover           string     `json:"cover" binding:"max=500"`
 	CategoryID      uint       `json:"category_id" binding:"required"`
-	ChapterID       *uint      `json:"chapter_id"` // 章节ID，可为空
+	ChapterID       *uint      `json:"chapter_id"`      // 章节ID，可为空
+	SortInChapter   *int       `json:"sort_in_chapter"` // 章节内排序，可选，不传时按标题序号或排在章节末尾
 	TagIDs          []uint     `json:"tag_ids"`
 	Status          int        `json:"status" binding:"oneof=0 1 2"` // 0: draft, 1: published, 2: offline
 	CreatedAt       *time.Time `json:"created_at"`                   // 创建时间，可选，如果不传则使用当前时间
@@ -19,12 +21,14 @@ type CreateArticleRequest struct {
 // UpdateArticleRequest 更新文章请求
 type UpdateArticleRequest struct {
 	Title           string     `json:"title" binding:"omitempty,max=200"`
+	Slug            string     `json:"slug" binding:"max=200"`
 	ContentMarkdown string     `json:"content_markdown"`
 	ContentHTML     string     `json:"content_html"` // 可选
 	Summary         string     `json:"summary" binding:"max=500"`
 	Cover           string     `json:"cover" binding:"max=500"`
 	CategoryID      uint       `json:"category_id"`
-	ChapterID       *uint      `json:"chapter_id"` // 章节ID，可为空
+	ChapterID       *uint      `json:"chapter_id"`      // 章节ID，可为空
+	SortInChapter   *int       `json:"sort_in_chapter"` // 章节内排序，可选，移动到新章节且不传时按标题序号或排在章节末尾
 	TagIDs          []uint     `json:"tag_ids"`
 	Status          int        `json:"status" binding:"omitempty,oneof=0 1 2"`
 	CreatedAt       *time.Time `json:"created_at"` // 创建时间，可选，允许手动修改创建时间
@@ -59,17 +63,19 @@ type BatchDeleteRequest struct {
 // ArticleListRequest 文章列表请求
 type ArticleListRequest struct {
 	PageRequest
-	Category string `form:"category"`
-	Tag      string `form:"tag"`
-	Status   string `form:"status"`
-	Keyword  string `form:"keyword"`
-	Sort     string `form:"sort"` // latest, views, likes
+	Category   string `form:"category"`
+	CategoryID uint   `form:"category_id"` // 包含子孙分类下的文章
+	Tag        string `form:"tag"`
+	Status     string `form:"status"`
+	Keyword    string `form:"keyword"`
+	Sort       string `form:"sort"` // latest, views, likes
 }
 
 // ArticleResponse 文章响应
 type ArticleResponse struct {
 	ID              uint             `json:"id"`
 	Title           string           `json:"title"`
+	Slug            string           `json:"slug"`
 	ContentMarkdown string           `json:"content_markdown"`
 	ContentHTML     string           `json:"content_html"`
 	Summary         string           `json:"summary"`
@@ -77,6 +83,7 @@ type ArticleResponse struct {
 	AuthorID        uint             `json:"author_id"`
 	CategoryID      uint             `json:"category_id"`
 	ChapterID       *uint            `json:"chapter_id"`
+	SortInChapter   int              `json:"sort_in_chapter"`
 	Status          int              `json:"status"`
 	ViewCount       int              `json:"view_count"`
 	LikeCount       int              `json:"like_count"`
@@ -87,6 +94,13 @@ type ArticleResponse struct {
 	Author          *AuthorInfo      `json:"author,omitempty"`
 	Category        *CategoryInfo    `json:"category,omitempty"`
 	Tags            []TagInfo        `json:"tags,omitempty"`
+	ImageFailures   []ImageFailure   `json:"image_failures,omitempty"` // 保存时处理失败的远程图片
+}
+
+// ImageFailure 远程图片处理失败信息
+type ImageFailure struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
 }
 
 // ArticleListItem 文章列表项
@@ -0,0 +1,16 @@
+package dto
+
+// RestoreReport 全站恢复报告
+type RestoreReport struct {
+	Version    int              `json:"version"` // 备份格式版本
+	Users      MigrationCounter `json:"users"`   // 同名用户（如默认管理员）计入已存在
+	Categories MigrationCounter `json:"categories"`
+	Tags       MigrationCounter `json:"tags"`
+	Chapters   MigrationCounter `json:"chapters"`
+	Articles   MigrationCounter `json:"articles"`
+	Comments   MigrationCounter `json:"comments"`
+	Files      MigrationCounter `json:"files"`
+	Settings   MigrationCounter `json:"settings"`
+	Media      int              `json:"media"` // 已恢复的媒体文件数
+	Warnings   []string         `json:"warnings"`
+}
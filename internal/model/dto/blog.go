@@ -46,8 +46,9 @@ type CommentListResponse struct {
 // ArticleDetailResponse 文章详情响应（包含用户状态）
 type ArticleDetailResponse struct {
 	ArticleResponse
-	IsLiked     bool `json:"is_liked"`
-	IsFavorited bool `json:"is_favorited"`
+	IsLiked     bool           `json:"is_liked"`
+	IsFavorited bool           `json:"is_favorited"`
+	Breadcrumbs []CategoryInfo `json:"breadcrumbs"` // 分类路径，从一级分类到文章所属分类
 }
 
 // LikeInfo 点赞信息
@@ -0,0 +1,77 @@
+package dto
+
+import "time"
+
+// ChapterArticle 章节下的文章
+type ChapterArticle struct {
+	ID            uint      `json:"id"`
+	Title         string    `json:"title"`
+	ChapterID     *uint     `json:"chapter_id"`
+	SortInChapter int       `json:"sort_in_chapter"`
+	ViewCount     int       `json:"view_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ChapterTreeNode 章节树节点
+type ChapterTreeNode struct {
+	ID          uint              `json:"id"`
+	TagID       uint              `json:"tag_id"`
+	ParentID    *uint             `json:"parent_id"`
+	Name        string            `json:"name"`
+	Sort        int               `json:"sort"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Articles    []ChapterArticle  `json:"articles"`
+	SubChapters []ChapterTreeNode `json:"sub_chapters"`
+}
+
+// ReorderChapterArticlesRequest 章节内文章排序请求
+type ReorderChapterArticlesRequest struct {
+	ArticleIDs []uint `json:"article_ids" binding:"required,min=1"` // 按顺序排列的文章ID，未列出的文章排在后面
+}
+
+// ChapterTreeItem 章节树批量调整中的单个节点
+type ChapterTreeItem struct {
+	ID       uint  `json:"id" binding:"required"`
+	ParentID *uint `json:"parent_id"` // 为空表示移动到一级章节
+	Sort     int   `json:"sort"`
+}
+
+// UpdateChapterTreeRequest 章节树批量调整请求
+type UpdateChapterTreeRequest struct {
+	TagID uint              `json:"tag_id" binding:"required"`
+	Items []ChapterTreeItem `json:"items" binding:"required,min=1,dive"` // 未列出的章节保持不变
+}
+
+// MoveChapterRequest 章节（连同子章节和文章）移动到其他标签的请求
+type MoveChapterRequest struct {
+	TagID    uint  `json:"tag_id" binding:"required"`
+	ParentID *uint `json:"parent_id"` // 目标标签下的父章节，为空表示作为一级章节
+	Sort     *int  `json:"sort"`      // 为空时排在同级章节最后
+}
+
+// ArticleProgress 章节文章的阅读进度
+type ArticleProgress struct {
+	ArticleID     uint       `json:"article_id"`
+	Title         string     `json:"title"`
+	ChapterID     *uint      `json:"chapter_id"`
+	ScrollPercent int        `json:"scroll_percent"`
+	Completed     bool       `json:"completed"`
+	LastReadAt    *time.Time `json:"last_read_at"` // 未读过为空
+}
+
+// ChapterProgressResponse 标签章节系列的阅读进度
+type ChapterProgressResponse struct {
+	TagID     uint              `json:"tag_id"`
+	Total     int               `json:"total"`     // 已发布文章数
+	Completed int               `json:"completed"` // 已读完的文章数
+	Percent   int               `json:"percent"`   // 整体进度百分比
+	LastRead  *ArticleProgress  `json:"last_read"` // 最近阅读的文章
+	Articles  []ArticleProgress `json:"articles"`  // 按章节阅读顺序排列
+}
+
+// ContinueReadingResponse 继续阅读
+type ContinueReadingResponse struct {
+	Article  *ArticleProgress `json:"article"`  // 下一篇要读的文章，全部读完时为空
+	Finished bool             `json:"finished"` // 是否已读完整个系列
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// ImportFile 待导入的文件
+type ImportFile struct {
+	Filename      string
+	Content       string
+	TagID         *uint          // 压缩包导入时指定的标签
+	Folders       []string       // 压缩包内的目录层级，映射为标签下的章节
+	ImageFailures []ImageFailure // 解析压缩包时无法处理的本地图片
+}
+
+// ImportJobResponse 导入任务响应
+type ImportJobResponse struct {
+	ID         uint                    `json:"id"`
+	Status     string                  `json:"status"` // pending, running, completed
+	Total      int                     `json:"total"`
+	Processed  int                     `json:"processed"`
+	Succeeded  int                     `json:"succeeded"`
+	Failed     int                     `json:"failed"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+	FinishedAt *time.Time              `json:"finished_at"`
+	Items      []ImportJobItemResponse `json:"items,omitempty"`
+}
+
+// ImportJobItemResponse 导入文件进度
+type ImportJobItemResponse struct {
+	ID            uint           `json:"id"`
+	Filename      string         `json:"filename"`
+	Status        string         `json:"status"` // pending, running, success, failed, conflict
+	ArticleID     *uint          `json:"article_id"`
+	Error         string         `json:"error,omitempty"`
+	ImageFailures []ImageFailure `json:"image_failures,omitempty"`
+}
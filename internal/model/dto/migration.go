@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// MigrationCounter 单类实体的迁移统计
+type MigrationCounter struct {
+	Created  int `json:"created"`  // 新建数量（试运行时为将要新建的数量）
+	Existing int `json:"existing"` // 已迁移过或本站已存在的数量
+	Failed   int `json:"failed"`
+}
+
+// MigrationReport 迁移报告
+type MigrationReport struct {
+	Source      string           `json:"source"`
+	DryRun      bool             `json:"dry_run"`
+	Users       MigrationCounter `json:"users"`
+	Categories  MigrationCounter `json:"categories"`
+	Tags        MigrationCounter `json:"tags"`
+	Attachments MigrationCounter `json:"attachments"`
+	Articles    MigrationCounter `json:"articles"`
+	Comments    MigrationCounter `json:"comments"`
+	Skipped     []string         `json:"skipped"`  // 跳过的内容及原因
+	Warnings    []string         `json:"warnings"` // 不影响导入的问题，如图片下载失败
+	Errors      []string         `json:"errors"`
+}
+
+// MigrationRunResponse 迁移任务响应
+type MigrationRunResponse struct {
+	ID         uint             `json:"id"`
+	Source     string           `json:"source"`
+	DryRun     bool             `json:"dry_run"`
+	Status     string           `json:"status"` // pending, running, completed, failed
+	Error      string           `json:"error,omitempty"`
+	Report     *MigrationReport `json:"report,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// CategoryTreeNode 分类树节点
+type CategoryTreeNode struct {
+	ID          uint               `json:"id"`
+	ParentID    *uint              `json:"parent_id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Color       string             `json:"color"`
+	Sort        int                `json:"sort"`
+	CreatedAt   time.Time          `json:"created_at"`
+	Children    []CategoryTreeNode `json:"children"`
+}
+
+// UpdateCategoryRequest 更新分类请求，字段为空表示不修改
+type UpdateCategoryRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Description *string `json:"description" binding:"omitempty,max=200"`
+	Color       *string `json:"color" binding:"omitempty,max=20"`
+	Sort        *int    `json:"sort"`
+	ParentID    *uint   `json:"parent_id"` // 父分类ID，传 0 表示移到一级分类
+}
+
+// UpdateTagRequest 更新标签请求，字段为空表示不修改
+type UpdateTagRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Color       *string `json:"color" binding:"omitempty,max=20"`
+	Description *string `json:"description" binding:"omitempty,max=200"`
+}
+
+// MergeRequest 合并分类或标签请求
+type MergeRequest struct {
+	TargetID uint `json:"target_id" binding:"required"` // 合并到的目标ID
+}
@@ -0,0 +1,53 @@
+package po
+
+import "time"
+
+// 导入任务状态
+const (
+	ImportJobPending   = "pending"   // 等待处理
+	ImportJobRunning   = "running"   // 处理中
+	ImportJobCompleted = "completed" // 已完成（可能包含失败的文件）
+)
+
+// 导入文件状态
+const (
+	ImportItemPending  = "pending"
+	ImportItemRunning  = "running"
+	ImportItemSuccess  = "success"
+	ImportItemFailed   = "failed"
+	ImportItemConflict = "conflict" // 与已有文章冲突，未导入
+)
+
+// ImportJob 文章导入任务
+type ImportJob struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	AuthorID   uint       `gorm:"index" json:"author_id"`
+	Status     string     `gorm:"size:20;index;default:pending" json:"status"`
+	Total      int        `gorm:"default:0" json:"total"`
+	Processed  int        `gorm:"default:0" json:"processed"`
+	Succeeded  int        `gorm:"default:0" json:"succeeded"`
+	Failed     int        `gorm:"default:0" json:"failed"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	BundlePath string     `gorm:"size:500" json:"-"` // 压缩包导入时保存的临时文件，图片在处理文件时上传，任务完成后删除
+
+	Items []ImportJobItem `gorm:"foreignKey:JobID" json:"items,omitempty"`
+}
+
+// ImportJobItem 导入任务中的单个文件
+type ImportJobItem struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	JobID         uint       `gorm:"index;not null" json:"job_id"`
+	Filename      string     `gorm:"size:255" json:"filename"`
+	Content       string     `gorm:"type:longtext" json:"-"`       // 待导入内容，处理完成后清空
+	TagID         *uint      `json:"tag_id"`                       // 压缩包导入时指定的标签
+	ChapterPath   string     `gorm:"size:500" json:"chapter_path"` // 压缩包内的目录层级，以 / 分隔，映射为章节
+	Status        string     `gorm:"size:20;index;default:pending" json:"status"`
+	ArticleID     *uint      `json:"article_id"`
+	Error         string     `gorm:"type:text" json:"error"`
+	ImageFailures string     `gorm:"type:text" json:"-"` // JSON 格式的图片处理失败列表
+	StartedAt     *time.Time `json:"started_at"`         // 领取或最近一次续租的时间，超过租期仍在处理中的文件视为中断
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
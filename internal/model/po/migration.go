@@ -0,0 +1,46 @@
+package po
+
+import "time"
+
+// 迁移映射的实体类型
+const (
+	MigrationKindUser       = "user"
+	MigrationKindCommenter  = "commenter" // 非站点用户的评论者，按邮箱或昵称映射
+	MigrationKindCategory   = "category"
+	MigrationKindTag        = "tag"
+	MigrationKindArticle    = "article"
+	MigrationKindComment    = "comment"
+	MigrationKindAttachment = "attachment"
+)
+
+// 迁移任务状态
+const (
+	MigrationRunPending   = "pending"
+	MigrationRunRunning   = "running"
+	MigrationRunCompleted = "completed"
+	MigrationRunFailed    = "failed"
+)
+
+// MigrationMapping 外部博客系统的实体 ID 到本站 ID 的映射，保证重复迁移不会产生重复数据
+type MigrationMapping struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Source    string    `gorm:"size:200;not null;uniqueIndex:idx_migration_mapping" json:"source"` // 来源站点，如 wordpress:https://example.com
+	Kind      string    `gorm:"size:20;not null;uniqueIndex:idx_migration_mapping" json:"kind"`
+	SourceID  string    `gorm:"size:200;not null;uniqueIndex:idx_migration_mapping" json:"source_id"`
+	TargetID  uint      `gorm:"index;not null" json:"target_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MigrationRun 迁移任务
+type MigrationRun struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	Source     string     `gorm:"size:200" json:"source"`
+	DryRun     bool       `gorm:"default:false" json:"dry_run"`
+	Status     string     `gorm:"size:20;index;default:pending" json:"status"`
+	Report     string     `gorm:"type:longtext" json:"-"` // JSON 格式的迁移报告
+	Error      string     `gorm:"type:text" json:"error"`
+	AdminID    uint       `gorm:"index" json:"admin_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
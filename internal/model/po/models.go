@@ -47,6 +47,7 @@ type User struct {
 type Article struct {
 	ID              uint           `gorm:"primarykey" json:"id"`
 	Title           string         `gorm:"size:200;not null" json:"title"`
+	Slug            string         `gorm:"size:200;index" json:"slug"` // 导入时保留的原文章 slug
 	ContentMarkdown string         `gorm:"type:longtext" json:"content_markdown"`
 	ContentHTML     string         `gorm:"type:longtext" json:"content_html"`
 	Summary         string         `gorm:"size:500" json:"summary"`
@@ -54,6 +55,7 @@ type Article struct {
 	AuthorID        uint           `gorm:"index" json:"author_id"`
 	CategoryID      uint           `gorm:"index" json:"category_id"`
 	ChapterID       *uint          `gorm:"index" json:"chapter_id"` // 所属章节ID,可为空
+	SortInChapter   int            `gorm:"default:0" json:"sort_in_chapter"` // 章节内排序,数字越小越靠前
 	Status          int            `gorm:"default:0" json:"status"` // 0: draft, 1: published, 2: offline
 	ViewCount       int            `gorm:"default:0" json:"view_count"`
 	LikeCount       int            `gorm:"default:0" json:"like_count"`
@@ -72,22 +74,41 @@ type Article struct {
 // Category 分类模型
 type Category struct {
 	ID          uint           `gorm:"primarykey" json:"id"`
+	ParentID    *uint          `gorm:"index" json:"parent_id"` // 父分类ID，为空表示一级分类
 	Name        string         `gorm:"size:50;uniqueIndex;not null" json:"name"`
 	Description string         `gorm:"size:200" json:"description"`
+	Color       string         `gorm:"size:20" json:"color"`
 	Sort        int            `gorm:"default:0" json:"sort"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// CategoryAlias 分类别名，合并后保留的旧名称，按名称查询时仍能解析到目标分类
+type CategoryAlias struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	Name       string    `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	CategoryID uint      `gorm:"index;not null" json:"category_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // Tag 标签模型
 type Tag struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	Name      string         `gorm:"size:50;uniqueIndex;not null" json:"name"`
-	Color     string         `gorm:"size:20" json:"color"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          uint           `gorm:"primarykey" json:"id"`
+	Name        string         `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	Color       string         `gorm:"size:20" json:"color"`
+	Description string         `gorm:"size:200" json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TagAlias 标签别名，合并后保留的旧名称，按名称查询时仍能解析到目标标签
+type TagAlias struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Name      string    `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	TagID     uint      `gorm:"index;not null" json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // Comment 评论模型
@@ -146,10 +167,21 @@ type CommentLike struct {
 // View 浏览记录
 type View struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
-	ArticleID uint      `gorm:"index;not null" json:"article_id"`
-	UserID    uint      `gorm:"index" json:"user_id"`
+	ArticleID uint      `gorm:"index:idx_views_article_created;not null" json:"article_id"`
+	UserID    uint      `gorm:"index" json:"user_id"` // 0 表示游客
 	IP        string    `gorm:"size:50" json:"ip"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `gorm:"index:idx_views_article_created" json:"created_at"`
+}
+
+// ReadingProgress 用户文章阅读进度
+type ReadingProgress struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	UserID        uint      `gorm:"uniqueIndex:idx_reading_progress_user_article;not null" json:"user_id"`
+	ArticleID     uint      `gorm:"uniqueIndex:idx_reading_progress_user_article;index;not null" json:"article_id"`
+	ScrollPercent int       `gorm:"default:0" json:"scroll_percent"` // 读到的最大滚动百分比 0-100
+	Completed     bool      `gorm:"default:false" json:"completed"`  // 是否已读完
+	LastReadAt    time.Time `gorm:"index" json:"last_read_at"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // PageVisit 页面访问时长记录
@@ -157,11 +189,91 @@ type PageVisit struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
 	UserID    *uint     `gorm:"index" json:"user_id"` // 可为空，游客访问
 	IP        string    `gorm:"size:50;index" json:"ip"`
+	Visitor   string    `gorm:"size:20" json:"-"`             // 按周期更换的 IP+UA 哈希，用于统计独立访客
 	Path      string    `gorm:"size:500" json:"path"`         // 访问路径
 	Duration  int       `gorm:"not null" json:"duration"`      // 停留时长（秒）
 	UserAgent string    `gorm:"size:500" json:"user_agent"`    // 用户代理
 	Referrer  string    `gorm:"size:500" json:"referrer"`      // 来源页面
 	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	// 记录时从 Referrer 和访问地址解析
+	RefDomain    string `gorm:"size:255" json:"ref_domain"`   // 来源域名
+	RefCategory  string `gorm:"size:20" json:"ref_category"`  // 来源类别: direct, internal, search, social, referral
+	SearchEngine string `gorm:"size:50" json:"search_engine"` // 搜索引擎名称
+	SearchTerm   string `gorm:"size:255" json:"search_term"`  // 搜索关键词
+	UTMSource    string `gorm:"size:100" json:"utm_source"`
+	UTMMedium    string `gorm:"size:100" json:"utm_medium"`
+	UTMCampaign  string `gorm:"size:100" json:"utm_campaign"`
+
+	// 记录时从 UserAgent 解析
+	Browser     string `gorm:"size:50" json:"browser"`
+	OS          string `gorm:"size:50" json:"os"`
+	Device      string `gorm:"size:20" json:"device"` // desktop, mobile, tablet, bot
+	IsBot       bool   `gorm:"index;default:false" json:"is_bot"`
+	BotName     string `gorm:"size:100" json:"bot_name"`
+	BotCategory string `gorm:"size:20" json:"bot_category"`
+
+	// 记录时从 IP 地址库解析，未配置地址库时为空
+	Country  string `gorm:"size:50" json:"country"`
+	Province string `gorm:"size:50" json:"province"`
+	City     string `gorm:"size:50" json:"city"`
+
+	// 文章页上报，没有上报时按路径匹配文章
+	ArticleID     uint `gorm:"index;default:0" json:"article_id"`
+	ScrollPercent *int `json:"scroll_percent"` // 滚动深度 0-100，没有上报时为空
+}
+
+// 登录入口
+const (
+	LoginPortalAdmin = "admin" // 管理后台
+	LoginPortalBlog  = "blog"  // 博客前台
+)
+
+// LoginLog 登录记录
+type LoginLog struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	Portal    string    `gorm:"size:20" json:"portal"` // admin, blog
+	IP        string    `gorm:"size:50" json:"ip"`
+	UserAgent string    `gorm:"size:500" json:"user_agent"`
+	Browser   string    `gorm:"size:50" json:"browser"`
+	OS        string    `gorm:"size:50" json:"os"`
+	Device    string    `gorm:"size:20" json:"device"`
+	Country   string    `gorm:"size:50" json:"country"`
+	Province  string    `gorm:"size:50" json:"province"`
+	City      string    `gorm:"size:50" json:"city"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// 访问统计汇总维度
+const (
+	VisitDimensionTotal    = "total"    // 全站合计，Name 为空
+	VisitDimensionPath     = "path"     // 按页面路径，Name 为路径
+	VisitDimensionReferrer = "referrer" // 按来源页面，Name 为来源地址
+)
+
+// VisitHourly 按小时汇总的页面访问统计，由 page_visits 定时汇总
+type VisitHourly struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	Period        time.Time `gorm:"index:idx_visit_hourly_period_dimension;not null" json:"period"` // 小时开始时间
+	Dimension     string    `gorm:"size:20;index:idx_visit_hourly_period_dimension;not null" json:"dimension"`
+	Name          string    `gorm:"size:500" json:"name"`
+	PV            int64     `json:"pv"`
+	UV            int64     `json:"uv"`             // 按访客标识去重
+	DurationSum   int64     `json:"duration_sum"`   // 停留时长合计（秒）
+	DurationCount int64     `json:"duration_count"` // 有停留时长（duration > 0）的记录数
+}
+
+// VisitDaily 按天汇总的页面访问统计，由 page_visits 定时汇总
+type VisitDaily struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	Period        time.Time `gorm:"index:idx_visit_daily_period_dimension;not null" json:"period"` // 当天零点
+	Dimension     string    `gorm:"size:20;index:idx_visit_daily_period_dimension;not null" json:"dimension"`
+	Name          string    `gorm:"size:500" json:"name"`
+	PV            int64     `json:"pv"`
+	UV            int64     `json:"uv"`             // 按访客标识去重
+	DurationSum   int64     `json:"duration_sum"`   // 停留时长合计（秒）
+	DurationCount int64     `json:"duration_count"` // 有停留时长（duration > 0）的记录数
 }
 
 // File 文件模型
@@ -172,10 +284,20 @@ type File struct {
 	Size      int64          `json:"size"`
 	Type      string         `gorm:"size:50" json:"type"`
 	MimeType  string         `gorm:"size:100" json:"mime_type"`
+	UserID    uint           `gorm:"index" json:"user_id"` // 上传者ID
 	CreatedAt time.Time      `json:"created_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// ImageCache 远程图片导入缓存（源地址 -> 已上传地址）
+type ImageCache struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	SourceKey string    `gorm:"size:64;uniqueIndex;not null" json:"source_key"` // 源地址的 SHA-256
+	SourceURL string    `gorm:"type:text" json:"source_url"`
+	StoredURL string    `gorm:"size:500;not null" json:"stored_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // Setting 系统设置
 type Setting struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
@@ -192,6 +314,8 @@ func AutoMigrate(db *gorm.DB) error {
 		&Article{},
 		&Category{},
 		&Tag{},
+		&CategoryAlias{},
+		&TagAlias{},
 		&Chapter{},
 		&Comment{},
 		&Like{},
@@ -199,7 +323,16 @@ func AutoMigrate(db *gorm.DB) error {
 		&CommentLike{},
 		&View{},
 		&PageVisit{},
+		&VisitHourly{},
+		&VisitDaily{},
+		&LoginLog{},
+		&ReadingProgress{},
 		&File{},
+		&ImageCache{},
+		&ImportJob{},
+		&ImportJobItem{},
+		&MigrationMapping{},
+		&MigrationRun{},
 		&Setting{},
 	)
 }
@@ -20,6 +20,8 @@ import (
 type HTTPServer struct {
 	engine *gin.Engine
 	addr   string
+	biz    *biz.Biz
+	live   *service.LiveService
 }
 
 // NewHTTPServer 创建 HTTP 服务器
@@ -53,23 +55,27 @@ func NewHTTPServer(b *biz.Biz, d *data.Data) *HTTPServer {
 	})
 
 	// 初始化服务
-	authService := service.NewAuthService(b.AuthUseCase)
-	articleService := service.NewArticleService(b.ArticleUseCase)
+	authService := service.NewAuthService(b.AuthUseCase, b.AnalyticsUseCase)
+	articleService := service.NewArticleService(b.ArticleUseCase, b.ImportJobUseCase)
 	userService := service.NewUserService(b.UserUseCase)
 	categoryService := service.NewCategoryService(b.CategoryUseCase)
 	tagService := service.NewTagService(b.TagUseCase)
 	commentService := service.NewCommentService(b.CommentUseCase)
-	chapterService := service.NewChapterService(d)
-	statsService := service.NewStatsService(d)
+	chapterService := service.NewChapterService(d, b.ChapterUseCase)
+	statsService := service.NewStatsService(d, b.AnalyticsUseCase)
 	settingsService := service.NewSettingsService(d)
 	fileService := service.NewFileService(d)
-	blogService := service.NewBlogService(b.BlogUseCase)
+	blogService := service.NewBlogService(b.BlogUseCase, b.AnalyticsUseCase, b.ViewUseCase)
 	onlineService := service.NewOnlineService(d)
-	visitService := service.NewVisitService(d)
-	analyticsService := service.NewAnalyticsService(d)
+	visitService := service.NewVisitService(d, b.AnalyticsUseCase)
+	analyticsService := service.NewAnalyticsService(d, b.AnalyticsUseCase)
+	liveService := service.NewLiveService()
+	jobService := service.NewJobService(b.ImportJobUseCase)
+	migrationService := service.NewMigrationService(b.MigrationUseCase)
+	backupService := service.NewBackupService(b.BackupUseCase)
 
 	// 注册路由
-	registerRoutes(r, authService, articleService, userService, categoryService, tagService, commentService, chapterService, statsService, settingsService, fileService, blogService, onlineService, visitService, analyticsService)
+	registerRoutes(r, authService, articleService, userService, categoryService, tagService, commentService, chapterService, statsService, settingsService, fileService, blogService, onlineService, visitService, analyticsService, liveService, jobService, migrationService, backupService)
 
 	// 获取端口
 	port := viper.GetInt("server.port")
@@ -78,6 +84,8 @@ func NewHTTPServer(b *biz.Biz, d *data.Data) *HTTPServer {
 	return &HTTPServer{
 		engine: r,
 		addr:   addr,
+		biz:    b,
+		live:   liveService,
 	}
 }
 
@@ -88,8 +96,13 @@ func (s *HTTPServer) Start() error {
 
 // Stop 停止 HTTP 服务器
 func (s *HTTPServer) Stop() error {
-	// 这里可以添加优雅关闭的逻辑
-	return nil
+	// 写入缓冲中的浏览记录，进程内缓存退出后会丢失
+	return s.biz.ViewUseCase.Flush()
+}
+
+// CloseStreams 结束所有 SSE 长连接，注册到 http.Server.RegisterOnShutdown，否则关闭时要等到超时
+func (s *HTTPServer) CloseStreams() {
+	s.live.Close()
 }
 
 // GetEngine 获取 Gin Engine（用于测试）
@@ -40,6 +40,31 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// StreamAuth 实时推送认证中间件，URL 中带 token 参数时按短期推送令牌认证（EventSource 无法设置请求头），否则同 JWTAuth
+func StreamAuth() gin.HandlerFunc {
+	headerAuth := JWTAuth()
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			headerAuth(c)
+			return
+		}
+
+		claims, err := jwt.ParseStreamToken(token)
+		if err != nil {
+			response.Unauthorized(c, "无效的Token")
+			c.Abort()
+			return
+		}
+
+		c.Set("admin_id", claims.AdminID)
+		c.Set("user_id", claims.AdminID)
+		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Next()
+	}
+}
+
 // OptionalJWTAuth 可选JWT认证中间件（token存在则解析，不存在不报错）
 func OptionalJWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
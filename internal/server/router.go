@@ -23,6 +23,10 @@ func registerRoutes(
 	onlineService *service.OnlineService,
 	visitService *service.VisitService,
 	analyticsService *service.AnalyticsService,
+	liveService *service.LiveService,
+	jobService *service.JobService,
+	migrationService *service.MigrationService,
+	backupService *service.BackupService,
 ) {
 	// 管理后台认证路由（不需要 JWT 验证）
 	auth := r.Group("/auth")
@@ -54,10 +58,12 @@ func registerRoutes(
 
 		// 分类和标签
 		blog.GET("/categories", categoryService.List) // 分类列表
+		blog.GET("/categories/tree", categoryService.Tree) // 分类树
 		blog.GET("/tags", tagService.List)            // 标签列表
 
 		// 章节
 		blog.GET("/chapters/:tag", chapterService.GetChaptersByTag) // 获取标签下的章节及文章
+		blog.GET("/chapters/:tag/export", chapterService.ExportByTag) // 导出章节电子书
 
 		// 统计
 		blog.GET("/stats", statsService.GetStats) // 站点统计
@@ -103,6 +109,10 @@ func registerRoutes(
 		blogAuthed.GET("/user/favorites", blogService.GetUserFavorites)
 		blogAuthed.GET("/user/stats", blogService.GetUserStats)
 
+		// 用户导出和删除自己的追踪数据
+		blogAuthed.GET("/user/tracking", visitService.ExportTrackingData)
+		blogAuthed.DELETE("/user/tracking", visitService.DeleteTrackingData)
+
 		// 评论
 		blogAuthed.POST("/comments", blogService.CreateComment)
 		blogAuthed.POST("/comments/:id/like", blogService.LikeComment)
@@ -112,8 +122,15 @@ func registerRoutes(
 		// 留言板
 		blogAuthed.POST("/guestbook", blogService.CreateGuestbookMessage)
 		blogAuthed.DELETE("/guestbook/:id", blogService.DeleteGuestbookMessage)
+
+		// 章节阅读进度
+		blogAuthed.GET("/chapters/:tag/progress", chapterService.GetProgress)
+		blogAuthed.GET("/chapters/:tag/continue", chapterService.ContinueReading)
 	}
 
+	// 实时推送（EventSource 无法设置请求头，支持通过 URL 中的短期令牌认证）
+	r.GET("/analytics/stream", middleware.StreamAuth(), liveService.Stream)
+
 	// 管理后台 API 路由（需要 JWT 验证）
 	api := r.Group("/")
 	api.Use(middleware.JWTAuth())
@@ -156,6 +173,8 @@ func registerRoutes(
 		{
 			tags.GET("", tagService.List)
 			tags.POST("", tagService.Create)
+			tags.PUT("/:id", tagService.Update)
+			tags.POST("/:id/merge", tagService.Merge)
 			tags.DELETE("/:id", tagService.Delete)
 		}
 
@@ -163,7 +182,10 @@ func registerRoutes(
 		categories := api.Group("/categories")
 		{
 			categories.GET("", categoryService.List)
+			categories.GET("/tree", categoryService.Tree)
 			categories.POST("", categoryService.Create)
+			categories.PUT("/:id", categoryService.Update)
+			categories.POST("/:id/merge", categoryService.Merge)
 			categories.DELETE("/:id", categoryService.Delete)
 		}
 
@@ -175,6 +197,9 @@ func registerRoutes(
 			chapters.POST("", chapterService.CreateChapter)
 			chapters.PUT("/:id", chapterService.UpdateChapter)
 			chapters.DELETE("/:id", chapterService.DeleteChapter)
+			chapters.PUT("/:id/articles/order", chapterService.ReorderArticles)
+			chapters.PUT("/tree", chapterService.UpdateTree)
+			chapters.POST("/:id/move", chapterService.MoveChapter)
 		}
 
 		// 统计
@@ -192,6 +217,16 @@ func registerRoutes(
 			analytics.GET("/online/stats", analyticsService.GetOnlineStats)
 			analytics.GET("/visits/realtime", analyticsService.GetRealtimeVisits)
 			analytics.GET("/pages/top", analyticsService.GetTopPages)
+			analytics.GET("/referrers/top", analyticsService.GetTopReferrers)
+			analytics.GET("/articles/:id", analyticsService.GetArticleReport)
+			analytics.GET("/articles/:id/visitors", analyticsService.GetArticleVisitors)
+			analytics.GET("/sources", analyticsService.GetSources)
+			analytics.GET("/campaigns", analyticsService.GetCampaigns)
+			analytics.GET("/search-engines", analyticsService.GetSearchEngines)
+			analytics.GET("/crawlers", analyticsService.GetCrawlers)
+			analytics.GET("/geo", analyticsService.GetLocations)
+			analytics.GET("/logins", analyticsService.GetLoginLogs)
+			analytics.POST("/stream/token", liveService.StreamToken)
 		}
 
 		// 设置
@@ -208,5 +243,26 @@ func registerRoutes(
 			files.GET("", fileService.List)
 			files.DELETE("/:id", fileService.Delete)
 		}
+
+		// 后台任务
+		jobs := api.Group("/jobs")
+		{
+			jobs.GET("/:id", jobService.GetByID)
+		}
+
+		// 博客迁移
+		migrations := api.Group("/migrations")
+		{
+			migrations.POST("/wordpress", migrationService.ImportWordPress)
+			migrations.POST("/hexo", migrationService.ImportHexo)
+			migrations.GET("/:id", migrationService.GetRun)
+		}
+
+		// 全站备份
+		backups := api.Group("/backup")
+		{
+			backups.GET("/export", backupService.Export)
+			backups.POST("/import", backupService.Import)
+		}
 	}
 }
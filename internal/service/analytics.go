@@ -1,107 +1,96 @@
 package service
 
 import (
+	"bytes"
+	"encoding/csv"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
 	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
-	"github.com/ydcloud-dy/leaf-api/pkg/redis"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
 )
 
 // AnalyticsService 数据分析服务
 type AnalyticsService struct {
-	data *data.Data
+	data             *data.Data
+	analyticsUseCase biz.AnalyticsUseCase
 }
 
 // NewAnalyticsService 创建数据分析服务
-func NewAnalyticsService(d *data.Data) *AnalyticsService {
+func NewAnalyticsService(d *data.Data, analyticsUseCase biz.AnalyticsUseCase) *AnalyticsService {
 	return &AnalyticsService{
-		data: d,
+		data:             d,
+		analyticsUseCase: analyticsUseCase,
 	}
 }
 
 // Get7DaysVisits 获取近7天的访问量统计
 // @Summary 获取近7天访问量
-// @Description 获取近7天每天的访问量统计数据（PV和UV）
+// @Description 获取近7天每天的访问量统计数据（PV和UV），UV 为独立访客数估算
 // @Tags 数据分析
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} response.Response{data=object{dates=[]string,pv=[]int64,uv=[]int64,total_pv=int64,total_uv=int64}} "获取成功"
+// @Success 200 {object} response.Response{data=dto.VisitTrendResponse} "获取成功"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /analytics/visits/7days [get]
 func (s *AnalyticsService) Get7DaysVisits(c *gin.Context) {
-	now := time.Now()
-	dates := make([]string, 7)
-	pvData := make([]int64, 7)
-	uvData := make([]int64, 7)
-
-	var totalPV int64
-	var totalUV int64
-
-	// 计算近7天的数据
-	for i := 6; i >= 0; i-- {
-		date := now.AddDate(0, 0, -i)
-		dateStr := date.Format("2006-01-02")
-		dates[6-i] = dateStr
-
-		// 当天开始和结束时间
-		startTime := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-		endTime := startTime.Add(24 * time.Hour)
-
-		// 统计 PV（页面访问量）
-		var pv int64
-		s.data.GetDB().Model(&po.PageVisit{}).
-			Where("created_at >= ? AND created_at < ?", startTime, endTime).
-			Count(&pv)
-		pvData[6-i] = pv
-		totalPV += pv
-
-		// 统计 UV（独立访客数）- 按 IP 去重
-		var uv int64
-		s.data.GetDB().Model(&po.PageVisit{}).
-			Select("COUNT(DISTINCT ip)").
-			Where("created_at >= ? AND created_at < ?", startTime, endTime).
-			Count(&uv)
-		uvData[6-i] = uv
-		totalUV += uv
+	// 从按天汇总表读取，当天的数据按汇总间隔更新
+	trend, err := s.analyticsUseCase.VisitTrend(7)
+	if err != nil {
+		response.ServerError(c, "获取访问量统计失败")
+		return
 	}
 
-	response.Success(c, gin.H{
-		"dates":    dates,
-		"pv":       pvData,
-		"uv":       uvData,
-		"total_pv": totalPV,
-		"total_uv": totalUV,
-	})
+	response.Success(c, trend)
 }
 
 // GetOnlineUsers 获取当前在线用户详情
 // @Summary 获取在线用户详情
-// @Description 获取当前在线的用户列表，包括用户ID、用户名、IP、最后活跃时间等详细信息
+// @Description 获取当前在线的用户列表，包括用户ID、用户名、IP、最后活跃时间等详细信息。
+// @Description 用户和游客按最后活跃时间倒序分别分页，total 和 summary 为全部在线人数
 // @Tags 数据分析
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量，最大 100" default(50)
 // @Success 200 {object} response.Response{data=object{total=int,users=[]object,guests=[]object}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /analytics/online/users [get]
 func (s *AnalyticsService) GetOnlineUsers(c *gin.Context) {
-	// 获取所有在线用户的键
-	userKeys, err := redis.Keys(onlineUserPrefix + "*")
+	req := dto.PageRequest{Page: 1, Limit: 50}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	offset, limit := int64((req.Page-1)*req.Limit), int64(req.Limit)
+
+	totalUsers, totalGuests, err := countOnline()
+	if err != nil {
+		response.ServerError(c, "获取在线人数失败")
+		return
+	}
+
+	// 获取当前页的在线用户
+	onlineUsers, err := listOnline(onlineUsersKey, onlineUserPrefix, offset, limit)
 	if err != nil {
 		response.ServerError(c, "获取在线用户失败")
 		return
 	}
 
-	// 获取所有在线游客的键
-	guestKeys, err := redis.Keys(onlineGuestPrefix + "*")
+	// 获取当前页的在线游客
+	onlineGuests, err := listOnline(onlineGuestsKey, onlineGuestPrefix, offset, limit)
 	if err != nil {
 		response.ServerError(c, "获取在线游客失败")
 		return
@@ -116,6 +105,10 @@ func (s *AnalyticsService) GetOnlineUsers(c *gin.Context) {
 		IP             string    `json:"ip"`
 		CurrentPage    string    `json:"current_page"`    // 当前访问的页面
 		UserAgent      string    `json:"user_agent"`      // 浏览器信息
+		Browser        string    `json:"browser"`         // 浏览器及版本
+		OS             string    `json:"os"`              // 操作系统及版本
+		Device         string    `json:"device"`          // desktop, mobile, tablet
+		Location       string    `json:"location"`        // IP归属地，未配置IP地址库时为空
 		LastActiveAt   time.Time `json:"last_active_at"`
 		OnlineDuration int64     `json:"online_duration"` // 在线时长（秒）
 	}
@@ -125,44 +118,44 @@ func (s *AnalyticsService) GetOnlineUsers(c *gin.Context) {
 		IP           string    `json:"ip"`
 		CurrentPage  string    `json:"current_page"`  // 当前访问的页面
 		UserAgent    string    `json:"user_agent"`    // 浏览器信息
+		Browser      string    `json:"browser"`       // 浏览器及版本
+		OS           string    `json:"os"`            // 操作系统及版本
+		Device       string    `json:"device"`        // desktop, mobile, tablet
 		LastActiveAt time.Time `json:"last_active_at"`
-		Location     string    `json:"location,omitempty"` // IP地理位置（可选）
+		Location     string    `json:"location"`      // IP归属地，未配置IP地址库时为空
 	}
 
-	users := make([]OnlineUser, 0)
-	guests := make([]OnlineGuest, 0)
-
-	client := redis.GetClient()
-	ctx := redis.GetContext()
+	users := make([]OnlineUser, 0, len(onlineUsers))
+	guests := make([]OnlineGuest, 0, len(onlineGuests))
 
-	// 处理在线用户
-	for _, key := range userKeys {
-		// 提取用户ID
-		userIDStr := strings.TrimPrefix(key, onlineUserPrefix)
-		var userID uint
-		fmt.Sscanf(userIDStr, "%d", &userID)
-
-		// 从 Redis Hash 获取详细信息
-		data, err := client.HGetAll(ctx, key).Result()
-		if err != nil || len(data) == 0 {
-			continue
+	// 一次查询所有在线用户的资料
+	userIDs := make([]uint, 0, len(onlineUsers))
+	for _, p := range onlineUsers {
+		if id, err := strconv.ParseUint(p.Member, 10, 64); err == nil {
+			userIDs = append(userIDs, uint(id))
 		}
-
-		// 解析时间戳
-		var lastActiveTimestamp int64
-		if ts, ok := data["last_active_at"]; ok {
-			fmt.Sscanf(ts, "%d", &lastActiveTimestamp)
+	}
+	userMap := make(map[string]po.User, len(userIDs))
+	if len(userIDs) > 0 {
+		var list []po.User
+		if err := s.data.GetDB().Where("id IN ?", userIDs).Find(&list).Error; err != nil {
+			response.ServerError(c, "获取在线用户失败")
+			return
+		}
+		for _, user := range list {
+			userMap[strconv.FormatUint(uint64(user.ID), 10)] = user
 		}
-		lastActiveAt := time.Unix(lastActiveTimestamp, 0)
+	}
 
-		// 从数据库获取用户详情
-		var user po.User
-		if err := s.data.GetDB().First(&user, userID).Error; err != nil {
+	// 处理在线用户
+	for _, p := range onlineUsers {
+		user, ok := userMap[p.Member]
+		if !ok {
 			continue
 		}
 
 		// 计算在线时长
-		onlineDuration := time.Since(lastActiveAt).Seconds()
+		onlineDuration := time.Since(p.LastActiveAt).Seconds()
 		if onlineDuration < 0 {
 			onlineDuration = 0
 		}
@@ -172,48 +165,41 @@ func (s *AnalyticsService) GetOnlineUsers(c *gin.Context) {
 			Username:       user.Username,
 			Nickname:       user.Nickname,
 			Avatar:         user.Avatar,
-			IP:             data["ip"],
-			CurrentPage:    data["path"],
-			UserAgent:      data["user_agent"],
-			LastActiveAt:   lastActiveAt,
+			IP:             p.Fields["ip"],
+			CurrentPage:    p.Fields["path"],
+			UserAgent:      p.Fields["user_agent"],
+			Browser:        p.Fields["browser"],
+			OS:             p.Fields["os"],
+			Device:         p.Fields["device"],
+			Location:       p.Fields["location"],
+			LastActiveAt:   p.LastActiveAt,
 			OnlineDuration: int64(onlineDuration),
 		})
 	}
 
 	// 处理在线游客
-	for _, key := range guestKeys {
-		// 提取IP
-		ip := strings.TrimPrefix(key, onlineGuestPrefix)
-
-		// 从 Redis Hash 获取详细信息
-		data, err := client.HGetAll(ctx, key).Result()
-		if err != nil || len(data) == 0 {
-			continue
-		}
-
-		// 解析时间戳
-		var lastActiveTimestamp int64
-		if ts, ok := data["last_active_at"]; ok {
-			fmt.Sscanf(ts, "%d", &lastActiveTimestamp)
-		}
-		lastActiveAt := time.Unix(lastActiveTimestamp, 0)
-
+	for _, p := range onlineGuests {
 		guests = append(guests, OnlineGuest{
-			IP:           ip,
-			CurrentPage:  data["path"],
-			UserAgent:    data["user_agent"],
-			LastActiveAt: lastActiveAt,
-			Location:     s.getIPLocation(ip), // 获取IP地理位置
+			IP:           p.Fields["ip"],
+			CurrentPage:  p.Fields["path"],
+			UserAgent:    p.Fields["user_agent"],
+			Browser:      p.Fields["browser"],
+			OS:           p.Fields["os"],
+			Device:       p.Fields["device"],
+			Location:     p.Fields["location"],
+			LastActiveAt: p.LastActiveAt,
 		})
 	}
 
 	response.Success(c, gin.H{
-		"total": len(users) + len(guests),
-		"users": users,
+		"total":  totalUsers + totalGuests,
+		"page":   req.Page,
+		"limit":  req.Limit,
+		"users":  users,
 		"guests": guests,
 		"summary": gin.H{
-			"registered_users": len(users),
-			"guest_users":      len(guests),
+			"registered_users": totalUsers,
+			"guest_users":      totalGuests,
 		},
 	})
 }
@@ -230,24 +216,16 @@ func (s *AnalyticsService) GetOnlineUsers(c *gin.Context) {
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /analytics/online/stats [get]
 func (s *AnalyticsService) GetOnlineStats(c *gin.Context) {
-	// 获取在线用户数
-	userKeys, err := redis.Keys(onlineUserPrefix + "*")
+	users, guests, err := countOnline()
 	if err != nil {
 		response.ServerError(c, "获取在线用户失败")
 		return
 	}
 
-	// 获取在线游客数
-	guestKeys, err := redis.Keys(onlineGuestPrefix + "*")
-	if err != nil {
-		response.ServerError(c, "获取在线游客失败")
-		return
-	}
-
 	response.Success(c, gin.H{
-		"total":  len(userKeys) + len(guestKeys),
-		"users":  len(userKeys),
-		"guests": len(guestKeys),
+		"total":  users + guests,
+		"users":  users,
+		"guests": guests,
 	})
 }
 
@@ -263,40 +241,24 @@ func (s *AnalyticsService) GetOnlineStats(c *gin.Context) {
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /analytics/visits/realtime [get]
 func (s *AnalyticsService) GetRealtimeVisits(c *gin.Context) {
-	now := time.Now()
-	timestamps := make([]string, 60)
-	visits := make([]int64, 60)
-
-	// 统计最近60分钟的访问量
-	for i := 59; i >= 0; i-- {
-		minuteTime := now.Add(time.Duration(-i) * time.Minute)
-		timestamps[59-i] = minuteTime.Format("15:04")
-
-		startTime := minuteTime.Truncate(time.Minute)
-		endTime := startTime.Add(time.Minute)
-
-		var count int64
-		s.data.GetDB().Model(&po.PageVisit{}).
-			Where("created_at >= ? AND created_at < ?", startTime, endTime).
-			Count(&count)
-		visits[59-i] = count
+	visits, err := s.analyticsUseCase.RealtimeVisits()
+	if err != nil {
+		response.ServerError(c, "获取实时访问数据失败")
+		return
 	}
 
-	response.Success(c, gin.H{
-		"timestamps": timestamps,
-		"visits":     visits,
-	})
+	response.Success(c, visits)
 }
 
 // GetTopPages 获取热门页面访问统计
 // @Summary 获取热门页面
-// @Description 获取访问量最高的页面列表（近7天）
+// @Description 获取访问量最高的页面列表（近7天），daily_uv_sum 为各天独立访客数之和
 // @Tags 数据分析
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param limit query int false "返回数量" default(10)
-// @Success 200 {object} response.Response{data=[]object{path=string,visits=int64,avg_duration=float64}} "获取成功"
+// @Success 200 {object} response.Response{data=[]dto.PageStat} "获取成功"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /analytics/pages/top [get]
@@ -306,39 +268,355 @@ func (s *AnalyticsService) GetTopPages(c *gin.Context) {
 		fmt.Sscanf(limitStr, "%d", &limit)
 	}
 
-	type PageStats struct {
-		Path        string  `json:"path"`
-		Visits      int64   `json:"visits"`
-		AvgDuration float64 `json:"avg_duration"`
+	// 从按天汇总表读取
+	stats, err := s.analyticsUseCase.TopPages(7, limit)
+	if err != nil {
+		response.ServerError(c, "获取热门页面失败")
+		return
 	}
 
-	var stats []PageStats
-	startTime := time.Now().AddDate(0, 0, -7)
+	response.Success(c, stats)
+}
 
-	err := s.data.GetDB().Model(&po.PageVisit{}).
-		Select("path, COUNT(*) as visits, AVG(duration) as avg_duration").
-		Where("created_at >= ?", startTime).
-		Group("path").
-		Order("visits DESC").
-		Limit(limit).
-		Find(&stats).Error
+// GetTopReferrers 获取主要来源页面统计
+// @Summary 获取主要来源页面
+// @Description 获取带来访问量最多的来源页面列表（近7天），来源为空表示直接访问，daily_uv_sum 为各天独立访客数之和
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=[]dto.ReferrerStat} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /analytics/referrers/top [get]
+func (s *AnalyticsService) GetTopReferrers(c *gin.Context) {
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
 
+	stats, err := s.analyticsUseCase.TopReferrers(7, limit)
 	if err != nil {
-		response.ServerError(c, "获取热门页面失败")
+		response.ServerError(c, "获取来源页面失败")
 		return
 	}
 
 	response.Success(c, stats)
 }
 
-// getIPLocation 获取IP地理位置（简单实现）
-// 实际项目中可以接入IP地址库或第三方API
-func (s *AnalyticsService) getIPLocation(ip string) string {
-	// 简单判断内网IP
-	if strings.HasPrefix(ip, "192.168.") || strings.HasPrefix(ip, "10.") || strings.HasPrefix(ip, "127.") {
-		return "内网"
+// GetArticleVisitors 获取文章独立访客统计
+// @Summary 获取文章独立访客
+// @Description 获取文章最近几天每天的独立访客数、区间去重访客数和累计访客数（HyperLogLog 估算）
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param days query int false "天数，最多30天" default(7)
+// @Success 200 {object} response.Response{data=dto.ArticleVisitorsResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "文章不存在"
+// @Router /analytics/articles/{id}/visitors [get]
+func (s *AnalyticsService) GetArticleVisitors(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	days := 7
+	if daysStr := c.Query("days"); daysStr != "" {
+		fmt.Sscanf(daysStr, "%d", &days)
+	}
+
+	visitors, err := s.analyticsUseCase.ArticleVisitors(uri.ID, days)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
 	}
-	// 这里可以接入IP地址库，如：ip2region、纯真IP库等
-	// 或调用第三方API：高德、百度、ipapi.co等
-	return "未知"
+
+	response.Success(c, visitors)
+}
+
+// GetArticleReport 获取文章统计报表
+// @Summary 获取文章统计报表
+// @Description 按天统计文章的浏览量、独立访客、访问次数、平均阅读时长、平均滚动深度、点赞、收藏和评论数，并列出主要外部来源。
+// @Description 原始访问记录只保留 analytics.retention_days 天，开始日期早于保留期时从保留期开始统计。format=csv 时下载 CSV 文件
+// @Tags 数据分析
+// @Accept json
+// @Produce json,text/csv
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param start_date query string false "开始日期 YYYY-MM-DD，默认为结束日期前6天"
+// @Param end_date query string false "结束日期 YYYY-MM-DD（含），默认今天"
+// @Param limit query int false "外部来源数量" default(10)
+// @Param format query string false "返回格式 json/csv" default(json)
+// @Success 200 {object} response.Response{data=dto.ArticleReport} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "文章不存在"
+// @Router /analytics/articles/{id} [get]
+func (s *AnalyticsService) GetArticleReport(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	var req dto.ArticleReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := s.analyticsUseCase.ArticleReport(uri.ID, &req.DateRangeRequest)
+	if err != nil {
+		if err.Error() == "文章不存在" {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if req.Format != "csv" {
+		response.Success(c, report)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writeArticleReportCSV(&buf, report); err != nil {
+		response.ServerError(c, "导出 CSV 失败")
+		return
+	}
+	filename := fmt.Sprintf("article-%d-%s-%s.csv", report.ArticleID, report.StartDate, report.EndDate)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(200, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// writeArticleReportCSV 把文章统计报表写成 CSV：先是按天统计和合计，空一行后是外部来源
+func writeArticleReportCSV(w io.Writer, report *dto.ArticleReport) error {
+	// UTF-8 BOM，Excel 打开时中文不乱码
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	formatInt := func(v int64) string { return strconv.FormatInt(v, 10) }
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"日期", "浏览量", "独立访客", "访问次数", "平均阅读时长(秒)", "平均滚动深度(%)", "点赞", "收藏", "评论"})
+	for _, d := range report.Days {
+		cw.Write([]string{
+			d.Date, formatInt(d.Views), formatInt(d.Visitors), formatInt(d.Visits),
+			formatFloat(d.AvgDuration), formatFloat(d.AvgScroll),
+			formatInt(d.Likes), formatInt(d.Favorites), formatInt(d.Comments),
+		})
+	}
+	t := report.Totals
+	cw.Write([]string{
+		"合计", formatInt(t.Views), "", formatInt(t.Visits),
+		formatFloat(t.AvgDuration), formatFloat(t.AvgScroll),
+		formatInt(t.Likes), formatInt(t.Favorites), formatInt(t.Comments),
+	})
+
+	cw.Write(nil)
+	cw.Write([]string{"来源域名", "来源类别", "访问次数", "独立访客"})
+	for _, r := range report.Referrers {
+		cw.Write([]string{csvSafe(r.Domain), csvSafe(r.Category), formatInt(r.Visits), formatInt(r.UV)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe 以 = + - @ 或制表符、回车开头的单元格会被表格软件当作公式执行，加单引号前缀作为文本
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// GetSources 获取访问来源统计
+// @Summary 获取访问来源
+// @Description 按来源类别（直接访问、站内、搜索、社交、外部链接）统计访问量，并列出主要的外部来源域名
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string false "开始日期 YYYY-MM-DD，默认为结束日期前6天"
+// @Param end_date query string false "结束日期 YYYY-MM-DD（含），默认今天"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=dto.SourceReport} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/sources [get]
+func (s *AnalyticsService) GetSources(c *gin.Context) {
+	var req dto.DateRangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := s.analyticsUseCase.Sources(&req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetCampaigns 获取推广活动统计
+// @Summary 获取推广活动
+// @Description 按访问地址中的 UTM 参数（utm_source、utm_medium、utm_campaign）统计访问量
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string false "开始日期 YYYY-MM-DD，默认为结束日期前6天"
+// @Param end_date query string false "结束日期 YYYY-MM-DD（含），默认今天"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=dto.CampaignReport} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/campaigns [get]
+func (s *AnalyticsService) GetCampaigns(c *gin.Context) {
+	var req dto.DateRangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := s.analyticsUseCase.Campaigns(&req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetSearchEngines 获取搜索引擎统计
+// @Summary 获取搜索引擎来源
+// @Description 按搜索引擎统计访问量，并列出搜索引擎传递了的搜索关键词
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string false "开始日期 YYYY-MM-DD，默认为结束日期前6天"
+// @Param end_date query string false "结束日期 YYYY-MM-DD（含），默认今天"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=dto.SearchReport} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/search-engines [get]
+func (s *AnalyticsService) GetSearchEngines(c *gin.Context) {
+	var req dto.DateRangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := s.analyticsUseCase.SearchEngines(&req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetCrawlers 获取爬虫访问统计
+// @Summary 获取爬虫访问
+// @Description 按爬虫统计访问量、访问的页面数和最后访问时间，爬虫访问不计入 PV/UV 和浏览量；爬虫抓取文章由文章详情接口在服务端记录
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string false "开始日期 YYYY-MM-DD，默认为结束日期前6天"
+// @Param end_date query string false "结束日期 YYYY-MM-DD（含），默认今天"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=dto.CrawlerReport} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/crawlers [get]
+func (s *AnalyticsService) GetCrawlers(c *gin.Context) {
+	var req dto.DateRangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := s.analyticsUseCase.Crawlers(&req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetLocations 获取地区分布
+// @Summary 获取地区分布
+// @Description 按国家、省份或城市统计页面访问或登录次数，需要配置 IP 地址库，未配置时 enabled 为 false
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param source query string false "统计对象 visits/logins" default(visits)
+// @Param level query string false "统计粒度 country/province/city" default(country)
+// @Param country query string false "只统计该国家"
+// @Param province query string false "只统计该省份"
+// @Param start_date query string false "开始日期 YYYY-MM-DD，默认为结束日期前6天"
+// @Param end_date query string false "结束日期 YYYY-MM-DD（含），默认今天"
+// @Param limit query int false "返回数量" default(10)
+// @Success 200 {object} response.Response{data=dto.GeoReport} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/geo [get]
+func (s *AnalyticsService) GetLocations(c *gin.Context) {
+	var req dto.GeoRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := s.analyticsUseCase.Locations(&req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// GetLoginLogs 获取登录记录
+// @Summary 获取登录记录
+// @Description 分页获取用户登录记录，包括登录入口、IP 归属地和客户端
+// @Tags 数据分析
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(20)
+// @Param user_id query int false "只查询该用户"
+// @Success 200 {object} response.Response{data=dto.PageResponse{data=[]dto.LoginLogResponse}} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/logins [get]
+func (s *AnalyticsService) GetLoginLogs(c *gin.Context) {
+	// 未传分页参数时使用默认值
+	req := dto.LoginLogListRequest{PageRequest: dto.PageRequest{Page: 1, Limit: 20}}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := s.analyticsUseCase.LoginLogs(&req)
+	if err != nil {
+		response.ServerError(c, err.Error())
+		return
+	}
+
+	response.Success(c, resp)
 }
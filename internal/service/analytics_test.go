@@ -0,0 +1,52 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+)
+
+func TestCSVSafe(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"example.com", "example.com"},
+		{"", ""},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"\rcmd", "'\rcmd"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := csvSafe(tt.in); got != tt.want {
+			t.Errorf("csvSafe(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteArticleReportCSVEscapesReferrers(t *testing.T) {
+	report := &dto.ArticleReport{
+		Days:      []dto.ArticleDayStat{{Date: "2026-01-01", Views: 1}},
+		Referrers: []dto.SourceStat{{Domain: "=cmd|' /C calc'!A0", Category: "referral", Visits: 1, UV: 1}},
+	}
+	var buf bytes.Buffer
+	if err := writeArticleReportCSV(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(buf.Bytes(), []byte("\xEF\xBB\xBF"))))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := records[len(records)-1]
+	if last[0] != "'=cmd|' /C calc'!A0" {
+		t.Errorf("referrer domain = %q, want escaped", last[0])
+	}
+}
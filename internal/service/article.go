@@ -1,12 +1,15 @@
 package service
 
 import (
+	"fmt"
 	"io"
+	"mime/multipart"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/config"
 	"github.com/ydcloud-dy/leaf-api/internal/biz"
 	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
@@ -14,13 +17,15 @@ import (
 
 // ArticleService 文章服务
 type ArticleService struct {
-	articleUseCase biz.ArticleUseCase
+	articleUseCase   biz.ArticleUseCase
+	importJobUseCase biz.ImportJobUseCase
 }
 
 // NewArticleService 创建文章服务
-func NewArticleService(articleUseCase biz.ArticleUseCase) *ArticleService {
+func NewArticleService(articleUseCase biz.ArticleUseCase, importJobUseCase biz.ImportJobUseCase) *ArticleService {
 	return &ArticleService{
-		articleUseCase: articleUseCase,
+		articleUseCase:   articleUseCase,
+		importJobUseCase: importJobUseCase,
 	}
 }
 
@@ -157,7 +162,8 @@ func (s *ArticleService) GetByID(c *gin.Context) {
 // @Produce json
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
-// @Param category query string false "分类"
+// @Param category query string false "分类名称（包含子分类）"
+// @Param category_id query int false "分类ID（包含子分类）"
 // @Param tag query string false "标签"
 // @Param status query string false "状态"
 // @Param keyword query string false "搜索关键词"
@@ -181,6 +187,8 @@ func (s *ArticleService) List(c *gin.Context) {
 
 	// 解析过滤参数
 	req.Category = c.Query("category")
+	categoryID, _ := strconv.ParseUint(c.Query("category_id"), 10, 64)
+	req.CategoryID = uint(categoryID)
 	req.Tag = c.Query("tag")
 	req.Status = c.Query("status")
 	req.Keyword = c.Query("keyword")
@@ -289,15 +297,18 @@ func (s *ArticleService) Archive(c *gin.Context) {
 
 // ImportMarkdown 批量导入 Markdown 文件
 // @Summary 批量导入Markdown文件
-// @Description 批量导入Markdown文件为文章
+// @Description 提交Markdown文件导入任务，支持 Hugo/Hexo 风格的 YAML/TOML Front Matter（title、date、tags、categories、summary、cover、draft、slug、chapter），文件由后台异步处理，通过 /jobs/{id} 查询进度。
+// @Description 也可以上传一个 Obsidian/Typora/语雀导出的 zip 压缩包：包内相对路径的图片会上传并替换链接，指定 tag_id 时目录结构映射为该标签下的章节
 // @Tags 文章管理
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
-// @Param files formData file true "Markdown文件（可多个）"
-// @Success 200 {object} response.Response "导入成功"
+// @Param files formData file true "Markdown文件（可多个）或单个zip压缩包"
+// @Param tag_id formData int false "压缩包导入时目录映射到的标签ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "任务已提交"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器错误"
 // @Router /articles/import [post]
 func (s *ArticleService) ImportMarkdown(c *gin.Context) {
 	// 获取作者 ID
@@ -320,29 +331,28 @@ func (s *ArticleService) ImportMarkdown(c *gin.Context) {
 		return
 	}
 
-	// 获取默认分类ID（使用第一个可用分类）
-	defaultCategoryID, err := s.articleUseCase.GetDefaultCategoryID()
-	if err != nil {
-		response.BadRequest(c, "获取默认分类失败: "+err.Error())
+	// 压缩包导入
+	if len(files) == 1 && strings.ToLower(filepath.Ext(files[0].Filename)) == ".zip" {
+		s.importBundle(c, adminID.(uint), files[0])
 		return
 	}
 
-	successCount := 0
-	failedFiles := []string{}
+	importFiles := make([]dto.ImportFile, 0, len(files))
+	rejectedFiles := []string{}
 
-	// 遍历所有文件
+	// 遍历所有文件，读取内容后交给后台任务处理
 	for _, file := range files {
 		// 检查文件扩展名
 		ext := strings.ToLower(filepath.Ext(file.Filename))
 		if ext != ".md" && ext != ".markdown" {
-			failedFiles = append(failedFiles, file.Filename+": 不支持的文件格式")
+			rejectedFiles = append(rejectedFiles, file.Filename+": 不支持的文件格式")
 			continue
 		}
 
 		// 打开文件
 		f, err := file.Open()
 		if err != nil {
-			failedFiles = append(failedFiles, file.Filename+": 打开文件失败")
+			rejectedFiles = append(rejectedFiles, file.Filename+": 打开文件失败")
 			continue
 		}
 
@@ -350,61 +360,75 @@ func (s *ArticleService) ImportMarkdown(c *gin.Context) {
 		content, err := io.ReadAll(f)
 		f.Close()
 		if err != nil {
-			failedFiles = append(failedFiles, file.Filename+": 读取文件失败")
+			rejectedFiles = append(rejectedFiles, file.Filename+": 读取文件失败")
 			continue
 		}
 
-		// 提取文件名作为标题（去掉扩展名）
-		title := strings.TrimSuffix(file.Filename, ext)
-
-		// 创建文章
-		req := &dto.CreateArticleRequest{
-			Title:           title,
-			ContentMarkdown: string(content),
-			Summary:         generateSummary(string(content), 200),
-			Status:          0, // 默认为草稿
-			CategoryID:      defaultCategoryID,
-			TagIDs:          []uint{},
-		}
+		importFiles = append(importFiles, dto.ImportFile{
+			Filename: file.Filename,
+			Content:  string(content),
+		})
+	}
 
-		_, err = s.articleUseCase.Create(req, adminID.(uint))
-		if err != nil {
-			failedFiles = append(failedFiles, file.Filename+": 创建文章失败 - "+err.Error())
-			continue
-		}
+	if len(importFiles) == 0 {
+		response.BadRequest(c, "没有可导入的文件: "+strings.Join(rejectedFiles, "; "))
+		return
+	}
+
+	job, err := s.importJobUseCase.Submit(adminID.(uint), importFiles)
+	if err != nil {
+		response.ServerError(c, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"job_id":         job.ID,
+		"job":            job,
+		"rejected_files": rejectedFiles,
+	})
+}
 
-		successCount++
+// importBundle 导入 Markdown 压缩包
+func (s *ArticleService) importBundle(c *gin.Context, authorID uint, file *multipart.FileHeader) {
+	if file.Size > config.AppConfig.Import.MaxBundleSize*1024*1024 {
+		response.BadRequest(c, fmt.Sprintf("压缩包大小超过限制: 最大 %dMB", config.AppConfig.Import.MaxBundleSize))
+		return
 	}
 
-	result := map[string]interface{}{
-		"total":   len(files),
-		"success": successCount,
-		"failed":  len(failedFiles),
+	var tagID *uint
+	if raw := c.PostForm("tag_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			response.BadRequest(c, "无效的标签ID")
+			return
+		}
+		tid := uint(id)
+		tagID = &tid
 	}
 
-	if len(failedFiles) > 0 {
-		result["failed_files"] = failedFiles
+	f, err := file.Open()
+	if err != nil {
+		response.BadRequest(c, "打开文件失败")
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		response.BadRequest(c, "读取文件失败")
+		return
 	}
 
-	response.Success(c, result)
-}
+	job, err := s.importJobUseCase.SubmitBundle(authorID, data, tagID)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
 
-// generateSummary 从内容中生成摘要
-func generateSummary(content string, maxLen int) string {
-	// 移除 Markdown 标记
-	content = strings.ReplaceAll(content, "#", "")
-	content = strings.ReplaceAll(content, "*", "")
-	content = strings.ReplaceAll(content, "_", "")
-	content = strings.ReplaceAll(content, "`", "")
-	content = strings.ReplaceAll(content, "\n", " ")
-	content = strings.TrimSpace(content)
-
-	// 截取指定长度
-	runes := []rune(content)
-	if len(runes) > maxLen {
-		return string(runes[:maxLen]) + "..."
-	}
-	return content
+	response.Success(c, gin.H{
+		"job_id":         job.ID,
+		"job":            job,
+		"rejected_files": []string{},
+	})
 }
 
 // BatchUpdateCover 批量更新封面
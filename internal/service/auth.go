@@ -4,18 +4,22 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/ydcloud-dy/leaf-api/internal/biz"
 	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
 )
 
 // AuthService 认证服务
 type AuthService struct {
-	authUseCase biz.AuthUseCase
+	authUseCase      biz.AuthUseCase
+	analyticsUseCase biz.AnalyticsUseCase
 }
 
 // NewAuthService 创建认证服务
-func NewAuthService(authUseCase biz.AuthUseCase) *AuthService {
+func NewAuthService(authUseCase biz.AuthUseCase, analyticsUseCase biz.AnalyticsUseCase) *AuthService {
 	return &AuthService{
-		authUseCase: authUseCase,
+		authUseCase:      authUseCase,
+		analyticsUseCase: analyticsUseCase,
 	}
 }
 
@@ -43,6 +47,10 @@ func (s *AuthService) Login(c *gin.Context) {
 		return
 	}
 
+	if err := s.analyticsUseCase.RecordLogin(resp.Admin.ID, po.LoginPortalAdmin, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		logger.Warn("记录登录日志失败: ", err)
+	}
+
 	response.Success(c, resp)
 }
 
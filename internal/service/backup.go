@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/response"
+)
+
+// BackupService 全站备份服务
+type BackupService struct {
+	backupUseCase biz.BackupUseCase
+}
+
+// NewBackupService 创建全站备份服务
+func NewBackupService(backupUseCase biz.BackupUseCase) *BackupService {
+	return &BackupService{
+		backupUseCase: backupUseCase,
+	}
+}
+
+// Export 导出全站备份
+// @Summary 导出全站备份
+// @Description 导出版本化的 zip 归档：用户、文章、章节、标签、分类、评论、设置各一个 JSON 文件，以及文章、头像、设置中引用的全部媒体文件。
+// @Description 默认不导出用户密码哈希，include_passwords=true 时导出
+// @Tags 全站备份
+// @Produce application/zip
+// @Security BearerAuth
+// @Param include_passwords query bool false "是否导出密码哈希"
+// @Success 200 {file} file "备份文件"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Router /backup/export [get]
+func (s *BackupService) Export(c *gin.Context) {
+	if !isAdminRole(c.GetString("role")) {
+		response.Forbidden(c, "只有管理员可以导出备份")
+		return
+	}
+
+	includePasswords := c.Query("include_passwords") == "true" || c.Query("include_passwords") == "1"
+
+	// 归档边生成边写入响应，不在内存中缓存；包含大量媒体文件时导出时间可能超过服务器写超时
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("取消备份导出写超时失败: ", err)
+	}
+
+	filename := fmt.Sprintf("leaf-backup-%s.zip", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if err := s.backupUseCase.Export(c.Writer, includePasswords); err != nil {
+		logger.Error("Failed to export backup: ", err)
+		// 已经开始写入时无法再返回错误响应，客户端收到的归档不完整，无法解压
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "")
+			c.Header("Content-Disposition", "")
+			response.ServerError(c, "导出备份失败: "+err.Error())
+		}
+		c.Abort()
+	}
+}
+
+// Import 从全站备份恢复
+// @Summary 从全站备份恢复
+// @Description 将导出的备份恢复到空数据库（不能有文章、标签、章节和评论），所有 ID 重新分配，媒体文件重新上传并替换内容中的地址。
+// @Description 同名用户和分类（如默认管理员和默认分类）直接复用；备份不含密码的用户会设置随机密码
+// @Tags 全站备份
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "备份文件"
+// @Success 200 {object} response.Response{data=dto.RestoreReport} "恢复成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Router /backup/import [post]
+func (s *BackupService) Import(c *gin.Context) {
+	if !isAdminRole(c.GetString("role")) {
+		response.Forbidden(c, "只有管理员可以恢复备份")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "请上传文件")
+		return
+	}
+	if file.Size > config.AppConfig.Import.MaxBundleSize*1024*1024 {
+		response.BadRequest(c, fmt.Sprintf("文件大小超过限制: 最大 %dMB", config.AppConfig.Import.MaxBundleSize))
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.BadRequest(c, "打开文件失败")
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		response.BadRequest(c, "读取文件失败")
+		return
+	}
+
+	report, err := s.backupUseCase.Restore(bytes.NewReader(data), int64(len(data)), config.AppConfig.Import.MaxBundleSize*1024*1024)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
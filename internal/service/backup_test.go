@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+)
+
+// fakeBackupUseCase 按 export 函数生成归档
+type fakeBackupUseCase struct {
+	export func(w io.Writer) error
+}
+
+func (f *fakeBackupUseCase) Export(w io.Writer, includePasswords bool) error {
+	return f.export(w)
+}
+
+func (f *fakeBackupUseCase) Restore(r io.ReaderAt, size, maxSize int64) (*dto.RestoreReport, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestBackupExport(t *testing.T) {
+	tests := []struct {
+		name        string
+		export      func(w io.Writer) error
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			"streams archive",
+			func(w io.Writer) error { _, err := io.WriteString(w, "zip-data"); return err },
+			http.StatusOK, "application/zip", "zip-data",
+		},
+		{
+			"error before writing",
+			func(w io.Writer) error { return errors.New("db down") },
+			http.StatusOK, "application/json", `"code":500`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewBackupService(&fakeBackupUseCase{export: tt.export})
+			r := gin.New()
+			r.GET("/backup/export", func(c *gin.Context) { c.Set("role", "admin") }, s.Export)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/backup/export", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", ct, tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -2,22 +2,30 @@ package service
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ydcloud-dy/leaf-api/internal/biz"
 	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/privacy"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
 )
 
 // BlogService 博客服务
 type BlogService struct {
-	blogUseCase biz.BlogUseCase
+	blogUseCase      biz.BlogUseCase
+	analyticsUseCase biz.AnalyticsUseCase
+	viewUseCase      biz.ViewUseCase
 }
 
 // NewBlogService 创建博客服务
-func NewBlogService(blogUseCase biz.BlogUseCase) *BlogService {
+func NewBlogService(blogUseCase biz.BlogUseCase, analyticsUseCase biz.AnalyticsUseCase, viewUseCase biz.ViewUseCase) *BlogService {
 	return &BlogService{
-		blogUseCase: blogUseCase,
+		blogUseCase:      blogUseCase,
+		analyticsUseCase: analyticsUseCase,
+		viewUseCase:      viewUseCase,
 	}
 }
 
@@ -70,6 +78,12 @@ func (s *BlogService) Login(c *gin.Context) {
 		return
 	}
 
+	if resp.User != nil {
+		if err := s.analyticsUseCase.RecordLogin(resp.User.ID, po.LoginPortalBlog, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+			logger.Warn("记录登录日志失败: ", err)
+		}
+	}
+
 	response.Success(c, resp)
 }
 
@@ -125,9 +139,32 @@ func (s *BlogService) GetArticleDetail(c *gin.Context) {
 		return
 	}
 
+	// 浏览器预加载的请求用户不一定会看到，拒绝追踪（DNT/GPC）的请求不记录，都不计浏览量和访客
+	if !isPrefetch(c) && !privacy.OptedOut(c.Request.Header) {
+		ip, userAgent := c.ClientIP(), c.GetHeader("User-Agent")
+		if err := s.analyticsUseCase.RecordCrawl(uint(articleID), c.Request.URL.Path, ip, userAgent); err != nil {
+			logger.Warn("记录爬虫访问失败: ", err)
+		}
+		s.viewUseCase.Record(uint(articleID), userID, ip, userAgent)
+		if err := s.analyticsUseCase.RecordVisitor(userID, ip, userAgent, uint(articleID)); err != nil {
+			logger.Warn("记录独立访客失败: ", err)
+		}
+	}
+
 	response.Success(c, resp)
 }
 
+// isPrefetch 判断是否为浏览器预加载/预渲染请求
+func isPrefetch(c *gin.Context) bool {
+	for _, header := range []string{"Sec-Purpose", "Purpose", "X-Purpose", "X-Moz"} {
+		purpose := strings.ToLower(c.GetHeader(header))
+		if strings.Contains(purpose, "prefetch") || strings.Contains(purpose, "preview") {
+			return true
+		}
+	}
+	return false
+}
+
 // GetAdjacentArticles 获取文章的上一篇和下一篇
 // @Summary 获取相邻文章
 // @Description 获取指定文章的上一篇和下一篇文章
@@ -38,6 +38,25 @@ func (s *CategoryService) List(c *gin.Context) {
 	response.Success(c, categories)
 }
 
+// Tree 查询分类树
+// @Summary 获取分类树
+// @Description 按父子关系返回多级分类树，同级按 sort 排序
+// @Tags 分类管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=[]dto.CategoryTreeNode} "获取成功"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /blog/categories/tree [get]
+func (s *CategoryService) Tree(c *gin.Context) {
+	tree, err := s.categoryUseCase.Tree()
+	if err != nil {
+		response.ServerError(c, err.Error())
+		return
+	}
+
+	response.Success(c, tree)
+}
+
 // Create 创建分类
 // @Summary 创建分类
 // @Description 创建新的文章分类
@@ -45,7 +64,7 @@ func (s *CategoryService) List(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body object{name=string,description=string,sort=int} true "分类信息"
+// @Param request body object{name=string,description=string,sort=int,parent_id=uint} true "分类信息"
 // @Success 200 {object} response.Response "创建成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
@@ -56,6 +75,7 @@ func (s *CategoryService) Create(c *gin.Context) {
 		Name        string `json:"name" binding:"required,max=50"`
 		Description string `json:"description" binding:"max=200"`
 		Sort        int    `json:"sort"`
+		ParentID    *uint  `json:"parent_id"` // 父分类ID，可选
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -63,7 +83,7 @@ func (s *CategoryService) Create(c *gin.Context) {
 		return
 	}
 
-	if err := s.categoryUseCase.Create(req.Name, req.Description, req.Sort); err != nil {
+	if err := s.categoryUseCase.Create(req.Name, req.Description, req.Sort, req.ParentID); err != nil {
 		response.ServerError(c, err.Error())
 		return
 	}
@@ -73,7 +93,7 @@ func (s *CategoryService) Create(c *gin.Context) {
 
 // Delete 删除分类
 // @Summary 删除分类
-// @Description 根据ID删除文章分类
+// @Description 根据ID删除文章分类（分类下有子分类或文章时无法删除）
 // @Tags 分类管理
 // @Accept json
 // @Produce json
@@ -98,3 +118,72 @@ func (s *CategoryService) Delete(c *gin.Context) {
 
 	response.Success(c, nil)
 }
+
+// Update 更新分类
+// @Summary 更新分类
+// @Description 修改分类名称、描述、颜色、排序或父分类（parent_id 传 0 表示移到一级分类），未提供的字段保持不变
+// @Tags 分类管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分类ID"
+// @Param request body dto.UpdateCategoryRequest true "分类信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /categories/{id} [put]
+func (s *CategoryService) Update(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req dto.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	category, err := s.categoryUseCase.Update(uri.ID, &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, category)
+}
+
+// Merge 合并分类
+// @Summary 合并分类
+// @Description 将分类合并到目标分类：文章和子分类移到目标分类，原分类名称保留为别名，按名称查询时仍解析到目标分类
+// @Tags 分类管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "被合并的分类ID"
+// @Param request body dto.MergeRequest true "目标分类"
+// @Success 200 {object} response.Response "合并成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /categories/{id}/merge [post]
+func (s *CategoryService) Merge(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req dto.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := s.categoryUseCase.Merge(uri.ID, req.TargetID); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
@@ -1,24 +1,25 @@
 package service
 
 import (
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
+	"fmt"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
 	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
 	"gorm.io/gorm"
 )
 
 type ChapterService struct {
-	data *data.Data
+	data           *data.Data
+	chapterUseCase biz.ChapterUseCase
 }
 
-func NewChapterService(d *data.Data) *ChapterService {
-	return &ChapterService{data: d}
+func NewChapterService(d *data.Data, chapterUseCase biz.ChapterUseCase) *ChapterService {
+	return &ChapterService{data: d, chapterUseCase: chapterUseCase}
 }
 
 // GetChapters 获取章节列表(按标签)
@@ -157,6 +158,17 @@ func (s *ChapterService) UpdateChapter(c *gin.Context) {
 		return
 	}
 
+	if req.ParentID != nil {
+		tagID := chapter.TagID
+		if req.TagID != nil {
+			tagID = *req.TagID
+		}
+		if err := s.chapterUseCase.CheckParent(chapter.ID, tagID, *req.ParentID); err != nil {
+			response.Error(c, 400, err.Error())
+			return
+		}
+	}
+
 	updates := make(map[string]interface{})
 	if req.TagID != nil {
 		updates["tag_id"] = *req.TagID
@@ -222,6 +234,101 @@ func (s *ChapterService) DeleteChapter(c *gin.Context) {
 	response.Success(c, nil)
 }
 
+// ReorderArticles 调整章节内文章顺序
+// @Summary 调整章节内文章顺序
+// @Description 按给定的文章ID顺序设置章节内排序，未列出的文章保持原顺序排在后面。章节目录和上一篇/下一篇都使用该顺序
+// @Tags 章节管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "章节ID"
+// @Param request body dto.ReorderChapterArticlesRequest true "文章顺序"
+// @Success 200 {object} response.Response "排序成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /chapters/{id}/articles/order [put]
+func (s *ChapterService) ReorderArticles(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req dto.ReorderChapterArticlesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := s.chapterUseCase.ReorderArticles(uri.ID, req.ArticleIDs); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// UpdateTree 批量调整章节树
+// @Summary 批量调整章节树
+// @Description 一次提交多个章节的父章节和排序（拖拽调整目录），校验父章节属于同一标签且不形成循环后在一个事务中生效，未列出的章节保持不变
+// @Tags 章节管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateChapterTreeRequest true "章节树调整"
+// @Success 200 {object} response.Response "调整成功"
+// @Failure 400 {object} response.Response "请求参数错误或形成循环"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /chapters/tree [put]
+func (s *ChapterService) UpdateTree(c *gin.Context) {
+	var req dto.UpdateChapterTreeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := s.chapterUseCase.UpdateTree(req.TagID, req.Items); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// MoveChapter 将章节移动到其他标签
+// @Summary 移动章节到其他标签
+// @Description 将章节连同所有子章节移动到目标标签（可指定目标父章节），章节下文章的标签同步从原标签换为目标标签
+// @Tags 章节管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "章节ID"
+// @Param request body dto.MoveChapterRequest true "目标位置"
+// @Success 200 {object} response.Response "移动成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /chapters/{id}/move [post]
+func (s *ChapterService) MoveChapter(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req dto.MoveChapterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := s.chapterUseCase.MoveToTag(uri.ID, &req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
 // GetChaptersByTag 获取标签下的章节及文章(用于前端笔记页面)
 // 支持多级目录结构
 // @Summary 获取标签下的章节和文章
@@ -237,9 +344,9 @@ func (s *ChapterService) DeleteChapter(c *gin.Context) {
 func (s *ChapterService) GetChaptersByTag(c *gin.Context) {
 	tagName := c.Param("tag")
 
-	// 先查找标签
-	var tag po.Tag
-	if err := s.data.GetDB().Where("name = ?", tagName).First(&tag).Error; err != nil {
+	// 先查找标签（合并后的旧名称按别名解析）
+	tag, err := s.data.TagRepo.FindByName(tagName)
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.Error(c, 404, "标签不存在")
 			return
@@ -248,134 +355,152 @@ func (s *ChapterService) GetChaptersByTag(c *gin.Context) {
 		return
 	}
 
-	// 查询该标签下的所有章节
-	var chapters []po.Chapter
-	s.data.GetDB().Where("tag_id = ?", tag.ID).Order("sort ASC, id ASC").Find(&chapters)
-
-	// 如果没有章节，直接返回
-	if len(chapters) == 0 {
-		response.Success(c, []interface{}{})
+	// 构建章节树
+	tree, err := s.chapterUseCase.GetTreeByTag(tag.ID)
+	if err != nil {
+		response.Error(c, 500, "查询失败")
 		return
 	}
 
-	// 收集所有章节ID
-	chapterIDs := make([]uint, len(chapters))
-	for i, chapter := range chapters {
-		chapterIDs[i] = chapter.ID
-	}
+	response.Success(c, chaptersWithArticles(tree))
+}
 
-	// 一次性查询所有章节的文章 (优化N+1查询问题)
-	var allArticles []po.Article
-	s.data.GetDB().Where("chapter_id IN ? AND status = 1", chapterIDs).
-		Select("id, title, chapter_id, view_count, created_at").
-		Find(&allArticles)
+// ChapterWithArticles 前台章节树节点，保持章节和文章模型原有的 JSON 结构
+type ChapterWithArticles struct {
+	po.Chapter
+	Articles    []po.Article          `json:"articles"`
+	SubChapters []ChapterWithArticles `json:"sub_chapters"`
+}
 
-	// 按章节ID分组文章
-	articlesByChapter := make(map[uint][]po.Article)
-	for _, article := range allArticles {
-		if article.ChapterID != nil {
-			articlesByChapter[*article.ChapterID] = append(articlesByChapter[*article.ChapterID], article)
+// chaptersWithArticles 将章节树转换为前台接口的响应结构
+func chaptersWithArticles(nodes []dto.ChapterTreeNode) []ChapterWithArticles {
+	result := make([]ChapterWithArticles, 0, len(nodes))
+	for _, node := range nodes {
+		articles := make([]po.Article, 0, len(node.Articles))
+		for _, a := range node.Articles {
+			articles = append(articles, po.Article{
+				ID:            a.ID,
+				Title:         a.Title,
+				ChapterID:     a.ChapterID,
+				SortInChapter: a.SortInChapter,
+				ViewCount:     a.ViewCount,
+				CreatedAt:     a.CreatedAt,
+			})
 		}
+		result = append(result, ChapterWithArticles{
+			Chapter: po.Chapter{
+				ID:        node.ID,
+				TagID:     node.TagID,
+				ParentID:  node.ParentID,
+				Name:      node.Name,
+				Sort:      node.Sort,
+				CreatedAt: node.CreatedAt,
+				UpdatedAt: node.UpdatedAt,
+			},
+			Articles:    articles,
+			SubChapters: chaptersWithArticles(node.SubChapters),
+		})
 	}
+	return result
+}
 
-	// 为每个章节的文章排序
-	for chapterID := range articlesByChapter {
-		sortArticlesByTitleNumber(articlesByChapter[chapterID])
+// GetProgress 获取当前用户在标签章节系列中的阅读进度
+// @Summary 获取章节系列阅读进度
+// @Description 按章节阅读顺序返回标签下每篇已发布文章的阅读进度（滚动百分比、是否读完、最近阅读时间）及整体进度。进度由 /blog/visit 上报的 article_id 和 scroll_percent 记录
+// @Tags 博客前台
+// @Produce json
+// @Security BearerAuth
+// @Param tag path string true "标签名称"
+// @Success 200 {object} response.Response{data=dto.ChapterProgressResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /blog/chapters/{tag}/progress [get]
+func (s *ChapterService) GetProgress(c *gin.Context) {
+	tag, err := s.data.TagRepo.FindByName(c.Param("tag"))
+	if err != nil {
+		response.NotFound(c, "标签不存在")
+		return
 	}
 
-	// 组装结果 - 构建树形结构
-	type ChapterWithArticles struct {
-		po.Chapter
-		Articles     []po.Article           `json:"articles"`
-		SubChapters  []ChapterWithArticles  `json:"sub_chapters"`
+	result, err := s.chapterUseCase.GetProgress(c.GetUint("user_id"), tag.ID)
+	if err != nil {
+		response.ServerError(c, "查询失败")
+		return
 	}
 
-	// 先构建一个章节ID到章节的映射
-	chapterMap := make(map[uint]*ChapterWithArticles)
-	for _, chapter := range chapters {
-		articles := articlesByChapter[chapter.ID]
-		if articles == nil {
-			articles = []po.Article{}
-		}
-
-		chapterMap[chapter.ID] = &ChapterWithArticles{
-			Chapter:     chapter,
-			Articles:    articles,
-			SubChapters: []ChapterWithArticles{},
-		}
-	}
+	response.Success(c, result)
+}
 
-	// 构建树形结构 - 先找出所有子章节并添加到父章节的 SubChapters 中
-	for _, chapter := range chapters {
-		if chapter.ParentID != nil {
-			// 如果是子章节，添加到父章节的 SubChapters 中
-			if parentChapter, ok := chapterMap[*chapter.ParentID]; ok {
-				chapterItem := chapterMap[chapter.ID]
-				parentChapter.SubChapters = append(parentChapter.SubChapters, *chapterItem)
-			}
-		}
+// ContinueReading 继续阅读
+// @Summary 继续阅读
+// @Description 按章节阅读顺序返回下一篇要读的文章：最近阅读的文章未读完时继续该篇，否则为其后第一篇未读完的文章
+// @Tags 博客前台
+// @Produce json
+// @Security BearerAuth
+// @Param tag path string true "标签名称"
+// @Success 200 {object} response.Response{data=dto.ContinueReadingResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /blog/chapters/{tag}/continue [get]
+func (s *ChapterService) ContinueReading(c *gin.Context) {
+	tag, err := s.data.TagRepo.FindByName(c.Param("tag"))
+	if err != nil {
+		response.NotFound(c, "标签不存在")
+		return
 	}
 
-	// 然后构建结果列表,只包含一级章节
-	var result []ChapterWithArticles
-	for _, chapter := range chapters {
-		if chapter.ParentID == nil {
-			result = append(result, *chapterMap[chapter.ID])
-		}
+	result, err := s.chapterUseCase.ContinueReading(c.GetUint("user_id"), tag.ID)
+	if err != nil {
+		response.ServerError(c, "查询失败")
+		return
 	}
 
 	response.Success(c, result)
 }
 
-// sortArticlesByTitleNumber 按标题中的序号对文章进行排序
-func sortArticlesByTitleNumber(articles []po.Article) {
-	// 中文数字映射表
-	chineseNumbers := map[rune]int{
-		'一': 1, '二': 2, '三': 3, '四': 4, '五': 5,
-		'六': 6, '七': 7, '八': 8, '九': 9, '十': 10,
-		'壹': 1, '贰': 2, '叁': 3, '肆': 4, '伍': 5,
-		'陆': 6, '柒': 7, '捌': 8, '玖': 9, '拾': 10,
+// ExportByTag 将标签下的章节导出为电子书
+// @Summary 导出章节电子书
+// @Description 将标签的章节树导出为 EPUB 3 电子书（目录按章节层级嵌套，打包本站存储的图片，带封面），或单页可打印的 HTML，均以附件下载；结果按内容版本缓存
+// @Tags 博客前台
+// @Produce application/epub+zip
+// @Produce html
+// @Param tag path string true "标签名称"
+// @Param format query string false "导出格式：epub（默认）或 html"
+// @Success 200 {file} file "电子书文件"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /blog/chapters/{tag}/export [get]
+func (s *ChapterService) ExportByTag(c *gin.Context) {
+	tagName := c.Param("tag")
+	format := c.DefaultQuery("format", biz.BookFormatEPUB)
+	if format != biz.BookFormatEPUB && format != biz.BookFormatHTML {
+		response.BadRequest(c, "format 只能是 epub 或 html")
+		return
 	}
 
-	// 提取标题中的序号
-	extractNumber := func(title string) int {
-		if title == "" {
-			return 999999 // 没有标题的排在最后
-		}
-
-		// 去除空格
-		title = strings.TrimSpace(title)
-
-		// 尝试匹配阿拉伯数字: "1."、"1、"、"1 "、"1-" 等
-		arabicRegex := regexp.MustCompile(`^(\d+)[.\s、,，-]`)
-		if matches := arabicRegex.FindStringSubmatch(title); len(matches) > 1 {
-			if num, err := strconv.Atoi(matches[1]); err == nil {
-				return num
-			}
-		}
-
-		// 尝试匹配中文数字: "一、"、"一."、"一 " 等
-		if len(title) > 0 {
-			firstChar := []rune(title)[0]
-			if num, ok := chineseNumbers[firstChar]; ok {
-				return num
-			}
-		}
-
-		// 如果都没有匹配到,返回一个很大的数,排在后面
-		return 999999
+	tag, err := s.data.TagRepo.FindByName(tagName)
+	if err != nil {
+		response.NotFound(c, "标签不存在")
+		return
 	}
 
-	// 使用 sort.Slice 进行排序
-	sort.Slice(articles, func(i, j int) bool {
-		numI := extractNumber(articles[i].Title)
-		numJ := extractNumber(articles[j].Title)
-
-		// 如果序号相同,按创建时间排序
-		if numI == numJ {
-			return articles[i].CreatedAt.Before(articles[j].CreatedAt)
-		}
+	path, err := s.chapterUseCase.ExportBook(tag.ID, format)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
 
-		return numI < numJ
-	})
+	// HTML 也作为附件下载，不在 API 域名下直接渲染文章内容
+	contentType := "application/epub+zip"
+	if format == biz.BookFormatHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	filename := tag.Name + "." + format
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="book.%s"; filename*=UTF-8''%s`, format, url.PathEscape(filename)))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Type", contentType)
+	c.File(path)
 }
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+)
+
+func TestChaptersWithArticlesShape(t *testing.T) {
+	parent := uint(1)
+	tree := []dto.ChapterTreeNode{{
+		ID:       1,
+		TagID:    3,
+		Name:     "第一章",
+		Articles: []dto.ChapterArticle{{ID: 10, Title: "1. 开始", ChapterID: &parent}},
+		SubChapters: []dto.ChapterTreeNode{{
+			ID:          2,
+			TagID:       3,
+			ParentID:    &parent,
+			Name:        "1.1",
+			SubChapters: []dto.ChapterTreeNode{{ID: 4, TagID: 3, Name: "1.1.1"}},
+		}},
+	}}
+
+	raw, err := json.Marshal(chaptersWithArticles(tree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("len = %d, want 1", len(got))
+	}
+
+	// 章节字段平铺在节点上，文章为完整的文章模型
+	for _, key := range []string{"id", "tag_id", "parent_id", "name", "sort", "created_at", "updated_at", "articles", "sub_chapters"} {
+		if _, ok := got[0][key]; !ok {
+			t.Errorf("chapter missing %q", key)
+		}
+	}
+	articles := got[0]["articles"].([]interface{})
+	article := articles[0].(map[string]interface{})
+	for _, key := range []string{"id", "title", "chapter_id", "view_count", "created_at", "content_html", "status"} {
+		if _, ok := article[key]; !ok {
+			t.Errorf("article missing %q", key)
+		}
+	}
+
+	// 多级子章节及空列表
+	sub := got[0]["sub_chapters"].([]interface{})[0].(map[string]interface{})
+	if arts, ok := sub["articles"].([]interface{}); !ok || len(arts) != 0 {
+		t.Errorf("sub articles = %v, want []", sub["articles"])
+	}
+	deep := sub["sub_chapters"].([]interface{})
+	if len(deep) != 1 || deep[0].(map[string]interface{})["name"] != "1.1.1" {
+		t.Errorf("deep sub_chapters = %v", deep)
+	}
+
+	empty, _ := json.Marshal(chaptersWithArticles(nil))
+	if string(empty) != "[]" {
+		t.Errorf("empty = %s, want []", empty)
+	}
+}
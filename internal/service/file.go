@@ -1,14 +1,19 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/config"
 	"github.com/ydcloud-dy/leaf-api/internal/data"
 	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
 	"github.com/ydcloud-dy/leaf-api/pkg/oss"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
+	"github.com/ydcloud-dy/leaf-api/pkg/upload"
 )
 
 // FileService 文件服务
@@ -25,15 +30,15 @@ func NewFileService(d *data.Data) *FileService {
 
 // Upload 上传文件
 // @Summary 上传文件
-// @Description 上传文件到OSS，支持图片、视频等多种文件类型
+// @Description 上传文件到OSS，按内容嗅探文件类型，并按文件夹限制类型和大小；非管理员用户受存储配额限制
 // @Tags 文件管理
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
 // @Param file formData file true "文件"
-// @Param folder formData string false "文件夹名称" default(uploads)
+// @Param folder formData string false "文件夹名称（小写字母、数字、_、-，可用/分隔）" default(uploads)
 // @Success 200 {object} response.Response "上传成功"
-// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 400 {object} response.Response "请求参数错误、文件类型或大小不合法、超出配额"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /files/upload [post]
@@ -45,8 +50,37 @@ func (s *FileService) Upload(c *gin.Context) {
 		return
 	}
 
-	// 获取文件夹参数
-	folder := c.DefaultPostForm("folder", "uploads")
+	// 获取并校验文件夹参数
+	folder, err := upload.SanitizeFolder(c.DefaultPostForm("folder", upload.DefaultFolder))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 校验文件大小和类型（按内容嗅探，不信任客户端的 Content-Type）
+	mimeType, err := upload.Validate(file, folder)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	// 非管理员用户检查存储配额，明显超出时不上传
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	role, _ := c.Get("role")
+	var quota int64
+	if !isAdminRole(role) && config.AppConfig.Upload.UserQuota > 0 {
+		quota = config.AppConfig.Upload.UserQuota * 1024 * 1024
+		used, err := s.data.FileRepo.SumSizeByUser(uid)
+		if err != nil {
+			response.ServerError(c, "查询存储用量失败")
+			return
+		}
+		if used+file.Size > quota {
+			response.BadRequest(c, fmt.Sprintf("超出存储配额: 最大 %dMB", config.AppConfig.Upload.UserQuota))
+			return
+		}
+	}
 
 	// 上传到 OSS
 	url, err := oss.UploadFile(file, folder)
@@ -55,28 +89,48 @@ func (s *FileService) Upload(c *gin.Context) {
 		return
 	}
 
-	// 保存文件记录
+	// 保存文件记录，有配额时在事务中重新检查，避免并发上传绕过配额
 	fileRecord := &po.File{
 		Name:     file.Filename,
 		URL:      url,
 		Size:     file.Size,
 		Type:     folder,
-		MimeType: file.Header.Get("Content-Type"),
+		MimeType: mimeType,
+		UserID:   uid,
 	}
-
-	if err := s.data.FileRepo.Create(fileRecord); err != nil {
+	if quota > 0 {
+		err = s.data.FileRepo.CreateWithinQuota(fileRecord, quota)
+	} else {
+		err = s.data.FileRepo.Create(fileRecord)
+	}
+	if err != nil {
+		// 没有记录的文件无法管理，直接删除
+		if removeErr := oss.RemoveFile(url); removeErr != nil {
+			logger.Warn("删除未保存记录的文件失败: ", removeErr)
+		}
+		if errors.Is(err, data.ErrQuotaExceeded) {
+			response.BadRequest(c, fmt.Sprintf("超出存储配额: 最大 %dMB", config.AppConfig.Upload.UserQuota))
+			return
+		}
 		response.ServerError(c, "保存文件记录失败")
 		return
 	}
 
 	response.Success(c, gin.H{
-		"url":  url,
-		"name": file.Filename,
-		"size": file.Size,
-		"id":   fileRecord.ID,
+		"url":       url,
+		"name":      file.Filename,
+		"size":      file.Size,
+		"mime_type": mimeType,
+		"id":        fileRecord.ID,
 	})
 }
 
+// isAdminRole 判断是否为管理员角色
+func isAdminRole(role interface{}) bool {
+	r, _ := role.(string)
+	return r == "admin" || r == "super_admin"
+}
+
 // List 查询文件列表
 // @Summary 获取文件列表
 // @Description 分页获取已上传的文件列表
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/data"
+	"github.com/ydcloud-dy/leaf-api/internal/model/po"
+)
+
+// fakeFileRepo 记录创建的文件，CreateWithinQuota 按 used 检查配额
+type fakeFileRepo struct {
+	data.FileRepo
+	used    int64 // SumSizeByUser 返回的用量
+	usedNow int64 // CreateWithinQuota 时的用量，模拟并发上传已经用掉的空间
+	created []*po.File
+}
+
+func (r *fakeFileRepo) SumSizeByUser(userID uint) (int64, error) {
+	return r.used, nil
+}
+
+func (r *fakeFileRepo) Create(file *po.File) error {
+	r.created = append(r.created, file)
+	return nil
+}
+
+func (r *fakeFileRepo) CreateWithinQuota(file *po.File, quota int64) error {
+	if r.usedNow+file.Size > quota {
+		return data.ErrQuotaExceeded
+	}
+	return r.Create(file)
+}
+
+func TestFileUpload(t *testing.T) {
+	t.Chdir(t.TempDir())
+	prev := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = prev })
+	config.AppConfig = &config.Config{}
+	config.AppConfig.Upload.MaxSize = 1
+	config.AppConfig.Upload.UserQuota = 1
+
+	png := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), make([]byte, 1000)...)
+	const mb = 1024 * 1024
+	tests := []struct {
+		name    string
+		role    string
+		folder  string
+		content []byte
+		used    int64
+		usedNow int64
+		code    int
+		stored  bool
+	}{
+		{"within quota", "user", "", png, 0, 0, 0, true},
+		{"invalid folder", "user", "../etc", png, 0, 0, 400, false},
+		{"html rejected", "user", "", []byte("<!DOCTYPE html><script>alert(1)</script>"), 0, 0, 400, false},
+		{"quota exceeded", "user", "", png, mb, mb, 400, false},
+		// 检查用量后其他上传占满了配额，上传的文件被删除
+		{"quota exceeded concurrently", "user", "", png, 0, mb, 400, false},
+		{"admin ignores quota", "admin", "", png, mb, mb, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeFileRepo{used: tt.used, usedNow: tt.usedNow}
+			s := NewFileService(&data.Data{FileRepo: repo})
+			r := gin.New()
+			r.POST("/files/upload", func(c *gin.Context) {
+				c.Set("user_id", uint(7))
+				c.Set("role", tt.role)
+			}, s.Upload)
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, _ := form.CreateFormFile("file", "a.png")
+			part.Write(tt.content)
+			if tt.folder != "" {
+				form.WriteField("folder", tt.folder)
+			}
+			form.Close()
+			req := httptest.NewRequest(http.MethodPost, "/files/upload", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var resp struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tt.code {
+				t.Fatalf("code = %d (%s), want %d", resp.Code, resp.Message, tt.code)
+			}
+			if stored := len(repo.created) == 1; stored != tt.stored {
+				t.Errorf("record stored = %v, want %v", stored, tt.stored)
+			}
+
+			// 没有保存记录的文件不能留在存储中
+			var files []string
+			filepath.Walk("uploads", func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if tt.stored && (len(files) != 1 || "/"+filepath.ToSlash(files[0]) != repo.created[0].URL) {
+				t.Errorf("files = %v, want the recorded file", files)
+			}
+			if !tt.stored && len(files) != 0 {
+				t.Errorf("files = %v, want none", files)
+			}
+			os.RemoveAll("uploads")
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/pkg/response"
+)
+
+// JobService 后台任务服务
+type JobService struct {
+	importJobUseCase biz.ImportJobUseCase
+}
+
+// NewJobService 创建后台任务服务
+func NewJobService(importJobUseCase biz.ImportJobUseCase) *JobService {
+	return &JobService{
+		importJobUseCase: importJobUseCase,
+	}
+}
+
+// GetByID 查询任务进度
+// @Summary 查询导入任务进度
+// @Description 查询导入任务的整体进度及每个文件的状态、错误和创建的文章ID
+// @Tags 文章管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /jobs/{id} [get]
+func (s *JobService) GetByID(c *gin.Context) {
+	var req dto.IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	job, err := s.importJobUseCase.GetByID(req.ID)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, job)
+}
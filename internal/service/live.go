@@ -0,0 +1,280 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/jwt"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/response"
+)
+
+const (
+	// 实时事件频道，Redis 模式下所有实例共享
+	liveChannel = "analytics:live"
+	// 按分钟的访问计数 Key 前缀，后缀为 "2006-01-02 15:04"
+	liveVisitsPrefix = "live:visits:"
+	// 按分钟的访问计数保留时间
+	liveVisitsExpire = 2 * time.Hour
+	// 清理过期在线成员并发布离开事件的间隔
+	liveSweepInterval = 10 * time.Second
+	// SSE 连接保活间隔
+	livePingInterval = 15 * time.Second
+	// 订阅失败后重试的间隔
+	liveRetryInterval = 5 * time.Second
+	// 每个 SSE 连接缓冲的事件数
+	liveClientBuffer = 32
+	// 推送令牌有效期，只需覆盖从获取令牌到建立连接的时间
+	liveTokenTTL = time.Minute
+)
+
+// 实时事件类型
+const (
+	LiveEventJoin   = "join"   // 用户或游客上线
+	LiveEventLeave  = "leave"  // 用户或游客超时离线
+	LiveEventPage   = "page"   // 在线用户或游客切换页面
+	LiveEventVisits = "visits" // 当前分钟的访问量
+)
+
+// liveEvent 实时事件
+type liveEvent struct {
+	Type     string    `json:"type"`
+	Kind     string    `json:"kind,omitempty"`     // user, guest
+	Member   string    `json:"member,omitempty"`   // 用户ID或游客标识
+	Username string    `json:"username,omitempty"` // 登录用户的用户名
+	Path     string    `json:"path,omitempty"`
+	PrevPath string    `json:"prev_path,omitempty"` // 切换页面前的路径
+	Device   string    `json:"device,omitempty"`
+	Location string    `json:"location,omitempty"`
+	Minute   string    `json:"minute,omitempty"` // 访问量所在分钟 "2006-01-02 15:04"
+	Count    int64     `json:"count,omitempty"`  // 该分钟的访问量
+	At       time.Time `json:"at"`
+}
+
+// publishLiveEvent 发布实时事件，失败只记录日志，不影响业务
+func publishLiveEvent(event liveEvent) {
+	payload, _ := json.Marshal(event)
+	if err := cache.Default().Publish(liveChannel, string(payload)); err != nil {
+		logger.Warn("发布实时事件失败: ", err)
+	}
+}
+
+// recordLiveVisit 累加当前分钟的访问量并发布
+func recordLiveVisit(at time.Time) {
+	minute := at.Format("2006-01-02 15:04")
+	store := cache.Default()
+	count, err := store.IncrBy(liveVisitsPrefix+minute, 1)
+	if err != nil {
+		logger.Warn("记录实时访问量失败: ", err)
+		return
+	}
+	if count == 1 {
+		store.Expire(liveVisitsPrefix+minute, liveVisitsExpire)
+	}
+	publishLiveEvent(liveEvent{Type: LiveEventVisits, Minute: minute, Count: count, At: at})
+}
+
+// liveMessage 推送给 SSE 连接的事件
+type liveMessage struct {
+	name string
+	data string
+}
+
+// LiveService 实时数据推送服务，订阅事件频道并转发给本实例的 SSE 连接
+type LiveService struct {
+	mu      sync.Mutex
+	clients map[chan liveMessage]struct{}
+
+	done      chan struct{} // 关闭后所有 SSE 连接结束
+	closeOnce sync.Once
+}
+
+// NewLiveService 创建实时数据推送服务，并启动事件订阅和在线成员清理
+func NewLiveService() *LiveService {
+	s := &LiveService{
+		clients: make(map[chan liveMessage]struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.subscribe()
+	go s.sweep()
+	return s
+}
+
+// Close 结束所有 SSE 连接并停止清理在线成员，服务关闭时调用，否则长连接会一直阻塞关闭流程
+func (s *LiveService) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// subscribe 订阅事件频道，订阅断开后重试，服务关闭时取消订阅并退出
+func (s *LiveService) subscribe() {
+	for {
+		sub, err := cache.Default().Subscribe(liveChannel)
+		if err != nil {
+			logger.Warn("订阅实时事件失败: ", err)
+		} else {
+			closed := s.forward(sub)
+			sub.Close()
+			if closed {
+				return
+			}
+		}
+
+		select {
+		case <-s.done:
+			return
+		case <-time.After(liveRetryInterval):
+		}
+	}
+}
+
+// forward 转发订阅收到的事件，订阅断开时返回 false，服务关闭时返回 true
+func (s *LiveService) forward(sub cache.Subscription) bool {
+	messages := sub.Messages()
+	for {
+		select {
+		case <-s.done:
+			return true
+		case payload, ok := <-messages:
+			if !ok {
+				return false
+			}
+			var event liveEvent
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				continue
+			}
+			s.broadcast(liveMessage{name: event.Type, data: payload})
+		}
+	}
+}
+
+// sweep 定时清理过期的在线成员，清理时发布离开事件
+func (s *LiveService) sweep() {
+	ticker := time.NewTicker(liveSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := pruneOnline(cache.Default()); err != nil {
+				logger.Warn("清理在线成员失败: ", err)
+			}
+		}
+	}
+}
+
+// broadcast 转发事件给所有连接，连接接收太慢时丢弃
+func (s *LiveService) broadcast(msg liveMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.clients {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// addClient 注册 SSE 连接
+func (s *LiveService) addClient() chan liveMessage {
+	ch := make(chan liveMessage, liveClientBuffer)
+	s.mu.Lock()
+	s.clients[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+// removeClient 注销 SSE 连接
+func (s *LiveService) removeClient(ch chan liveMessage) {
+	s.mu.Lock()
+	delete(s.clients, ch)
+	s.mu.Unlock()
+}
+
+// StreamToken 获取实时推送令牌
+// @Summary 获取实时推送令牌
+// @Description 生成 1 分钟内有效的令牌，用于 EventSource 连接 /analytics/stream?token=...，令牌不能用于其它接口
+// @Tags 数据分析
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/stream/token [post]
+func (s *LiveService) StreamToken(c *gin.Context) {
+	token, expiresAt, err := jwt.GenerateStreamToken(c.GetUint("admin_id"), c.GetString("username"), c.GetString("role"), liveTokenTTL)
+	if err != nil {
+		response.ServerError(c, "生成令牌失败")
+		return
+	}
+	response.Success(c, gin.H{
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// Stream 实时数据推送
+// @Summary 实时数据推送
+// @Description Server-Sent Events 长连接。连接后先推送 snapshot（当前在线人数和本分钟访问量），
+// @Description 之后推送 join/leave/page（在线用户上线、离线、切换页面）和 visits（本分钟访问量）事件，
+// @Description 每 15 秒发送一次注释行保活。EventSource 无法设置请求头，可先调用 /analytics/stream/token 获取短期令牌，
+// @Description 通过 token 参数连接；也可以在请求头中携带 Authorization 并用 fetch 读取流。
+// @Tags 数据分析
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param token query string false "短期推送令牌"
+// @Success 200 {string} string "事件流"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /analytics/stream [get]
+func (s *LiveService) Stream(c *gin.Context) {
+	// 长连接不受服务器写超时限制
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("取消 SSE 写超时失败: ", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+
+	ch := s.addClient()
+	defer s.removeClient(ch)
+
+	// 先推送当前状态，之后只推送变化
+	now := time.Now()
+	users, guests, err := countOnline()
+	if err != nil {
+		logger.Warn("获取在线人数失败: ", err)
+	}
+	value, _ := cache.Default().Get(liveVisitsPrefix + now.Format("2006-01-02 15:04"))
+	visits, _ := strconv.ParseInt(value, 10, 64)
+	c.SSEvent("snapshot", gin.H{
+		"online_users":  users,
+		"online_guests": guests,
+		"minute":        now.Format("2006-01-02 15:04"),
+		"visits":        visits,
+		"at":            now,
+	})
+	c.Writer.Flush()
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-s.done:
+			return
+		case msg := <-ch:
+			c.SSEvent(msg.name, msg.data)
+		case <-ping.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
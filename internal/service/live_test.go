@@ -0,0 +1,101 @@
+package service
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+)
+
+func TestLiveServiceCloseEndsStreams(t *testing.T) {
+	useMemoryCache(t)
+	s := NewLiveService()
+	r := gin.New()
+	r.GET("/stream", s.Stream)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "event:snapshot") {
+		t.Fatalf("first line = %q, %v, want snapshot event", line, err)
+	}
+
+	s.Close()
+	s.Close() // 重复关闭不会 panic
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := reader.ReadString(0)
+		done <- err
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream still open after Close")
+	}
+}
+
+// trackedCache 记录订阅的创建和关闭
+type trackedCache struct {
+	cache.Cache
+	subscribed chan struct{}
+	closed     chan struct{}
+}
+
+func (c *trackedCache) Subscribe(channel string) (cache.Subscription, error) {
+	sub, err := c.Cache.Subscribe(channel)
+	if err != nil {
+		return nil, err
+	}
+	c.subscribed <- struct{}{}
+	return &trackedSubscription{Subscription: sub, closed: c.closed}, nil
+}
+
+type trackedSubscription struct {
+	cache.Subscription
+	closed chan struct{}
+}
+
+func (s *trackedSubscription) Close() error {
+	s.closed <- struct{}{}
+	return s.Subscription.Close()
+}
+
+func TestLiveServiceCloseStopsSubscription(t *testing.T) {
+	prev := cache.Default()
+	store := &trackedCache{Cache: cache.NewMemory(), subscribed: make(chan struct{}, 1), closed: make(chan struct{}, 1)}
+	cache.Use(store)
+	t.Cleanup(func() { cache.Use(prev) })
+
+	s := NewLiveService()
+	select {
+	case <-store.subscribed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("not subscribed")
+	}
+
+	// 关闭服务后等待消息的订阅被取消，不会再重新订阅
+	s.Close()
+	select {
+	case <-store.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription still open after Close")
+	}
+	select {
+	case <-store.subscribed:
+		t.Error("resubscribed after Close")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
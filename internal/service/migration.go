@@ -0,0 +1,168 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
+	"github.com/ydcloud-dy/leaf-api/internal/model/dto"
+	"github.com/ydcloud-dy/leaf-api/pkg/migrate"
+	"github.com/ydcloud-dy/leaf-api/pkg/response"
+)
+
+// MigrationService 博客迁移服务
+type MigrationService struct {
+	migrationUseCase biz.MigrationUseCase
+}
+
+// NewMigrationService 创建博客迁移服务
+func NewMigrationService(migrationUseCase biz.MigrationUseCase) *MigrationService {
+	return &MigrationService{
+		migrationUseCase: migrationUseCase,
+	}
+}
+
+// ImportWordPress 导入 WordPress 导出文件
+// @Summary 迁移WordPress站点
+// @Description 导入 WordPress 导出的 WXR 文件：文章（HTML 转为 Markdown）、分类、标签、评论（保留回复关系）、作者和附件。
+// @Description 已迁移的内容通过映射表识别，重复执行不会产生重复数据；dry_run=true 时只生成报告。任务在后台执行，通过 /migrations/{id} 查询报告
+// @Tags 博客迁移
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "WXR 文件（WordPress 后台 工具 -> 导出）"
+// @Param dry_run formData bool false "是否试运行"
+// @Success 200 {object} response.Response{data=dto.MigrationRunResponse} "任务已提交"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Router /migrations/wordpress [post]
+func (s *MigrationService) ImportWordPress(c *gin.Context) {
+	s.submit(c, func(data []byte) (*migrate.Site, error) {
+		return migrate.ParseWXR(bytes.NewReader(data))
+	})
+}
+
+// ImportHexo 导入 Hexo 站点
+// @Summary 迁移Hexo站点
+// @Description 导入 Hexo 站点的 zip 压缩包（包含 source/_posts，或直接是文章目录），文章的 Front Matter 映射为分类、标签和发布时间。
+// @Description 已迁移的文章通过映射表识别，重复执行不会产生重复数据；dry_run=true 时只生成报告。
+// @Description 映射按站点区分：默认使用 _config.yml 中的 url，未配置站点地址时必须通过 site 指定站点标识
+// @Tags 博客迁移
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Hexo 站点 zip 压缩包"
+// @Param site formData string false "站点地址或标识，覆盖 _config.yml 中的 url"
+// @Param dry_run formData bool false "是否试运行"
+// @Success 200 {object} response.Response{data=dto.MigrationRunResponse} "任务已提交"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权限"
+// @Router /migrations/hexo [post]
+func (s *MigrationService) ImportHexo(c *gin.Context) {
+	s.submit(c, func(data []byte) (*migrate.Site, error) {
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("解析压缩包失败: %w", err)
+		}
+		site, err := migrate.LoadHexo(reader, config.AppConfig.Import.MaxBundleSize*1024*1024)
+		if err != nil {
+			return nil, err
+		}
+		if key := strings.TrimSpace(c.PostForm("site")); key != "" {
+			site.Source = migrate.HexoSource(key)
+		}
+		if site.Source == "" {
+			return nil, errors.New("_config.yml 中没有配置站点地址，请通过 site 参数指定站点标识")
+		}
+		return site, nil
+	})
+}
+
+// GetRun 查询迁移任务
+// @Summary 查询迁移任务
+// @Description 查询迁移任务状态及报告（新建、已存在、失败的数量，跳过的内容和错误）
+// @Tags 博客迁移
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response{data=dto.MigrationRunResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /migrations/{id} [get]
+func (s *MigrationService) GetRun(c *gin.Context) {
+	var req dto.IDRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	run, err := s.migrationUseCase.GetRun(req.ID)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, run)
+}
+
+// submit 读取上传的文件，解析后提交迁移任务
+func (s *MigrationService) submit(c *gin.Context, parse func(data []byte) (*migrate.Site, error)) {
+	adminID, exists := c.Get("admin_id")
+	if !exists {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+	if !isAdminRole(c.GetString("role")) {
+		response.Forbidden(c, "只有管理员可以迁移站点")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "请上传文件")
+		return
+	}
+	if file.Size > config.AppConfig.Import.MaxBundleSize*1024*1024 {
+		response.BadRequest(c, fmt.Sprintf("文件大小超过限制: 最大 %dMB", config.AppConfig.Import.MaxBundleSize))
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		response.BadRequest(c, "打开文件失败")
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		response.BadRequest(c, "读取文件失败")
+		return
+	}
+
+	site, err := parse(data)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	run, err := s.migrationUseCase.Submit(site, biz.MigrationOptions{
+		DryRun:   c.PostForm("dry_run") == "true" || c.PostForm("dry_run") == "1",
+		AuthorID: adminID.(uint),
+	})
+	if err != nil {
+		response.ServerError(c, err.Error())
+		return
+	}
+
+	response.Success(c, run)
+}
@@ -3,20 +3,33 @@ package service
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
 	"github.com/ydcloud-dy/leaf-api/internal/data"
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
-	"github.com/ydcloud-dy/leaf-api/pkg/redis"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/geoip"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+	"github.com/ydcloud-dy/leaf-api/pkg/privacy"
+	"github.com/ydcloud-dy/leaf-api/pkg/referrer"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
+	"github.com/ydcloud-dy/leaf-api/pkg/useragent"
 )
 
 const (
-	// 在线用户 Redis Key 前缀
+	// 在线用户 Redis Key 前缀（Hash，保存最近一次心跳的详细信息）
 	onlineUserPrefix = "online:user:"
-	// 在线游客 Redis Key 前缀
+	// 在线游客 Redis Key 前缀（Hash，保存最近一次心跳的详细信息）
 	onlineGuestPrefix = "online:guest:"
+	// 在线用户集合（Sorted Set，成员为用户ID，分数为最后活跃时间戳）
+	onlineUsersKey = "online:users"
+	// 在线游客集合（Sorted Set，成员为按周期更换的 IP+UA 哈希，分数为最后活跃时间戳）
+	onlineGuestsKey = "online:guests"
 	// 在线用户过期时间（60秒）
 	onlineUserExpire = 60 * time.Second
 )
@@ -35,7 +48,7 @@ func NewOnlineService(d *data.Data) *OnlineService {
 
 // RecordHeartbeat 记录用户心跳（保持在线状态）
 // @Summary 记录用户心跳
-// @Description 记录用户在线状态，登录用户按UserID追踪，未登录按IP追踪
+// @Description 记录用户在线状态，登录用户按UserID追踪，未登录按加盐的 IP+UA 哈希追踪，盐按周期更换
 // @Tags 在线追踪
 // @Accept json
 // @Produce json
@@ -50,70 +63,178 @@ func (s *OnlineService) RecordHeartbeat(c *gin.Context) {
 	}
 	c.ShouldBindJSON(&req)
 
+	// 爬虫和拒绝追踪（DNT/GPC）的请求不计入在线人数
+	userAgent := c.GetHeader("User-Agent")
+	client := useragent.Parse(userAgent)
+	if client.IsBot || privacy.OptedOut(c.Request.Header) {
+		response.Success(c, gin.H{"status": "ignored"})
+		return
+	}
+
 	// 获取用户ID（如果已登录）
 	userIDValue, exists := c.Get("user_id")
-	ip := c.ClientIP()
+	// 归属地按原始 IP 解析，保存的是按隐私策略处理后的 IP
+	rawIP := c.ClientIP()
+	ip := privacy.IP(rawIP)
 
-	var key string
-	var userID uint = 0
+	var setKey, member, hashPrefix, kind string
 	if exists && userIDValue != nil {
-		userID = userIDValue.(uint)
-		key = fmt.Sprintf("%s%d", onlineUserPrefix, userID)
+		setKey, member, hashPrefix, kind = onlineUsersKey, strconv.FormatUint(uint64(userIDValue.(uint)), 10), onlineUserPrefix, "user"
 	} else {
-		// 未登录用户，使用 IP 作为标识
-		key = fmt.Sprintf("%s%s", onlineGuestPrefix, ip)
+		// 未登录用户按原始 IP+UA 的哈希识别，截断 IP 时同一网段的游客不会合并
+		setKey, member, hashPrefix, kind = onlineGuestsKey, privacy.Visitor(rawIP, userAgent), onlineGuestPrefix, "guest"
 	}
 
-	// 使用 Redis Hash 存储详细信息
-	client := redis.GetClient()
-	ctx := redis.GetContext()
-
-	// 存储在线信息
-	fields := map[string]interface{}{
-		"last_active_at": time.Now().Unix(),
+	now := time.Now()
+	fields := map[string]string{
+		"last_active_at": strconv.FormatInt(now.Unix(), 10),
 		"ip":             ip,
 		"path":           req.Path,
-		"user_agent":     c.GetHeader("User-Agent"),
+		"user_agent":     privacy.UserAgent(userAgent),
+		"browser":        strings.TrimSpace(client.Browser + " " + client.BrowserVersion),
+		"os":             strings.TrimSpace(client.OS + " " + client.OSVersion),
+		"device":         client.Device,
+		"location":       geoip.Lookup(rawIP).String(),
+	}
+
+	// 与上一次心跳比较，判断是上线还是切换页面
+	store := cache.Default()
+	prev, err := store.HGetAll(hashPrefix + member)
+	if err != nil {
+		response.Error(c, 500, "记录在线状态失败: "+err.Error())
+		return
 	}
 
-	err := client.HSet(ctx, key, fields).Err()
+	// 详细信息写入带过期时间的 Hash，活跃时间写入 Sorted Set
+	err = store.HSet(hashPrefix+member, fields, onlineUserExpire)
+	if err == nil {
+		err = store.ZAdd(setKey, cache.ZMember{Member: member, Score: float64(now.Unix())})
+	}
 	if err != nil {
 		response.Error(c, 500, "记录在线状态失败: "+err.Error())
 		return
 	}
 
-	// 设置过期时间
-	client.Expire(ctx, key, onlineUserExpire)
+	event := liveEvent{
+		Kind:     kind,
+		Member:   member,
+		Username: c.GetString("username"),
+		Path:     req.Path,
+		Device:   client.Device,
+		Location: fields["location"],
+		At:       now,
+	}
+	if len(prev) == 0 {
+		event.Type = LiveEventJoin
+		publishLiveEvent(event)
+	} else if prev["path"] != req.Path {
+		event.Type, event.PrevPath = LiveEventPage, prev["path"]
+		publishLiveEvent(event)
+	}
 
 	response.Success(c, gin.H{"status": "ok"})
 }
 
 // GetOnlineCount 获取在线人数
 func (s *OnlineService) GetOnlineCount() (int64, error) {
-	// 查询所有在线用户键
-	userKeys, err := redis.Keys(onlineUserPrefix + "*")
+	users, guests, err := countOnline()
+	return users + guests, err
+}
+
+// presence 在线记录
+type presence struct {
+	Member       string            // 用户ID或IP
+	LastActiveAt time.Time         // 最后活跃时间
+	Fields       map[string]string // 最近一次心跳的详细信息
+}
+
+// pruneOnline 按分数移除超过在线过期时间未活跃的成员，并发布离开事件
+func pruneOnline(store cache.Cache) error {
+	max := float64(time.Now().Add(-onlineUserExpire).Unix() - 1)
+	sets := []struct{ key, kind string }{{onlineUsersKey, "user"}, {onlineGuestsKey, "guest"}}
+	for _, set := range sets {
+		expired, err := store.ZRangeByScore(set.key, math.Inf(-1), max)
+		if err != nil {
+			return err
+		}
+		for _, m := range expired {
+			// 多个实例同时清理时，只有实际移除成员的实例发布离开事件
+			removed, err := store.ZRem(set.key, m.Member)
+			if err != nil {
+				return err
+			}
+			if removed > 0 {
+				publishLiveEvent(liveEvent{Type: LiveEventLeave, Kind: set.kind, Member: m.Member, At: time.Unix(int64(m.Score), 0)})
+			}
+		}
+	}
+	return nil
+}
+
+// countOnline 统计在线用户数和游客数
+func countOnline() (users, guests int64, err error) {
+	store := cache.Default()
+	if err := pruneOnline(store); err != nil {
+		return 0, 0, err
+	}
+	if users, err = store.ZCard(onlineUsersKey); err != nil {
+		return 0, 0, err
+	}
+	if guests, err = store.ZCard(onlineGuestsKey); err != nil {
+		return 0, 0, err
+	}
+	return users, guests, nil
+}
+
+// listOnline 按最后活跃时间倒序列出从 offset 开始的 limit 个在线成员，详细信息批量读取
+func listOnline(setKey, hashPrefix string, offset, limit int64) ([]presence, error) {
+	store := cache.Default()
+	if err := pruneOnline(store); err != nil {
+		return nil, err
+	}
+	members, err := store.ZRevRange(setKey, offset, offset+limit-1)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if len(members) == 0 {
+		return []presence{}, nil
 	}
 
-	// 查询所有在线游客键
-	guestKeys, err := redis.Keys(onlineGuestPrefix + "*")
+	keys := make([]string, len(members))
+	for i, m := range members {
+		keys[i] = hashPrefix + m.Member
+	}
+	details, err := store.HGetAllMulti(keys)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return int64(len(userKeys) + len(guestKeys)), nil
+	result := make([]presence, 0, len(members))
+	for i, m := range members {
+		// Hash 已过期说明成员刚好失效，跳过
+		if len(details[i]) == 0 {
+			continue
+		}
+		result = append(result, presence{
+			Member:       m.Member,
+			LastActiveAt: time.Unix(int64(m.Score), 0),
+			Fields:       details[i],
+		})
+	}
+	return result, nil
 }
 
 // VisitService 页面访问时长记录服务
 type VisitService struct {
-	data *data.Data
+	data             *data.Data
+	analyticsUseCase biz.AnalyticsUseCase
 }
 
 // NewVisitService 创建访问时长记录服务
-func NewVisitService(d *data.Data) *VisitService {
+func NewVisitService(d *data.Data, analyticsUseCase biz.AnalyticsUseCase) *VisitService {
 	return &VisitService{
-		data: d,
+		data:             d,
+		analyticsUseCase: analyticsUseCase,
 	}
 }
 
@@ -123,15 +244,18 @@ func NewVisitService(d *data.Data) *VisitService {
 // @Tags 在线追踪
 // @Accept json
 // @Produce json
-// @Param request body object{path=string,duration=int} true "访问信息 path:页面路径 duration:停留时长(秒)"
+// @Param request body object{path=string,duration=int,article_id=uint,scroll_percent=int} true "访问信息 path:页面路径 duration:停留时长(秒) article_id:文章ID(文章页) scroll_percent:滚动百分比(0-100)"
 // @Success 200 {object} response.Response "记录成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 500 {object} response.Response "服务器错误"
 // @Router /blog/visit [post]
 func (s *VisitService) RecordVisitDuration(c *gin.Context) {
 	var req struct {
-		Path     string `json:"path"`
-		Duration int    `json:"duration"` // 秒，0表示刚进入页面
+		Path          string `json:"path"`
+		Duration      int    `json:"duration"`       // 秒，0表示刚进入页面
+		ArticleID     uint   `json:"article_id"`     // 文章页上报，用于文章统计和记录阅读进度
+		ScrollPercent *int   `json:"scroll_percent"` // 文章页滚动百分比 0-100
+		Referrer      string `json:"referrer"`       // 页面的 document.referrer，为空时使用请求头 Referer
 	}
 
 	// 兼容不同的 Content-Type (支持 sendBeacon 发送的 text/plain 等)
@@ -175,36 +299,195 @@ func (s *VisitService) RecordVisitDuration(c *gin.Context) {
 		userID = &uid
 	}
 
-	// 创建访问记录
+	// 信标请求的 Referer 是发出请求的页面本身，真正的来源由前端通过 referrer 字段上报
+	pageURL := c.GetHeader("Referer")
+	ref := req.Referrer
+	if ref == "" {
+		ref = pageURL
+	}
+	source := referrer.Parse(ref, referrer.Host(pageURL), referrer.Host("http://"+c.Request.Host))
+	// UTM 参数优先从上报的路径中取，没有时从页面地址中取
+	utm := referrer.ParseUTM(req.Path)
+	if utm.Source == "" && utm.Campaign == "" {
+		utm = referrer.ParseUTM(pageURL)
+	}
+
+	// 没有上报文章ID时按文章页路径规则解析，滚动深度限制在 0-100
+	if req.ArticleID == 0 {
+		req.ArticleID = s.analyticsUseCase.ArticleIDFromPath(req.Path)
+	}
+	if req.ScrollPercent != nil {
+		scroll := min(max(*req.ScrollPercent, 0), 100)
+		req.ScrollPercent = &scroll
+	}
+
+	// 拒绝追踪（DNT/GPC）时不记录访问，阅读进度是用户自己的功能数据，照常记录
+	if privacy.OptedOut(c.Request.Header) {
+		s.recordReadingProgress(userID, req.ArticleID, req.ScrollPercent)
+		response.Success(c, gin.H{"status": "ignored"})
+		return
+	}
+
+	ip, userAgent := c.ClientIP(), c.GetHeader("User-Agent")
+	client := useragent.Parse(userAgent)
+	// 爬虫抓取文章已在文章详情接口中记录，执行脚本的爬虫再上报时不重复记录
+	if client.IsBot && req.ArticleID != 0 {
+		response.Success(c, gin.H{"status": "ignored"})
+		return
+	}
+	loc := geoip.Lookup(ip)
+
+	// 创建访问记录，爬虫的访问也记录下来用于爬虫报表，但不计入 PV/UV；IP 和 UA 按隐私策略保存
 	visit := &po.PageVisit{
-		UserID:    userID,
-		IP:        c.ClientIP(),
-		Path:      req.Path,
-		Duration:  req.Duration,
-		UserAgent: c.GetHeader("User-Agent"),
-		Referrer:  c.GetHeader("Referer"),
-		CreatedAt: time.Now(),
+		UserID:        userID,
+		IP:            privacy.IP(ip),
+		Visitor:       privacy.Visitor(ip, userAgent),
+		Path:          req.Path,
+		Duration:      req.Duration,
+		UserAgent:     privacy.UserAgent(userAgent),
+		Referrer:      ref,
+		CreatedAt:     time.Now(),
+		RefDomain:     source.Domain,
+		RefCategory:   source.Category,
+		SearchEngine:  source.Engine,
+		SearchTerm:    source.SearchTerm,
+		UTMSource:     utm.Source,
+		UTMMedium:     utm.Medium,
+		UTMCampaign:   utm.Campaign,
+		Browser:       client.Browser,
+		OS:            client.OS,
+		Device:        client.Device,
+		IsBot:         client.IsBot,
+		BotName:       client.BotName,
+		BotCategory:   client.BotCategory,
+		Country:       loc.Country,
+		Province:      loc.Province,
+		City:          loc.City,
+		ArticleID:     req.ArticleID,
+		ScrollPercent: req.ScrollPercent,
 	}
 
 	if err := s.data.GetDB().Create(visit).Error; err != nil {
 		response.Error(c, 500, "记录访问时长失败")
 		return
 	}
+	if !visit.IsBot {
+		recordLiveVisit(visit.CreatedAt)
+	}
+
+	// 记录独立访客
+	uid := uint(0)
+	if userID != nil {
+		uid = *userID
+	}
+	if err := s.analyticsUseCase.RecordVisitor(uid, ip, userAgent, req.ArticleID); err != nil {
+		logger.Warn("记录独立访客失败: ", err)
+	}
+
+	s.recordReadingProgress(userID, req.ArticleID, req.ScrollPercent)
 
 	response.Success(c, gin.H{"status": "ok"})
 }
 
+// recordReadingProgress 登录用户在文章页上报时记录阅读进度
+func (s *VisitService) recordReadingProgress(userID *uint, articleID uint, scroll *int) {
+	if userID == nil || articleID == 0 {
+		return
+	}
+	scrollPercent := 0
+	if scroll != nil {
+		scrollPercent = *scroll
+	}
+	if err := s.data.ReadingProgressRepo.Record(*userID, articleID, scrollPercent); err != nil {
+		logger.Warn("记录阅读进度失败: ", err)
+	}
+}
+
+// ExportTrackingData 导出当前用户的追踪数据
+// @Summary 导出我的追踪数据
+// @Description 以 JSON 文件下载当前用户的页面访问记录、文章浏览记录、前台登录记录和当前在线状态
+// @Tags 在线追踪
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.TrackingExport "追踪数据文件"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /blog/user/tracking [get]
+func (s *VisitService) ExportTrackingData(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	export, err := s.analyticsUseCase.ExportUserData(userID)
+	if err != nil {
+		response.ServerError(c, err.Error())
+		return
+	}
+	presence, err := cache.Default().HGetAll(onlineUserPrefix + strconv.FormatUint(uint64(userID), 10))
+	if err != nil {
+		response.ServerError(c, "查询在线状态失败")
+		return
+	}
+	if len(presence) > 0 {
+		export.Presence = presence
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		response.ServerError(c, "导出追踪数据失败")
+		return
+	}
+	filename := fmt.Sprintf("tracking-%d-%s.json", userID, export.ExportedAt.Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(200, "application/json; charset=utf-8", data)
+}
+
+// DeleteTrackingData 删除当前用户的追踪数据
+// @Summary 删除我的追踪数据
+// @Description 删除当前用户的页面访问记录、文章浏览记录、前台登录记录，并清除在线状态。
+// @Description 已汇总的统计数据不含个人信息，不受影响
+// @Tags 在线追踪
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.TrackingDeleteResult} "删除成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器错误"
+// @Router /blog/user/tracking [delete]
+func (s *VisitService) DeleteTrackingData(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	result, err := s.analyticsUseCase.DeleteUserData(userID)
+	if err != nil {
+		response.ServerError(c, err.Error())
+		return
+	}
+
+	// 清除在线状态，实际移除了成员时发布离开事件
+	store := cache.Default()
+	member := strconv.FormatUint(uint64(userID), 10)
+	if err := store.Del(onlineUserPrefix + member); err != nil {
+		response.ServerError(c, "清除在线状态失败")
+		return
+	}
+	removed, err := store.ZRem(onlineUsersKey, member)
+	if err != nil {
+		response.ServerError(c, "清除在线状态失败")
+		return
+	}
+	if removed > 0 {
+		result.Presence = true
+		publishLiveEvent(liveEvent{Type: LiveEventLeave, Kind: "user", Member: member, At: time.Now()})
+	}
+
+	response.Success(c, result)
+}
+
 // GetAverageVisitDuration 获取平均访问时长（秒）
-// 只统计 duration > 0 的记录（排除刚进入页面的记录）
+// 从小时汇总表统计，只计算 duration > 0 的记录（排除刚进入页面的记录）
 func (s *VisitService) GetAverageVisitDuration() (float64, error) {
-	var avgDuration float64
-	err := s.data.GetDB().Model(&po.PageVisit{}).
-		Select("COALESCE(AVG(duration), 0)").
-		Where("created_at >= ? AND duration > 0", time.Now().Add(-24*time.Hour)).
-		Row().
-		Scan(&avgDuration)
-
-	return avgDuration, err
+	stat, err := s.data.AnalyticsRepo.SumHourlyTotal(last24Hours())
+	if err != nil {
+		return 0, err
+	}
+	return stat.AvgDuration(), nil
 }
 
 // GetAverageVisitDurationByPath 按页面路径获取平均访问时长
@@ -219,12 +502,17 @@ func (s *VisitService) GetAverageVisitDurationByPath(path string) (float64, erro
 	return avgDuration, err
 }
 
-// Get24HourPageViews 获取最近24小时的页面访问量（PV）
+// Get24HourPageViews 获取最近24小时的页面访问量（PV），从小时汇总表统计
 func (s *VisitService) Get24HourPageViews() (int64, error) {
-	var count int64
-	err := s.data.GetDB().Model(&po.PageVisit{}).
-		Where("created_at >= ?", time.Now().Add(-24*time.Hour)).
-		Count(&count).Error
+	stat, err := s.data.AnalyticsRepo.SumHourlyTotal(last24Hours())
+	if err != nil {
+		return 0, err
+	}
+	return stat.PV, nil
+}
 
-	return count, err
+// last24Hours 最近24个小时汇总时间段中最早的一个（含当前小时）
+func last24Hours() time.Time {
+	t := time.Now().Add(-23 * time.Hour)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
 }
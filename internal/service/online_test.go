@@ -0,0 +1,285 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/privacy"
+)
+
+// benchmarkVisitors 基准测试中同时在线的游客数
+const benchmarkVisitors = 10000
+
+// chromeUA 测试用的浏览器 UA
+const chromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// heartbeat 以游客 ip 发送一次心跳，userID 不为 0 时作为登录用户
+func heartbeat(s *OnlineService, ip string, userID uint, path string) *httptest.ResponseRecorder {
+	return heartbeatWithHeaders(s, ip, userID, path, map[string]string{"User-Agent": chromeUA})
+}
+
+// heartbeatWithHeaders 带指定请求头发送一次心跳
+func heartbeatWithHeaders(s *OnlineService, ip string, userID uint, path string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/blog/heartbeat", strings.NewReader(`{"path":"`+path+`"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		c.Request.Header.Set(k, v)
+	}
+	c.Request.RemoteAddr = ip + ":12345"
+	if userID > 0 {
+		c.Set("user_id", userID)
+	}
+	s.RecordHeartbeat(c)
+	return w
+}
+
+// visitorIP 第 i 个游客的 IP
+func visitorIP(i int) string {
+	return fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff)
+}
+
+// useMemoryCache 测试期间使用新的进程内缓存
+func useMemoryCache(tb testing.TB) cache.Cache {
+	tb.Helper()
+	prev := cache.Default()
+	store := cache.NewMemory()
+	cache.Use(store)
+	tb.Cleanup(func() { cache.Use(prev) })
+	return store
+}
+
+func BenchmarkRecordHeartbeat(b *testing.B) {
+	useMemoryCache(b)
+	s := NewOnlineService(nil)
+	for i := 0; i < benchmarkVisitors; i++ {
+		heartbeat(s, visitorIP(i), 0, "/")
+	}
+
+	var next atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := int(next.Add(1)) % benchmarkVisitors
+			heartbeat(s, visitorIP(i), 0, "/articles/1")
+		}
+	})
+}
+
+func BenchmarkListOnline(b *testing.B) {
+	store := useMemoryCache(b)
+	now := float64(time.Now().Unix())
+	for i := 0; i < benchmarkVisitors; i++ {
+		ip := visitorIP(i)
+		store.HSet(onlineGuestPrefix+ip, map[string]string{"ip": ip, "path": "/"}, time.Hour)
+		store.ZAdd(onlineGuestsKey, cache.ZMember{Member: ip, Score: now})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list, err := listOnline(onlineGuestsKey, onlineGuestPrefix, 0, 50)
+		if err != nil || len(list) != 50 {
+			b.Fatalf("list = %d, err = %v", len(list), err)
+		}
+	}
+}
+
+// nextEvent 等待下一条实时事件
+func nextEvent(t *testing.T, sub cache.Subscription) liveEvent {
+	t.Helper()
+	select {
+	case payload := <-sub.Messages():
+		var event liveEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			t.Fatal(err)
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no live event")
+		return liveEvent{}
+	}
+}
+
+func TestRecordHeartbeat(t *testing.T) {
+	tests := []struct {
+		name    string
+		ip      string
+		userID  uint
+		headers map[string]string
+		dnt     bool   // 是否遵守 DNT/GPC
+		status  string // 响应中的 status
+		users   int64
+		guests  int64
+	}{
+		{"guest", "203.0.113.1", 0, map[string]string{"User-Agent": chromeUA}, false, "ok", 0, 1},
+		{"user", "203.0.113.1", 7, map[string]string{"User-Agent": chromeUA}, false, "ok", 1, 0},
+		{"bot", "203.0.113.1", 0, map[string]string{"User-Agent": "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"}, false, "ignored", 0, 0},
+		{"dnt honored", "203.0.113.1", 0, map[string]string{"User-Agent": chromeUA, "DNT": "1"}, true, "ignored", 0, 0},
+		{"gpc honored", "203.0.113.1", 7, map[string]string{"User-Agent": chromeUA, "Sec-GPC": "1"}, true, "ignored", 0, 0},
+		{"dnt not honored", "203.0.113.1", 0, map[string]string{"User-Agent": chromeUA, "DNT": "1"}, false, "ok", 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useMemoryCache(t)
+			usePrivacy(t, privacy.ModeFull, tt.dnt)
+			s := NewOnlineService(nil)
+
+			w := heartbeatWithHeaders(s, tt.ip, tt.userID, "/", tt.headers)
+			if !strings.Contains(w.Body.String(), `"status":"`+tt.status+`"`) {
+				t.Fatalf("response = %s, want status %s", w.Body.String(), tt.status)
+			}
+			users, _ := store.ZCard(onlineUsersKey)
+			guests, _ := store.ZCard(onlineGuestsKey)
+			if users != tt.users || guests != tt.guests {
+				t.Errorf("online = %d users, %d guests, want %d, %d", users, guests, tt.users, tt.guests)
+			}
+		})
+	}
+}
+
+func TestRecordHeartbeatEvents(t *testing.T) {
+	store := useMemoryCache(t)
+	usePrivacy(t, privacy.ModeFull, false)
+	sub, err := store.Subscribe(liveChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	s := NewOnlineService(nil)
+	guest := privacy.Visitor("203.0.113.1", chromeUA)
+
+	// 第一次心跳上线，切换页面发布 page 事件，同一页面的心跳不发布事件
+	heartbeat(s, "203.0.113.1", 0, "/")
+	if event := nextEvent(t, sub); event.Type != LiveEventJoin || event.Kind != "guest" || event.Member != guest {
+		t.Errorf("first event = %+v, want guest join", event)
+	}
+	heartbeat(s, "203.0.113.1", 0, "/")
+	heartbeat(s, "203.0.113.1", 0, "/articles/1")
+	if event := nextEvent(t, sub); event.Type != LiveEventPage || event.PrevPath != "/" || event.Path != "/articles/1" {
+		t.Errorf("second event = %+v, want page change", event)
+	}
+
+	fields, _ := store.HGetAll(onlineGuestPrefix + guest)
+	if fields["path"] != "/articles/1" || fields["ip"] != "203.0.113.1" || fields["browser"] == "" {
+		t.Errorf("presence fields = %v", fields)
+	}
+}
+
+func TestRecordHeartbeatTruncatedIP(t *testing.T) {
+	store := useMemoryCache(t)
+	usePrivacy(t, privacy.ModeTruncate, false)
+	s := NewOnlineService(nil)
+
+	// 同一网段的游客截断后 IP 相同，仍按各自的访客标识分别在线
+	heartbeat(s, "203.0.113.1", 0, "/")
+	heartbeat(s, "203.0.113.2", 0, "/")
+	heartbeat(s, "203.0.113.2", 0, "/")
+	if guests, _ := store.ZCard(onlineGuestsKey); guests != 2 {
+		t.Errorf("guests = %d, want 2", guests)
+	}
+	fields, _ := store.HGetAll(onlineGuestPrefix + privacy.Visitor("203.0.113.2", chromeUA))
+	if fields["ip"] != "203.0.113.0" {
+		t.Errorf("stored ip = %q, want truncated", fields["ip"])
+	}
+}
+
+func TestPruneOnline(t *testing.T) {
+	store := useMemoryCache(t)
+	sub, err := store.Subscribe(liveChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	now := time.Now()
+	stale := float64(now.Add(-2 * onlineUserExpire).Unix())
+	store.ZAdd(onlineUsersKey, cache.ZMember{Member: "1", Score: float64(now.Unix())}, cache.ZMember{Member: "2", Score: stale})
+	store.ZAdd(onlineGuestsKey, cache.ZMember{Member: "203.0.113.9", Score: stale})
+
+	if err := pruneOnline(store); err != nil {
+		t.Fatal(err)
+	}
+	users, guests, err := countOnline()
+	if err != nil || users != 1 || guests != 0 {
+		t.Fatalf("online = %d users, %d guests (%v), want 1, 0", users, guests, err)
+	}
+
+	left := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		event := nextEvent(t, sub)
+		if event.Type != LiveEventLeave {
+			t.Fatalf("event = %+v, want leave", event)
+		}
+		left[event.Kind+":"+event.Member] = true
+	}
+	if !left["user:2"] || !left["guest:203.0.113.9"] {
+		t.Errorf("leave events = %v", left)
+	}
+
+	// 再次清理没有成员被移除，不再发布事件
+	if err := pruneOnline(store); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case payload := <-sub.Messages():
+		t.Errorf("unexpected event %s", payload)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestListOnlinePages(t *testing.T) {
+	store := useMemoryCache(t)
+	now := time.Now().Unix()
+	for i := 0; i < 5; i++ {
+		ip := visitorIP(i)
+		store.HSet(onlineGuestPrefix+ip, map[string]string{"ip": ip}, time.Hour)
+		store.ZAdd(onlineGuestsKey, cache.ZMember{Member: ip, Score: float64(now - int64(i))})
+	}
+	// 集合中有成员但详细信息已过期的跳过
+	store.ZAdd(onlineGuestsKey, cache.ZMember{Member: "203.0.113.1", Score: float64(now - 2)})
+
+	tests := []struct {
+		offset, limit int64
+		want          []string
+	}{
+		{0, 2, []string{visitorIP(0), visitorIP(1)}},
+		{2, 2, []string{visitorIP(2)}}, // 同分数按成员倒序，203.0.113.1 排在前面被跳过
+		{4, 10, []string{visitorIP(3), visitorIP(4)}},
+		{10, 10, []string{}},
+	}
+	for _, tt := range tests {
+		list, err := listOnline(onlineGuestsKey, onlineGuestPrefix, tt.offset, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0, len(list))
+		for _, p := range list {
+			got = append(got, p.Member)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("listOnline(%d, %d) = %v, want %v", tt.offset, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// usePrivacy 测试期间使用指定的隐私策略
+func usePrivacy(tb testing.TB, mode string, honorDNT bool) {
+	tb.Helper()
+	prev := privacy.Default()
+	policy, err := privacy.New(mode, time.Hour, honorDNT)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	privacy.Use(policy)
+	tb.Cleanup(func() { privacy.Use(prev) })
+}
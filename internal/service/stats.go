@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/leaf-api/internal/biz"
 	"github.com/ydcloud-dy/leaf-api/internal/data"
 	"github.com/ydcloud-dy/leaf-api/internal/model/po"
 	"github.com/ydcloud-dy/leaf-api/pkg/response"
@@ -11,17 +12,19 @@ import (
 
 // StatsService 统计服务
 type StatsService struct {
-	data          *data.Data
-	onlineService *OnlineService
-	visitService  *VisitService
+	data             *data.Data
+	onlineService    *OnlineService
+	visitService     *VisitService
+	analyticsUseCase biz.AnalyticsUseCase
 }
 
 // NewStatsService 创建统计服务
-func NewStatsService(d *data.Data) *StatsService {
+func NewStatsService(d *data.Data, analyticsUseCase biz.AnalyticsUseCase) *StatsService {
 	return &StatsService{
-		data:          d,
-		onlineService: NewOnlineService(d),
-		visitService:  NewVisitService(d),
+		data:             d,
+		onlineService:    NewOnlineService(d),
+		visitService:     NewVisitService(d, analyticsUseCase),
+		analyticsUseCase: analyticsUseCase,
 	}
 }
 
@@ -44,6 +47,7 @@ func (s *StatsService) GetStats(c *gin.Context) {
 		CommentCount       int64   `json:"comment_count"`        // 评论总数
 		TotalViews         int64   `json:"total_views"`          // 总浏览量
 		TodayViews         int64   `json:"today_views"`          // 今日浏览量
+		TodayUV            int64   `json:"today_uv"`             // 今日独立访客数（估算）
 		OnlineCount        int64   `json:"online_count"`         // 当前在线人数
 		AvgVisitDuration   float64 `json:"avg_visit_duration"`   // 平均访问时长（秒）
 		SiteRuntime        int64   `json:"site_runtime"`         // 网站运行天数
@@ -70,10 +74,14 @@ func (s *StatsService) GetStats(c *gin.Context) {
 	// 统计总浏览量（所有文章的浏览量之和）
 	s.data.GetDB().Model(&po.Article{}).Select("COALESCE(SUM(view_count), 0)").Row().Scan(&stats.TotalViews)
 
-	// 统计24小时访问量（PV）- 从 page_visits 表统计
+	// 统计24小时访问量（PV）- 从小时汇总表统计
 	pv24h, _ := s.visitService.Get24HourPageViews()
 	stats.TodayViews = pv24h
 
+	// 今日独立访客数 - HyperLogLog 估算
+	todayUV, _ := s.analyticsUseCase.TodayUV()
+	stats.TodayUV = todayUV
+
 	// 获取在线人数
 	onlineCount, _ := s.onlineService.GetOnlineCount()
 	stats.OnlineCount = onlineCount
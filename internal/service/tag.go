@@ -97,3 +97,72 @@ func (s *TagService) Delete(c *gin.Context) {
 
 	response.Success(c, nil)
 }
+
+// Update 更新标签
+// @Summary 更新标签
+// @Description 修改标签名称、颜色或描述，未提供的字段保持不变
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "标签ID"
+// @Param request body dto.UpdateTagRequest true "标签信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /tags/{id} [put]
+func (s *TagService) Update(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req dto.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	tag, err := s.tagUseCase.Update(uri.ID, &req)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, tag)
+}
+
+// Merge 合并标签
+// @Summary 合并标签
+// @Description 将标签合并到目标标签：文章关联和章节移到目标标签，原标签名称保留为别名，按名称查询时仍解析到目标标签
+// @Tags 标签管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "被合并的标签ID"
+// @Param request body dto.MergeRequest true "目标标签"
+// @Success 200 {object} response.Response "合并成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /tags/{id}/merge [post]
+func (s *TagService) Merge(c *gin.Context) {
+	var uri dto.IDRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var req dto.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := s.tagUseCase.Merge(uri.ID, req.TargetID); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
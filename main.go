@@ -49,6 +49,28 @@ func main() {
 		os.Exit(0)
 	}
 
+	// 子命令
+	if flag.NArg() > 0 {
+		var err error
+		switch flag.Arg(0) {
+		case "migrate":
+			err = cmd.Migrate(configPath, flag.Args()[1:])
+		case "export":
+			err = cmd.Export(configPath, flag.Args()[1:])
+		case "import":
+			err = cmd.Import(configPath, flag.Args()[1:])
+		case "build-static":
+			err = cmd.BuildStatic(configPath, flag.Args()[1:])
+		default:
+			err = fmt.Errorf("unknown command: %s", flag.Arg(0))
+		}
+		if err != nil {
+			fmt.Printf("Failed to run %s: %v\n", flag.Arg(0), err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 运行应用
 	if err := cmd.Run(configPath); err != nil {
 		fmt.Printf("Failed to run application: %v\n", err)
@@ -0,0 +1,200 @@
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// Format 归档格式标识
+const Format = "leaf-api-backup"
+
+// Version 当前归档格式版本，格式不兼容的变更需要递增版本并在恢复时兼容旧版本
+const Version = 1
+
+// 归档中的文件
+const (
+	ManifestFile   = "manifest.json"
+	UsersFile      = "users.json"
+	CategoriesFile = "categories.json"
+	TagsFile       = "tags.json"
+	ChaptersFile   = "chapters.json"
+	ArticlesFile   = "articles.json"
+	CommentsFile   = "comments.json"
+	SettingsFile   = "settings.json"
+	FilesFile      = "files.json"
+	MediaDir       = "media"
+)
+
+// ErrUnsupportedVersion 归档版本不支持
+var ErrUnsupportedVersion = errors.New("不支持的备份格式版本")
+
+// ErrTooLarge 归档解压后超过大小限制
+var ErrTooLarge = errors.New("备份文件解压后超过大小限制")
+
+// Manifest 归档清单
+type Manifest struct {
+	Format           string         `json:"format"`
+	Version          int            `json:"version"`
+	CreatedAt        time.Time      `json:"created_at"`
+	IncludePasswords bool           `json:"include_passwords"`
+	Counts           map[string]int `json:"counts"`
+	Media            []MediaEntry   `json:"media"`
+}
+
+// MediaEntry 归档中的媒体文件
+type MediaEntry struct {
+	URL  string `json:"url"`  // 原站地址
+	Path string `json:"path"` // 归档内路径
+	Size int    `json:"size"`
+}
+
+// Writer 归档写入器
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+}
+
+// NewWriter 创建归档写入器
+func NewWriter(w io.Writer, includePasswords bool) *Writer {
+	return &Writer{
+		zw: zip.NewWriter(w),
+		manifest: Manifest{
+			Format:           Format,
+			Version:          Version,
+			CreatedAt:        time.Now(),
+			IncludePasswords: includePasswords,
+			Counts:           make(map[string]int),
+			Media:            []MediaEntry{},
+		},
+	}
+}
+
+// WriteEntities 写入一类实体
+func (w *Writer) WriteEntities(name string, count int, v interface{}) error {
+	if err := w.writeJSON(name, v); err != nil {
+		return err
+	}
+	w.manifest.Counts[name] = count
+	return nil
+}
+
+// WriteMedia 写入媒体文件，objectKey 为存储中的对象键
+func (w *Writer) WriteMedia(url, objectKey string, data []byte) error {
+	name := path.Join(MediaDir, objectKey)
+	f, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	w.manifest.Media = append(w.manifest.Media, MediaEntry{URL: url, Path: name, Size: len(data)})
+	return nil
+}
+
+// Close 写入清单并关闭归档
+func (w *Writer) Close() error {
+	if err := w.writeJSON(ManifestFile, w.manifest); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// writeJSON 写入 JSON 文件
+func (w *Writer) writeJSON(name string, v interface{}) error {
+	f, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Reader 归档读取器
+type Reader struct {
+	files    map[string]*zip.File
+	Manifest Manifest
+}
+
+// OpenReader 打开归档并校验格式和版本，maxSize 为解压后总大小上限（字节），0 表示不限制
+func OpenReader(r io.ReaderAt, size, maxSize int64) (*Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("解析备份文件失败: %w", err)
+	}
+
+	reader := &Reader{files: make(map[string]*zip.File)}
+	var total uint64
+	for _, f := range zr.File {
+		total += f.UncompressedSize64
+		if maxSize > 0 && total > uint64(maxSize) {
+			return nil, ErrTooLarge
+		}
+		reader.files[f.Name] = f
+	}
+
+	if err := reader.ReadJSON(ManifestFile, &reader.Manifest); err != nil {
+		return nil, err
+	}
+	if reader.Manifest.Format != Format {
+		return nil, errors.New("不是有效的备份文件")
+	}
+	if reader.Manifest.Version < 1 || reader.Manifest.Version > Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, reader.Manifest.Version)
+	}
+
+	return reader, nil
+}
+
+// ReadJSON 读取 JSON 文件，文件不存在时保持 v 不变
+func (r *Reader) ReadJSON(name string, v interface{}) error {
+	f, ok := r.files[name]
+	if !ok {
+		if name == ManifestFile {
+			return errors.New("备份文件缺少 manifest.json")
+		}
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(limitReader(rc, f)).Decode(v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// ReadMedia 读取媒体文件
+func (r *Reader) ReadMedia(entry MediaEntry) ([]byte, error) {
+	f, ok := r.files[entry.Path]
+	if !ok {
+		return nil, fmt.Errorf("媒体文件不存在: %s", entry.Path)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(limitReader(rc, f))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) > f.UncompressedSize64 {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// limitReader 按文件头声明的大小限制读取，防止伪造头部的压缩炸弹
+func limitReader(rc io.Reader, f *zip.File) io.Reader {
+	return io.LimitReader(rc, int64(f.UncompressedSize64)+1)
+}
@@ -0,0 +1,59 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestArchive 生成包含一个用户和一个媒体文件的归档
+func newTestArchive(t *testing.T, media []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, false)
+	if err := w.WriteEntities(UsersFile, 1, []UserRecord{{ID: 1, Username: "admin"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteMedia("/uploads/a.png", "a.png", media); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	data := newTestArchive(t, []byte("image"))
+
+	r, err := OpenReader(bytes.NewReader(data), int64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users []UserRecord
+	if err := r.ReadJSON(UsersFile, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Username != "admin" || r.Manifest.Counts[UsersFile] != 1 {
+		t.Errorf("users = %+v, counts = %v", users, r.Manifest.Counts)
+	}
+	if len(r.Manifest.Media) != 1 {
+		t.Fatalf("media = %+v", r.Manifest.Media)
+	}
+	content, err := r.ReadMedia(r.Manifest.Media[0])
+	if err != nil || string(content) != "image" {
+		t.Errorf("ReadMedia = %q, %v", content, err)
+	}
+}
+
+func TestOpenReaderSizeLimit(t *testing.T) {
+	data := newTestArchive(t, []byte(strings.Repeat("x", 10000)))
+
+	if _, err := OpenReader(bytes.NewReader(data), int64(len(data)), 5000); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+	if _, err := OpenReader(bytes.NewReader(data), int64(len(data)), 1<<20); err != nil {
+		t.Errorf("err = %v, want ok", err)
+	}
+}
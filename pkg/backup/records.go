@@ -0,0 +1,113 @@
+package backup
+
+import "time"
+
+// 归档中的实体记录与数据库模型解耦，字段变更时只需调整导出和恢复的转换逻辑
+
+// UserRecord 用户
+type UserRecord struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Password  string    `json:"password,omitempty"` // 仅在导出时指定包含密码才有值
+	Nickname  string    `json:"nickname"`
+	Avatar    string    `json:"avatar"`
+	Bio       string    `json:"bio"`
+	Skills    string    `json:"skills"`
+	Contacts  string    `json:"contacts"`
+	Role      string    `json:"role"`
+	IsBlogger bool      `json:"is_blogger"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CategoryRecord 分类
+type CategoryRecord struct {
+	ID          uint      `json:"id"`
+	ParentID    *uint     `json:"parent_id,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Color       string    `json:"color,omitempty"`
+	Sort        int       `json:"sort"`
+	Aliases     []string  `json:"aliases,omitempty"` // 合并留下的旧名称
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TagRecord 标签
+type TagRecord struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	Description string    `json:"description,omitempty"`
+	Aliases     []string  `json:"aliases,omitempty"` // 合并留下的旧名称
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ChapterRecord 章节
+type ChapterRecord struct {
+	ID        uint      `json:"id"`
+	TagID     uint      `json:"tag_id"`
+	ParentID  *uint     `json:"parent_id"`
+	Name      string    `json:"name"`
+	Sort      int       `json:"sort"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ArticleRecord 文章
+type ArticleRecord struct {
+	ID              uint      `json:"id"`
+	Title           string    `json:"title"`
+	Slug            string    `json:"slug"`
+	ContentMarkdown string    `json:"content_markdown"`
+	ContentHTML     string    `json:"content_html"`
+	Summary         string    `json:"summary"`
+	Cover           string    `json:"cover"`
+	AuthorID        uint      `json:"author_id"`
+	CategoryID      uint      `json:"category_id"`
+	ChapterID       *uint     `json:"chapter_id"`
+	SortInChapter   int       `json:"sort_in_chapter"`
+	TagIDs          []uint    `json:"tag_ids"`
+	Status          int       `json:"status"`
+	ViewCount       int       `json:"view_count"`
+	LikeCount       int       `json:"like_count"`
+	FavoriteCount   int       `json:"favorite_count"`
+	CommentCount    int       `json:"comment_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// CommentRecord 评论
+type CommentRecord struct {
+	ID            uint      `json:"id"`
+	ArticleID     *uint     `json:"article_id"`
+	UserID        uint      `json:"user_id"`
+	ParentID      *uint     `json:"parent_id"`
+	ReplyToUserID *uint     `json:"reply_to_user_id"`
+	Content       string    `json:"content"`
+	LikeCount     int       `json:"like_count"`
+	Status        int       `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SettingRecord 系统设置
+type SettingRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// FileRecord 上传文件记录
+type FileRecord struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Size      int64     `json:"size"`
+	Type      string    `json:"type"`
+	MimeType  string    `json:"mime_type"`
+	UserID    uint      `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
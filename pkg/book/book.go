@@ -0,0 +1,55 @@
+package book
+
+import (
+	"strconv"
+	"time"
+)
+
+// Book 电子书
+type Book struct {
+	ID       string // 唯一标识，如 urn:uuid:...
+	Title    string
+	Author   string
+	Language string
+	Modified time.Time
+	Cover    *Resource // 封面图片，为空时生成文字封面
+	Sections []Section
+}
+
+// Section 书中的一节，Children 为下级小节
+type Section struct {
+	Title    string
+	Content  string // HTML 片段，章节标题页为空
+	Children []Section
+}
+
+// Resource 书中引用的资源文件
+type Resource struct {
+	MediaType string
+	Data      []byte
+}
+
+// ImageFetcher 获取正文中引用的图片，返回错误时图片替换为替代文本
+type ImageFetcher func(src string) (*Resource, error)
+
+// flatSection 按阅读顺序展开的小节
+type flatSection struct {
+	*Section
+	id    string // 锚点或文件名，如 s-1-2
+	depth int
+}
+
+// flatten 按阅读顺序展开小节树
+func flatten(sections []Section) []flatSection {
+	var result []flatSection
+	var walk func(sections []Section, prefix string, depth int)
+	walk = func(sections []Section, prefix string, depth int) {
+		for i := range sections {
+			id := prefix + "-" + strconv.Itoa(i+1)
+			result = append(result, flatSection{Section: &sections[i], id: id, depth: depth})
+			walk(sections[i].Children, id, depth+1)
+		}
+	}
+	walk(sections, "s", 1)
+	return result
+}
@@ -0,0 +1,340 @@
+package book
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// imageExts 电子书支持的图片类型及扩展名
+var imageExts = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+}
+
+// epubCSS 电子书样式
+const epubCSS = `body { font-family: serif; line-height: 1.6; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; line-height: 1.3; }
+img { max-width: 100%; }
+pre { white-space: pre-wrap; word-wrap: break-word; font-size: 0.85em; background: #f6f8fa; padding: 0.6em; }
+code { font-family: monospace; }
+blockquote { margin-left: 0; padding-left: 1em; border-left: 3px solid #ccc; color: #555; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; }
+.cover { margin: 0; padding: 0; text-align: center; }
+.cover img { height: 100%; }
+`
+
+// epubWriter EPUB 写入过程的状态
+type epubWriter struct {
+	zw       *zip.Writer
+	book     *Book
+	fetch    ImageFetcher
+	images   map[string]string // 原地址 -> 书内路径，空字符串表示获取失败
+	manifest []string          // content.opf 中的 item
+}
+
+// WriteEPUB 将电子书写为 EPUB 3
+func WriteEPUB(w io.Writer, b *Book, fetch ImageFetcher) error {
+	ew := &epubWriter{
+		zw:     zip.NewWriter(w),
+		book:   b,
+		fetch:  fetch,
+		images: make(map[string]string),
+	}
+
+	// mimetype 必须是第一个文件且不压缩
+	mimetype, err := ew.zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	if err := ew.writeFile("META-INF/container.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`); err != nil {
+		return err
+	}
+
+	if err := ew.writeFile("OEBPS/style.css", epubCSS); err != nil {
+		return err
+	}
+	ew.addItem("css", "style.css", "text/css", "")
+
+	if err := ew.writeCover(); err != nil {
+		return err
+	}
+
+	sections := flatten(b.Sections)
+	spine := []string{`<itemref idref="cover"/>`, `<itemref idref="nav"/>`}
+	for _, s := range sections {
+		if err := ew.writeSection(s); err != nil {
+			return err
+		}
+		spine = append(spine, fmt.Sprintf(`<itemref idref="%s"/>`, s.id))
+	}
+
+	if err := ew.writeFile("OEBPS/nav.xhtml", ew.nav()); err != nil {
+		return err
+	}
+	ew.addItem("nav", "nav.xhtml", "application/xhtml+xml", "nav")
+	if err := ew.writeFile("OEBPS/toc.ncx", ew.ncx()); err != nil {
+		return err
+	}
+	ew.addItem("ncx", "toc.ncx", "application/x-dtbncx+xml", "")
+
+	if err := ew.writeFile("OEBPS/content.opf", ew.opf(spine)); err != nil {
+		return err
+	}
+
+	return ew.zw.Close()
+}
+
+// writeCover 写入封面图片和封面页，没有封面图片时生成文字封面
+func (ew *epubWriter) writeCover() error {
+	cover := ew.book.Cover
+	ext := ""
+	if cover != nil {
+		if cover.MediaType == "" {
+			cover.MediaType = detectImageType("", cover.Data)
+		}
+		ext = imageExts[cover.MediaType]
+	}
+	if ext == "" {
+		cover = &Resource{MediaType: "image/svg+xml", Data: []byte(textCover(ew.book.Title, ew.book.Author))}
+		ext = ".svg"
+	}
+
+	name := "images/cover" + ext
+	if err := ew.writeBytes("OEBPS/"+name, cover.Data); err != nil {
+		return err
+	}
+	ew.addItem("cover-image", name, cover.MediaType, "cover-image")
+
+	page := xhtmlPage(ew.book, "封面", "../style.css", fmt.Sprintf(`<div class="cover"><img src="../%s" alt="%s"/></div>`, name, html.EscapeString(ew.book.Title)))
+	if err := ew.writeFile("OEBPS/text/cover.xhtml", page); err != nil {
+		return err
+	}
+	ew.addItem("cover", "text/cover.xhtml", "application/xhtml+xml", "")
+	return nil
+}
+
+// writeSection 写入一节，正文中的图片一并打包
+func (ew *epubWriter) writeSection(s flatSection) error {
+	body, err := toXHTML(s.Content, ew.image)
+	if err != nil {
+		return fmt.Errorf("转换 %s 失败: %w", s.Title, err)
+	}
+
+	level := s.depth
+	if level > 6 {
+		level = 6
+	}
+	content := fmt.Sprintf(`<section epub:type="chapter"><h%d>%s</h%d>%s</section>`, level, html.EscapeString(s.Title), level, body)
+
+	name := "text/" + s.id + ".xhtml"
+	if err := ew.writeFile("OEBPS/"+name, xhtmlPage(ew.book, s.Title, "../style.css", content)); err != nil {
+		return err
+	}
+	ew.addItem(s.id, name, "application/xhtml+xml", "")
+	return nil
+}
+
+// image 获取并打包图片，返回相对于正文页的地址
+func (ew *epubWriter) image(src string) string {
+	if name, ok := ew.images[src]; ok {
+		if name == "" {
+			return ""
+		}
+		return "../" + name
+	}
+
+	ew.images[src] = ""
+	if ew.fetch == nil {
+		return ""
+	}
+	res, err := ew.fetch(src)
+	if err != nil || res == nil {
+		return ""
+	}
+	mediaType := res.MediaType
+	if mediaType == "" {
+		mediaType = detectImageType(src, res.Data)
+	}
+	ext, ok := imageExts[mediaType]
+	if !ok {
+		return ""
+	}
+
+	id := fmt.Sprintf("img-%d", len(ew.images))
+	name := "images/" + id + ext
+	if err := ew.writeBytes("OEBPS/"+name, res.Data); err != nil {
+		return ""
+	}
+	ew.addItem(id, name, mediaType, "")
+	ew.images[src] = name
+	return "../" + name
+}
+
+// nav 生成 EPUB 3 导航文档，目录按小节树嵌套
+func (ew *epubWriter) nav() string {
+	var sb strings.Builder
+	sb.WriteString(`<nav epub:type="toc" id="toc"><h1>目录</h1>`)
+	var walk func(sections []Section, prefix string)
+	walk = func(sections []Section, prefix string) {
+		sb.WriteString("<ol>")
+		for i := range sections {
+			id := fmt.Sprintf("%s-%d", prefix, i+1)
+			fmt.Fprintf(&sb, `<li><a href="text/%s.xhtml">%s</a>`, id, html.EscapeString(sections[i].Title))
+			if len(sections[i].Children) > 0 {
+				walk(sections[i].Children, id)
+			}
+			sb.WriteString("</li>")
+		}
+		sb.WriteString("</ol>")
+	}
+	walk(ew.book.Sections, "s")
+	sb.WriteString(`</nav>`)
+	return xhtmlPage(ew.book, "目录", "style.css", sb.String())
+}
+
+// ncx 生成 EPUB 2 目录，兼容旧阅读器
+func (ew *epubWriter) ncx() string {
+	var sb strings.Builder
+	order := 0
+	var walk func(sections []Section, prefix string)
+	walk = func(sections []Section, prefix string) {
+		for i := range sections {
+			id := fmt.Sprintf("%s-%d", prefix, i+1)
+			order++
+			fmt.Fprintf(&sb, `<navPoint id="nav-%s" playOrder="%d"><navLabel><text>%s</text></navLabel><content src="text/%s.xhtml"/>`,
+				id, order, html.EscapeString(sections[i].Title), id)
+			walk(sections[i].Children, id)
+			sb.WriteString("</navPoint>")
+		}
+	}
+	walk(ew.book.Sections, "s")
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head><meta name="dtb:uid" content="%s"/></head>
+<docTitle><text>%s</text></docTitle>
+<navMap>%s</navMap>
+</ncx>
+`, html.EscapeString(ew.book.ID), html.EscapeString(ew.book.Title), sb.String())
+}
+
+// opf 生成包文档
+func (ew *epubWriter) opf(spine []string) string {
+	modified := ew.book.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	language := ew.book.Language
+	if language == "" {
+		language = "zh-CN"
+	}
+	creator := ""
+	if ew.book.Author != "" {
+		creator = fmt.Sprintf("\n    <dc:creator>%s</dc:creator>", html.EscapeString(ew.book.Author))
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="%s">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:language>%s</dc:language>%s
+    <meta property="dcterms:modified">%s</meta>
+    <meta name="cover" content="cover-image"/>
+  </metadata>
+  <manifest>
+    %s
+  </manifest>
+  <spine toc="ncx">
+    %s
+  </spine>
+</package>
+`, language, html.EscapeString(ew.book.ID), html.EscapeString(ew.book.Title), language, creator,
+		modified.UTC().Format("2006-01-02T15:04:05Z"),
+		strings.Join(ew.manifest, "\n    "), strings.Join(spine, "\n    "))
+}
+
+// addItem 添加 manifest 条目
+func (ew *epubWriter) addItem(id, href, mediaType, properties string) {
+	item := fmt.Sprintf(`<item id="%s" href="%s" media-type="%s"`, id, href, mediaType)
+	if properties != "" {
+		item += fmt.Sprintf(` properties="%s"`, properties)
+	}
+	ew.manifest = append(ew.manifest, item+"/>")
+}
+
+// writeFile 写入文本文件
+func (ew *epubWriter) writeFile(name, content string) error {
+	return ew.writeBytes(name, []byte(content))
+}
+
+// writeBytes 写入文件
+func (ew *epubWriter) writeBytes(name string, data []byte) error {
+	f, err := ew.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// xhtmlPage 生成 XHTML 页面
+func xhtmlPage(b *Book, title, css, body string) string {
+	language := b.Language
+	if language == "" {
+		language = "zh-CN"
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%s" lang="%s">
+<head>
+<meta charset="UTF-8"/>
+<title>%s</title>
+<link rel="stylesheet" type="text/css" href="%s"/>
+</head>
+<body>
+%s
+</body>
+</html>
+`, language, language, html.EscapeString(title), css, body)
+}
+
+// textCover 生成文字封面
+func textCover(title, author string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="600" height="800" viewBox="0 0 600 800">
+  <rect width="600" height="800" fill="#1f2937"/>
+  <rect x="40" y="40" width="520" height="720" fill="none" stroke="#9ca3af" stroke-width="2"/>
+  <text x="300" y="360" fill="#ffffff" font-size="48" font-family="sans-serif" text-anchor="middle">%s</text>
+  <text x="300" y="440" fill="#d1d5db" font-size="24" font-family="sans-serif" text-anchor="middle">%s</text>
+</svg>
+`, html.EscapeString(title), html.EscapeString(author))
+}
+
+// detectImageType 识别图片类型
+func detectImageType(src string, data []byte) string {
+	if strings.EqualFold(path.Ext(strings.SplitN(src, "?", 2)[0]), ".svg") {
+		return "image/svg+xml"
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,111 @@
+package book
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+)
+
+// printTemplate 单页可打印 HTML
+var printTemplate = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 800px; margin: 0 auto; padding: 24px; font: 16px/1.75 serif; color: #222; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; line-height: 1.3; }
+img { max-width: 100%; }
+pre { white-space: pre-wrap; word-wrap: break-word; background: #f6f8fa; padding: 12px; font-size: 14px; }
+blockquote { margin-left: 0; padding-left: 1em; border-left: 3px solid #ccc; color: #555; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; }
+.title-page { text-align: center; padding: 30vh 0; }
+.toc ol { list-style: none; padding-left: 1.5em; }
+.toc > ol { padding-left: 0; }
+.toc a { color: inherit; text-decoration: none; }
+@media print {
+  body { max-width: none; padding: 0; }
+  .title-page, .toc { page-break-after: always; }
+  .section-1 { page-break-before: always; }
+  pre, blockquote, img, table { page-break-inside: avoid; }
+  a { color: inherit; text-decoration: none; }
+}
+</style>
+</head>
+<body>
+<div class="title-page">
+  <h1>{{.Title}}</h1>
+  {{if .Author}}<p>{{.Author}}</p>{{end}}
+</div>
+<nav class="toc">
+  <h2>目录</h2>
+  {{template "toc" .Sections}}
+</nav>
+{{range .Flat}}
+<section id="{{.ID}}" class="section-{{.Depth}}">
+  {{.Heading}}
+  {{.Content}}
+</section>
+{{end}}
+</body>
+</html>
+{{define "toc"}}<ol>{{range .}}<li><a href="#{{.ID}}">{{.Title}}</a>{{if .Children}}{{template "toc" .Children}}{{end}}</li>{{end}}</ol>{{end}}
+`))
+
+// printTOC 目录节点
+type printTOC struct {
+	ID       string
+	Title    string
+	Children []printTOC
+}
+
+// printSection 正文小节
+type printSection struct {
+	ID      string
+	Depth   int
+	Heading template.HTML
+	Content template.HTML
+}
+
+// WriteHTML 将电子书写为单页可打印 HTML，图片保留原地址
+func WriteHTML(w io.Writer, b *Book) error {
+	var toc func(sections []Section, prefix string) []printTOC
+	toc = func(sections []Section, prefix string) []printTOC {
+		result := make([]printTOC, 0, len(sections))
+		for i := range sections {
+			id := prefix + "-" + strconv.Itoa(i+1)
+			result = append(result, printTOC{ID: id, Title: sections[i].Title, Children: toc(sections[i].Children, id)})
+		}
+		return result
+	}
+
+	flat := flatten(b.Sections)
+	sections := make([]printSection, 0, len(flat))
+	for _, s := range flat {
+		level := s.depth
+		if level > 6 {
+			level = 6
+		}
+		heading := "<h" + strconv.Itoa(level) + ">" + template.HTMLEscapeString(s.Title) + "</h" + strconv.Itoa(level) + ">"
+		sections = append(sections, printSection{
+			ID:      s.id,
+			Depth:   s.depth,
+			Heading: template.HTML(heading),
+			Content: template.HTML(s.Content),
+		})
+	}
+
+	language := b.Language
+	if language == "" {
+		language = "zh-CN"
+	}
+
+	return printTemplate.Execute(w, map[string]interface{}{
+		"Language": language,
+		"Title":    b.Title,
+		"Author":   b.Author,
+		"Sections": toc(b.Sections, "s"),
+		"Flat":     sections,
+	})
+}
@@ -0,0 +1,117 @@
+package book
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// voidElements 空元素，XHTML 中需要自闭合
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// droppedElements 电子书中不支持的元素，连同内容一起丢弃
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"form": true, "noscript": true, "template": true,
+}
+
+// xmlNameRegex 合法的 XML 属性名
+var xmlNameRegex = regexp.MustCompile(`^[a-zA-Z_][-a-zA-Z0-9_.]*$`)
+
+// toXHTML 将 HTML 片段转为格式良好的 XHTML，image 返回图片的新地址，返回空字符串时图片替换为替代文本
+func toXHTML(fragment string, image func(src string) string) (string, error) {
+	context := &nethtml.Node{Type: nethtml.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := nethtml.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, n := range nodes {
+		writeXHTML(&sb, n, image)
+	}
+	return sb.String(), nil
+}
+
+// writeXHTML 输出节点
+func writeXHTML(sb *strings.Builder, n *nethtml.Node, image func(src string) string) {
+	switch n.Type {
+	case nethtml.TextNode:
+		sb.WriteString(html.EscapeString(n.Data))
+		return
+	case nethtml.ElementNode:
+	default:
+		// 注释、文档类型等直接忽略
+		return
+	}
+
+	name := strings.ToLower(n.Data)
+	if droppedElements[name] {
+		return
+	}
+
+	attrs := make([]nethtml.Attribute, 0, len(n.Attr))
+	seen := make(map[string]bool)
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !xmlNameRegex.MatchString(key) || strings.HasPrefix(key, "on") || seen[key] {
+			continue
+		}
+		seen[key] = true
+		attrs = append(attrs, nethtml.Attribute{Key: key, Val: attr.Val})
+	}
+
+	if name == "img" {
+		var src, alt string
+		for _, attr := range attrs {
+			switch attr.Key {
+			case "src":
+				src = attr.Val
+			case "alt":
+				alt = attr.Val
+			}
+		}
+		newSrc := ""
+		if src != "" {
+			newSrc = image(src)
+		}
+		if newSrc == "" {
+			sb.WriteString(html.EscapeString(alt))
+			return
+		}
+		kept := attrs[:0]
+		for _, attr := range attrs {
+			if attr.Key != "src" && attr.Key != "alt" && attr.Key != "srcset" && attr.Key != "loading" {
+				kept = append(kept, attr)
+			}
+		}
+		attrs = append(kept, nethtml.Attribute{Key: "src", Val: newSrc}, nethtml.Attribute{Key: "alt", Val: alt})
+	}
+
+	sb.WriteByte('<')
+	sb.WriteString(name)
+	for _, attr := range attrs {
+		sb.WriteByte(' ')
+		sb.WriteString(attr.Key)
+		sb.WriteString(`="`)
+		sb.WriteString(html.EscapeString(attr.Val))
+		sb.WriteByte('"')
+	}
+	if voidElements[name] {
+		sb.WriteString("/>")
+		return
+	}
+	sb.WriteByte('>')
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeXHTML(sb, c, image)
+	}
+	sb.WriteString("</")
+	sb.WriteString(name)
+	sb.WriteByte('>')
+}
@@ -0,0 +1,109 @@
+// Package cache 缓存抽象，提供键值、计数器、哈希、列表、有序集合、HyperLogLog、过期时间和发布订阅，
+// 有 Redis 和进程内两种实现，启动时按配置选择。
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound key 不存在
+var ErrNotFound = errors.New("cache: key not found")
+
+// 缓存驱动
+const (
+	DriverAuto   = "auto"   // Redis 可用时使用 Redis，否则使用进程内缓存
+	DriverRedis  = "redis"  // 只使用 Redis
+	DriverMemory = "memory" // 只使用进程内缓存，适合单节点部署
+)
+
+// ZMember 有序集合成员
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// Cache 缓存接口
+type Cache interface {
+	// Get 获取字符串值，不存在时返回 ErrNotFound
+	Get(key string) (string, error)
+	// Set 设置字符串值，ttl 为 0 表示不过期
+	Set(key, value string, ttl time.Duration) error
+	// SetNX key 不存在时设置，返回是否设置成功
+	SetNX(key, value string, ttl time.Duration) (bool, error)
+	// Del 删除 key
+	Del(keys ...string) error
+	// Exists 检查 key 是否存在
+	Exists(key string) (bool, error)
+	// Expire 设置 key 的过期时间
+	Expire(key string, ttl time.Duration) error
+
+	// IncrBy 计数器加 n，返回加后的值
+	IncrBy(key string, n int64) (int64, error)
+
+	// HSet 写入哈希字段，ttl 大于 0 时同时刷新整个哈希的过期时间
+	HSet(key string, fields map[string]string, ttl time.Duration) error
+	// HGetAll 获取哈希的所有字段，不存在时返回空 map
+	HGetAll(key string) (map[string]string, error)
+	// HGetAllMulti 批量获取多个哈希，结果与 keys 顺序一致
+	HGetAllMulti(keys []string) ([]map[string]string, error)
+	// HIncrBy 哈希字段加 n，返回加后的值
+	HIncrBy(key, field string, n int64) (int64, error)
+
+	// RPush 追加到列表末尾
+	RPush(key string, values ...string) error
+	// LPopN 从列表头部原子地取出最多 n 个元素，列表为空时返回空切片
+	LPopN(key string, n int64) ([]string, error)
+
+	// ZAdd 添加或更新有序集合成员的分数
+	ZAdd(key string, members ...ZMember) error
+	// ZRemRangeByScore 移除分数在 [min, max] 内的成员
+	ZRemRangeByScore(key string, min, max float64) error
+	// ZCard 有序集合成员数
+	ZCard(key string) (int64, error)
+	// ZRevRange 按分数从高到低返回下标 [start, stop] 的成员，stop 为 -1 表示到最后
+	ZRevRange(key string, start, stop int64) ([]ZMember, error)
+	// ZRangeByScore 按分数从低到高返回分数在 [min, max] 内的成员
+	ZRangeByScore(key string, min, max float64) ([]ZMember, error)
+	// ZRem 移除有序集合成员，返回实际移除的个数
+	ZRem(key string, members ...string) (int64, error)
+
+	// PFAdd 向 HyperLogLog 添加元素，ttl 大于 0 时同时刷新过期时间
+	PFAdd(key string, ttl time.Duration, members ...string) error
+	// PFCount 估算一个或多个 HyperLogLog 合并后的基数，不存在的 key 视为空
+	PFCount(keys ...string) (int64, error)
+
+	// Publish 向频道发布消息，没有订阅者时消息被丢弃
+	Publish(channel, message string) error
+	// Subscribe 订阅频道，Redis 实现可以收到所有实例发布的消息
+	Subscribe(channel string) (Subscription, error)
+
+	// Close 释放资源
+	Close() error
+}
+
+// Subscription 频道订阅
+type Subscription interface {
+	// Messages 接收消息，订阅关闭后通道被关闭；接收太慢时新消息会被丢弃
+	Messages() <-chan string
+	// Close 取消订阅
+	Close() error
+}
+
+// subscriptionBuffer 每个订阅缓冲的消息数
+const subscriptionBuffer = 64
+
+var current Cache = NewMemory()
+
+// Use 设置全局缓存实现，原实现会被关闭
+func Use(c Cache) {
+	if current != nil && current != c {
+		current.Close()
+	}
+	current = c
+}
+
+// Default 获取全局缓存实现，未设置时为进程内缓存
+func Default() Cache {
+	return current
+}
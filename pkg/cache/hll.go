@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// 进程内 HyperLogLog，参数与 Redis 一致：2^14 个寄存器，标准误差约 0.81%
+const (
+	hllPrecision = 14
+	hllRegisters = 1 << hllPrecision
+	// hllSparseMax 稀疏表示最多保存的寄存器数，超过后转为稠密数组
+	hllSparseMax = hllRegisters / 16
+)
+
+// hyperLogLog 基数估算，基数小时用稀疏 map 节省内存
+type hyperLogLog struct {
+	sparse map[uint16]uint8
+	dense  []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{sparse: make(map[uint16]uint8)}
+}
+
+// hllHash 64 位哈希，FNV-1a 之后再做一次混合让低位分布更均匀
+func hllHash(member string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(member))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// add 添加元素，返回寄存器是否有变化
+func (h *hyperLogLog) add(member string) bool {
+	x := hllHash(member)
+	index := uint16(x & (hllRegisters - 1))
+	// 剩余位中末尾 0 的个数加 1，最高位补 1 保证结果有界
+	rank := uint8(bits.TrailingZeros64(x>>hllPrecision|1<<(64-hllPrecision))) + 1
+	return h.set(index, rank)
+}
+
+// set 寄存器取较大值
+func (h *hyperLogLog) set(index uint16, rank uint8) bool {
+	if h.dense != nil {
+		if rank > h.dense[index] {
+			h.dense[index] = rank
+			return true
+		}
+		return false
+	}
+	if rank <= h.sparse[index] {
+		return false
+	}
+	h.sparse[index] = rank
+	if len(h.sparse) > hllSparseMax {
+		h.dense = make([]uint8, hllRegisters)
+		for i, r := range h.sparse {
+			h.dense[i] = r
+		}
+		h.sparse = nil
+	}
+	return true
+}
+
+// mergeInto 把寄存器合并到 registers
+func (h *hyperLogLog) mergeInto(registers []uint8) {
+	if h.dense != nil {
+		for i, r := range h.dense {
+			if r > registers[i] {
+				registers[i] = r
+			}
+		}
+		return
+	}
+	for i, r := range h.sparse {
+		if r > registers[i] {
+			registers[i] = r
+		}
+	}
+}
+
+// hllEstimate 根据寄存器估算基数，基数较小时使用线性计数修正
+func hllEstimate(registers []uint8) int64 {
+	m := float64(hllRegisters)
+	sum := 0.0
+	zeros := 0
+	for _, r := range registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(estimate + 0.5)
+}
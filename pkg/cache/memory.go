@@ -0,0 +1,455 @@
+package cache
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryEntry 进程内缓存条目，按使用的命令存放在对应字段
+type memoryEntry struct {
+	value    string
+	hash     map[string]string
+	list     []string
+	zset     map[string]float64
+	hll      *hyperLogLog
+	expireAt time.Time // 零值表示不过期
+}
+
+// memoryCache 进程内实现，适合单节点部署和测试
+type memoryCache struct {
+	mu    sync.Mutex
+	items map[string]*memoryEntry
+	stop  chan struct{}
+	once  sync.Once
+
+	subMu sync.Mutex
+	subs  map[string]map[*memorySubscription]struct{} // 按频道保存订阅
+}
+
+// memorySweepInterval 定期清理过期条目的间隔
+const memorySweepInterval = time.Minute
+
+// NewMemory 创建进程内缓存
+func NewMemory() Cache {
+	m := &memoryCache{
+		items: make(map[string]*memoryEntry),
+		stop:  make(chan struct{}),
+		subs:  make(map[string]map[*memorySubscription]struct{}),
+	}
+	go m.sweep()
+	return m
+}
+
+// sweep 定期清理过期条目，避免不再访问的 key 一直占用内存
+func (m *memoryCache) sweep() {
+	ticker := time.NewTicker(memorySweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			m.mu.Lock()
+			for key, entry := range m.items {
+				if entry.expired(now) {
+					delete(m.items, key)
+				}
+			}
+			m.mu.Unlock()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// lookup 获取未过期的条目，调用方需持有锁
+func (m *memoryCache) lookup(key string) *memoryEntry {
+	entry, ok := m.items[key]
+	if !ok {
+		return nil
+	}
+	if entry.expired(time.Now()) {
+		delete(m.items, key)
+		return nil
+	}
+	return entry
+}
+
+// entry 获取或创建条目，调用方需持有锁
+func (m *memoryCache) entry(key string) *memoryEntry {
+	if entry := m.lookup(key); entry != nil {
+		return entry
+	}
+	entry := &memoryEntry{}
+	m.items[key] = entry
+	return entry
+}
+
+// expireAt 计算过期时间，ttl 为 0 表示不过期
+func expireAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func (m *memoryCache) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil {
+		return "", ErrNotFound
+	}
+	return entry.value, nil
+}
+
+func (m *memoryCache) Set(key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[key] = &memoryEntry{value: value, expireAt: expireAt(ttl)}
+	return nil
+}
+
+func (m *memoryCache) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lookup(key) != nil {
+		return false, nil
+	}
+	m.items[key] = &memoryEntry{value: value, expireAt: expireAt(ttl)}
+	return true, nil
+}
+
+func (m *memoryCache) Del(keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		delete(m.items, key)
+	}
+	return nil
+}
+
+func (m *memoryCache) Exists(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lookup(key) != nil, nil
+}
+
+func (m *memoryCache) Expire(key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry := m.lookup(key); entry != nil {
+		entry.expireAt = expireAt(ttl)
+	}
+	return nil
+}
+
+func (m *memoryCache) IncrBy(key string, n int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	var current int64
+	if entry.value != "" {
+		v, err := strconv.ParseInt(entry.value, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		current = v
+	}
+	current += n
+	entry.value = strconv.FormatInt(current, 10)
+	return current, nil
+}
+
+func (m *memoryCache) HSet(key string, fields map[string]string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry.hash == nil {
+		entry.hash = make(map[string]string, len(fields))
+	}
+	for k, v := range fields {
+		entry.hash[k] = v
+	}
+	if ttl > 0 {
+		entry.expireAt = expireAt(ttl)
+	}
+	return nil
+}
+
+func (m *memoryCache) HGetAll(key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hgetall(key), nil
+}
+
+func (m *memoryCache) HGetAllMulti(keys []string) ([]map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]map[string]string, len(keys))
+	for i, key := range keys {
+		result[i] = m.hgetall(key)
+	}
+	return result, nil
+}
+
+// hgetall 复制哈希内容，调用方需持有锁
+func (m *memoryCache) hgetall(key string) map[string]string {
+	result := make(map[string]string)
+	if entry := m.lookup(key); entry != nil {
+		for k, v := range entry.hash {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func (m *memoryCache) HIncrBy(key, field string, n int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry.hash == nil {
+		entry.hash = make(map[string]string)
+	}
+	var current int64
+	if v, ok := entry.hash[field]; ok {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		current = parsed
+	}
+	current += n
+	entry.hash[field] = strconv.FormatInt(current, 10)
+	return current, nil
+}
+
+func (m *memoryCache) RPush(key string, values ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	entry.list = append(entry.list, values...)
+	return nil
+}
+
+func (m *memoryCache) LPopN(key string, n int64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil || len(entry.list) == 0 || n <= 0 {
+		return []string{}, nil
+	}
+	if n > int64(len(entry.list)) {
+		n = int64(len(entry.list))
+	}
+	values := make([]string, n)
+	copy(values, entry.list[:n])
+	entry.list = entry.list[n:]
+	if len(entry.list) == 0 {
+		// 与 Redis 一致：列表为空时 key 不再存在
+		delete(m.items, key)
+	}
+	return values, nil
+}
+
+func (m *memoryCache) ZAdd(key string, members ...ZMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry.zset == nil {
+		entry.zset = make(map[string]float64, len(members))
+	}
+	for _, member := range members {
+		entry.zset[member.Member] = member.Score
+	}
+	return nil
+}
+
+func (m *memoryCache) ZRemRangeByScore(key string, min, max float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry := m.lookup(key); entry != nil {
+		for member, score := range entry.zset {
+			if score >= min && score <= max {
+				delete(entry.zset, member)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *memoryCache) ZCard(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry := m.lookup(key); entry != nil {
+		return int64(len(entry.zset)), nil
+	}
+	return 0, nil
+}
+
+func (m *memoryCache) ZRevRange(key string, start, stop int64) ([]ZMember, error) {
+	m.mu.Lock()
+	entry := m.lookup(key)
+	members := make([]ZMember, 0)
+	if entry != nil {
+		for member, score := range entry.zset {
+			members = append(members, ZMember{Member: member, Score: score})
+		}
+	}
+	m.mu.Unlock()
+
+	// 与 Redis 一致：分数从高到低，分数相同时按成员倒序
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score > members[j].Score
+		}
+		return members[i].Member > members[j].Member
+	})
+
+	n := int64(len(members))
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return []ZMember{}, nil
+	}
+	return members[start : stop+1], nil
+}
+
+func (m *memoryCache) ZRangeByScore(key string, min, max float64) ([]ZMember, error) {
+	m.mu.Lock()
+	entry := m.lookup(key)
+	members := make([]ZMember, 0)
+	if entry != nil {
+		for member, score := range entry.zset {
+			if score >= min && score <= max {
+				members = append(members, ZMember{Member: member, Score: score})
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	// 与 Redis 一致：分数从低到高，分数相同时按成员正序
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member < members[j].Member
+	})
+	return members, nil
+}
+
+func (m *memoryCache) ZRem(key string, members ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.lookup(key)
+	if entry == nil {
+		return 0, nil
+	}
+	var removed int64
+	for _, member := range members {
+		if _, ok := entry.zset[member]; ok {
+			delete(entry.zset, member)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (m *memoryCache) PFAdd(key string, ttl time.Duration, members ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.entry(key)
+	if entry.hll == nil {
+		entry.hll = newHyperLogLog()
+	}
+	for _, member := range members {
+		entry.hll.add(member)
+	}
+	if ttl > 0 {
+		entry.expireAt = expireAt(ttl)
+	}
+	return nil
+}
+
+func (m *memoryCache) PFCount(keys ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	registers := make([]uint8, hllRegisters)
+	for _, key := range keys {
+		if entry := m.lookup(key); entry != nil && entry.hll != nil {
+			entry.hll.mergeInto(registers)
+		}
+	}
+	return hllEstimate(registers), nil
+}
+
+// memorySubscription 进程内订阅
+type memorySubscription struct {
+	cache   *memoryCache
+	channel string
+	ch      chan string
+}
+
+func (s *memorySubscription) Messages() <-chan string {
+	return s.ch
+}
+
+func (s *memorySubscription) Close() error {
+	s.cache.subMu.Lock()
+	defer s.cache.subMu.Unlock()
+	if subs, ok := s.cache.subs[s.channel]; ok {
+		if _, ok := subs[s]; ok {
+			delete(subs, s)
+			close(s.ch)
+		}
+		if len(subs) == 0 {
+			delete(s.cache.subs, s.channel)
+		}
+	}
+	return nil
+}
+
+func (m *memoryCache) Publish(channel, message string) error {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	for sub := range m.subs[channel] {
+		select {
+		case sub.ch <- message:
+		default:
+			// 订阅者处理不过来时丢弃，不阻塞发布者
+		}
+	}
+	return nil
+}
+
+func (m *memoryCache) Subscribe(channel string) (Subscription, error) {
+	sub := &memorySubscription{cache: m, channel: channel, ch: make(chan string, subscriptionBuffer)}
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	if m.subs[channel] == nil {
+		m.subs[channel] = make(map[*memorySubscription]struct{})
+	}
+	m.subs[channel][sub] = struct{}{}
+	return sub, nil
+}
+
+// Close 停止过期清理
+func (m *memoryCache) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	return nil
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// shortTTL 测试过期用的时间，expireWait 后条目一定已过期
+const (
+	shortTTL   = 30 * time.Millisecond
+	expireWait = 60 * time.Millisecond
+)
+
+func newTestMemory(t *testing.T) Cache {
+	t.Helper()
+	c := NewMemory()
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestMemoryHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []map[string]string
+		ttl    time.Duration
+		wait   time.Duration
+		want   map[string]string
+	}{
+		{"set", []map[string]string{{"a": "1", "b": "2"}}, 0, 0, map[string]string{"a": "1", "b": "2"}},
+		{"merge fields", []map[string]string{{"a": "1", "b": "2"}, {"b": "3", "c": "4"}}, 0, 0, map[string]string{"a": "1", "b": "3", "c": "4"}},
+		{"not expired", []map[string]string{{"a": "1"}}, time.Hour, 0, map[string]string{"a": "1"}},
+		{"expired", []map[string]string{{"a": "1"}}, shortTTL, expireWait, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestMemory(t)
+			for _, fields := range tt.writes {
+				if err := c.HSet("h", fields, tt.ttl); err != nil {
+					t.Fatal(err)
+				}
+			}
+			time.Sleep(tt.wait)
+
+			got, err := c.HGetAll("h")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HGetAll = %v, want %v", got, tt.want)
+			}
+			multi, _ := c.HGetAllMulti([]string{"h", "missing"})
+			if !reflect.DeepEqual(multi, []map[string]string{tt.want, {}}) {
+				t.Errorf("HGetAllMulti = %v", multi)
+			}
+		})
+	}
+}
+
+func TestMemorySortedSet(t *testing.T) {
+	members := []ZMember{{"a", 1}, {"b", 2}, {"c", 3}, {"d", 4}, {"e", 5}}
+	tests := []struct {
+		name     string
+		min, max float64
+		start    int64
+		stop     int64
+		want     []string // ZRevRange(start, stop) 的成员
+		card     int64
+	}{
+		{"nothing removed", 10, 20, 0, -1, []string{"e", "d", "c", "b", "a"}, 5},
+		{"remove low scores", math.Inf(-1), 2, 0, -1, []string{"e", "d", "c"}, 3},
+		{"remove middle", 2, 4, 0, -1, []string{"e", "a"}, 2},
+		{"remove all", math.Inf(-1), math.Inf(1), 0, -1, []string{}, 0},
+		{"page", 10, 20, 1, 2, []string{"d", "c"}, 5},
+		{"page past end", 10, 20, 4, 10, []string{"a"}, 5},
+		{"empty page", 10, 20, 5, 10, []string{}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestMemory(t)
+			if err := c.ZAdd("z", members...); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.ZRemRangeByScore("z", tt.min, tt.max); err != nil {
+				t.Fatal(err)
+			}
+
+			card, _ := c.ZCard("z")
+			if card != tt.card {
+				t.Errorf("ZCard = %d, want %d", card, tt.card)
+			}
+			list, _ := c.ZRevRange("z", tt.start, tt.stop)
+			got := make([]string, 0, len(list))
+			for _, m := range list {
+				got = append(got, m.Member)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ZRevRange = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySortedSetScoreRange(t *testing.T) {
+	c := newTestMemory(t)
+	c.ZAdd("z", ZMember{"a", 1}, ZMember{"b", 2}, ZMember{"c", 3})
+	c.ZAdd("z", ZMember{"a", 4}) // 更新分数
+
+	list, _ := c.ZRangeByScore("z", 2, 4)
+	if want := []ZMember{{"b", 2}, {"c", 3}, {"a", 4}}; !reflect.DeepEqual(list, want) {
+		t.Errorf("ZRangeByScore = %v, want %v", list, want)
+	}
+	removed, _ := c.ZRem("z", "a", "missing")
+	if removed != 1 {
+		t.Errorf("ZRem = %d, want 1", removed)
+	}
+	if removed, _ = c.ZRem("z", "a"); removed != 0 {
+		t.Errorf("second ZRem = %d, want 0", removed)
+	}
+}
+
+func TestMemoryHyperLogLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		members int
+		ttl     time.Duration
+		wait    time.Duration
+		want    int64
+	}{
+		{"empty", 0, 0, 0, 0},
+		{"small", 10, 0, 0, 10},
+		{"large", 10000, time.Hour, 0, 10000},
+		{"expired", 100, shortTTL, expireWait, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestMemory(t)
+			for i := 0; i < tt.members; i++ {
+				// 重复添加不增加计数
+				if err := c.PFAdd("hll", tt.ttl, fmt.Sprintf("v%d", i), fmt.Sprintf("v%d", i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			time.Sleep(tt.wait)
+
+			got, _ := c.PFCount("hll")
+			// HyperLogLog 是估算，允许 2% 误差
+			if diff := math.Abs(float64(got - tt.want)); diff > float64(tt.want)*0.02 {
+				t.Errorf("PFCount = %d, want about %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryHyperLogLogMerge(t *testing.T) {
+	c := newTestMemory(t)
+	for i := 0; i < 1000; i++ {
+		c.PFAdd("day1", 0, fmt.Sprintf("v%d", i))
+		c.PFAdd("day2", 0, fmt.Sprintf("v%d", i+500))
+	}
+	got, _ := c.PFCount("day1", "day2", "missing")
+	if got < 1470 || got > 1530 {
+		t.Errorf("merged PFCount = %d, want about 1500", got)
+	}
+}
+
+func TestMemoryStringExpiry(t *testing.T) {
+	c := newTestMemory(t)
+	c.Set("k", "v", shortTTL)
+	ok, _ := c.SetNX("k", "other", 0)
+	if ok {
+		t.Error("SetNX succeeded on an existing key")
+	}
+	time.Sleep(expireWait)
+
+	if _, err := c.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after expiry err = %v, want ErrNotFound", err)
+	}
+	if ok, _ := c.SetNX("k", "new", 0); !ok {
+		t.Error("SetNX failed after expiry")
+	}
+}
@@ -0,0 +1,264 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisCache Redis 实现
+type redisCache struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewRedis 基于 Redis 客户端创建缓存
+func NewRedis(client *redis.Client) Cache {
+	return &redisCache{client: client, ctx: context.Background()}
+}
+
+func (r *redisCache) Get(key string) (string, error) {
+	value, err := r.client.Get(r.ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (r *redisCache) Set(key, value string, ttl time.Duration) error {
+	return r.client.Set(r.ctx, key, value, ttl).Err()
+}
+
+func (r *redisCache) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(r.ctx, key, value, ttl).Result()
+}
+
+func (r *redisCache) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(r.ctx, keys...).Err()
+}
+
+func (r *redisCache) Exists(key string) (bool, error) {
+	n, err := r.client.Exists(r.ctx, key).Result()
+	return n > 0, err
+}
+
+func (r *redisCache) Expire(key string, ttl time.Duration) error {
+	return r.client.Expire(r.ctx, key, ttl).Err()
+}
+
+func (r *redisCache) IncrBy(key string, n int64) (int64, error) {
+	return r.client.IncrBy(r.ctx, key, n).Result()
+}
+
+func (r *redisCache) HSet(key string, fields map[string]string, ttl time.Duration) error {
+	values := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		values[k] = v
+	}
+	// 写入和设置过期时间在一次往返内完成
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(r.ctx, key, values)
+		if ttl > 0 {
+			pipe.Expire(r.ctx, key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *redisCache) HGetAll(key string) (map[string]string, error) {
+	return r.client.HGetAll(r.ctx, key).Result()
+}
+
+func (r *redisCache) HGetAllMulti(keys []string) ([]map[string]string, error) {
+	if len(keys) == 0 {
+		return []map[string]string{}, nil
+	}
+	cmds := make([]*redis.MapStringStringCmd, len(keys))
+	if _, err := r.client.Pipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.HGetAll(r.ctx, key)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]string, len(cmds))
+	for i, cmd := range cmds {
+		result[i] = cmd.Val()
+	}
+	return result, nil
+}
+
+func (r *redisCache) HIncrBy(key, field string, n int64) (int64, error) {
+	return r.client.HIncrBy(r.ctx, key, field, n).Result()
+}
+
+func (r *redisCache) RPush(key string, values ...string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return r.client.RPush(r.ctx, key, args...).Err()
+}
+
+func (r *redisCache) LPopN(key string, n int64) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+	// LRANGE + LTRIM 放在事务中保证原子性，兼容不支持 LPOP count 的旧版本 Redis
+	var values *redis.StringSliceCmd
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		values = pipe.LRange(r.ctx, key, 0, n-1)
+		pipe.LTrim(r.ctx, key, n, -1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values.Val(), nil
+}
+
+func (r *redisCache) ZAdd(key string, members ...ZMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	zs := make([]redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = redis.Z{Score: m.Score, Member: m.Member}
+	}
+	return r.client.ZAdd(r.ctx, key, zs...).Err()
+}
+
+func (r *redisCache) ZRemRangeByScore(key string, min, max float64) error {
+	return r.client.ZRemRangeByScore(r.ctx, key, formatScore(min), formatScore(max)).Err()
+}
+
+func (r *redisCache) ZCard(key string) (int64, error) {
+	return r.client.ZCard(r.ctx, key).Result()
+}
+
+func (r *redisCache) ZRevRange(key string, start, stop int64) ([]ZMember, error) {
+	zs, err := r.client.ZRevRangeWithScores(r.ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]ZMember, len(zs))
+	for i, z := range zs {
+		member, _ := z.Member.(string)
+		members[i] = ZMember{Member: member, Score: z.Score}
+	}
+	return members, nil
+}
+
+func (r *redisCache) ZRangeByScore(key string, min, max float64) ([]ZMember, error) {
+	zs, err := r.client.ZRangeByScoreWithScores(r.ctx, key, &redis.ZRangeBy{
+		Min: formatScore(min),
+		Max: formatScore(max),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]ZMember, len(zs))
+	for i, z := range zs {
+		member, _ := z.Member.(string)
+		members[i] = ZMember{Member: member, Score: z.Score}
+	}
+	return members, nil
+}
+
+func (r *redisCache) ZRem(key string, members ...string) (int64, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	values := make([]interface{}, len(members))
+	for i, m := range members {
+		values[i] = m
+	}
+	return r.client.ZRem(r.ctx, key, values...).Result()
+}
+
+func (r *redisCache) PFAdd(key string, ttl time.Duration, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.PFAdd(r.ctx, key, args...)
+		if ttl > 0 {
+			pipe.Expire(r.ctx, key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *redisCache) PFCount(keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return r.client.PFCount(r.ctx, keys...).Result()
+}
+
+func (r *redisCache) Publish(channel, message string) error {
+	return r.client.Publish(r.ctx, channel, message).Err()
+}
+
+// redisSubscription Redis 订阅，断线后 go-redis 会自动重连并重新订阅
+type redisSubscription struct {
+	pubsub *redis.PubSub
+	ch     chan string
+}
+
+func (s *redisSubscription) Messages() <-chan string {
+	return s.ch
+}
+
+func (s *redisSubscription) Close() error {
+	return s.pubsub.Close()
+}
+
+func (r *redisCache) Subscribe(channel string) (Subscription, error) {
+	pubsub := r.client.Subscribe(r.ctx, channel)
+	// 等待订阅确认，连接失败时直接返回错误
+	if _, err := pubsub.Receive(r.ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	sub := &redisSubscription{pubsub: pubsub, ch: make(chan string, subscriptionBuffer)}
+	go func() {
+		defer close(sub.ch)
+		for msg := range pubsub.Channel() {
+			select {
+			case sub.ch <- msg.Payload:
+			default:
+				// 订阅者处理不过来时丢弃，避免阻塞 go-redis 的接收协程
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// Close Redis 客户端由 pkg/redis 管理，这里不关闭
+func (r *redisCache) Close() error {
+	return nil
+}
+
+// formatScore 格式化分数，支持正负无穷
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
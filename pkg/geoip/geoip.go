@@ -0,0 +1,108 @@
+// Package geoip 根据本地离线数据库查询 IP 归属地，支持 ip2region 的 xdb 格式和 MaxMind 的 mmdb 格式。
+// 未配置数据库文件时所有查询都返回空结果，内网和本机地址固定返回 "内网"。
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LocalNetwork 内网和本机地址的国家名称
+const LocalNetwork = "内网"
+
+// Location IP 归属地，查不到的字段为空
+type Location struct {
+	Country  string `json:"country"`
+	Province string `json:"province"`
+	City     string `json:"city"`
+	ISP      string `json:"isp,omitempty"`
+}
+
+// IsEmpty 是否没有查到归属地
+func (l Location) IsEmpty() bool {
+	return l.Country == "" && l.Province == "" && l.City == ""
+}
+
+// String 拼接国家、省份和城市，省份和城市相同（如直辖市）时只保留一个
+func (l Location) String() string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{l.Country, l.Province, l.City} {
+		if part != "" && (len(parts) == 0 || parts[len(parts)-1] != part) {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Locator IP 归属地查询接口
+type Locator interface {
+	// Lookup 查询公网 IP 的归属地，数据库中没有时返回空 Location
+	Lookup(ip net.IP) (Location, error)
+	// Close 释放数据库
+	Close() error
+}
+
+// Open 按扩展名打开数据库文件：.xdb 为 ip2region，.mmdb 为 MaxMind；
+// lang 为 mmdb 中地名的语言，如 zh-CN、en
+func Open(path, lang string) (Locator, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xdb":
+		return OpenXDB(path)
+	case ".mmdb":
+		return OpenMMDB(path, lang)
+	default:
+		return nil, fmt.Errorf("不支持的 IP 数据库格式: %s", path)
+	}
+}
+
+// noopLocator 未配置数据库时使用，总是返回空结果
+type noopLocator struct{}
+
+func (noopLocator) Lookup(net.IP) (Location, error) { return Location{}, nil }
+func (noopLocator) Close() error                    { return nil }
+
+var (
+	mu      sync.RWMutex
+	current Locator = noopLocator{}
+)
+
+// Use 设置全局数据库，并关闭之前的数据库
+func Use(l Locator) {
+	mu.Lock()
+	prev := current
+	current = l
+	mu.Unlock()
+	prev.Close()
+}
+
+// Default 获取全局数据库，未设置时返回空实现
+func Default() Locator {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Enabled 是否已配置数据库
+func Enabled() bool {
+	_, noop := Default().(noopLocator)
+	return !noop
+}
+
+// Lookup 使用全局数据库查询 IP 归属地，无效地址和查询失败都返回空 Location
+func Lookup(ip string) Location {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return Location{}
+	}
+	if parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsLinkLocalUnicast() || parsed.IsUnspecified() {
+		return Location{Country: LocalNetwork}
+	}
+	loc, err := Default().Lookup(parsed)
+	if err != nil {
+		return Location{}
+	}
+	return loc
+}
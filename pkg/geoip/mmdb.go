@@ -0,0 +1,355 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// MaxMind DB 文件结构：二叉搜索树、16 字节分隔符、数据区，文件末尾是元数据。
+// 格式说明见 https://maxmind.github.io/MaxMind-DB/
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// mmdb 数据类型
+const (
+	mmdbExtended = iota
+	mmdbPointer
+	mmdbString
+	mmdbDouble
+	mmdbBytes
+	mmdbUint16
+	mmdbUint32
+	mmdbMap
+	mmdbInt32
+	mmdbUint64
+	mmdbUint128
+	mmdbArray
+	mmdbContainer
+	mmdbEndMarker
+	mmdbBool
+	mmdbFloat
+)
+
+// mmdbMaxDepth 解码时指针和嵌套容器的最大深度，避免损坏的文件中循环引用的指针导致栈溢出
+const mmdbMaxDepth = 32
+
+// mmdbLocator MaxMind DB 数据库，整个文件加载到内存
+type mmdbLocator struct {
+	tree       []byte // 搜索树
+	data       []byte // 数据区
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint // IPv6 数据库中 IPv4 地址（::/96）对应的节点
+	lang       string
+}
+
+// OpenMMDB 打开 MaxMind DB 数据库，lang 为地名语言，没有该语言时使用英文
+func OpenMMDB(path, lang string) (Locator, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 IP 数据库失败: %w", err)
+	}
+	markerAt := bytes.LastIndex(content, mmdbMetadataMarker)
+	if markerAt < 0 {
+		return nil, errors.New("IP 数据库不是有效的 mmdb 文件")
+	}
+
+	metaStart := markerAt + len(mmdbMetadataMarker)
+	meta, _, err := (&mmdbDecoder{buf: content[metaStart:]}).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("解析 mmdb 元数据失败: %w", err)
+	}
+	metaMap, _ := meta.(map[string]interface{})
+	m := &mmdbLocator{
+		nodeCount:  uint(toUint(metaMap["node_count"])),
+		recordSize: uint(toUint(metaMap["record_size"])),
+		ipVersion:  uint(toUint(metaMap["ip_version"])),
+		lang:       lang,
+	}
+	if m.recordSize != 24 && m.recordSize != 28 && m.recordSize != 32 {
+		return nil, fmt.Errorf("不支持的 mmdb 记录长度: %d", m.recordSize)
+	}
+
+	// 先按文件大小限制节点数，避免节点数过大时计算搜索树大小溢出
+	if m.nodeCount == 0 || m.nodeCount > uint(markerAt) {
+		return nil, errors.New("mmdb 搜索树越界")
+	}
+	treeSize := int(m.nodeCount * m.recordSize / 4)
+	if treeSize+16 > markerAt {
+		return nil, errors.New("mmdb 搜索树越界")
+	}
+	m.tree = content[:treeSize]
+	m.data = content[treeSize+16 : markerAt]
+
+	if m.ipVersion == 6 {
+		for i := 0; i < 96 && m.ipv4Start < m.nodeCount; i++ {
+			if m.ipv4Start, err = m.readNode(m.ipv4Start, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// readNode 读取节点的左（bit=0）或右（bit=1）记录
+func (m *mmdbLocator) readNode(node uint, bit uint) (uint, error) {
+	size := m.recordSize / 4 // 每个节点的字节数
+	if node >= m.nodeCount || (node+1)*size > uint(len(m.tree)) {
+		return 0, errMMDBCorrupt
+	}
+	b := m.tree[node*size : (node+1)*size]
+	switch m.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6]), nil
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:])), nil
+	}
+}
+
+// Lookup 查询 IP 归属地
+func (m *mmdbLocator) Lookup(ip net.IP) (Location, error) {
+	addr, node := ip.To4(), uint(0)
+	if addr != nil {
+		node = m.ipv4Start
+	} else if m.ipVersion == 6 {
+		addr = ip.To16()
+	} else {
+		return Location{}, nil
+	}
+
+	bits := uint(len(addr) * 8)
+	for i := uint(0); i < bits && node < m.nodeCount; i++ {
+		bit := uint(addr[i>>3]>>(7-i%8)) & 1
+		var err error
+		if node, err = m.readNode(node, bit); err != nil {
+			return Location{}, err
+		}
+	}
+	if node <= m.nodeCount {
+		return Location{}, nil
+	}
+	// 数据记录的值为节点数 + 16 字节分隔符 + 数据区偏移
+	if node < m.nodeCount+16 {
+		return Location{}, errMMDBCorrupt
+	}
+
+	offset := int(node - m.nodeCount - 16)
+	record, _, err := (&mmdbDecoder{buf: m.data}).decode(offset, 0)
+	if err != nil {
+		return Location{}, err
+	}
+	return m.toLocation(record), nil
+}
+
+// toLocation 从 GeoIP2/GeoLite2 City 记录中提取地名
+func (m *mmdbLocator) toLocation(record interface{}) Location {
+	r, _ := record.(map[string]interface{})
+	loc := Location{
+		Country: m.name(r["country"]),
+		City:    m.name(r["city"]),
+	}
+	if subdivisions, ok := r["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+		loc.Province = m.name(subdivisions[0])
+	}
+	if isp, ok := r["isp"].(string); ok {
+		loc.ISP = isp
+	} else if org, ok := r["autonomous_system_organization"].(string); ok {
+		loc.ISP = org
+	}
+	return loc
+}
+
+// name 取 {"names": {"zh-CN": ..., "en": ...}} 中指定语言的名称
+func (m *mmdbLocator) name(value interface{}) string {
+	v, _ := value.(map[string]interface{})
+	names, _ := v["names"].(map[string]interface{})
+	if name, ok := names[m.lang].(string); ok && name != "" {
+		return name
+	}
+	name, _ := names["en"].(string)
+	return name
+}
+
+// Close 释放数据库
+func (m *mmdbLocator) Close() error {
+	m.tree, m.data = nil, nil
+	return nil
+}
+
+// toUint 元数据中的整数统一转为 uint64
+func toUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		return uint64(n)
+	}
+	return 0
+}
+
+// mmdbDecoder 数据区解码器，指针都是相对 buf 起始位置的偏移
+type mmdbDecoder struct {
+	buf []byte
+}
+
+var errMMDBCorrupt = errors.New("mmdb 数据损坏")
+
+// decode 解码 offset 处的值，返回值和下一个值的位置；depth 为当前指针和容器的嵌套深度
+func (d *mmdbDecoder) decode(offset, depth int) (interface{}, int, error) {
+	if depth > mmdbMaxDepth {
+		return nil, 0, errMMDBCorrupt
+	}
+	typ, size, offset, err := d.decodeControl(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ == mmdbPointer {
+		// 指针指向的值解码后从指针之后继续
+		value, _, err := d.decode(size, depth+1)
+		return value, offset, err
+	}
+	return d.decodeValue(typ, size, offset, depth)
+}
+
+// decodeControl 解析控制字节，返回类型、长度（指针类型为目标位置）和数据开始位置
+func (d *mmdbDecoder) decodeControl(offset int) (int, int, int, error) {
+	if offset < 0 || offset >= len(d.buf) {
+		return 0, 0, 0, errMMDBCorrupt
+	}
+	ctrl := d.buf[offset]
+	offset++
+	typ := int(ctrl >> 5)
+
+	if typ == mmdbPointer {
+		n := int(ctrl>>3&0x3) + 1
+		if offset+n > len(d.buf) {
+			return 0, 0, 0, errMMDBCorrupt
+		}
+		b := d.buf[offset : offset+n]
+		var ptr int
+		switch n {
+		case 1:
+			ptr = int(ctrl&0x7)<<8 | int(b[0])
+		case 2:
+			ptr = (int(ctrl&0x7)<<16 | int(b[0])<<8 | int(b[1])) + 2048
+		case 3:
+			ptr = (int(ctrl&0x7)<<24 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])) + 526336
+		default:
+			ptr = int(binary.BigEndian.Uint32(b))
+		}
+		return typ, ptr, offset + n, nil
+	}
+
+	if typ == mmdbExtended {
+		if offset >= len(d.buf) {
+			return 0, 0, 0, errMMDBCorrupt
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size := int(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > len(d.buf) {
+			return 0, 0, 0, errMMDBCorrupt
+		}
+		b := d.buf[offset : offset+n]
+		switch n {
+		case 1:
+			size = 29 + int(b[0])
+		case 2:
+			size = 285 + (int(b[0])<<8 | int(b[1]))
+		default:
+			size = 65821 + (int(b[0])<<16 | int(b[1])<<8 | int(b[2]))
+		}
+		offset += n
+	}
+	return typ, size, offset, nil
+}
+
+// decodeValue 按类型解码数据
+func (d *mmdbDecoder) decodeValue(typ, size, offset, depth int) (interface{}, int, error) {
+	// 每个元素至少占一个字节，元素数不会超过剩余的数据长度
+	if (typ == mmdbMap || typ == mmdbArray) && size > len(d.buf)-offset {
+		return nil, 0, errMMDBCorrupt
+	}
+	switch typ {
+	case mmdbMap:
+		m := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, next, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			k, _ := key.(string)
+			m[k] = value
+			offset = next
+		}
+		return m, offset, nil
+	case mmdbArray:
+		list := make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			value, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, value)
+			offset = next
+		}
+		return list, offset, nil
+	case mmdbBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > len(d.buf) {
+		return nil, 0, errMMDBCorrupt
+	}
+	b := d.buf[offset : offset+size]
+	next := offset + size
+	switch typ {
+	case mmdbString:
+		return string(b), next, nil
+	case mmdbBytes, mmdbUint128:
+		return b, next, nil
+	case mmdbDouble:
+		if size != 8 {
+			return nil, 0, errMMDBCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case mmdbFloat:
+		if size != 4 {
+			return nil, 0, errMMDBCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case mmdbUint16, mmdbUint32, mmdbUint64:
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, next, nil
+	case mmdbInt32:
+		var n uint32
+		for _, c := range b {
+			n = n<<8 | uint32(c)
+		}
+		return int64(int32(n)), next, nil
+	default:
+		return nil, 0, fmt.Errorf("不支持的 mmdb 数据类型: %d", typ)
+	}
+}
@@ -0,0 +1,256 @@
+package geoip
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// mmdbNetwork 测试数据库中的一个网段及其记录
+type mmdbNetwork struct {
+	cidr   string
+	record interface{}
+}
+
+// encodeMMDB 按 MaxMind DB 格式编码测试用的值，只支持长度小于 285 的字符串、无符号整数、map 和数组
+func encodeMMDB(v interface{}) []byte {
+	control := func(typ, size int) []byte {
+		var extra []byte
+		if size >= 29 {
+			extra, size = []byte{byte(size - 29)}, 29
+		}
+		out := []byte{byte(typ<<5 | size)}
+		if typ > 7 {
+			out = []byte{byte(size), byte(typ - 7)}
+		}
+		return append(out, extra...)
+	}
+	switch v := v.(type) {
+	case string:
+		return append(control(mmdbString, len(v)), v...)
+	case uint32:
+		var b []byte
+		for n := v; n > 0; n >>= 8 {
+			b = append([]byte{byte(n)}, b...)
+		}
+		return append(control(mmdbUint32, len(b)), b...)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := control(mmdbMap, len(v))
+		for _, k := range keys {
+			out = append(out, encodeMMDB(k)...)
+			out = append(out, encodeMMDB(v[k])...)
+		}
+		return out
+	case []interface{}:
+		out := control(mmdbArray, len(v))
+		for _, item := range v {
+			out = append(out, encodeMMDB(item)...)
+		}
+		return out
+	case []byte:
+		// 原样写入，用于构造损坏的数据
+		return v
+	}
+	panic("unsupported value")
+}
+
+// buildMMDB 生成记录长度为 24 位的测试数据库
+func buildMMDB(t *testing.T, ipVersion uint32, networks []mmdbNetwork) []byte {
+	t.Helper()
+	const (
+		empty = -1
+		data  = -2 // 小于等于 data 的值为 data - 数据下标
+	)
+	nodes := [][2]int{{empty, empty}}
+	var records [][]byte
+
+	for _, n := range networks {
+		_, ipnet, err := net.ParseCIDR(n.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := ipnet.IP.To16()
+		ones, _ := ipnet.Mask.Size()
+		if ipVersion == 6 && ipnet.IP.To4() != nil {
+			ones += 96 // IPv4 网段在 IPv6 数据库中位于 ::/96 下
+			addr = append(make(net.IP, 12), ipnet.IP.To4()...)
+		} else if ipVersion == 4 {
+			addr = ipnet.IP.To4()
+		}
+
+		node := 0
+		for i := 0; i < ones-1; i++ {
+			bit := int(addr[i/8]>>(7-i%8)) & 1
+			if nodes[node][bit] == empty {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+		bit := int(addr[(ones-1)/8]>>(7-(ones-1)%8)) & 1
+		nodes[node][bit] = data - len(records)
+		records = append(records, encodeMMDB(n.record))
+	}
+
+	var section []byte
+	offsets := make([]int, len(records))
+	for i, r := range records {
+		offsets[i] = len(section)
+		section = append(section, r...)
+	}
+
+	nodeCount := len(nodes)
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		for _, v := range node {
+			switch {
+			case v == empty:
+				v = nodeCount
+			case v <= data:
+				v = nodeCount + 16 + offsets[data-v]
+			}
+			buf.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	buf.Write(make([]byte, 16))
+	buf.Write(section)
+	buf.Write(mmdbMetadataMarker)
+	buf.Write(encodeMMDB(map[string]interface{}{
+		"node_count":  uint32(nodeCount),
+		"record_size": uint32(24),
+		"ip_version":  ipVersion,
+	}))
+	return buf.Bytes()
+}
+
+// writeDB 将数据库写入临时文件
+func writeDB(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// cityRecord 生成 GeoIP2 City 格式的记录，地名只有 lang 一种语言
+func cityRecord(lang, country, province, city string) map[string]interface{} {
+	names := func(name string) map[string]interface{} {
+		return map[string]interface{}{"names": map[string]interface{}{lang: name}}
+	}
+	return map[string]interface{}{
+		"country":                        names(country),
+		"subdivisions":                   []interface{}{names(province)},
+		"city":                           names(city),
+		"autonomous_system_organization": "Example ISP",
+	}
+}
+
+func TestMMDBLookup(t *testing.T) {
+	networks := []mmdbNetwork{
+		{"203.0.113.0/24", cityRecord("zh-CN", "中国", "浙江", "杭州")},
+		{"198.51.100.0/25", cityRecord("en", "United States", "California", "San Jose")},
+		{"2001:db8::/32", cityRecord("zh-CN", "日本", "东京都", "东京")},
+	}
+	tests := []struct {
+		ip string
+		v4 Location // IPv4 数据库的查询结果
+		v6 Location // IPv6 数据库的查询结果
+	}{
+		{"203.0.113.7", Location{Country: "中国", Province: "浙江", City: "杭州", ISP: "Example ISP"}, Location{Country: "中国", Province: "浙江", City: "杭州", ISP: "Example ISP"}},
+		{"203.0.114.1", Location{}, Location{}},
+		// 没有指定语言时使用英文
+		{"198.51.100.1", Location{Country: "United States", Province: "California", City: "San Jose", ISP: "Example ISP"}, Location{Country: "United States", Province: "California", City: "San Jose", ISP: "Example ISP"}},
+		{"198.51.100.200", Location{}, Location{}},
+		{"2001:db8::1", Location{}, Location{Country: "日本", Province: "东京都", City: "东京", ISP: "Example ISP"}},
+		{"2001:db9::1", Location{}, Location{}},
+	}
+
+	for _, ipVersion := range []uint32{4, 6} {
+		db := networks
+		if ipVersion == 4 {
+			db = networks[:2]
+		}
+		m, err := OpenMMDB(writeDB(t, "city.mmdb", buildMMDB(t, ipVersion, db)), "zh-CN")
+		if err != nil {
+			t.Fatalf("ipv%d: %v", ipVersion, err)
+		}
+		for _, tt := range tests {
+			want := tt.v4
+			if ipVersion == 6 {
+				want = tt.v6
+			}
+			got, err := m.Lookup(net.ParseIP(tt.ip))
+			if err != nil || got != want {
+				t.Errorf("ipv%d Lookup(%s) = %+v, %v, want %+v", ipVersion, tt.ip, got, err, want)
+			}
+		}
+		m.Close()
+	}
+}
+
+func TestMMDBCorrupt(t *testing.T) {
+	tests := []struct {
+		name   string
+		record []byte // 写入数据区的原始数据
+	}{
+		{"self pointer", []byte{0x20, 0x00}},
+		{"map pointing to itself", []byte{mmdbMap<<5 | 1, mmdbString<<5 | 1, 'a', 0x20, 0x00}},
+		{"oversized map", []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		{"truncated string", []byte{0x45, 'a'}},
+	}
+
+	for _, tt := range tests {
+		m, err := OpenMMDB(writeDB(t, "bad.mmdb", buildMMDB(t, 4, []mmdbNetwork{{"203.0.113.0/24", tt.record}})), "en")
+		if err != nil {
+			t.Fatalf("%s: open: %v", tt.name, err)
+		}
+		if _, err := m.Lookup(net.ParseIP("203.0.113.1")); !errors.Is(err, errMMDBCorrupt) {
+			t.Errorf("%s: err = %v, want corrupt", tt.name, err)
+		}
+	}
+}
+
+func TestOpenMMDBCorrupt(t *testing.T) {
+	valid := buildMMDB(t, 4, []mmdbNetwork{{"203.0.113.0/24", cityRecord("en", "China", "", "")}})
+	markerAt := bytes.LastIndex(valid, mmdbMetadataMarker)
+	withMeta := func(meta map[string]interface{}) []byte {
+		out := append([]byte{}, valid[:markerAt]...)
+		out = append(out, mmdbMetadataMarker...)
+		return append(out, encodeMMDB(meta)...)
+	}
+
+	tests := map[string][]byte{
+		"no metadata":      valid[:markerAt],
+		"record size":      withMeta(map[string]interface{}{"node_count": uint32(1), "record_size": uint32(20), "ip_version": uint32(4)}),
+		"huge node count":  withMeta(map[string]interface{}{"node_count": uint32(0xFFFFFFFF), "record_size": uint32(32), "ip_version": uint32(4)}),
+		"tree beyond file": withMeta(map[string]interface{}{"node_count": uint32(markerAt / 6), "record_size": uint32(24), "ip_version": uint32(4)}),
+	}
+	for name, content := range tests {
+		if _, err := OpenMMDB(writeDB(t, "bad.mmdb", content), "en"); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestMMDBReadNodeBounds(t *testing.T) {
+	// 节点数与搜索树长度不一致时不能越界读取
+	m := &mmdbLocator{tree: make([]byte, 12), nodeCount: 5, recordSize: 24}
+	if _, err := m.readNode(1, 1); err != nil {
+		t.Errorf("readNode(1) = %v", err)
+	}
+	for _, node := range []uint{2, 5, 100} {
+		if _, err := m.readNode(node, 1); !errors.Is(err, errMMDBCorrupt) {
+			t.Errorf("readNode(%d) err = %v, want corrupt", node, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// ip2region xdb 文件结构：256 字节头部，256*256 个向量索引（每个 8 字节，为二级索引的起止位置），
+// 之后是按起始 IP 排序的二级索引（每个 14 字节：起始 IP、结束 IP、地区长度、地区位置），
+// 地区为 "国家|区域|省份|城市|ISP"，未知字段为 "0"。
+const (
+	xdbHeaderLength      = 256
+	xdbVectorIndexCols   = 256
+	xdbVectorIndexSize   = 8
+	xdbVectorIndexLength = xdbVectorIndexCols * xdbVectorIndexCols * xdbVectorIndexSize
+	xdbSegmentIndexSize  = 14
+)
+
+// xdbLocator ip2region xdb 数据库，整个文件加载到内存，只支持 IPv4
+type xdbLocator struct {
+	content []byte
+}
+
+// OpenXDB 打开 ip2region xdb 数据库
+func OpenXDB(path string) (Locator, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 IP 数据库失败: %w", err)
+	}
+	if len(content) < xdbHeaderLength+xdbVectorIndexLength {
+		return nil, errors.New("IP 数据库不是有效的 xdb 文件")
+	}
+	if version := binary.LittleEndian.Uint16(content); version != 2 {
+		return nil, fmt.Errorf("不支持的 xdb 版本: %d", version)
+	}
+	return &xdbLocator{content: content}, nil
+}
+
+// Lookup 查询 IP 归属地
+func (x *xdbLocator) Lookup(ip net.IP) (Location, error) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return Location{}, nil
+	}
+	value := binary.BigEndian.Uint32(ip4)
+
+	// 按前两段定位二级索引范围
+	offset := xdbHeaderLength + (int(ip4[0])*xdbVectorIndexCols+int(ip4[1]))*xdbVectorIndexSize
+	start := int(binary.LittleEndian.Uint32(x.content[offset:]))
+	end := int(binary.LittleEndian.Uint32(x.content[offset+4:]))
+	if end+xdbSegmentIndexSize > len(x.content) || start > end {
+		return Location{}, errors.New("xdb 索引越界")
+	}
+
+	// 二分查找包含该 IP 的区间
+	low, high := 0, (end-start)/xdbSegmentIndexSize
+	for low <= high {
+		mid := (low + high) / 2
+		p := start + mid*xdbSegmentIndexSize
+		switch {
+		case value < binary.LittleEndian.Uint32(x.content[p:]):
+			high = mid - 1
+		case value > binary.LittleEndian.Uint32(x.content[p+4:]):
+			low = mid + 1
+		default:
+			dataLen := int(binary.LittleEndian.Uint16(x.content[p+8:]))
+			dataPtr := int(binary.LittleEndian.Uint32(x.content[p+10:]))
+			if dataPtr+dataLen > len(x.content) {
+				return Location{}, errors.New("xdb 数据越界")
+			}
+			return parseRegion(string(x.content[dataPtr : dataPtr+dataLen])), nil
+		}
+	}
+	return Location{}, nil
+}
+
+// Close 释放数据库
+func (x *xdbLocator) Close() error {
+	x.content = nil
+	return nil
+}
+
+// parseRegion 解析 "国家|区域|省份|城市|ISP"，也兼容没有区域字段的 "国家|省份|城市|ISP"
+func parseRegion(region string) Location {
+	fields := strings.Split(region, "|")
+	for i, f := range fields {
+		if f == "0" {
+			fields[i] = ""
+		}
+	}
+	switch len(fields) {
+	case 5:
+		return Location{Country: fields[0], Province: fields[2], City: fields[3], ISP: fields[4]}
+	case 4:
+		return Location{Country: fields[0], Province: fields[1], City: fields[2], ISP: fields[3]}
+	default:
+		return Location{Country: fields[0]}
+	}
+}
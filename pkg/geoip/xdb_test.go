@@ -0,0 +1,109 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// xdbSegment 测试数据库中的一个 IP 区间
+type xdbSegment struct {
+	start, end string
+	region     string
+}
+
+// buildXDB 生成 xdb 测试数据库，每个 /16 内的区间须连续给出
+func buildXDB(segments []xdbSegment) []byte {
+	content := make([]byte, xdbHeaderLength+xdbVectorIndexLength)
+	binary.LittleEndian.PutUint16(content, 2)
+
+	// 二级索引之后是地区数据
+	segmentStart := len(content)
+	content = append(content, make([]byte, len(segments)*xdbSegmentIndexSize)...)
+	for i, seg := range segments {
+		start, end := net.ParseIP(seg.start).To4(), net.ParseIP(seg.end).To4()
+		p := segmentStart + i*xdbSegmentIndexSize
+		binary.LittleEndian.PutUint32(content[p:], binary.BigEndian.Uint32(start))
+		binary.LittleEndian.PutUint32(content[p+4:], binary.BigEndian.Uint32(end))
+		binary.LittleEndian.PutUint16(content[p+8:], uint16(len(seg.region)))
+		binary.LittleEndian.PutUint32(content[p+10:], uint32(len(content)))
+		content = append(content, seg.region...)
+
+		// 向量索引记录 /16 内第一个和最后一个区间的位置
+		v := xdbHeaderLength + (int(start[0])*xdbVectorIndexCols+int(start[1]))*xdbVectorIndexSize
+		if binary.LittleEndian.Uint32(content[v:]) == 0 {
+			binary.LittleEndian.PutUint32(content[v:], uint32(p))
+		}
+		binary.LittleEndian.PutUint32(content[v+4:], uint32(p))
+	}
+	return content
+}
+
+func TestXDBLookup(t *testing.T) {
+	x, err := OpenXDB(writeDB(t, "ip2region.xdb", buildXDB([]xdbSegment{
+		{"1.2.0.0", "1.2.3.255", "中国|0|广东省|深圳市|电信"},
+		{"1.2.4.0", "1.2.4.255", "美国|0|0|0|0"},
+		{"1.2.5.0", "1.2.255.255", "中国|0|0|0|内网IP"},
+		{"5.6.0.0", "5.6.255.255", "中国|北京|北京市|联通"},
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"1.2.0.0", Location{Country: "中国", Province: "广东省", City: "深圳市", ISP: "电信"}},
+		{"1.2.3.255", Location{Country: "中国", Province: "广东省", City: "深圳市", ISP: "电信"}},
+		{"1.2.4.8", Location{Country: "美国"}},
+		{"1.2.200.1", Location{Country: "中国", ISP: "内网IP"}},
+		// 没有区域字段的旧格式
+		{"5.6.7.8", Location{Country: "中国", Province: "北京", City: "北京市", ISP: "联通"}},
+		{"9.9.9.9", Location{}},
+		// 只支持 IPv4
+		{"2001:db8::1", Location{}},
+	}
+	for _, tt := range tests {
+		got, err := x.Lookup(net.ParseIP(tt.ip))
+		if err != nil || got != tt.want {
+			t.Errorf("Lookup(%s) = %+v, %v, want %+v", tt.ip, got, err, tt.want)
+		}
+	}
+}
+
+func TestXDBCorrupt(t *testing.T) {
+	valid := buildXDB([]xdbSegment{{"1.2.0.0", "1.2.255.255", "中国|0|0|0|0"}})
+	if _, err := OpenXDB(writeDB(t, "short.xdb", valid[:xdbHeaderLength])); err == nil {
+		t.Error("truncated file: want error")
+	}
+	version := append([]byte{}, valid...)
+	version[0] = 3
+	if _, err := OpenXDB(writeDB(t, "version.xdb", version)); err == nil {
+		t.Error("version 3: want error")
+	}
+
+	segment := xdbHeaderLength + xdbVectorIndexLength
+	tests := map[string]func(content []byte){
+		// 向量索引指向文件之外
+		"vector index": func(content []byte) {
+			binary.LittleEndian.PutUint32(content[xdbHeaderLength+(1*xdbVectorIndexCols+2)*xdbVectorIndexSize+4:], uint32(len(content)))
+		},
+		// 地区数据超出文件
+		"region": func(content []byte) {
+			binary.LittleEndian.PutUint16(content[segment+8:], 0xFFFF)
+		},
+	}
+	for name, corrupt := range tests {
+		content := append([]byte{}, valid...)
+		corrupt(content)
+		x, err := OpenXDB(writeDB(t, "bad.xdb", content))
+		if err != nil {
+			t.Fatalf("%s: open: %v", name, err)
+		}
+		if _, err := x.Lookup(net.ParseIP("1.2.3.4")); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
@@ -35,6 +35,49 @@ func GenerateToken(adminID uint, username, role string) (string, error) {
 	return token.SignedString([]byte(config.AppConfig.JWT.Secret))
 }
 
+// streamAudience 实时推送令牌的受众，这类令牌只能用于建立 SSE 连接
+const streamAudience = "stream"
+
+// GenerateStreamToken 生成实时推送使用的短期令牌
+// EventSource 无法设置请求头，令牌放在 URL 参数中，因此有效期很短且不能用于其它接口
+func GenerateStreamToken(adminID uint, username, role string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := Claims{
+		AdminID:  adminID,
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "blog-admin-api",
+			Audience:  jwt.ClaimStrings{streamAudience},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(config.AppConfig.JWT.Secret))
+	return signed, expiresAt, err
+}
+
+// ParseStreamToken 解析实时推送令牌
+func ParseStreamToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWT.Secret), nil
+	}, jwt.WithAudience(streamAudience))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
 // ParseToken 解析JWT Token
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -45,7 +88,8 @@ func ParseToken(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	// 实时推送令牌会出现在 URL 中，不能当作登录令牌使用
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && len(claims.Audience) == 0 {
 		return claims, nil
 	}
 
@@ -0,0 +1,39 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+)
+
+func TestStreamTokenScope(t *testing.T) {
+	config.AppConfig = &config.Config{JWT: config.JWTConfig{Secret: "test-secret", Expire: 1}}
+
+	stream, _, err := GenerateStreamToken(1, "admin", "admin", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, err := GenerateToken(1, "admin", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseStreamToken(stream); err != nil {
+		t.Errorf("ParseStreamToken(stream) = %v, want ok", err)
+	}
+	if _, err := ParseToken(stream); err == nil {
+		t.Error("stream token accepted as login token")
+	}
+	if _, err := ParseStreamToken(login); err == nil {
+		t.Error("login token accepted as stream token")
+	}
+	if _, err := ParseToken(login); err != nil {
+		t.Errorf("ParseToken(login) = %v, want ok", err)
+	}
+
+	expired, _, _ := GenerateStreamToken(1, "admin", "admin", -time.Second)
+	if _, err := ParseStreamToken(expired); err == nil {
+		t.Error("expired stream token accepted")
+	}
+}
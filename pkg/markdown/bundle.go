@@ -0,0 +1,333 @@
+package markdown
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+	"github.com/ydcloud-dy/leaf-api/pkg/upload"
+)
+
+// wikiImgRegex 匹配 Obsidian 的图片嵌入语法: ![[image.png]] 或 ![[image.png|300]]
+var wikiImgRegex = regexp.MustCompile(`!\[\[([^\]|]+)(\|[^\]]*)?\]\]`)
+
+// ErrBundleTooLarge 压缩包解压后超过大小限制
+var ErrBundleTooLarge = errors.New("压缩包解压后超过大小限制")
+
+// BundleDocument 压缩包中的 Markdown 文档
+type BundleDocument struct {
+	Path    string   // 压缩包内的路径（已去掉公共根目录）
+	Folders []string // 所在的目录层级，用于映射章节
+	Content string   // 原始内容，相对路径图片由 ResolveImages 上传替换
+}
+
+// Bundle Obsidian、Typora、语雀等导出的 Markdown 压缩包
+type Bundle struct {
+	files  map[string]*zip.File // 规范化路径 -> 文件
+	byName map[string]string    // 文件名 -> 规范化路径，用于 Obsidian 按文件名引用
+	docs   []string
+	root   string // 所有文档共同的根目录
+	folder string // 图片上传的 OSS 文件夹
+	stored map[string]string
+	closer io.Closer // 从文件打开时需要关闭
+}
+
+// OpenBundle 打开压缩包，maxSize 为解压后总大小上限（字节）
+func OpenBundle(data []byte, maxSize int64) (*Bundle, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("解析压缩包失败: %w", err)
+	}
+	return newBundle(reader, maxSize)
+}
+
+// OpenBundleFile 打开磁盘上的压缩包，使用完需要调用 Close
+func OpenBundleFile(name string, maxSize int64) (*Bundle, error) {
+	rc, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, fmt.Errorf("解析压缩包失败: %w", err)
+	}
+	b, err := newBundle(&rc.Reader, maxSize)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	b.closer = rc
+	return b, nil
+}
+
+// newBundle 索引压缩包中的文件，解压后总大小超过 maxSize 时返回 ErrBundleTooLarge
+func newBundle(reader *zip.Reader, maxSize int64) (*Bundle, error) {
+	b := &Bundle{
+		files:  make(map[string]*zip.File),
+		byName: make(map[string]string),
+		folder: "articles",
+		stored: make(map[string]string),
+	}
+
+	var total uint64
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := cleanBundlePath(f.Name)
+		if name == "" || isHiddenPath(name) {
+			continue
+		}
+
+		total += f.UncompressedSize64
+		if maxSize > 0 && total > uint64(maxSize) {
+			return nil, ErrBundleTooLarge
+		}
+
+		b.files[name] = f
+		if _, ok := b.byName[path.Base(name)]; !ok {
+			b.byName[path.Base(name)] = name
+		}
+
+		ext := strings.ToLower(path.Ext(name))
+		if ext == ".md" || ext == ".markdown" {
+			b.docs = append(b.docs, name)
+		}
+	}
+	sort.Strings(b.docs)
+	b.root = commonRoot(b.docs)
+
+	return b, nil
+}
+
+// Close 关闭从文件打开的压缩包
+func (b *Bundle) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+	return b.closer.Close()
+}
+
+// Documents 读取所有 Markdown 文档，图片不在这里上传，避免提交任务的请求等待上传
+func (b *Bundle) Documents() ([]BundleDocument, error) {
+	docs := make([]BundleDocument, 0, len(b.docs))
+	for _, name := range b.docs {
+		raw, err := b.read(name)
+		if err != nil {
+			return nil, err
+		}
+
+		rel := strings.TrimPrefix(name, b.root)
+		var folders []string
+		if dir := path.Dir(rel); dir != "." {
+			folders = strings.Split(dir, "/")
+		}
+
+		docs = append(docs, BundleDocument{
+			Path:    rel,
+			Folders: folders,
+			Content: string(raw),
+		})
+	}
+	return docs, nil
+}
+
+// ResolveImages 上传文档中引用压缩包内图片的链接并替换为存储地址，docPath 为 Documents 返回的路径
+// 返回每张图片的处理结果，上传成功的为 ImageStatusUploaded，导入失败时调用方可按 StoredURL 删除
+func (b *Bundle) ResolveImages(docPath, content string) (string, []ImageResult) {
+	return b.resolveImages(b.root+docPath, content)
+}
+
+// resolveImages 替换文档中引用压缩包内图片的链接
+func (b *Bundle) resolveImages(docPath, content string) (string, []ImageResult) {
+	var results []ImageResult
+	seen := make(map[string]bool)
+	record := func(src, stored string, err error) {
+		if seen[src] {
+			return
+		}
+		seen[src] = true
+		if err != nil {
+			results = append(results, ImageResult{URL: src, Status: ImageStatusFailed, Error: err.Error()})
+		} else {
+			results = append(results, ImageResult{URL: src, StoredURL: stored, Status: ImageStatusUploaded})
+		}
+	}
+
+	dir := path.Dir(docPath)
+	content = imgRegex.ReplaceAllStringFunc(content, func(m string) string {
+		sub := imgRegex.FindStringSubmatch(m)
+		src := imageURL(sub[2])
+		if !isRelativeRef(src) {
+			return m
+		}
+		stored, err := b.uploadAsset(docPath, b.locate(dir, src))
+		record(src, stored, err)
+		if err != nil {
+			return m
+		}
+		return fmt.Sprintf("![%s](%s)", sub[1], strings.Replace(sub[2], src, stored, 1))
+	})
+
+	content = wikiImgRegex.ReplaceAllStringFunc(content, func(m string) string {
+		sub := wikiImgRegex.FindStringSubmatch(m)
+		src := strings.TrimSpace(sub[1])
+		stored, err := b.uploadAsset(docPath, b.locate(dir, src))
+		record(src, stored, err)
+		if err != nil {
+			return m
+		}
+		return fmt.Sprintf("![%s](%s)", path.Base(src), stored)
+	})
+
+	return content, results
+}
+
+// locate 在压缩包中查找图片：先按相对文档的路径，再按压缩包根路径，最后按文件名
+func (b *Bundle) locate(dir, src string) string {
+	if i := strings.IndexAny(src, "?#"); i >= 0 {
+		src = src[:i]
+	}
+	if unescaped, err := url.PathUnescape(src); err == nil {
+		src = unescaped
+	}
+
+	candidates := []string{
+		cleanBundlePath(path.Join(dir, src)),
+		cleanBundlePath(path.Join(b.root, src)),
+		cleanBundlePath(src),
+	}
+	for _, name := range candidates {
+		if _, ok := b.files[name]; ok {
+			return name
+		}
+	}
+	if name, ok := b.byName[path.Base(src)]; ok {
+		return name
+	}
+	return ""
+}
+
+// uploadAsset 上传文档 docPath 引用的压缩包中的图片，同一文档引用同一文件只上传一次
+func (b *Bundle) uploadAsset(docPath, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("压缩包中不存在该图片")
+	}
+	key := docPath + "\x00" + name
+	if stored, ok := b.stored[key]; ok {
+		return stored, nil
+	}
+
+	data, err := b.read(name)
+	if err != nil {
+		return "", err
+	}
+
+	// 与后台上传文件使用相同的校验，扩展名按内容类型确定（SVG 可能包含脚本，不允许）
+	mimeType, err := upload.ValidateBytes(data, b.folder)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("不是图片: %s", mimeType)
+	}
+
+	// 按文档和内容命名：任务重试时同一文档的图片不会重复保存；不同文档各自保存一份，
+	// 某篇文档导入失败时删除它上传的图片不会影响其它文档
+	h := sha256.New()
+	h.Write([]byte(docPath))
+	h.Write([]byte{0})
+	h.Write(data)
+	sum := h.Sum(nil)
+	filename := fmt.Sprintf("%s/%s/%s%s",
+		b.folder,
+		time.Now().Format("2006/01/02"),
+		hex.EncodeToString(sum[:16]),
+		upload.Extension(mimeType),
+	)
+	stored, err := oss.UploadBytes(data, filename)
+	if err != nil {
+		return "", fmt.Errorf("上传失败: %w", err)
+	}
+
+	b.stored[key] = stored
+	return stored, nil
+}
+
+// read 读取压缩包中的文件
+func (b *Bundle) read(name string) ([]byte, error) {
+	f, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("文件不存在: %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开 %s 失败: %w", name, err)
+	}
+	defer rc.Close()
+
+	// 按声明的大小限制读取，防止伪造头部的压缩炸弹
+	data, err := io.ReadAll(io.LimitReader(rc, int64(f.UncompressedSize64)+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", name, err)
+	}
+	if uint64(len(data)) > f.UncompressedSize64 {
+		return nil, ErrBundleTooLarge
+	}
+	return data, nil
+}
+
+// cleanBundlePath 规范化压缩包内路径，拒绝跳出压缩包根目录的路径
+func cleanBundlePath(name string) string {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimPrefix(name, "/")
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+// isHiddenPath 判断是否为隐藏文件或 macOS 生成的元数据
+func isHiddenPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// isRelativeRef 判断图片链接是否为压缩包内的相对路径
+func isRelativeRef(src string) bool {
+	if src == "" || strings.HasPrefix(src, "/uploads/") || strings.HasPrefix(src, "//") {
+		return false
+	}
+	if u, err := url.Parse(src); err == nil && u.Scheme != "" {
+		return false
+	}
+	return true
+}
+
+// commonRoot 计算所有文档共同的根目录（如导出时外层的单个文件夹），返回带 / 结尾的前缀
+func commonRoot(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	root := path.Dir(names[0])
+	for _, name := range names[1:] {
+		for root != "." && !strings.HasPrefix(name, root+"/") {
+			root = path.Dir(root)
+		}
+	}
+	if root == "." {
+		return ""
+	}
+	return root + "/"
+}
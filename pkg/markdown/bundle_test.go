@@ -0,0 +1,121 @@
+package markdown
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestBundle 按文件名和内容生成 zip 压缩包
+func newTestBundle(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestBundleResolveImages(t *testing.T) {
+	t.Chdir(t.TempDir())
+	data := newTestBundle(t, map[string]string{
+		"export/notes/a.md":     "![a](../images/a.png)\n![x](../images/x.svg)\n![[a.png]]",
+		"export/notes/sub/b.md": "![a](/images/a.png) ![missing](c.png)",
+		"export/images/a.png":   string(pngData),
+		"export/images/x.svg":   `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`,
+	})
+
+	bundle, err := OpenBundle(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := bundle.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].Path != "a.md" || docs[1].Path != "sub/b.md" {
+		t.Fatalf("docs = %+v", docs)
+	}
+	if !strings.Contains(docs[0].Content, "../images/a.png") {
+		t.Errorf("Documents should not upload images: %q", docs[0].Content)
+	}
+
+	contentA, resultsA := bundle.ResolveImages(docs[0].Path, docs[0].Content)
+	lines := strings.Split(contentA, "\n")
+	stored := strings.TrimSuffix(strings.TrimPrefix(lines[0], "![a]("), ")")
+	if !strings.HasPrefix(stored, "/uploads/articles/") || filepath.Ext(stored) != ".png" {
+		t.Fatalf("stored = %q", stored)
+	}
+	if lines[2] != "![a.png]("+stored+")" {
+		t.Errorf("wiki embed = %q, want %s", lines[2], stored)
+	}
+	if _, err := os.Stat(strings.TrimPrefix(stored, "/")); err != nil {
+		t.Errorf("stored file missing: %v", err)
+	}
+	// 同一文档多次引用同一张图片只上传一次，失败的图片单独列出
+	want := []ImageResult{
+		{URL: "../images/a.png", StoredURL: stored, Status: ImageStatusUploaded},
+		{URL: "../images/x.svg", Status: ImageStatusFailed},
+		{URL: "a.png", StoredURL: stored, Status: ImageStatusUploaded},
+	}
+	if len(resultsA) != len(want) {
+		t.Fatalf("results = %+v", resultsA)
+	}
+	for i, r := range resultsA {
+		r.Error = ""
+		if r != want[i] {
+			t.Errorf("results[%d] = %+v, want %+v", i, r, want[i])
+		}
+	}
+
+	// 任务重试时同一文档的图片存储地址不变，不会重复保存
+	reopened, _ := OpenBundle(data, 0)
+	if again, _ := reopened.ResolveImages(docs[0].Path, docs[0].Content); again != contentA {
+		t.Errorf("retry content = %q, want %q", again, contentA)
+	}
+
+	// 不同文档引用同一张图片时各自保存，删除其中一份不影响另一份
+	contentB, resultsB := reopened.ResolveImages(docs[1].Path, docs[1].Content)
+	if strings.Contains(contentB, stored) || !strings.Contains(contentB, "![a](/uploads/articles/") {
+		t.Errorf("content = %q, want a separate copy of %s", contentB, stored)
+	}
+	if len(resultsB) != 2 || resultsB[1].URL != "c.png" || resultsB[1].Status != ImageStatusFailed {
+		t.Errorf("results = %+v, want missing image", resultsB)
+	}
+}
+
+func TestOpenBundleFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "bundle.zip")
+	data := newTestBundle(t, map[string]string{"a.md": "# A", "b.md": strings.Repeat("b", 100)})
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := OpenBundleFile(name, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := bundle.Documents()
+	if err != nil || len(docs) != 2 {
+		t.Errorf("docs = %d, %v", len(docs), err)
+	}
+	if err := bundle.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := OpenBundleFile(name, 50); !errors.Is(err, ErrBundleTooLarge) {
+		t.Errorf("err = %v, want ErrBundleTooLarge", err)
+	}
+}
@@ -0,0 +1,207 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter Hugo/Hexo 风格的文章元数据
+type FrontMatter struct {
+	Title      string
+	Date       *time.Time
+	Tags       []string
+	Categories []string
+	Summary    string
+	Cover      string
+	Draft      *bool // nil 表示未指定
+	Slug       string
+	Chapter    string
+}
+
+// dateLayouts 支持的日期格式
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// ParseFrontMatter 解析 Markdown 开头的 YAML（---）或 TOML（+++）元数据
+// 返回元数据和去掉元数据后的正文；没有元数据时返回 nil 和原文
+func ParseFrontMatter(content string) (*FrontMatter, string, error) {
+	// 去掉 UTF-8 BOM
+	content = strings.TrimPrefix(content, "\ufeff")
+
+	var delimiter string
+	switch {
+	case strings.HasPrefix(content, "---"):
+		delimiter = "---"
+	case strings.HasPrefix(content, "+++"):
+		delimiter = "+++"
+	default:
+		return nil, content, nil
+	}
+
+	// 定界符必须独占一行
+	firstLineEnd := strings.IndexByte(content, '\n')
+	if firstLineEnd < 0 || strings.TrimSpace(content[:firstLineEnd]) != delimiter {
+		return nil, content, nil
+	}
+
+	rest := content[firstLineEnd+1:]
+	var raw, body string
+	found := false
+	for offset := 0; offset < len(rest); {
+		lineEnd := strings.IndexByte(rest[offset:], '\n')
+		var line string
+		next := len(rest)
+		if lineEnd >= 0 {
+			line = rest[offset : offset+lineEnd]
+			next = offset + lineEnd + 1
+		} else {
+			line = rest[offset:]
+		}
+		if strings.TrimSpace(line) == delimiter {
+			raw = rest[:offset]
+			body = rest[next:]
+			found = true
+			break
+		}
+		offset = next
+	}
+	if !found {
+		return nil, content, nil
+	}
+
+	values := make(map[string]interface{})
+	var err error
+	if delimiter == "---" {
+		err = yaml.Unmarshal([]byte(raw), &values)
+	} else {
+		err = toml.Unmarshal([]byte(raw), &values)
+	}
+	if err != nil {
+		return nil, content, fmt.Errorf("解析 Front Matter 失败: %w", err)
+	}
+
+	return buildFrontMatter(values), strings.TrimLeft(body, "\r\n"), nil
+}
+
+// buildFrontMatter 将原始键值转换为 FrontMatter，兼容 Hugo 和 Hexo 的常用字段
+func buildFrontMatter(values map[string]interface{}) *FrontMatter {
+	fm := &FrontMatter{}
+	lower := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		lower[strings.ToLower(k)] = v
+	}
+
+	fm.Title = toString(lower["title"])
+	fm.Slug = toString(lower["slug"])
+	fm.Chapter = toString(lower["chapter"])
+
+	fm.Summary = toString(lower["summary"])
+	if fm.Summary == "" {
+		fm.Summary = toString(lower["description"])
+	}
+	if fm.Summary == "" {
+		fm.Summary = toString(lower["excerpt"])
+	}
+
+	// 封面：cover、image、thumbnail，或 Hugo PaperMod 的 cover.image
+	switch cover := lower["cover"].(type) {
+	case map[string]interface{}:
+		fm.Cover = toString(cover["image"])
+	default:
+		fm.Cover = toString(cover)
+	}
+	if fm.Cover == "" {
+		fm.Cover = toString(lower["image"])
+	}
+	if fm.Cover == "" {
+		fm.Cover = toString(lower["thumbnail"])
+	}
+
+	if date := toTime(lower["date"]); date != nil {
+		fm.Date = date
+	}
+
+	fm.Tags = toStrings(lower["tags"])
+	fm.Categories = toStrings(lower["categories"])
+	if len(fm.Categories) == 0 {
+		fm.Categories = toStrings(lower["category"])
+	}
+
+	// 草稿：Hugo 使用 draft，Hexo 使用 published
+	if draft, ok := lower["draft"].(bool); ok {
+		fm.Draft = &draft
+	} else if published, ok := lower["published"].(bool); ok {
+		draft := !published
+		fm.Draft = &draft
+	}
+
+	return fm
+}
+
+// toString 将任意值转换为字符串
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	default:
+		return strings.TrimSpace(fmt.Sprint(val))
+	}
+}
+
+// toStrings 将字符串或列表转换为字符串切片，Hexo 的多级分类列表会被展开
+func toStrings(v interface{}) []string {
+	var result []string
+	switch val := v.(type) {
+	case nil:
+	case string:
+		for _, part := range strings.Split(val, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			result = append(result, toStrings(item)...)
+		}
+	default:
+		if s := toString(val); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// toTime 将日期值转换为时间
+func toTime(v interface{}) *time.Time {
+	switch val := v.(type) {
+	case time.Time:
+		return &val
+	case toml.LocalDateTime:
+		t := val.AsTime(time.Local)
+		return &t
+	case toml.LocalDate:
+		t := val.AsTime(time.Local)
+		return &t
+	case string:
+		s := strings.TrimSpace(val)
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return &t
+			}
+		}
+	}
+	return nil
+}
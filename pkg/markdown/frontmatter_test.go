@@ -0,0 +1,130 @@
+package markdown
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		title      string
+		date       string // 按 "2006-01-02 15:04" 格式化，空表示没有日期
+		tags       []string
+		categories []string
+		draft      string // nil、true 或 false
+		body       string
+		noMatter   bool // 没有元数据，原文返回
+	}{
+		{
+			name:    "yaml",
+			content: "---\ntitle: 你好\ndate: 2024-01-02 10:30:00\ntags: [go, web]\ncategories: [后端]\ndraft: false\n---\n\n正文",
+			title:   "你好", date: "2024-01-02 10:30", tags: []string{"go", "web"}, categories: []string{"后端"}, draft: "false", body: "正文",
+		},
+		{
+			name:    "toml",
+			content: "+++\ntitle = \"你好\"\ndate = 2024-01-02T10:30:00+08:00\ntags = [\"go\"]\ncategories = \"后端\"\ndraft = true\n+++\n正文",
+			title:   "你好", date: "2024-01-02 10:30", tags: []string{"go"}, categories: []string{"后端"}, draft: "true", body: "正文",
+		},
+		{
+			name:    "bom and crlf",
+			content: "\ufeff---\r\ntitle: BOM\r\n---\r\n正文",
+			title:   "BOM", draft: "nil", body: "正文",
+		},
+		// 日期格式
+		{name: "date only", content: "---\ndate: 2024-01-02\n---\n", date: "2024-01-02 00:00", draft: "nil"},
+		{name: "quoted rfc3339", content: "---\ndate: \"2024-01-02T10:30:00+08:00\"\n---\n", date: "2024-01-02 10:30", draft: "nil"},
+		{name: "date without seconds", content: "---\ndate: \"2024-01-02 10:30\"\n---\n", date: "2024-01-02 10:30", draft: "nil"},
+		{name: "slash date", content: "---\ndate: 2024/01/02 10:30:00\n---\n", date: "2024-01-02 10:30", draft: "nil"},
+		{name: "toml local date", content: "+++\ndate = 2024-01-02\n+++\n", date: "2024-01-02 00:00", draft: "nil"},
+		{name: "toml local datetime", content: "+++\ndate = 2024-01-02T10:30:00\n+++\n", date: "2024-01-02 10:30", draft: "nil"},
+		{name: "invalid date", content: "---\ndate: 昨天\n---\n", draft: "nil"},
+		// 标签和分类既可以是字符串也可以是列表
+		{
+			name:    "comma separated scalars",
+			content: "---\ntags: go, web ,\ncategory: 后端\n---\n",
+			tags:    []string{"go", "web"}, categories: []string{"后端"}, draft: "nil",
+		},
+		{
+			name:    "hexo nested categories",
+			content: "---\ntags:\n  - go\n  - 2024\ncategories:\n  - [后端, Go]\n  - 随笔\n---\n",
+			tags:    []string{"go", "2024"}, categories: []string{"后端", "Go", "随笔"}, draft: "nil",
+		},
+		// 草稿：draft 优先，Hexo 的 published 取反
+		{name: "hexo published", content: "---\npublished: false\n---\n", draft: "true"},
+		{name: "draft wins over published", content: "---\ndraft: false\npublished: false\n---\n", draft: "false"},
+		{name: "draft not bool", content: "---\ndraft: \"yes\"\n---\n", draft: "nil"},
+		// 没有元数据
+		{name: "plain markdown", content: "# 标题\n正文", body: "# 标题\n正文", noMatter: true},
+		{name: "horizontal rule", content: "--- \n正文没有结束定界符", body: "--- \n正文没有结束定界符", noMatter: true},
+		{name: "delimiter not alone", content: "---title: x\n---\n", body: "---title: x\n---\n", noMatter: true},
+	}
+
+	for _, tt := range tests {
+		fm, body, err := ParseFrontMatter(tt.content)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.noMatter {
+			if fm != nil || body != tt.body {
+				t.Errorf("%s: fm = %+v, body = %q, want nil and original", tt.name, fm, body)
+			}
+			continue
+		}
+		if fm == nil {
+			t.Errorf("%s: fm = nil", tt.name)
+			continue
+		}
+		if body != tt.body {
+			t.Errorf("%s: body = %q, want %q", tt.name, body, tt.body)
+		}
+		if fm.Title != tt.title {
+			t.Errorf("%s: title = %q, want %q", tt.name, fm.Title, tt.title)
+		}
+		date := ""
+		if fm.Date != nil {
+			date = fm.Date.Format("2006-01-02 15:04")
+		}
+		if date != tt.date {
+			t.Errorf("%s: date = %q, want %q", tt.name, date, tt.date)
+		}
+		if !reflect.DeepEqual(fm.Tags, tt.tags) {
+			t.Errorf("%s: tags = %q, want %q", tt.name, fm.Tags, tt.tags)
+		}
+		if !reflect.DeepEqual(fm.Categories, tt.categories) {
+			t.Errorf("%s: categories = %q, want %q", tt.name, fm.Categories, tt.categories)
+		}
+		draft := "nil"
+		if fm.Draft != nil {
+			draft = map[bool]string{true: "true", false: "false"}[*fm.Draft]
+		}
+		if draft != tt.draft {
+			t.Errorf("%s: draft = %s, want %s", tt.name, draft, tt.draft)
+		}
+	}
+}
+
+func TestParseFrontMatterInvalid(t *testing.T) {
+	for _, content := range []string{
+		"---\ntitle: [unclosed\n---\n正文",
+		"+++\ntitle = \n+++\n正文",
+	} {
+		fm, body, err := ParseFrontMatter(content)
+		if err == nil || fm != nil || body != content {
+			t.Errorf("ParseFrontMatter(%q) = %+v, %q, %v, want error and original content", content, fm, body, err)
+		}
+	}
+}
+
+func TestParseFrontMatterFields(t *testing.T) {
+	fm, _, err := ParseFrontMatter("---\nTitle: 大写键\nslug: hello\nchapter: 第一章\ndescription: 简介\ncover:\n  image: /a.png\n---\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FrontMatter{Title: "大写键", Slug: "hello", Chapter: "第一章", Summary: "简介", Cover: "/a.png"}
+	if !reflect.DeepEqual(*fm, want) {
+		t.Errorf("fm = %+v, want %+v", *fm, want)
+	}
+}
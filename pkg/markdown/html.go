@@ -0,0 +1,314 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// shortcodeRegex 匹配 WordPress 的 [caption]、[embed] 等短代码标签
+	shortcodeRegex = regexp.MustCompile(`\[/?(caption|embed|gallery|audio|video)[^\]]*\]`)
+	// blankLinesRegex 匹配三个及以上的连续换行
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+	// paragraphRegex 匹配文本中的段落分隔（WordPress 经典编辑器不输出 <p>）
+	paragraphRegex = regexp.MustCompile(`[ \t]*\n[ \t]*\n\s*`)
+	// spaceRegex 匹配连续空白
+	spaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// HTMLToMarkdown 将 HTML（如 WordPress 文章正文）转换为 Markdown
+// 无法用 Markdown 表达的元素（如表格、iframe）保留原始 HTML
+func HTMLToMarkdown(content string) (string, error) {
+	content = shortcodeRegex.ReplaceAllString(content, "")
+
+	nodes, err := html.ParseFragment(strings.NewReader(content), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("解析 HTML 失败: %w", err)
+	}
+
+	c := &htmlConverter{}
+	for _, n := range nodes {
+		c.node(n)
+	}
+
+	result := blankLinesRegex.ReplaceAllString(c.buf.String(), "\n\n")
+	return strings.TrimSpace(result) + "\n", nil
+}
+
+// htmlConverter HTML 到 Markdown 的转换状态
+type htmlConverter struct {
+	buf       strings.Builder
+	listStack []listState // 嵌套列表
+}
+
+// listState 列表状态
+type listState struct {
+	ordered bool
+	index   int
+}
+
+// node 转换单个节点
+func (c *htmlConverter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Figure:
+		c.block()
+		c.children(n)
+		c.block()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		c.block()
+		level := int(n.Data[1] - '0')
+		c.write(strings.Repeat("#", level) + " " + strings.TrimSpace(c.inline(n)))
+		c.block()
+	case atom.Br:
+		c.write("  \n")
+	case atom.Hr:
+		c.block()
+		c.write("---")
+		c.block()
+	case atom.Strong, atom.B:
+		c.wrap(n, "**")
+	case atom.Em, atom.I:
+		c.wrap(n, "*")
+	case atom.Del, atom.S, atom.Strike:
+		c.wrap(n, "~~")
+	case atom.Code:
+		c.write("`" + textContent(n) + "`")
+	case atom.Pre:
+		c.pre(n)
+	case atom.A:
+		text := strings.TrimSpace(c.inline(n))
+		href := attr(n, "href")
+		if href == "" {
+			c.write(text)
+		} else if text == "" {
+			c.write("<" + href + ">")
+		} else {
+			c.write("[" + text + "](" + href + ")")
+		}
+	case atom.Img:
+		c.write("![" + attr(n, "alt") + "](" + attr(n, "src") + ")")
+	case atom.Figcaption:
+		c.block()
+		c.write("*" + strings.TrimSpace(c.inline(n)) + "*")
+		c.block()
+	case atom.Ul, atom.Ol:
+		c.list(n)
+	case atom.Li:
+		c.item(n)
+	case atom.Blockquote:
+		sub := &htmlConverter{}
+		sub.children(n)
+		lines := strings.Split(strings.TrimSpace(blankLinesRegex.ReplaceAllString(sub.buf.String(), "\n\n")), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		c.block()
+		c.write(strings.Join(lines, "\n"))
+		c.block()
+	case atom.Table, atom.Iframe, atom.Video, atom.Audio:
+		c.block()
+		c.write(renderHTML(n))
+		c.block()
+	case atom.Script, atom.Style:
+	default:
+		c.children(n)
+	}
+}
+
+// children 转换所有子节点
+func (c *htmlConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+// text 输出文本，保留段落分隔，合并其它空白
+func (c *htmlConverter) text(s string) {
+	parts := paragraphRegex.Split(s, -1)
+	for i, part := range parts {
+		if i > 0 {
+			c.block()
+		}
+		part = spaceRegex.ReplaceAllString(part, " ")
+		if strings.HasSuffix(c.buf.String(), "\n") || c.buf.Len() == 0 {
+			part = strings.TrimLeft(part, " ")
+		}
+		c.write(part)
+	}
+}
+
+// write 直接输出内容
+func (c *htmlConverter) write(s string) {
+	c.buf.WriteString(s)
+}
+
+// block 开始一个新块（段落之间空一行）
+func (c *htmlConverter) block() {
+	out := c.buf.String()
+	if out == "" {
+		return
+	}
+	trimmed := strings.TrimRight(out, " ")
+	if len(trimmed) != len(out) {
+		c.buf.Reset()
+		c.buf.WriteString(trimmed)
+	}
+	switch {
+	case strings.HasSuffix(trimmed, "\n\n"):
+	case strings.HasSuffix(trimmed, "\n"):
+		c.write("\n")
+	default:
+		c.write("\n\n")
+	}
+}
+
+// wrap 用标记包裹行内元素
+func (c *htmlConverter) wrap(n *html.Node, mark string) {
+	text := strings.TrimSpace(c.inline(n))
+	if text == "" {
+		return
+	}
+	c.write(mark + text + mark)
+}
+
+// inline 将子节点转换为行内 Markdown，跳过嵌套列表
+func (c *htmlConverter) inline(n *html.Node) string {
+	sub := &htmlConverter{}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom != atom.Ul && child.DataAtom != atom.Ol {
+			sub.node(child)
+		}
+	}
+	return strings.ReplaceAll(strings.TrimSpace(sub.buf.String()), "\n\n", " ")
+}
+
+// pre 输出代码块，尝试从 class 中识别语言
+func (c *htmlConverter) pre(n *html.Node) {
+	lang := codeLanguage(attr(n, "class"))
+	code := n
+	if n.FirstChild != nil && n.FirstChild == n.LastChild && n.FirstChild.DataAtom == atom.Code {
+		code = n.FirstChild
+		if lang == "" {
+			lang = codeLanguage(attr(code, "class"))
+		}
+	}
+	c.block()
+	c.write("```" + lang + "\n" + strings.Trim(textContent(code), "\n") + "\n```")
+	c.block()
+}
+
+// list 输出列表
+func (c *htmlConverter) list(n *html.Node) {
+	if len(c.listStack) == 0 {
+		c.block()
+	}
+	c.listStack = append(c.listStack, listState{ordered: n.DataAtom == atom.Ol})
+	c.children(n)
+	c.listStack = c.listStack[:len(c.listStack)-1]
+	if len(c.listStack) == 0 {
+		c.block()
+	}
+}
+
+// item 输出列表项
+func (c *htmlConverter) item(n *html.Node) {
+	if len(c.listStack) == 0 {
+		c.children(n)
+		return
+	}
+	state := &c.listStack[len(c.listStack)-1]
+	state.index++
+
+	marker := "- "
+	if state.ordered {
+		marker = fmt.Sprintf("%d. ", state.index)
+	}
+	indent := strings.Repeat("  ", len(c.listStack)-1)
+
+	out := strings.TrimRight(c.buf.String(), " ")
+	c.buf.Reset()
+	c.buf.WriteString(out)
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		c.write("\n")
+	}
+	c.write(indent + marker + c.inline(n))
+	c.write("\n")
+
+	// 嵌套列表单独处理
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Ul || child.DataAtom == atom.Ol {
+			c.list(child)
+		}
+	}
+}
+
+// textContent 获取节点的纯文本内容
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.DataAtom == atom.Br {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(textContent(child))
+	}
+	return sb.String()
+}
+
+// attr 获取节点属性
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// codeLanguage 从 class 中识别代码语言，兼容 language-go、lang-go 和 SyntaxHighlighter 的 brush: go
+func codeLanguage(class string) string {
+	for _, field := range strings.Fields(strings.ReplaceAll(class, ";", " ")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(field, prefix) {
+				return strings.TrimPrefix(field, prefix)
+			}
+		}
+	}
+	if i := strings.Index(class, "brush:"); i >= 0 {
+		if fields := strings.Fields(class[i+len("brush:"):]); len(fields) > 0 {
+			return strings.TrimSuffix(fields[0], ";")
+		}
+	}
+	return ""
+}
+
+// renderHTML 输出节点的原始 HTML
+func renderHTML(n *html.Node) string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, n); err != nil {
+		return textContent(n)
+	}
+	return buf.String()
+}
@@ -1,110 +1,368 @@
 package markdown
 
 import (
+	"container/list"
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
+	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/ydcloud-dy/leaf-api/config"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
 	"github.com/ydcloud-dy/leaf-api/pkg/oss"
+	"github.com/ydcloud-dy/leaf-api/pkg/upload"
 )
 
+// 图片处理状态
+const (
+	ImageStatusUploaded = "uploaded" // 下载并上传成功
+	ImageStatusCached   = "cached"   // 命中缓存，直接复用已上传的地址
+	ImageStatusSkipped  = "skipped"  // 按规则跳过
+	ImageStatusFailed   = "failed"   // 处理失败
+)
+
+// 域名规则动作
+const (
+	ActionDownload = "download" // 直接下载，失败后如配置了代理则走代理
+	ActionSkip     = "skip"     // 保留原链接
+	ActionProxy    = "proxy"    // 始终通过代理下载
+)
+
+// memoryCacheSize 进程内图片地址缓存的最大条目数，超过时淘汰最久未使用的
+const memoryCacheSize = 10000
+
+// imgRegex 匹配 Markdown 图片语法: ![alt](url "title")
+var imgRegex = regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
+
+// defaultRules 未配置 image.rules 时使用的内置规则
+var defaultRules = []config.ImageHostRule{
+	{Host: "aliyuncs.com", Action: ActionSkip},
+	{Host: "nlark.com", Action: ActionDownload, Referer: "https://www.yuque.com/", Proxy: "https://images.weserv.nl/?url="},
+	{Host: "yuque.com", Action: ActionDownload, Referer: "https://www.yuque.com/", Proxy: "https://images.weserv.nl/?url="},
+}
+
+// URLCache 源图片地址到已上传地址的缓存
+type URLCache interface {
+	// Get 查询源地址对应的已上传地址
+	Get(sourceURL string) (string, bool)
+	// Set 记录源地址对应的已上传地址
+	Set(sourceURL, storedURL string) error
+}
+
+// ImageResult 单张图片的处理结果
+type ImageResult struct {
+	URL       string `json:"url"`
+	StoredURL string `json:"stored_url,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProcessResult 一篇 Markdown 的图片处理结果
+type ProcessResult struct {
+	Images []ImageResult `json:"images"`
+}
+
+// Failed 返回处理失败的图片
+func (r *ProcessResult) Failed() []ImageResult {
+	failed := make([]ImageResult, 0)
+	for _, img := range r.Images {
+		if img.Status == ImageStatusFailed {
+			failed = append(failed, img)
+		}
+	}
+	return failed
+}
+
 // ImageProcessor Markdown 图片处理器
 type ImageProcessor struct {
-	folder string // OSS 文件夹名称
+	folder  string // OSS 文件夹名称
+	client  *http.Client
+	workers int
+	perHost int
+	maxSize int64
+	rules   []config.ImageHostRule
+	cache   URLCache
+
+	mu        sync.Mutex
+	memory    map[string]*list.Element // 进程内缓存，值为 memoryLRU 中的节点
+	memoryLRU *list.List               // 按最近使用排序，队首最新，元素为 memoryEntry
+	hostSems  map[string]chan struct{} // 每个域名的并发限制
+}
+
+// memoryEntry 进程内缓存条目
+type memoryEntry struct {
+	src    string
+	stored string
 }
 
 // NewImageProcessor 创建图片处理器
 // uploadDir 和 baseURL 参数保留用于兼容性,但实际使用 OSS
 func NewImageProcessor(uploadDir, baseURL string) *ImageProcessor {
+	cfg := config.ImageConfig{Workers: 8, PerHost: 2, Timeout: 30, MaxSize: 20}
+	if config.AppConfig != nil {
+		cfg = config.AppConfig.Image
+	}
+
+	rules := cfg.Rules
+	if len(rules) == 0 {
+		rules = defaultRules
+	}
+
 	return &ImageProcessor{
-		folder: "articles", // 使用 articles 文件夹,与手动上传图片保持一致
+		folder:    "articles", // 使用 articles 文件夹,与手动上传图片保持一致
+		client:    &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+		workers:   cfg.Workers,
+		perHost:   cfg.PerHost,
+		maxSize:   cfg.MaxSize * 1024 * 1024,
+		rules:     rules,
+		memory:    make(map[string]*list.Element),
+		memoryLRU: list.New(),
+		hostSems:  make(map[string]chan struct{}),
 	}
 }
 
+// WithCache 设置持久化缓存，重复导入同一图片时直接复用已上传地址
+func (p *ImageProcessor) WithCache(cache URLCache) *ImageProcessor {
+	p.cache = cache
+	return p
+}
+
 // ProcessMarkdownImages 处理 Markdown 中的图片
 // 下载所有外部图片并上传到OSS,替换为OSS/本地链接
 func (p *ImageProcessor) ProcessMarkdownImages(content string) (string, error) {
-	// 匹配 Markdown 图片语法: ![alt](url)
-	imgRegex := regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
+	processed, _ := p.Process(content)
+	return processed, nil
+}
 
-	// 查找所有图片
-	matches := imgRegex.FindAllStringSubmatch(content, -1)
-	if len(matches) == 0 {
-		fmt.Println("[图片处理] 未找到任何图片链接")
-		return content, nil
+// Process 处理 Markdown 中的图片并返回每张图片的处理结果
+// 图片按 worker 池并发下载，单张失败不影响其它图片，失败的图片保留原链接
+func (p *ImageProcessor) Process(content string) (string, *ProcessResult) {
+	result := &ProcessResult{Images: []ImageResult{}}
+
+	// 收集去重后的图片地址，保持出现顺序
+	var urls []string
+	seen := make(map[string]bool)
+	for _, match := range imgRegex.FindAllStringSubmatch(content, -1) {
+		src := imageURL(match[2])
+		if src == "" || seen[src] {
+			continue
+		}
+		seen[src] = true
+		urls = append(urls, src)
+	}
+	if len(urls) == 0 {
+		return content, result
 	}
 
-	fmt.Printf("[图片处理] 找到 %d 个图片链接\n", len(matches))
+	logger.Debug("[图片处理] 找到 ", len(urls), " 个图片链接")
 
-	// 处理每个图片
-	for _, match := range matches {
-		if len(match) < 3 {
-			continue
+	results := make([]ImageResult, len(urls))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := p.workers
+	if workers <= 0 || workers > len(urls) {
+		workers = len(urls)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = p.processOne(urls[i])
+			}
+		}()
+	}
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// 替换图片链接
+	replaced := make(map[string]string)
+	for _, r := range results {
+		if r.StoredURL != "" && r.StoredURL != r.URL {
+			replaced[r.URL] = r.StoredURL
+		}
+	}
+	content = imgRegex.ReplaceAllStringFunc(content, func(m string) string {
+		sub := imgRegex.FindStringSubmatch(m)
+		src := imageURL(sub[2])
+		stored, ok := replaced[src]
+		if !ok {
+			return m
 		}
+		return fmt.Sprintf("![%s](%s)", sub[1], strings.Replace(sub[2], src, stored, 1))
+	})
 
-		originalURL := match[2]
-		alt := match[1]
+	result.Images = results
+	return content, result
+}
 
-		// 跳过已经是OSS或本地图片的情况
-		if strings.HasPrefix(originalURL, "/uploads/") ||
-			strings.Contains(originalURL, "oss-cn-") ||
-			strings.Contains(originalURL, "aliyuncs.com") {
-			fmt.Printf("[图片处理] 跳过已处理的图片: %s\n", originalURL)
-			continue
+// processOne 处理单张图片
+func (p *ImageProcessor) processOne(src string) ImageResult {
+	res := ImageResult{URL: src}
+	log := logger.WithFields(logrus.Fields{"url": src})
+
+	// 跳过已经是本地或非 http(s) 的图片
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || strings.HasPrefix(src, "/uploads/") {
+		res.Status = ImageStatusSkipped
+		return res
+	}
+
+	rule := p.matchRule(u.Hostname())
+	if rule.Action == ActionSkip {
+		res.Status = ImageStatusSkipped
+		return res
+	}
+
+	if stored, ok := p.lookup(src); ok {
+		res.StoredURL = stored
+		res.Status = ImageStatusCached
+		return res
+	}
+
+	stored, err := p.downloadAndUploadImage(u, rule)
+	if err != nil {
+		log.WithField("error", err).Warn("[图片处理] 处理图片失败")
+		res.Status = ImageStatusFailed
+		res.Error = err.Error()
+		return res
+	}
+
+	p.store(src, stored)
+	log.WithField("stored_url", stored).Debug("[图片处理] 图片上传成功")
+	res.StoredURL = stored
+	res.Status = ImageStatusUploaded
+	return res
+}
+
+// matchRule 按域名后缀匹配规则，未匹配时直接下载
+func (p *ImageProcessor) matchRule(host string) config.ImageHostRule {
+	host = strings.ToLower(host)
+	for _, rule := range p.rules {
+		h := strings.ToLower(strings.TrimPrefix(rule.Host, "*."))
+		if host == h || strings.HasSuffix(host, "."+h) {
+			if rule.Action == "" {
+				rule.Action = ActionDownload
+			}
+			return rule
 		}
+	}
+	return config.ImageHostRule{Action: ActionDownload}
+}
 
-		fmt.Printf("[图片处理] 开始下载图片: %s\n", originalURL)
+// lookup 查询缓存
+func (p *ImageProcessor) lookup(src string) (string, bool) {
+	p.mu.Lock()
+	stored, ok := p.memoryGet(src)
+	p.mu.Unlock()
+	if ok {
+		return stored, true
+	}
+	if p.cache != nil {
+		if stored, ok := p.cache.Get(src); ok {
+			p.mu.Lock()
+			p.memorySet(src, stored)
+			p.mu.Unlock()
+			return stored, true
+		}
+	}
+	return "", false
+}
 
-		// 下载图片并上传到OSS
-		uploadedURL, err := p.downloadAndUploadImage(originalURL)
-		if err != nil {
-			fmt.Printf("[图片处理] 处理图片失败 %s: %v\n", originalURL, err)
-			continue
+// store 写入缓存
+func (p *ImageProcessor) store(src, stored string) {
+	p.mu.Lock()
+	p.memorySet(src, stored)
+	p.mu.Unlock()
+	if p.cache != nil {
+		if err := p.cache.Set(src, stored); err != nil {
+			logger.Warn("[图片处理] 写入图片缓存失败: ", err)
 		}
+	}
+}
 
-		fmt.Printf("[图片处理] 图片上传成功,URL: %s\n", uploadedURL)
+// memoryGet 查询进程内缓存并标记为最近使用，调用方需持有 p.mu
+func (p *ImageProcessor) memoryGet(src string) (string, bool) {
+	elem, ok := p.memory[src]
+	if !ok {
+		return "", false
+	}
+	p.memoryLRU.MoveToFront(elem)
+	return elem.Value.(memoryEntry).stored, true
+}
 
-		// 替换图片链接
-		oldPattern := fmt.Sprintf("![%s](%s)", alt, originalURL)
-		newPattern := fmt.Sprintf("![%s](%s)", alt, uploadedURL)
-		content = strings.ReplaceAll(content, oldPattern, newPattern)
+// memorySet 写入进程内缓存，超过容量时淘汰最久未使用的条目，调用方需持有 p.mu
+func (p *ImageProcessor) memorySet(src, stored string) {
+	if elem, ok := p.memory[src]; ok {
+		elem.Value = memoryEntry{src: src, stored: stored}
+		p.memoryLRU.MoveToFront(elem)
+		return
+	}
+	p.memory[src] = p.memoryLRU.PushFront(memoryEntry{src: src, stored: stored})
+	for p.memoryLRU.Len() > memoryCacheSize {
+		oldest := p.memoryLRU.Back()
+		p.memoryLRU.Remove(oldest)
+		delete(p.memory, oldest.Value.(memoryEntry).src)
 	}
+}
 
-	return content, nil
+// hostSemaphore 获取域名的并发信号量
+func (p *ImageProcessor) hostSemaphore(host string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sem, ok := p.hostSems[host]
+	if !ok {
+		size := p.perHost
+		if size <= 0 {
+			size = 1
+		}
+		sem = make(chan struct{}, size)
+		p.hostSems[host] = sem
+	}
+	return sem
 }
 
 // downloadAndUploadImage 下载图片并上传到OSS
-func (p *ImageProcessor) downloadAndUploadImage(url string) (string, error) {
-	// 创建 HTTP 客户端
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+func (p *ImageProcessor) downloadAndUploadImage(u *url.URL, rule config.ImageHostRule) (string, error) {
+	src := u.String()
+	referer := rule.Referer
+	if referer == "" {
+		referer = u.Scheme + "://" + u.Host + "/"
 	}
 
-	// 尝试直接下载
-	imgData, contentType, err := p.tryDownload(client, url)
-	if err != nil {
-		// 如果是语雀图片且下载失败,尝试使用图片代理
-		if strings.Contains(url, "cdn.nlark.com") || strings.Contains(url, "yuque.com") {
-			fmt.Printf("[图片处理] 直接下载失败,尝试使用图片代理\n")
-			proxyURL := "https://images.weserv.nl/?url=" + url
-			imgData, contentType, err = p.tryDownload(client, proxyURL)
+	var imgData []byte
+	var err error
+	if rule.Action == ActionProxy && rule.Proxy != "" {
+		imgData, err = p.tryDownload(rule.Proxy+src, "")
+	} else {
+		imgData, err = p.tryDownload(src, referer)
+		if err != nil && rule.Proxy != "" {
+			// 直接下载失败,尝试使用图片代理
+			imgData, err = p.tryDownload(rule.Proxy+src, "")
 			if err != nil {
 				return "", fmt.Errorf("代理下载也失败: %w", err)
 			}
-		} else {
-			return "", err
 		}
 	}
+	if err != nil {
+		return "", err
+	}
 
-	// 获取文件扩展名
-	ext := filepath.Ext(url)
-	if ext == "" || len(ext) > 5 {
-		ext = getExtByContentType(contentType)
+	// 与手动上传使用相同的校验规则，只按内容判断类型，不信任响应头和链接中的扩展名；
+	// SVG 无法嗅探且可能包含脚本，不接受
+	mimeType, err := upload.ValidateBytes(imgData, p.folder)
+	if err != nil {
+		return "", err
 	}
 
 	// 生成 OSS 文件路径: articles/2025/11/28/uuid.ext
@@ -112,7 +370,7 @@ func (p *ImageProcessor) downloadAndUploadImage(url string) (string, error) {
 		p.folder,
 		time.Now().Format("2006/01/02"),
 		uuid.New().String(),
-		ext,
+		upload.Extension(mimeType),
 	)
 
 	// 上传到 OSS (如果 OSS 不可用会自动fallback到本地存储)
@@ -124,58 +382,60 @@ func (p *ImageProcessor) downloadAndUploadImage(url string) (string, error) {
 	return uploadedURL, nil
 }
 
-// tryDownload 尝试下载图片,返回图片数据和 Content-Type
-func (p *ImageProcessor) tryDownload(client *http.Client, url string) ([]byte, string, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// tryDownload 尝试下载图片,返回图片数据
+func (p *ImageProcessor) tryDownload(rawURL, referer string) ([]byte, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
 	if err != nil {
-		return nil, "", fmt.Errorf("创建请求失败: %w", err)
+		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
+	// 同一域名限制并发，避免触发对方限流
+	sem := p.hostSemaphore(req.URL.Host)
+	sem <- struct{}{}
+	defer func() { <-sem }()
+
 	// 设置请求头绕过防盗链
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Referer", "https://www.yuque.com/")
-	req.Header.Set("Accept", "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8")
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	req.Header.Set("Accept", "image/avif,image/webp,image/apng,image/*,*/*;q=0.8")
 	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Pragma", "no-cache")
 
-	resp, err := client.Do(req)
+	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("下载图片失败: %w", err)
+		return nil, fmt.Errorf("下载图片失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("HTTP 状态码错误: %d", resp.StatusCode)
+		return nil, fmt.Errorf("HTTP 状态码错误: %d", resp.StatusCode)
 	}
 
-	imgData, err := io.ReadAll(resp.Body)
+	imgData, err := io.ReadAll(io.LimitReader(resp.Body, p.maxSize+1))
 	if err != nil {
-		return nil, "", fmt.Errorf("读取图片数据失败: %w", err)
+		return nil, fmt.Errorf("读取图片数据失败: %w", err)
 	}
-
-	contentType := resp.Header.Get("Content-Type")
-	return imgData, contentType, nil
+	if int64(len(imgData)) > p.maxSize {
+		return nil, fmt.Errorf("图片超过大小限制: %dMB", p.maxSize/1024/1024)
+	}
+	return imgData, nil
 }
 
-// getExtByContentType 根据 Content-Type 获取文件扩展名
-func getExtByContentType(contentType string) string {
-	contentType = strings.ToLower(contentType)
-
-	switch {
-	case strings.Contains(contentType, "jpeg"), strings.Contains(contentType, "jpg"):
-		return ".jpg"
-	case strings.Contains(contentType, "png"):
-		return ".png"
-	case strings.Contains(contentType, "gif"):
-		return ".gif"
-	case strings.Contains(contentType, "webp"):
-		return ".webp"
-	case strings.Contains(contentType, "svg"):
-		return ".svg"
-	default:
-		return ".jpg"
+// imageURL 从图片语法的括号内容中提取地址，去掉可选的标题
+func imageURL(target string) string {
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(target, "<") {
+		if end := strings.Index(target, ">"); end > 0 {
+			return target[1:end]
+		}
+	}
+	if i := strings.IndexAny(target, " \t"); i >= 0 {
+		target = target[:i]
 	}
+	return target
 }
 
 // CleanMarkdownContent 清理 Markdown 内容中的多余符号
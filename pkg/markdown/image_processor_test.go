@@ -0,0 +1,231 @@
+package markdown
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+)
+
+// pngData 最小的 PNG 文件头，足够让内容嗅探识别为 image/png
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+// newTestProcessor 创建图片处理器，上传的文件写入临时目录
+func newTestProcessor(t *testing.T) *ImageProcessor {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	p := NewImageProcessor("", "")
+	p.rules = nil
+	return p
+}
+
+// newImageServer 按路径返回不同内容的图片服务器
+func newImageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/no-ext", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(pngData)
+	})
+	mux.HandleFunc("/x.svg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+	})
+	mux.HandleFunc("/fake.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("<html><script>alert(1)</script></html>"))
+	})
+	mux.HandleFunc("/missing.png", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProcessUploadsImages(t *testing.T) {
+	server := newImageServer(t)
+	p := newTestProcessor(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		status string
+		ext    string
+	}{
+		{"png", "/a.png", ImageStatusUploaded, ".png"},
+		{"extension from content", "/no-ext", ImageStatusUploaded, ".png"},
+		{"svg rejected", "/x.svg", ImageStatusFailed, ""},
+		{"html disguised as png", "/fake.png", ImageStatusFailed, ""},
+		{"not found", "/missing.png", ImageStatusFailed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := server.URL + tt.path
+			content, result := p.Process("![img](" + src + ")")
+			if len(result.Images) != 1 {
+				t.Fatalf("images = %d, want 1", len(result.Images))
+			}
+			img := result.Images[0]
+			if img.Status != tt.status {
+				t.Fatalf("status = %s (%s), want %s", img.Status, img.Error, tt.status)
+			}
+			if tt.status != ImageStatusUploaded {
+				if content != "![img]("+src+")" {
+					t.Errorf("content = %q, want original link kept", content)
+				}
+				return
+			}
+			if !strings.HasPrefix(img.StoredURL, "/uploads/articles/") || filepath.Ext(img.StoredURL) != tt.ext {
+				t.Errorf("stored url = %s, want /uploads/articles/...%s", img.StoredURL, tt.ext)
+			}
+			if content != "![img]("+img.StoredURL+")" {
+				t.Errorf("content = %q, want link replaced", content)
+			}
+			if _, err := os.Stat(strings.TrimPrefix(img.StoredURL, "/")); err != nil {
+				t.Errorf("stored file missing: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessLimitsConcurrencyPerHost(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak, requests := 0, 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		requests++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write(pngData)
+	}))
+	t.Cleanup(server.Close)
+
+	p := newTestProcessor(t)
+	p.workers, p.perHost = 8, 2
+	var content strings.Builder
+	for i := 0; i < 8; i++ {
+		content.WriteString("![img](" + server.URL + "/" + strconv.Itoa(i) + ".png)\n")
+	}
+	// 重复的地址只下载一次
+	content.WriteString("![dup](" + server.URL + "/0.png)\n")
+
+	_, result := p.Process(content.String())
+	if len(result.Images) != 8 || len(result.Failed()) != 0 {
+		t.Fatalf("images = %d, failed = %+v", len(result.Images), result.Failed())
+	}
+	if requests != 8 {
+		t.Errorf("requests = %d, want 8", requests)
+	}
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+}
+
+func TestProcessUsesCache(t *testing.T) {
+	server := newImageServer(t)
+	p := newTestProcessor(t)
+	src := server.URL + "/a.png"
+
+	_, first := p.Process("![a](" + src + ")")
+	_, second := p.Process("![b](" + src + " \"title\")")
+	if first.Images[0].Status != ImageStatusUploaded {
+		t.Fatalf("first status = %s, want uploaded", first.Images[0].Status)
+	}
+	if second.Images[0].Status != ImageStatusCached || second.Images[0].StoredURL != first.Images[0].StoredURL {
+		t.Errorf("second = %+v, want cached %s", second.Images[0], first.Images[0].StoredURL)
+	}
+}
+
+// mapCache 测试用的持久化缓存
+type mapCache map[string]string
+
+func (m mapCache) Get(src string) (string, bool) { v, ok := m[src]; return v, ok }
+func (m mapCache) Set(src, stored string) error  { m[src] = stored; return nil }
+
+func TestProcessUsesPersistentCache(t *testing.T) {
+	p := newTestProcessor(t)
+	cache := mapCache{"https://img.example.com/a.png": "/uploads/articles/cached.png"}
+	p.WithCache(cache)
+
+	content, result := p.Process("![a](https://img.example.com/a.png)")
+	if result.Images[0].Status != ImageStatusCached {
+		t.Fatalf("status = %s, want cached", result.Images[0].Status)
+	}
+	if content != "![a](/uploads/articles/cached.png)" {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestProcessRulesAndLimits(t *testing.T) {
+	server := newImageServer(t)
+
+	t.Run("skip rule", func(t *testing.T) {
+		p := newTestProcessor(t)
+		p.rules = []config.ImageHostRule{{Host: "127.0.0.1", Action: ActionSkip}}
+		_, result := p.Process("![a](" + server.URL + "/a.png)")
+		if result.Images[0].Status != ImageStatusSkipped {
+			t.Errorf("status = %s, want skipped", result.Images[0].Status)
+		}
+	})
+
+	t.Run("local and relative links", func(t *testing.T) {
+		p := newTestProcessor(t)
+		_, result := p.Process("![a](/uploads/a.png) ![b](images/b.png)")
+		for _, img := range result.Images {
+			if img.Status != ImageStatusSkipped {
+				t.Errorf("%s status = %s, want skipped", img.URL, img.Status)
+			}
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		p := newTestProcessor(t)
+		p.maxSize = 8
+		_, result := p.Process("![a](" + server.URL + "/a.png)")
+		if result.Images[0].Status != ImageStatusFailed {
+			t.Errorf("status = %s, want failed", result.Images[0].Status)
+		}
+	})
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	p := NewImageProcessor("", "")
+	for i := 0; i < memoryCacheSize; i++ {
+		p.memorySet("src"+strconv.Itoa(i), "stored")
+	}
+	// 访问最早的条目后，它不再是最久未使用的
+	if _, ok := p.memoryGet("src0"); !ok {
+		t.Fatal("src0 missing before eviction")
+	}
+	p.memorySet("new", "stored")
+
+	if p.memoryLRU.Len() != memoryCacheSize || len(p.memory) != memoryCacheSize {
+		t.Fatalf("size = %d/%d, want %d", p.memoryLRU.Len(), len(p.memory), memoryCacheSize)
+	}
+	if _, ok := p.memoryGet("src1"); ok {
+		t.Error("src1 should be evicted")
+	}
+	for _, src := range []string{"src0", "new"} {
+		if _, ok := p.memoryGet(src); !ok {
+			t.Errorf("%s should be kept", src)
+		}
+	}
+}
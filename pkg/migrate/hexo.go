@@ -0,0 +1,225 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ydcloud-dy/leaf-api/pkg/markdown"
+	"gopkg.in/yaml.v3"
+)
+
+// hexoMoreTag Hexo 的摘要分隔标记
+const hexoMoreTag = "<!-- more -->"
+
+// ErrSiteTooLarge 读取的站点文件超过大小限制
+var ErrSiteTooLarge = errors.New("站点文件解压后超过大小限制")
+
+// HexoSource 根据站点地址或自定义标识生成 Hexo 站点的来源标识，不同站点的 ID 映射互不影响
+func HexoSource(key string) string {
+	return "hexo:" + strings.TrimRight(strings.TrimSpace(key), "/")
+}
+
+// hexoLoader 读取 Hexo 站点文件，累计读取的大小不超过 limit
+type hexoLoader struct {
+	fsys  fs.FS
+	limit int64 // 0 表示不限制
+	read  int64
+}
+
+// LoadHexo 读取 Hexo 站点的文章
+// fsys 可以是站点根目录（读取 source/_posts 和 source/_drafts），也可以直接是存放文章的目录；
+// maxSize 为读取文件的总大小上限（字节），0 表示不限制。
+// 来源标识取自 _config.yml 中的 url，没有配置站点地址时 Source 为空，需要调用方用 HexoSource 指定
+func LoadHexo(fsys fs.FS, maxSize int64) (*Site, error) {
+	site := &Site{}
+
+	// 压缩包中站点可能位于外层文件夹内
+	if root := findHexoRoot(fsys); root != "." {
+		sub, err := fs.Sub(fsys, root)
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+	l := &hexoLoader{fsys: fsys, limit: maxSize}
+
+	siteURL, err := l.siteURL()
+	if err != nil {
+		return nil, err
+	}
+	if siteURL != "" {
+		site.Source = HexoSource(siteURL)
+	}
+
+	dirs := map[string]string{"source/_posts": PostPublished, "source/_drafts": PostDraft}
+	if _, err := fs.Stat(fsys, "source/_posts"); err != nil {
+		dirs = map[string]string{".": PostPublished}
+	}
+
+	for _, dir := range []string{"source/_posts", "source/_drafts", "."} {
+		status, ok := dirs[dir]
+		if !ok {
+			continue
+		}
+		if _, err := fs.Stat(fsys, dir); err != nil {
+			continue
+		}
+		err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name != dir && strings.HasPrefix(d.Name(), ".") {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			ext := strings.ToLower(path.Ext(name))
+			if ext != ".md" && ext != ".markdown" {
+				return nil
+			}
+
+			post, err := l.loadPost(name, status)
+			if errors.Is(err, ErrSiteTooLarge) {
+				return err
+			}
+			if err != nil {
+				site.Skipped = append(site.Skipped, fmt.Sprintf("%s: %v", name, err))
+				return nil
+			}
+			site.Posts = append(site.Posts, *post)
+			return nil
+		})
+		if errors.Is(err, ErrSiteTooLarge) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取 Hexo 文章失败: %w", err)
+		}
+	}
+
+	return site, nil
+}
+
+// readFile 读取文件，超过剩余的大小额度时返回 ErrSiteTooLarge
+func (l *hexoLoader) readFile(name string) ([]byte, error) {
+	f, err := l.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if l.limit > 0 {
+		// 按剩余额度读取，不依赖压缩包头部声明的大小
+		r = io.LimitReader(f, l.limit-l.read+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	l.read += int64(len(data))
+	if l.limit > 0 && l.read > l.limit {
+		return nil, ErrSiteTooLarge
+	}
+	return data, nil
+}
+
+// siteURL 读取 _config.yml 中配置的站点地址，未配置或仍是 Hexo 默认的示例地址时返回空
+func (l *hexoLoader) siteURL() (string, error) {
+	data, err := l.readFile("_config.yml")
+	if errors.Is(err, ErrSiteTooLarge) {
+		return "", err
+	}
+	if err != nil {
+		return "", nil
+	}
+
+	var cfg struct {
+		URL string `yaml:"url"`
+	}
+	if yaml.Unmarshal(data, &cfg) != nil {
+		return "", nil
+	}
+	u, err := url.Parse(strings.TrimSpace(cfg.URL))
+	if err != nil || u.Host == "" || u.Hostname() == "example.com" {
+		return "", nil
+	}
+	return strings.TrimSpace(cfg.URL), nil
+}
+
+// findHexoRoot 查找包含 source/_posts 的站点根目录，找不到时返回 "."
+func findHexoRoot(fsys fs.FS) string {
+	root := "."
+	_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if d.Name() == "node_modules" || (name != "." && strings.HasPrefix(d.Name(), ".")) {
+			return fs.SkipDir
+		}
+		if path.Base(name) == "_posts" && path.Base(path.Dir(name)) == "source" {
+			root = path.Dir(path.Dir(name))
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return root
+}
+
+// loadPost 读取单篇 Hexo 文章
+func (l *hexoLoader) loadPost(name, status string) (*Post, error) {
+	data, err := l.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, body, err := markdown.ParseFrontMatter(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if fm == nil {
+		fm = &markdown.FrontMatter{}
+	}
+
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	post := &Post{
+		ID:         name,
+		Title:      fm.Title,
+		Slug:       fm.Slug,
+		Status:     status,
+		Format:     FormatMarkdown,
+		Content:    body,
+		Excerpt:    fm.Summary,
+		Cover:      fm.Cover,
+		Categories: fm.Categories,
+		Tags:       fm.Tags,
+	}
+	if post.Title == "" {
+		post.Title = base
+	}
+	if post.Slug == "" {
+		post.Slug = base
+	}
+	if fm.Date != nil {
+		post.Date = *fm.Date
+	}
+	if fm.Draft != nil && *fm.Draft {
+		post.Status = PostDraft
+	}
+
+	// <!-- more --> 之前的内容作为摘要
+	if i := strings.Index(body, hexoMoreTag); i >= 0 {
+		if post.Excerpt == "" {
+			post.Excerpt = strings.TrimSpace(body[:i])
+		}
+		post.Content = body[:i] + body[i+len(hexoMoreTag):]
+	}
+
+	return post, nil
+}
@@ -0,0 +1,57 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadHexoSource(t *testing.T) {
+	post := &fstest.MapFile{Data: []byte("---\ntitle: Hello\n---\nbody")}
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"site url", "url: https://blog.example.org/\n", "hexo:https://blog.example.org"},
+		{"default example url", "url: http://example.com\n", ""},
+		{"no url", "title: blog\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"blog/_config.yml":            {Data: []byte(tt.config)},
+				"blog/source/_posts/hello.md": post,
+			}
+			site, err := LoadHexo(fsys, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if site.Source != tt.want {
+				t.Errorf("Source = %q, want %q", site.Source, tt.want)
+			}
+			if len(site.Posts) != 1 || site.Posts[0].Title != "Hello" {
+				t.Errorf("posts = %+v", site.Posts)
+			}
+		})
+	}
+}
+
+func TestLoadHexoSizeLimit(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.md": {Data: []byte("---\ntitle: A\n---\n" + strings.Repeat("a", 100))},
+		"b.md": {Data: []byte("---\ntitle: B\n---\n" + strings.Repeat("b", 100))},
+	}
+
+	if _, err := LoadHexo(fsys, 150); !errors.Is(err, ErrSiteTooLarge) {
+		t.Errorf("err = %v, want ErrSiteTooLarge", err)
+	}
+	site, err := LoadHexo(fsys, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(site.Posts) != 2 {
+		t.Errorf("posts = %d, want 2", len(site.Posts))
+	}
+}
@@ -0,0 +1,79 @@
+package migrate
+
+import "time"
+
+// 文章内容格式
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// 文章状态
+const (
+	PostPublished = "publish"
+	PostDraft     = "draft"
+)
+
+// Site 从其它博客系统导出的站点数据
+type Site struct {
+	Source      string // 来源标识，用于记录 ID 映射，如 wordpress:https://example.com
+	Authors     []Author
+	Categories  []Term
+	Tags        []Term
+	Posts       []Post
+	Attachments []Attachment
+	Skipped     []string // 解析时跳过的内容及原因
+}
+
+// Author 作者
+type Author struct {
+	ID          string
+	Login       string
+	Email       string
+	DisplayName string
+}
+
+// Term 分类或标签
+type Term struct {
+	Slug        string
+	Name        string
+	Parent      string // 父分类的 slug
+	Description string
+}
+
+// Post 文章
+type Post struct {
+	ID         string
+	Title      string
+	Slug       string
+	Author     string // 作者 Login
+	Date       time.Time
+	Status     string
+	Format     string // html 或 markdown
+	Content    string
+	Excerpt    string
+	Cover      string
+	Categories []string // 分类名称
+	Tags       []string // 标签名称
+	Comments   []Comment
+}
+
+// Comment 评论
+type Comment struct {
+	ID          string
+	ParentID    string // 为空表示顶级评论
+	AuthorID    string // 评论者是站点用户时的作者 ID
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+	Content     string
+	Approved    bool
+}
+
+// Attachment 附件
+type Attachment struct {
+	ID     string
+	PostID string // 所属文章 ID
+	URL    string
+	Title  string
+}
@@ -0,0 +1,255 @@
+package migrate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// wxrDocument WordPress 导出文件（WXR）结构
+// 各版本 WXR 的 wp 命名空间不同（1.0/1.1/1.2），因此只按本地名称匹配
+type wxrDocument struct {
+	Channel struct {
+		Links      []wxrEncoded  `xml:"link"` // 可能同时存在 atom:link
+		Authors    []wxrAuthor   `xml:"author"`
+		Categories []wxrCategory `xml:"category"`
+		Tags       []wxrTag      `xml:"tag"`
+		Items      []wxrItem     `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrAuthor struct {
+	ID          string `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+type wxrCategory struct {
+	Nicename    string `xml:"category_nicename"`
+	Parent      string `xml:"category_parent"`
+	Name        string `xml:"cat_name"`
+	Description string `xml:"category_description"`
+}
+
+type wxrTag struct {
+	Slug        string `xml:"tag_slug"`
+	Name        string `xml:"tag_name"`
+	Description string `xml:"tag_description"`
+}
+
+// wxrEncoded 带命名空间的文本元素，content:encoded 和 excerpt:encoded 本地名称相同，需要根据命名空间区分
+type wxrEncoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrItem struct {
+	Title         string        `xml:"title"`
+	Link          string        `xml:"link"`
+	Creator       string        `xml:"creator"`
+	Encoded       []wxrEncoded  `xml:"encoded"`
+	PostID        string        `xml:"post_id"`
+	PostDateGMT   string        `xml:"post_date_gmt"`
+	PostDate      string        `xml:"post_date"`
+	PostName      string        `xml:"post_name"`
+	Status        string        `xml:"status"`
+	PostParent    string        `xml:"post_parent"`
+	PostType      string        `xml:"post_type"`
+	AttachmentURL string        `xml:"attachment_url"`
+	Categories    []wxrItemTerm `xml:"category"`
+	PostMeta      []wxrPostMeta `xml:"postmeta"`
+	Comments      []wxrComment  `xml:"comment"`
+}
+
+type wxrItemTerm struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrPostMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+type wxrComment struct {
+	ID          string `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Date        string `xml:"comment_date"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      string `xml:"comment_parent"`
+	UserID      string `xml:"comment_user_id"`
+}
+
+// wxrTimeLayout WXR 中的时间格式
+const wxrTimeLayout = "2006-01-02 15:04:05"
+
+// ParseWXR 解析 WordPress 导出文件
+func ParseWXR(r io.Reader) (*Site, error) {
+	var doc wxrDocument
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析 WXR 文件失败: %w", err)
+	}
+
+	ch := doc.Channel
+	site := &Site{Source: "wordpress"}
+	for _, link := range ch.Links {
+		if link.XMLName.Space == "" && strings.TrimSpace(link.Value) != "" {
+			site.Source = "wordpress:" + strings.TrimRight(strings.TrimSpace(link.Value), "/")
+			break
+		}
+	}
+
+	for _, a := range ch.Authors {
+		site.Authors = append(site.Authors, Author{
+			ID:          strings.TrimSpace(a.ID),
+			Login:       strings.TrimSpace(a.Login),
+			Email:       strings.TrimSpace(a.Email),
+			DisplayName: strings.TrimSpace(a.DisplayName),
+		})
+	}
+
+	// 分类的父级在 WXR 中以 slug 表示
+	for _, c := range ch.Categories {
+		site.Categories = append(site.Categories, Term{
+			Slug:        strings.TrimSpace(c.Nicename),
+			Name:        strings.TrimSpace(c.Name),
+			Parent:      strings.TrimSpace(c.Parent),
+			Description: strings.TrimSpace(c.Description),
+		})
+	}
+	for _, t := range ch.Tags {
+		site.Tags = append(site.Tags, Term{
+			Slug:        strings.TrimSpace(t.Slug),
+			Name:        strings.TrimSpace(t.Name),
+			Description: strings.TrimSpace(t.Description),
+		})
+	}
+
+	// 先收集附件，文章的特色图片通过 _thumbnail_id 引用附件
+	attachments := make(map[string]string)
+	for _, item := range ch.Items {
+		if item.PostType != "attachment" {
+			continue
+		}
+		attURL := strings.TrimSpace(item.AttachmentURL)
+		if attURL == "" {
+			continue
+		}
+		attachments[item.PostID] = attURL
+		site.Attachments = append(site.Attachments, Attachment{
+			ID:     item.PostID,
+			PostID: item.PostParent,
+			URL:    attURL,
+			Title:  item.Title,
+		})
+	}
+
+	for _, item := range ch.Items {
+		switch item.PostType {
+		case "attachment":
+			continue
+		case "post":
+		default:
+			site.Skipped = append(site.Skipped, fmt.Sprintf("%s #%s %s: 不支持的类型", item.PostType, item.PostID, item.Title))
+			continue
+		}
+
+		var status string
+		switch item.Status {
+		case "publish":
+			status = PostPublished
+		case "draft", "pending", "private", "future":
+			status = PostDraft
+		default:
+			site.Skipped = append(site.Skipped, fmt.Sprintf("post #%s %s: 状态为 %s", item.PostID, item.Title, item.Status))
+			continue
+		}
+
+		post := Post{
+			ID:     item.PostID,
+			Title:  strings.TrimSpace(item.Title),
+			Slug:   decodeSlug(item.PostName),
+			Author: strings.TrimSpace(item.Creator),
+			Date:   parseWXRTime(item.PostDateGMT, item.PostDate),
+			Status: status,
+			Format: FormatHTML,
+		}
+		for _, enc := range item.Encoded {
+			if strings.Contains(enc.XMLName.Space, "excerpt") {
+				post.Excerpt = strings.TrimSpace(enc.Value)
+			} else {
+				post.Content = enc.Value
+			}
+		}
+		for _, term := range item.Categories {
+			switch term.Domain {
+			case "category":
+				post.Categories = append(post.Categories, strings.TrimSpace(term.Name))
+			case "post_tag":
+				post.Tags = append(post.Tags, strings.TrimSpace(term.Name))
+			}
+		}
+		for _, meta := range item.PostMeta {
+			if meta.Key == "_thumbnail_id" {
+				post.Cover = attachments[strings.TrimSpace(meta.Value)]
+			}
+		}
+
+		// 只导入普通评论，跳过 pingback/trackback 和垃圾评论
+		for _, c := range item.Comments {
+			if c.Type == "pingback" || c.Type == "trackback" || c.Approved == "spam" || c.Approved == "trash" {
+				continue
+			}
+			comment := Comment{
+				ID:          c.ID,
+				AuthorName:  strings.TrimSpace(c.Author),
+				AuthorEmail: strings.TrimSpace(c.AuthorEmail),
+				Date:        parseWXRTime(c.DateGMT, c.Date),
+				Content:     c.Content,
+				Approved:    c.Approved == "1",
+			}
+			if c.Parent != "" && c.Parent != "0" {
+				comment.ParentID = c.Parent
+			}
+			if c.UserID != "" && c.UserID != "0" {
+				comment.AuthorID = c.UserID
+			}
+			post.Comments = append(post.Comments, comment)
+		}
+
+		site.Posts = append(site.Posts, post)
+	}
+
+	return site, nil
+}
+
+// parseWXRTime 解析 WXR 时间，优先使用 GMT 时间
+func parseWXRTime(gmt, local string) time.Time {
+	if t, err := time.ParseInLocation(wxrTimeLayout, strings.TrimSpace(gmt), time.UTC); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation(wxrTimeLayout, strings.TrimSpace(local), time.Local); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// decodeSlug 中文 slug 在 WXR 中是 URL 编码的
+func decodeSlug(slug string) string {
+	slug = strings.TrimSpace(slug)
+	if decoded, err := url.PathUnescape(slug); err == nil {
+		return decoded
+	}
+	return slug
+}
@@ -7,6 +7,7 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -114,7 +115,10 @@ func uploadToOSS(src multipart.File, filename string) (string, error) {
 // uploadToLocal 上传文件到本地存储
 func uploadToLocal(src multipart.File, filename string) (string, error) {
 	// 创建目标目录
-	destPath := filepath.Join("uploads", filename)
+	destPath, err := localPath(filename)
+	if err != nil {
+		return "", err
+	}
 	destDir := filepath.Dir(destPath)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create directory: %w", err)
@@ -201,7 +205,10 @@ func uploadBytesToOSS(data []byte, filename string) (string, error) {
 // uploadBytesToLocal 上传字节数据到本地存储
 func uploadBytesToLocal(data []byte, filename string) (string, error) {
 	// 创建目标目录
-	destPath := filepath.Join("uploads", filename)
+	destPath, err := localPath(filename)
+	if err != nil {
+		return "", err
+	}
 	destDir := filepath.Dir(destPath)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create directory: %w", err)
@@ -216,3 +223,71 @@ func uploadBytesToLocal(data []byte, filename string) (string, error) {
 	url := fmt.Sprintf("/uploads/%s", filename)
 	return url, nil
 }
+
+// localPath 计算本地存储路径，拒绝跳出 uploads 目录的文件名
+func localPath(filename string) (string, error) {
+	destPath := filepath.Join("uploads", filename)
+	if !strings.HasPrefix(destPath, "uploads"+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path: %s", filename)
+	}
+	return destPath, nil
+}
+
+// ObjectKey 判断地址是否指向本站存储（本地 /uploads/ 或 OSS），返回对象键
+func ObjectKey(url string) (string, bool) {
+	if strings.HasPrefix(url, "/uploads/") {
+		return strings.TrimPrefix(url, "/uploads/"), true
+	}
+	baseURL := strings.TrimRight(config.AppConfig.OSS.BaseURL, "/")
+	if baseURL != "" && strings.HasPrefix(url, baseURL+"/") {
+		return strings.TrimPrefix(url, baseURL+"/"), true
+	}
+	return "", false
+}
+
+// ReadFile 读取本站存储中的文件
+func ReadFile(url string) ([]byte, error) {
+	objectKey, ok := ObjectKey(url)
+	if !ok {
+		return nil, fmt.Errorf("not a stored file: %s", url)
+	}
+
+	if strings.HasPrefix(url, "/uploads/") {
+		path, err := localPath(objectKey)
+		if err != nil {
+			return nil, err
+		}
+		return os.ReadFile(path)
+	}
+
+	if bucket == nil {
+		return nil, fmt.Errorf("OSS not initialized, cannot read %s", url)
+	}
+	body, err := bucket.GetObject(objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// RemoveFile 删除本站存储中的文件（本地 /uploads/ 或 OSS）
+func RemoveFile(url string) error {
+	objectKey, ok := ObjectKey(url)
+	if !ok {
+		return fmt.Errorf("not a stored file: %s", url)
+	}
+
+	if strings.HasPrefix(url, "/uploads/") {
+		path, err := localPath(objectKey)
+		if err != nil {
+			return err
+		}
+		return os.Remove(path)
+	}
+
+	if bucket == nil {
+		return fmt.Errorf("OSS not initialized, cannot delete %s", url)
+	}
+	return DeleteFile(objectKey)
+}
@@ -0,0 +1,166 @@
+// Package privacy 访客隐私保护：按配置截断 IP 或用定期更换的盐哈希 IP，
+// 并识别 Do Not Track（DNT）和 Global Privacy Control（Sec-GPC）请求头。
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydcloud-dy/leaf-api/pkg/cache"
+	"github.com/ydcloud-dy/leaf-api/pkg/logger"
+)
+
+// IP 保存方式
+const (
+	ModeFull     = "full"     // 保存完整 IP
+	ModeTruncate = "truncate" // IPv4 保留前 24 位，IPv6 保留前 48 位
+	ModeHash     = "hash"     // 加盐哈希，盐按周期更换，换盐后无法再关联之前的记录
+)
+
+// saltPrefix 各周期哈希盐的缓存 Key 前缀，后缀为周期序号，多实例共享同一个盐
+const saltPrefix = "privacy:salt:"
+
+// Policy 隐私策略
+type Policy struct {
+	mode         string
+	saltRotation time.Duration
+	honorDNT     bool
+
+	mu     sync.Mutex
+	period int64  // 当前盐所在的周期
+	salt   []byte // 当前周期的盐，只保存在内存和缓存中
+}
+
+// New 创建隐私策略，rotation 为哈希盐的更换周期，honorDNT 为是否遵守 DNT/GPC 请求头
+func New(mode string, rotation time.Duration, honorDNT bool) (*Policy, error) {
+	switch mode {
+	case ModeFull, ModeTruncate, ModeHash:
+	default:
+		return nil, fmt.Errorf("不支持的 IP 保存方式: %s", mode)
+	}
+	if rotation < time.Minute {
+		return nil, fmt.Errorf("哈希盐更换周期不能小于 1 分钟: %s", rotation)
+	}
+	return &Policy{mode: mode, saltRotation: rotation, honorDNT: honorDNT}, nil
+}
+
+// Mode IP 保存方式
+func (p *Policy) Mode() string {
+	return p.mode
+}
+
+// IP 按保存方式处理 IP，截断或哈希时无效地址返回空
+func (p *Policy) IP(ip string) string {
+	if p.mode == ModeFull {
+		return ip
+	}
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if p.mode == ModeTruncate {
+		if ip4 := parsed.To4(); ip4 != nil {
+			return ip4.Mask(net.CIDRMask(24, 32)).String()
+		}
+		return parsed.Mask(net.CIDRMask(48, 128)).String()
+	}
+	mac := hmac.New(sha256.New, p.currentSalt(time.Now()))
+	mac.Write([]byte(parsed.String()))
+	return "h:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// Visitor 用当前周期的盐对 IP+UA 做哈希，作为游客在周期内的标识；不受 IP 保存方式影响，
+// 截断 IP 时同一网段的不同访客也能区分开
+func (p *Policy) Visitor(ip, userAgent string) string {
+	mac := hmac.New(sha256.New, p.currentSalt(time.Now()))
+	mac.Write([]byte(strings.TrimSpace(ip) + "|" + userAgent))
+	return "v:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// UserAgent 只有保存完整 IP 时才保存完整 UA，否则返回空，浏览器、系统等解析结果另外保存
+func (p *Policy) UserAgent(userAgent string) string {
+	if p.mode == ModeFull {
+		return userAgent
+	}
+	return ""
+}
+
+// OptedOut 请求是否通过 DNT 或 Sec-GPC 请求头拒绝追踪，未开启遵守时总是返回 false
+func (p *Policy) OptedOut(header http.Header) bool {
+	return p.honorDNT && (header.Get("DNT") == "1" || header.Get("Sec-GPC") == "1")
+}
+
+// currentSalt 获取 now 所在周期的盐，周期内第一次使用时由先到的实例生成并写入缓存
+func (p *Policy) currentSalt(now time.Time) []byte {
+	period := now.Unix() / int64(p.saltRotation/time.Second)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.salt != nil && p.period == period {
+		return p.salt
+	}
+
+	random := make([]byte, 32)
+	rand.Read(random)
+	salt := []byte(hex.EncodeToString(random))
+
+	// 盐保留两个周期，过期后同一 IP 的旧哈希无法再计算出来
+	store := cache.Default()
+	key := saltPrefix + strconv.FormatInt(period, 10)
+	if _, err := store.SetNX(key, string(salt), 2*p.saltRotation); err != nil {
+		logger.Warn("保存 IP 哈希盐失败，使用本实例生成的盐: ", err)
+	} else if value, err := store.Get(key); err == nil && value != "" {
+		salt = []byte(value)
+	}
+
+	p.period, p.salt = period, salt
+	return salt
+}
+
+var (
+	mu      sync.RWMutex
+	current = &Policy{mode: ModeFull, saltRotation: 24 * time.Hour}
+)
+
+// Use 设置全局隐私策略
+func Use(p *Policy) {
+	mu.Lock()
+	current = p
+	mu.Unlock()
+}
+
+// Default 获取全局隐私策略，未设置时保存完整 IP 且不遵守 DNT/GPC
+func Default() *Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// IP 使用全局隐私策略处理 IP
+func IP(ip string) string {
+	return Default().IP(ip)
+}
+
+// UserAgent 使用全局隐私策略处理 UA
+func UserAgent(userAgent string) string {
+	return Default().UserAgent(userAgent)
+}
+
+// Visitor 使用全局隐私策略计算游客标识
+func Visitor(ip, userAgent string) string {
+	return Default().Visitor(ip, userAgent)
+}
+
+// OptedOut 使用全局隐私策略判断请求是否拒绝追踪
+func OptedOut(header http.Header) bool {
+	return Default().OptedOut(header)
+}
@@ -0,0 +1,54 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicyIP(t *testing.T) {
+	tests := []struct {
+		mode, ip, want string
+	}{
+		{ModeFull, "203.0.113.7", "203.0.113.7"},
+		{ModeTruncate, "203.0.113.7", "203.0.113.0"},
+		{ModeTruncate, "2001:db8:1:2::7", "2001:db8:1::"},
+		{ModeTruncate, "not-an-ip", ""},
+	}
+	for _, tt := range tests {
+		p, err := New(tt.mode, time.Hour, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.IP(tt.ip); got != tt.want {
+			t.Errorf("%s IP(%q) = %q, want %q", tt.mode, tt.ip, got, tt.want)
+		}
+	}
+
+	p, _ := New(ModeHash, time.Hour, false)
+	if got := p.IP("203.0.113.7"); !strings.HasPrefix(got, "h:") || got != p.IP("203.0.113.7") {
+		t.Errorf("hash IP = %q, want stable h: prefix", got)
+	}
+}
+
+func TestPolicyVisitor(t *testing.T) {
+	p, err := New(ModeTruncate, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := p.Visitor("203.0.113.1", "ua")
+	if !strings.HasPrefix(a, "v:") || a != p.Visitor("203.0.113.1", "ua") {
+		t.Errorf("Visitor = %q, want stable v: prefix", a)
+	}
+	// 截断后相同的 IP 和不同的 UA 都是不同的访客
+	if a == p.Visitor("203.0.113.2", "ua") {
+		t.Error("visitors in the same /24 collapsed")
+	}
+	if a == p.Visitor("203.0.113.1", "other") {
+		t.Error("visitors with different UA collapsed")
+	}
+	if len(a) > 20 {
+		t.Errorf("len(Visitor) = %d, exceeds the page_visits.visitor column", len(a))
+	}
+}
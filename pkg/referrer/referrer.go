@@ -0,0 +1,187 @@
+// Package referrer 解析访问来源，把来源地址归类为搜索引擎、社交网站、站内跳转、直接访问或外部链接，
+// 并从访问地址中提取 UTM 推广参数。
+package referrer
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maxValueLen 关键词和 UTM 参数保留的最大字符数
+const maxValueLen = 100
+
+// 来源类别
+const (
+	CategoryDirect   = "direct"   // 直接访问，没有来源
+	CategoryInternal = "internal" // 站内跳转
+	CategorySearch   = "search"   // 搜索引擎
+	CategorySocial   = "social"   // 社交网站
+	CategoryReferral = "referral" // 其他外部链接
+)
+
+// Source 访问来源
+type Source struct {
+	Domain     string // 来源域名，去掉 www. 前缀
+	Category   string
+	Engine     string // 搜索引擎名称，仅搜索来源有值
+	SearchTerm string // 搜索关键词，搜索引擎没有传递时为空
+}
+
+// UTM 推广参数
+type UTM struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// searchEngine 搜索引擎规则，关键词参数按顺序取第一个非空值
+// 域名按后缀匹配，以 "." 结尾的规则匹配 com 和国家域名（如 "google." 匹配 google.com.hk、google.co.uk、google.de，不匹配 google.evil.com）
+type searchEngine struct {
+	name    string
+	domains []string
+	params  []string
+}
+
+var searchEngines = []searchEngine{
+	{"Google", []string{"google."}, []string{"q"}},
+	{"Bing", []string{"bing.com"}, []string{"q"}},
+	{"Baidu", []string{"baidu.com"}, []string{"wd", "word", "kw"}},
+	{"Sogou", []string{"sogou.com"}, []string{"query", "keyword"}},
+	{"360", []string{"so.com"}, []string{"q"}},
+	{"Shenma", []string{"sm.cn"}, []string{"q"}},
+	{"Yandex", []string{"yandex."}, []string{"text"}},
+	{"DuckDuckGo", []string{"duckduckgo.com"}, []string{"q"}},
+	{"Yahoo", []string{"search.yahoo.com", "yahoo.co.jp"}, []string{"p"}},
+	{"Naver", []string{"search.naver.com"}, []string{"query"}},
+	{"Ecosia", []string{"ecosia.org"}, []string{"q"}},
+}
+
+// socialDomains 社交网站域名，按后缀匹配
+var socialDomains = []string{
+	"weibo.com", "weibo.cn", "zhihu.com", "douban.com", "weixin.qq.com", "qzone.qq.com",
+	"xiaohongshu.com", "bilibili.com", "douyin.com", "v2ex.com", "juejin.cn", "csdn.net",
+	"twitter.com", "x.com", "t.co", "facebook.com", "fb.com", "instagram.com", "linkedin.com", "lnkd.in",
+	"reddit.com", "news.ycombinator.com", "youtube.com", "t.me", "telegram.org", "mastodon.social",
+	"discord.com", "github.com",
+}
+
+// clip 去掉首尾空白并截断到 maxValueLen 个字符
+func clip(value string) string {
+	value = strings.TrimSpace(value)
+	if runes := []rune(value); len(runes) > maxValueLen {
+		return string(runes[:maxValueLen])
+	}
+	return value
+}
+
+// hasDomainSuffix 判断 host 是否为 domain 或其子域名
+func hasDomainSuffix(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// matchEngineDomain 匹配搜索引擎域名规则
+func matchEngineDomain(host, rule string) bool {
+	if !strings.HasSuffix(rule, ".") {
+		return hasDomainSuffix(host, rule)
+	}
+	i := strings.LastIndex(host, rule)
+	if i < 0 || (i > 0 && host[i-1] != '.') {
+		return false
+	}
+	return isEngineTLD(host[i+len(rule):])
+}
+
+// isEngineTLD 判断是否为搜索引擎使用的顶级域名：com、国家域名 xx、co.xx 或 com.xx
+func isEngineTLD(tld string) bool {
+	if tld == "com" {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(tld, "co."); ok {
+		tld = rest
+	} else if rest, ok := strings.CutPrefix(tld, "com."); ok {
+		tld = rest
+	}
+	return len(tld) == 2 && isLetter(tld[0]) && isLetter(tld[1])
+}
+
+// isLetter 判断是否为小写字母
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// Parse 解析来源地址，siteHosts 为本站域名，来源属于这些域名时视为站内跳转
+func Parse(referrer string, siteHosts ...string) Source {
+	referrer = strings.TrimSpace(referrer)
+	if referrer == "" {
+		return Source{Category: CategoryDirect}
+	}
+
+	u, err := url.Parse(referrer)
+	if err != nil || u.Host == "" {
+		return Source{Category: CategoryDirect}
+	}
+	host := strings.ToLower(u.Hostname())
+	domain := strings.TrimPrefix(host, "www.")
+	source := Source{Domain: domain, Category: CategoryReferral}
+
+	for _, site := range siteHosts {
+		site = strings.TrimPrefix(strings.ToLower(site), "www.")
+		if site != "" && hasDomainSuffix(domain, site) {
+			source.Category = CategoryInternal
+			return source
+		}
+	}
+
+	query := u.Query()
+	for _, engine := range searchEngines {
+		for _, d := range engine.domains {
+			if !matchEngineDomain(domain, d) {
+				continue
+			}
+			source.Category = CategorySearch
+			source.Engine = engine.name
+			for _, param := range engine.params {
+				if term := clip(query.Get(param)); term != "" {
+					source.SearchTerm = term
+					break
+				}
+			}
+			return source
+		}
+	}
+
+	for _, d := range socialDomains {
+		if hasDomainSuffix(domain, d) {
+			source.Category = CategorySocial
+			return source
+		}
+	}
+	return source
+}
+
+// Host 返回地址中的域名，地址无效时返回空字符串
+func Host(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// ParseUTM 从访问地址（可以只有路径和查询参数）中提取 UTM 参数
+func ParseUTM(rawURL string) UTM {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return UTM{}
+	}
+	query := u.Query()
+	return UTM{
+		Source:   clip(query.Get("utm_source")),
+		Medium:   clip(query.Get("utm_medium")),
+		Campaign: clip(query.Get("utm_campaign")),
+		Term:     clip(query.Get("utm_term")),
+		Content:  clip(query.Get("utm_content")),
+	}
+}
@@ -0,0 +1,34 @@
+package referrer
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		referrer string
+		category string
+		engine   string
+		term     string
+	}{
+		{"", CategoryDirect, "", ""},
+		{"https://www.google.com/search?q=leaf", CategorySearch, "Google", "leaf"},
+		{"https://www.google.com.hk/search?q=leaf", CategorySearch, "Google", "leaf"},
+		{"https://www.google.co.uk/", CategorySearch, "Google", ""},
+		{"https://google.de/?q=go", CategorySearch, "Google", "go"},
+		{"https://news.google.com/", CategorySearch, "Google", ""},
+		{"https://yandex.ru/search/?text=go", CategorySearch, "Yandex", "go"},
+		{"https://www.baidu.com/s?wd=%E5%8F%B6", CategorySearch, "Baidu", "叶"},
+		{"https://google.evil.com/?q=leaf", CategoryReferral, "", ""},
+		{"https://google.com.evil.com/", CategoryReferral, "", ""},
+		{"https://google.co.evil.io/", CategoryReferral, "", ""},
+		{"https://notgoogle.com/", CategoryReferral, "", ""},
+		{"https://www.zhihu.com/question/1", CategorySocial, "", ""},
+		{"https://blog.example.org/", CategoryReferral, "", ""},
+		{"https://www.mysite.com/a", CategoryInternal, "", ""},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.referrer, "mysite.com")
+		if got.Category != tt.category || got.Engine != tt.engine || got.SearchTerm != tt.term {
+			t.Errorf("Parse(%q) = %+v, want category %q engine %q term %q", tt.referrer, got, tt.category, tt.engine, tt.term)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package staticsite
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// manifestFile 记录上次构建结果的清单文件，用于增量构建
+const manifestFile = ".leaf-static.json"
+
+// manifest 构建清单
+type manifest struct {
+	BuiltAt time.Time         `json:"built_at"`
+	Files   map[string]string `json:"files"` // 输出路径 -> 输入指纹
+}
+
+// Stats 构建统计
+type Stats struct {
+	Rendered int `json:"rendered"` // 重新渲染的文件数
+	Skipped  int `json:"skipped"`  // 未变化跳过的文件数
+	Removed  int `json:"removed"`  // 删除的过期文件数
+	Assets   int `json:"assets"`   // 复制的资源文件数
+}
+
+// Builder 增量构建器
+//
+// 每个输出文件都带有一个输入指纹（主题指纹 + 渲染所需的数据），
+// 指纹和上次构建一致且文件仍存在时跳过渲染；上次构建有而本次没有的文件会被删除。
+type Builder struct {
+	out    string
+	theme  *Theme
+	full   bool
+	prev   manifest
+	next   manifest
+	assets map[string]bool // 本次已复制的资源文件
+	stats  Stats
+}
+
+// NewBuilder 创建构建器，full 为 true 时忽略上次构建结果全部重新渲染
+func NewBuilder(out string, theme *Theme, full bool) (*Builder, error) {
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return nil, err
+	}
+
+	b := &Builder{
+		out:    out,
+		theme:  theme,
+		full:   full,
+		prev:   manifest{Files: make(map[string]string)},
+		next:   manifest{Files: make(map[string]string)},
+		assets: make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, manifestFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &b.prev); err != nil || b.prev.Files == nil {
+			// 清单损坏时按全量构建处理
+			b.prev = manifest{Files: make(map[string]string)}
+		}
+	}
+
+	return b, nil
+}
+
+// Page 渲染页面，key 为决定页面内容的数据，load 只在需要重新渲染时调用
+func (b *Builder) Page(p, page string, key interface{}, load func() (interface{}, error)) error {
+	return b.File(p, []interface{}{page, key}, func() ([]byte, error) {
+		data, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return b.theme.Render(page, data)
+	})
+}
+
+// File 输出文件，key 为决定文件内容的数据，render 只在需要重新生成时调用
+func (b *Builder) File(p string, key interface{}, render func() ([]byte, error)) error {
+	rel, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+	if _, ok := b.next.Files[rel]; ok {
+		return fmt.Errorf("重复的输出路径: %s", p)
+	}
+
+	raw, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(append([]byte(b.theme.Hash()), raw...))
+	fingerprint := hex.EncodeToString(sum[:])
+	b.next.Files[rel] = fingerprint
+
+	target := filepath.Join(b.out, rel)
+	if !b.full && b.prev.Files[rel] == fingerprint {
+		if _, err := os.Stat(target); err == nil {
+			b.stats.Skipped++
+			return nil
+		}
+	}
+
+	content, err := render()
+	if err != nil {
+		delete(b.next.Files, rel)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return err
+	}
+	b.stats.Rendered++
+	return nil
+}
+
+// Asset 输出不会变化的资源文件（如上传的图片），文件已存在时跳过
+// 资源文件不记录在构建清单中，也不会作为过期文件删除
+func (b *Builder) Asset(p string, read func() ([]byte, error)) error {
+	rel, err := cleanPath(p)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(b.out, rel)
+	if _, err := os.Stat(target); err == nil && !b.full {
+		return nil
+	}
+	if b.assets[rel] {
+		return nil
+	}
+
+	content, err := read()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, content, 0644); err != nil {
+		return err
+	}
+	b.assets[rel] = true
+	b.stats.Assets++
+	return nil
+}
+
+// Finish 删除过期文件并保存构建清单
+func (b *Builder) Finish() (*Stats, error) {
+	stale := make([]string, 0)
+	for rel := range b.prev.Files {
+		if _, ok := b.next.Files[rel]; !ok {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(stale)
+	for _, rel := range stale {
+		target := filepath.Join(b.out, rel)
+		if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		b.stats.Removed++
+		removeEmptyDirs(b.out, filepath.Dir(target))
+	}
+
+	b.next.BuiltAt = time.Now()
+	data, err := json.MarshalIndent(b.next, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(b.out, manifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+
+	stats := b.stats
+	return &stats, nil
+}
+
+// removeEmptyDirs 向上删除空目录，直到输出目录为止
+func removeEmptyDirs(root, dir string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package staticsite
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// FeedItem 订阅条目
+type FeedItem struct {
+	Title     string
+	URL       string // 绝对地址
+	Summary   string
+	Author    string
+	Category  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// rss RSS 2.0
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	Author      string `xml:"author,omitempty"`
+	Category    string `xml:"category,omitempty"`
+	PubDate     string `xml:"pubDate"`
+}
+
+// atomFeed Atom 1.0
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Summary   string      `xml:"summary"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// RSS 生成 RSS 2.0 订阅
+func RSS(site Site, items []FeedItem) ([]byte, error) {
+	channel := rssChannel{
+		Title:       site.Title,
+		Link:        siteURL(site),
+		Description: site.Description,
+		Items:       make([]rssItem, 0, len(items)),
+	}
+	if len(items) > 0 {
+		channel.LastBuildDate = latest(items).Format(time.RFC1123Z)
+	}
+	for _, item := range items {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        item.URL,
+			Description: item.Summary,
+			Author:      item.Author,
+			Category:    item.Category,
+			PubDate:     item.CreatedAt.Format(time.RFC1123Z),
+		})
+	}
+	return marshalXML(rss{Version: "2.0", Channel: channel})
+}
+
+// Atom 生成 Atom 1.0 订阅
+func Atom(site Site, feedURL string, items []FeedItem) ([]byte, error) {
+	feed := atomFeed{
+		Title:   site.Title,
+		ID:      siteURL(site),
+		Links:   []atomLink{{Href: siteURL(site)}, {Href: feedURL, Rel: "self"}},
+		Updated: time.Unix(0, 0).UTC().Format(time.RFC3339),
+		Entries: make([]atomEntry, 0, len(items)),
+	}
+	if len(items) > 0 {
+		feed.Updated = latest(items).Format(time.RFC3339)
+	}
+	for _, item := range items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.URL,
+			Link:      atomLink{Href: item.URL},
+			Published: item.CreatedAt.Format(time.RFC3339),
+			Updated:   item.UpdatedAt.Format(time.RFC3339),
+			Summary:   item.Summary,
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalXML(feed)
+}
+
+// AbsURL 站点内路径转为绝对地址，未配置站点地址时返回站内路径
+func AbsURL(site Site, p string) string {
+	return strings.TrimRight(site.BaseURL, "/") + "/" + strings.TrimPrefix(strings.TrimPrefix(p, site.BasePath), "/")
+}
+
+// siteURL 站点首页地址
+func siteURL(site Site) string {
+	return AbsURL(site, "")
+}
+
+// latest 最近的更新时间
+func latest(items []FeedItem) time.Time {
+	var t time.Time
+	for _, item := range items {
+		if item.UpdatedAt.After(t) {
+			t = item.UpdatedAt
+		}
+	}
+	return t
+}
+
+// marshalXML 序列化为带声明的 XML
+func marshalXML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package staticsite
+
+import (
+	"html/template"
+	"time"
+)
+
+// 主题模板的数据结构，自定义主题可以使用这里的全部字段
+
+// Site 站点信息
+type Site struct {
+	Title       string
+	Description string
+	BaseURL     string // 站点公开地址，如 https://mirror.example.com/blog
+	BasePath    string // 站点路径前缀，以 / 结尾
+}
+
+// Link 链接
+type Link struct {
+	Title string
+	URL   string
+}
+
+// ArticleItem 文章摘要
+type ArticleItem struct {
+	ID        uint
+	Title     string
+	Summary   string
+	Cover     string
+	URL       string
+	Author    string
+	Category  *Link
+	Tags      []Link
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Pagination 分页信息，Prev/Next 为空表示没有上一页/下一页
+type Pagination struct {
+	Page       int
+	TotalPages int
+	Prev       string
+	Next       string
+}
+
+// ListPage 文章列表页（首页、分类、标签）
+type ListPage struct {
+	Site        Site
+	Title       string
+	Description string
+	Articles    []ArticleItem
+	Pagination  Pagination
+	Chapters    string // 标签页的章节目录地址，没有章节时为空
+}
+
+// ArticlePage 文章页
+type ArticlePage struct {
+	Site     Site
+	Article  ArticleItem
+	Content  template.HTML
+	Prev     *Link
+	Next     *Link
+	Chapters string // 所在章节目录地址，不属于章节时为空
+}
+
+// Term 分类或标签
+type Term struct {
+	Name  string
+	URL   string
+	Color string
+	Count int
+}
+
+// TermsPage 分类或标签索引页
+type TermsPage struct {
+	Site  Site
+	Title string
+	Terms []Term
+}
+
+// ChapterNode 章节目录节点
+type ChapterNode struct {
+	Name     string
+	Articles []Link
+	Children []ChapterNode
+}
+
+// ChaptersPage 标签的章节目录页
+type ChaptersPage struct {
+	Site     Site
+	Tag      Link
+	Chapters []ChapterNode
+}
+
+// ArchiveGroup 归档分组
+type ArchiveGroup struct {
+	Title    string
+	Articles []ArticleItem
+}
+
+// ArchivePage 归档页
+type ArchivePage struct {
+	Site   Site
+	Title  string
+	Groups []ArchiveGroup
+}
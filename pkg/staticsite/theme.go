@@ -0,0 +1,180 @@
+package staticsite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed themes/default
+var defaultTheme embed.FS
+
+// 主题中的页面模板，每个页面模板和 base.html 组合渲染
+const (
+	PageList     = "list.html"
+	PageArticle  = "article.html"
+	PageTerms    = "terms.html"
+	PageChapters = "chapters.html"
+	PageArchive  = "archive.html"
+
+	layoutFile = "base.html"
+	staticDir  = "static"
+)
+
+// Theme 页面主题
+type Theme struct {
+	pages  map[string]*template.Template
+	static map[string][]byte // 静态资源，键为 static/ 下的相对路径
+	hash   string
+}
+
+// LoadTheme 加载主题，dir 为空时使用内置主题；自定义主题缺少的文件使用内置主题补齐
+func LoadTheme(dir string, site Site) (*Theme, error) {
+	builtin, err := fs.Sub(defaultTheme, "themes/default")
+	if err != nil {
+		return nil, err
+	}
+	var custom fs.FS
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("主题目录不存在: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("主题路径不是目录: %s", dir)
+		}
+		custom = os.DirFS(dir)
+	}
+
+	read := func(name string) ([]byte, error) {
+		if custom != nil {
+			if data, err := fs.ReadFile(custom, name); err == nil {
+				return data, nil
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return fs.ReadFile(builtin, name)
+	}
+
+	funcs := template.FuncMap{
+		"url": func(p string) string {
+			return site.BasePath + strings.TrimPrefix(p, "/")
+		},
+		"date": func(t time.Time) string {
+			return t.Format("2006-01-02")
+		},
+	}
+
+	hasher := sha256.New()
+	layout, err := read(layoutFile)
+	if err != nil {
+		return nil, err
+	}
+	hasher.Write(layout)
+
+	theme := &Theme{pages: make(map[string]*template.Template), static: make(map[string][]byte)}
+	for _, page := range []string{PageList, PageArticle, PageTerms, PageChapters, PageArchive} {
+		content, err := read(page)
+		if err != nil {
+			return nil, err
+		}
+		hasher.Write([]byte(page))
+		hasher.Write(content)
+
+		tmpl, err := template.New(layoutFile).Funcs(funcs).Parse(string(layout))
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", layoutFile, err)
+		}
+		if _, err := tmpl.New(page).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", page, err)
+		}
+		theme.pages[page] = tmpl
+	}
+
+	// 静态资源：内置主题在前，自定义主题同名文件覆盖
+	sources := []fs.FS{builtin}
+	if custom != nil {
+		sources = append(sources, custom)
+	}
+	for _, source := range sources {
+		err := fs.WalkDir(source, staticDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return fs.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			data, err := fs.ReadFile(source, p)
+			if err != nil {
+				return err
+			}
+			theme.static[p] = data
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 站点信息影响所有页面的链接，一并计入主题指纹
+	fmt.Fprintf(hasher, "%s\n%s\n%s\n%s", site.Title, site.Description, site.BaseURL, site.BasePath)
+	theme.hash = hex.EncodeToString(hasher.Sum(nil))
+	return theme, nil
+}
+
+// Hash 主题指纹，主题或站点信息变化时所有页面都需要重新渲染
+func (t *Theme) Hash() string {
+	return t.hash
+}
+
+// Render 渲染页面
+func (t *Theme) Render(page string, data interface{}) ([]byte, error) {
+	tmpl, ok := t.pages[page]
+	if !ok {
+		return nil, fmt.Errorf("未知的页面模板: %s", page)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, layoutFile, data); err != nil {
+		return nil, fmt.Errorf("渲染 %s 失败: %w", page, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// StaticFiles 主题静态资源的输出路径列表
+func (t *Theme) StaticFiles() []string {
+	files := make([]string, 0, len(t.static))
+	for p := range t.static {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// StaticFile 读取主题静态资源
+func (t *Theme) StaticFile(p string) []byte {
+	return t.static[p]
+}
+
+// cleanPath 规范化输出路径，防止写出输出目录
+func cleanPath(p string) (string, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if cleaned == "" || cleaned == "." {
+		return "", fmt.Errorf("无效的输出路径: %q", p)
+	}
+	return filepath.FromSlash(cleaned), nil
+}
@@ -0,0 +1,204 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ydcloud-dy/leaf-api/config"
+)
+
+// DefaultFolder 默认上传文件夹
+const DefaultFolder = "uploads"
+
+// folderRegex 合法的文件夹名称：小写字母、数字、下划线和中划线，可用 / 分隔多级
+var folderRegex = regexp.MustCompile(`^[a-z0-9_-]+(/[a-z0-9_-]+)*$`)
+
+// defaultFolders 未配置 upload.folders 时使用的内置规则
+var defaultFolders = map[string]config.UploadFolderConfig{
+	"avatars":  {MaxSize: 2, AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"}},
+	"covers":   {MaxSize: 5, AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"}},
+	"articles": {MaxSize: 10, AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"}},
+	DefaultFolder: {AllowedTypes: []string{
+		"image/*", "video/mp4", "video/webm", "audio/mpeg",
+		"application/pdf", "application/zip", "text/plain",
+	}},
+}
+
+// typeExtensions 嗅探出的 MIME 类型允许使用的文件扩展名
+var typeExtensions = map[string][]string{
+	"image/jpeg":      {".jpg", ".jpeg"},
+	"image/png":       {".png"},
+	"image/gif":       {".gif"},
+	"image/webp":      {".webp"},
+	"image/bmp":       {".bmp"},
+	"image/x-icon":    {".ico"},
+	"video/mp4":       {".mp4"},
+	"video/webm":      {".webm"},
+	"audio/mpeg":      {".mp3"},
+	"audio/wave":      {".wav"},
+	"application/pdf": {".pdf"},
+	"application/zip": {".zip"},
+	"text/plain":      {".txt", ".md", ".markdown"},
+}
+
+var (
+	// ErrInvalidFolder 文件夹名称不合法
+	ErrInvalidFolder = errors.New("文件夹名称不合法")
+	// ErrFileTooLarge 文件超过大小限制
+	ErrFileTooLarge = errors.New("文件大小超过限制")
+	// ErrTypeNotAllowed 文件类型不允许
+	ErrTypeNotAllowed = errors.New("不支持的文件类型")
+)
+
+// SanitizeFolder 校验并规范化文件夹名称，防止路径穿越
+func SanitizeFolder(folder string) (string, error) {
+	folder = strings.ToLower(strings.Trim(strings.TrimSpace(folder), "/"))
+	if folder == "" {
+		return DefaultFolder, nil
+	}
+	if !folderRegex.MatchString(folder) {
+		return "", ErrInvalidFolder
+	}
+	return folder, nil
+}
+
+// Validate 校验上传文件，返回嗅探出的 MIME 类型
+// folder 需先经过 SanitizeFolder 处理
+func Validate(file *multipart.FileHeader, folder string) (string, error) {
+	rule := folderRule(folder)
+	if err := checkSize(file.Size, rule); err != nil {
+		return "", err
+	}
+
+	mimeType, err := Sniff(file)
+	if err != nil {
+		return "", err
+	}
+
+	if !typeAllowed(mimeType, rule.AllowedTypes) {
+		return "", fmt.Errorf("%w: %s", ErrTypeNotAllowed, mimeType)
+	}
+
+	// 扩展名必须与实际内容一致，避免伪装成图片的 HTML 等文件
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if !extensionMatches(mimeType, ext) {
+		return "", fmt.Errorf("%w: 扩展名 %s 与文件内容 %s 不符", ErrTypeNotAllowed, ext, mimeType)
+	}
+
+	return mimeType, nil
+}
+
+// ValidateBytes 按 folder 的规则校验服务端获取的文件内容（远程图片、压缩包中的文件等），返回嗅探出的 MIME 类型
+// 只接受能确定扩展名的类型，保存时使用 Extension 返回的扩展名，不使用来源中的扩展名；folder 需先经过 SanitizeFolder 处理
+func ValidateBytes(data []byte, folder string) (string, error) {
+	rule := folderRule(folder)
+	if err := checkSize(int64(len(data)), rule); err != nil {
+		return "", err
+	}
+
+	mimeType := SniffBytes(data)
+	if !typeAllowed(mimeType, rule.AllowedTypes) || Extension(mimeType) == "" {
+		return "", fmt.Errorf("%w: %s", ErrTypeNotAllowed, mimeType)
+	}
+	return mimeType, nil
+}
+
+// Extension 嗅探出的 MIME 类型对应的扩展名，不支持的类型返回空
+func Extension(mimeType string) string {
+	if exts := typeExtensions[mimeType]; len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// checkSize 校验文件大小，文件夹未单独配置时使用全局限制
+func checkSize(size int64, rule config.UploadFolderConfig) error {
+	maxSize := rule.MaxSize
+	if maxSize <= 0 && config.AppConfig != nil {
+		maxSize = config.AppConfig.Upload.MaxSize
+	}
+	if maxSize > 0 && size > maxSize*1024*1024 {
+		return fmt.Errorf("%w: 最大 %dMB", ErrFileTooLarge, maxSize)
+	}
+	return nil
+}
+
+// Sniff 根据文件内容嗅探 MIME 类型（忽略客户端提供的 Content-Type）
+func Sniff(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	return SniffBytes(head[:n]), nil
+}
+
+// SniffBytes 根据数据内容嗅探 MIME 类型，去掉 charset 等参数
+func SniffBytes(data []byte) string {
+	mimeType := http.DetectContentType(data)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.TrimSpace(mimeType)
+}
+
+// folderRule 获取文件夹对应的规则，按一级目录匹配，未配置时使用默认文件夹规则
+func folderRule(folder string) config.UploadFolderConfig {
+	var rules map[string]config.UploadFolderConfig
+	if config.AppConfig != nil {
+		rules = config.AppConfig.Upload.Folders
+	}
+	if len(rules) == 0 {
+		rules = defaultFolders
+	}
+
+	top := strings.SplitN(folder, "/", 2)[0]
+	if rule, ok := rules[top]; ok {
+		return rule
+	}
+	if rule, ok := rules[DefaultFolder]; ok {
+		return rule
+	}
+	return defaultFolders[DefaultFolder]
+}
+
+// typeAllowed 检查 MIME 类型是否在允许列表中，支持 image/* 形式的通配
+func typeAllowed(mimeType string, allowed []string) bool {
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mimeType || pattern == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionMatches 检查扩展名是否与嗅探出的类型一致
+func extensionMatches(mimeType, ext string) bool {
+	exts, ok := typeExtensions[mimeType]
+	if !ok {
+		return false
+	}
+	for _, e := range exts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestValidateBytes(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name   string
+		data   []byte
+		folder string
+		mime   string
+		err    error
+	}{
+		{"png article image", png, "articles", "image/png", nil},
+		{"pdf in default folder", []byte("%PDF-1.4\n"), DefaultFolder, "application/pdf", nil},
+		{"pdf not an article image", []byte("%PDF-1.4\n"), "articles", "", ErrTypeNotAllowed},
+		{"svg article image", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), "articles", "", ErrTypeNotAllowed},
+		{"html", []byte("<!DOCTYPE html><script>alert(1)</script>"), DefaultFolder, "", ErrTypeNotAllowed},
+		{"too large avatar", append(png, bytes.Repeat([]byte{0}, 2*1024*1024)...), "avatars", "", ErrFileTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mime, err := ValidateBytes(tt.data, tt.folder)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if mime != tt.mime {
+				t.Errorf("mime = %s, want %s", mime, tt.mime)
+			}
+		})
+	}
+}
+
+func TestExtension(t *testing.T) {
+	for mime, want := range map[string]string{"image/jpeg": ".jpg", "image/png": ".png", "image/svg+xml": "", "text/html": ""} {
+		if got := Extension(mime); got != want {
+			t.Errorf("Extension(%s) = %q, want %q", mime, got, want)
+		}
+	}
+}
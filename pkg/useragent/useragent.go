@@ -0,0 +1,237 @@
+// Package useragent 解析 User-Agent，识别浏览器、操作系统、设备类型和爬虫。
+// 规则保存在 rules.yaml 中随程序内置，也可以通过配置指定外部规则文件。
+package useragent
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// Info 解析结果
+type Info struct {
+	Browser        string `json:"browser"`
+	BrowserVersion string `json:"browser_version"`
+	OS             string `json:"os"`
+	OSVersion      string `json:"os_version"`
+	Device         string `json:"device"`
+	IsBot          bool   `json:"is_bot"`
+	BotName        string `json:"bot_name,omitempty"`
+	BotCategory    string `json:"bot_category,omitempty"` // search, social, seo, ai, monitor, tool, other
+}
+
+// rule 规则文件中的一条规则
+type rule struct {
+	Name     string            `yaml:"name"`
+	Category string            `yaml:"category"`
+	Pattern  string            `yaml:"pattern"`
+	Versions map[string]string `yaml:"versions"`
+
+	re *regexp.Regexp
+}
+
+// ruleSet 规则文件
+type ruleSet struct {
+	Bots     []*rule `yaml:"bots"`
+	Browsers []*rule `yaml:"browsers"`
+	OS       []*rule `yaml:"os"`
+	Devices  []*rule `yaml:"devices"`
+}
+
+// 解析结果缓存：同一 UA 反复出现，正则匹配是心跳和浏览计数的主要开销
+const (
+	// cacheSize 每一代缓存的 UA 数，当前代写满后整体换代，最多保留两代
+	cacheSize = 4096
+	// maxCachedLen 超过该长度的 UA 不缓存，避免异常请求占用内存
+	maxCachedLen = 512
+)
+
+// Parser UA 解析器
+type Parser struct {
+	rules ruleSet
+
+	mu       sync.Mutex
+	cache    map[string]Info // 当前代
+	previous map[string]Info // 上一代，命中时移到当前代
+}
+
+// Load 加载规则文件，path 为空时使用内置规则
+func Load(path string) (*Parser, error) {
+	content := defaultRules
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取 UA 规则文件失败: %w", err)
+		}
+		content = data
+	}
+	return parse(content)
+}
+
+// parse 解析并编译规则
+func parse(content []byte) (*Parser, error) {
+	var rules ruleSet
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("解析 UA 规则失败: %w", err)
+	}
+	groups := map[string][]*rule{"bots": rules.Bots, "browsers": rules.Browsers, "os": rules.OS, "devices": rules.Devices}
+	for group, list := range groups {
+		for _, r := range list {
+			re, err := regexp.Compile("(?i)" + r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("UA 规则 %s/%s 无效: %w", group, r.Name, err)
+			}
+			r.re = re
+		}
+	}
+	return &Parser{rules: rules, cache: make(map[string]Info, cacheSize)}, nil
+}
+
+// match 按顺序匹配规则，返回命中的规则和版本号
+func match(rules []*rule, ua string) (*rule, string) {
+	for _, r := range rules {
+		m := r.re.FindStringSubmatch(ua)
+		if m == nil {
+			continue
+		}
+		version := ""
+		for _, group := range m[1:] {
+			if group != "" {
+				version = strings.ReplaceAll(group, "_", ".")
+				break
+			}
+		}
+		if name, ok := r.Versions[version]; ok {
+			version = name
+		}
+		return r, version
+	}
+	return nil, ""
+}
+
+// Parse 解析 UA，空 UA 视为脚本请求，解析结果会被缓存
+func (p *Parser) Parse(ua string) Info {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return Info{Device: DeviceBot, IsBot: true, BotName: "Empty", BotCategory: "tool"}
+	}
+	if len(ua) > maxCachedLen {
+		return p.parse(ua)
+	}
+
+	if info, ok := p.cached(ua); ok {
+		return info
+	}
+	info := p.parse(ua)
+	p.store(ua, info)
+	return info
+}
+
+// cached 查询缓存的解析结果，上一代命中的移到当前代
+func (p *Parser) cached(ua string) (Info, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if info, ok := p.cache[ua]; ok {
+		return info, true
+	}
+	info, ok := p.previous[ua]
+	if ok {
+		p.put(ua, info)
+	}
+	return info, ok
+}
+
+// store 缓存解析结果
+func (p *Parser) store(ua string, info Info) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.put(ua, info)
+}
+
+// put 写入当前代，写满时换代，调用方需持有锁
+func (p *Parser) put(ua string, info Info) {
+	if len(p.cache) >= cacheSize {
+		p.previous = p.cache
+		p.cache = make(map[string]Info, cacheSize)
+	}
+	p.cache[ua] = info
+}
+
+// parse 按规则解析 UA
+func (p *Parser) parse(ua string) Info {
+	var info Info
+	if r, _ := match(p.rules.Bots, ua); r != nil {
+		info.IsBot = true
+		info.BotName = r.Name
+		info.BotCategory = r.Category
+		info.Device = DeviceBot
+	}
+	if r, version := match(p.rules.Browsers, ua); r != nil {
+		info.Browser, info.BrowserVersion = r.Name, version
+	}
+	if r, version := match(p.rules.OS, ua); r != nil {
+		info.OS, info.OSVersion = r.Name, version
+	}
+	if !info.IsBot {
+		info.Device = DeviceDesktop
+		if r, _ := match(p.rules.Devices, ua); r != nil {
+			info.Device = r.Name
+		}
+	}
+	return info
+}
+
+var (
+	mu      sync.RWMutex
+	current *Parser
+)
+
+// Use 设置全局解析器
+func Use(p *Parser) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = p
+}
+
+// Default 获取全局解析器，未设置时使用内置规则
+func Default() *Parser {
+	mu.RLock()
+	p := current
+	mu.RUnlock()
+	if p != nil {
+		return p
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		parser, err := parse(defaultRules)
+		if err != nil {
+			// 内置规则随代码发布，解析失败属于编程错误
+			panic(err)
+		}
+		current = parser
+	}
+	return current
+}
+
+// Parse 使用全局解析器解析 UA
+func Parse(ua string) Info {
+	return Default().Parse(ua)
+}
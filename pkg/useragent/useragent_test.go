@@ -0,0 +1,70 @@
+package useragent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const (
+	chromeUA    = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	googlebotUA = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+)
+
+func TestParse(t *testing.T) {
+	p := Default()
+
+	chrome := p.Parse(chromeUA)
+	if chrome.IsBot || chrome.Browser == "" || chrome.Device != DeviceDesktop {
+		t.Errorf("chrome = %+v", chrome)
+	}
+	bot := p.Parse(googlebotUA)
+	if !bot.IsBot || bot.BotName == "" || bot.Device != DeviceBot {
+		t.Errorf("googlebot = %+v", bot)
+	}
+	if empty := p.Parse("  "); !empty.IsBot || empty.BotName != "Empty" {
+		t.Errorf("empty = %+v", empty)
+	}
+
+	// 缓存命中和重新解析的结果一致
+	for _, ua := range []string{chromeUA, googlebotUA} {
+		if cached, parsed := p.Parse(ua), p.parse(ua); cached != parsed {
+			t.Errorf("cached %+v != parsed %+v", cached, parsed)
+		}
+	}
+}
+
+func TestParseCacheBounded(t *testing.T) {
+	p, err := parse(defaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Parse(chromeUA)
+	for i := 0; i < 3*cacheSize; i++ {
+		p.Parse(fmt.Sprintf("client/%d", i))
+		// 常用 UA 持续命中，换代后仍然保留
+		if i%100 == 0 {
+			p.Parse(chromeUA)
+		}
+	}
+	if len(p.cache) > cacheSize || len(p.previous) > cacheSize {
+		t.Errorf("cache size = %d + %d, want at most %d each", len(p.cache), len(p.previous), cacheSize)
+	}
+	if _, ok := p.cached(chromeUA); !ok {
+		t.Error("frequent UA evicted")
+	}
+
+	long := strings.Repeat("a", maxCachedLen+1)
+	p.Parse(long)
+	if _, ok := p.cache[long]; ok {
+		t.Error("long UA cached")
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	p := Default()
+	for i := 0; i < b.N; i++ {
+		p.Parse(chromeUA)
+	}
+}